@@ -0,0 +1,61 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `reflect`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+)
+
+// EncodeFunc writes the wire representation of val, which is a pointer to a value of the registered type,
+// into buf and returns the number of bytes written.
+//
+// For fixed-size wire types, buf is exactly as large as the Thrift Binary Protocol representation of the wire
+// type (1, 2, 4 or 8 bytes, big-endian), Frugal converts it for the Compact Protocol when needed. For "string"
+// and "binary" wire types, buf is exactly as large as the size reported by the SizeFunc, and the length prefix
+// is written by Frugal.
+type EncodeFunc func(buf []byte, val interface{}) (int, error)
+
+// DecodeFunc reads the wire representation in buf into val, which is a pointer to a value of the registered type.
+// buf has the same layout as in EncodeFunc, and is only valid during the call, it must be copied if retained.
+type DecodeFunc func(buf []byte, val interface{}) error
+
+// SizeFunc returns the size of the wire representation of val, which is a pointer to a value of the registered type.
+// It is only required for "string" and "binary" wire types.
+type SizeFunc func(val interface{}) int
+
+// RegisterCodec registers user-defined encoding and decoding functions for vt, which must be a named non-pointer
+// type, so that fields of type vt (or pointers to vt) can be used in structs with the wire type wt. wt must be one
+// of "i8", "byte", "i16", "i32", "i64", "double", "string" or "binary".
+//
+// Codecs must be registered before any struct that uses vt is encoded, decoded or pretouched, and each type can
+// only have one codec. The errors returned match ErrUnsupportedType.
+func RegisterCodec(vt reflect.Type, wt string, enc EncodeFunc, dec DecodeFunc, size SizeFunc) error {
+    return defs.RegisterCodec(vt, wt, enc, dec, size)
+}
+
+/* the raw value types, like Raw and Lazy[T], are adapted to the internal interface without exporting it */
+type rawValue interface {
+    rawValue() defs.RawValue
+}
+
+func init() {
+    defs.RegisterRawAdapter(reflect.TypeOf((*rawValue)(nil)).Elem(), func(v interface{}) defs.RawValue {
+        return v.(rawValue).rawValue()
+    })
+}
@@ -0,0 +1,87 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `fmt`
+    `reflect`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/deep`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+// Equal reports whether a and b are equal with Thrift semantics, which means they have the same
+// canonical encoding (see WithCanonical): maps and sets are compared regardless of their order, nil
+// and empty containers are equal in default and required fields, but not in optional fields, and
+// only the Thrift fields are compared. Two nil pointers are equal.
+//
+// Sets are only compared regardless of their order if their elements can be map keys.
+// Equal panics if a or b cannot be encoded.
+func Equal[T any](a *T, b *T) bool {
+    if a == nil || b == nil {
+        return a == b
+    } else if ret, err := deep.Equal(typeOf[T](), unsafe.Pointer(a), unsafe.Pointer(b)); err != nil {
+        panic(fmt.Errorf("frugal: cannot compare values: %w", err))
+    } else {
+        return ret
+    }
+}
+
+// Hash returns the 64-bit FNV-1a hash of the canonical encoding of v with Thrift Binary Protocol,
+// so values that are Equal have the same hash, which is also stable across processes. Hash of nil
+// is zero.
+//
+// Hash panics if v cannot be encoded.
+func Hash[T any](v *T) uint64 {
+    if v == nil {
+        return 0
+    } else if ret, err := deep.Hash(typeOf[T](), unsafe.Pointer(v)); err != nil {
+        panic(fmt.Errorf("frugal: cannot hash value: %w", err))
+    } else {
+        return ret
+    }
+}
+
+// Clone returns a deep copy of v, which is Equal to v and does not share any memory with it.
+// Only the Thrift fields (including the unknown fields if preserved) are copied, other fields
+// are left as zero values. Clone of nil is nil.
+//
+// Clone panics if v cannot be encoded.
+func Clone[T any](v *T) *T {
+    var ret T
+    var err error
+
+    /* nothing to copy */
+    if v == nil {
+        return nil
+    }
+
+    /* copy the value */
+    if err = deep.Clone(typeOf[T](), unsafe.Pointer(&ret), unsafe.Pointer(v)); err != nil {
+        panic(fmt.Errorf("frugal: cannot clone value: %w", err))
+    } else {
+        return &ret
+    }
+}
+
+func typeOf[T any]() *rt.GoType {
+    return rt.UnpackType(reflect.TypeOf((*T)(nil)).Elem())
+}
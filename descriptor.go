@@ -0,0 +1,59 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `reflect`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/descriptor`
+)
+
+// Requiredness is the requiredness of a struct field.
+type Requiredness = defs.Requiredness
+
+const (
+    Default  = defs.Default
+    Required = defs.Required
+    Optional = defs.Optional
+)
+
+// Enum is the Go type of enum fields in the types created by NewStructType.
+type Enum = descriptor.Enum
+
+// TypeDescriptor describes a Thrift type. Binary selects binary instead of string for WireString,
+// Enum selects enum instead of i32 for WireI32, Key and Elem are the key and value types of maps
+// or the element types of sets and lists, and Struct is the descriptor of WireStruct.
+type TypeDescriptor = descriptor.Type
+
+// FieldDescriptor describes a field of a Thrift struct.
+type FieldDescriptor = descriptor.Field
+
+// StructDescriptor describes a Thrift struct, union or exception.
+type StructDescriptor = descriptor.Struct
+
+// NewStructType creates a Go struct type at runtime from a struct descriptor, so that services
+// which only learn their schemas at runtime (from IDL files, a registry, etc.) can use all the
+// codecs without generated code. Values of the returned type can be created with reflect.New.
+//
+// Fields are named after the Thrift names converted to CamelCase, or "Field<ID>" if the name is
+// not usable, and also carry the `thrift` tag so that SimpleJSON keeps the Thrift names. Nested
+// structs are pointers, and optional scalar fields are pointers as well. Recursive structs are
+// not supported, because Go types created at runtime cannot refer to themselves.
+func NewStructType(sd *StructDescriptor) (reflect.Type, error) {
+    return descriptor.Build(sd)
+}
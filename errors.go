@@ -0,0 +1,67 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+    `github.com/cloudwego/frugal/internal/utils`
+)
+
+// Errors that tell the kind of failure, check for them with errors.Is. The errors returned by
+// the encoder and decoder keep their own messages, and match one of these, except the errors
+// returned by codecs registered with RegisterCodec, which are passed through unchanged.
+var (
+    // ErrTruncated means the input ends before the value is complete.
+    ErrTruncated = utils.ErrTruncated
+
+    // ErrTypeMismatch means a wire type in the input does not match the one the Go type expects.
+    ErrTypeMismatch = utils.ErrTypeMismatch
+
+    // ErrMissingRequired means a required field is missing from the input.
+    ErrMissingRequired = utils.ErrMissingRequired
+
+    // ErrInvalidData means the input or the value is malformed, like an invalid varint, a value
+    // out of range of its type, a union with more than one field set, or nesting too deep for
+    // the encoder or decoder stack.
+    ErrInvalidData = utils.ErrInvalidData
+
+    // ErrLimitExceeded means the input exceeds one of the decoding limits, the error is also
+    // a LimitError.
+    ErrLimitExceeded = utils.ErrLimitExceeded
+
+    // ErrUnsupportedType means the Go type cannot be encoded or decoded, like an invalid
+    // "frugal" tag or a type that Thrift does not support.
+    ErrUnsupportedType = utils.ErrUnsupportedType
+
+    // ErrBufferTooSmall means the encoded value does not fit in the buffer.
+    ErrBufferTooSmall = utils.ErrBufferTooSmall
+)
+
+// TypeError is returned when a Go type is not supported by Thrift, it matches ErrUnsupportedType.
+type TypeError = utils.TypeError
+
+// SyntaxError is returned when a "frugal" tag cannot be parsed, it matches ErrUnsupportedType.
+type SyntaxError = utils.SyntaxError
+
+// DecodeError is returned when decoding fails on the input, it tells where the failure happened,
+// like the field path `Req.items[3].price` and the byte offset, and wraps the actual error, so
+// errors.As still finds errors like LimitError. Use errors.As to check for it.
+//
+// The path starts with the name of the decoded type, followed by the Thrift field names and the
+// indices of list, set and map elements, unknown fields are named by their IDs. Expected and Actual
+// are set to the wire type names when the input has a container element of an unexpected type.
+type DecodeError = decoder.DecodeError
@@ -19,6 +19,7 @@ package frugal
 import (
     `github.com/cloudwego/frugal/internal/binary/decoder`
     `github.com/cloudwego/frugal/internal/binary/encoder`
+    `github.com/cloudwego/frugal/internal/opts`
     `github.com/cloudwego/frugal/iov`
 )
 
@@ -33,7 +34,57 @@ func EncodeObject(buf []byte, mem iov.BufferWriter, val interface{}) (int, error
     return encoder.EncodeObject(buf, mem, val)
 }
 
+// EncodeObjectWithOptions is like EncodeObject, but WithCanonical in options applies to this call.
+func EncodeObjectWithOptions(buf []byte, mem iov.BufferWriter, val interface{}, options ...Option) (int, error) {
+    return encoder.EncodeObjectWithOptions(buf, mem, val, applyOptions(options))
+}
+
 // DecodeObject deserializes buf into val with Thrift Binary Protocol.
 func DecodeObject(buf []byte, val interface{}) (int, error) {
     return decoder.DecodeObject(buf, val)
 }
+
+// DecodeObjectWithOptions is like DecodeObject, but the decoding limits and WithFields
+// in options apply to this call.
+func DecodeObjectWithOptions(buf []byte, val interface{}, options ...Option) (int, error) {
+    return decoder.DecodeObjectWithOptions(buf, val, applyOptions(options))
+}
+
+// EncodedSizeCompact measures the encoded size of val with Thrift Compact Protocol.
+func EncodedSizeCompact(val interface{}) int {
+    return encoder.EncodedSizeCompact(val)
+}
+
+// EncodeCompact serializes val into buf with Thrift Compact Protocol, with optional Zero-Copy iov.BufferWriter.
+// buf must be large enough to contain the entire serialization result.
+func EncodeCompact(buf []byte, mem iov.BufferWriter, val interface{}) (int, error) {
+    return encoder.EncodeCompact(buf, mem, val)
+}
+
+// EncodeCompactWithOptions is like EncodeCompact, but WithCanonical in options applies to this call.
+func EncodeCompactWithOptions(buf []byte, mem iov.BufferWriter, val interface{}, options ...Option) (int, error) {
+    return encoder.EncodeCompactWithOptions(buf, mem, val, applyOptions(options))
+}
+
+// DecodeCompact deserializes buf into val with Thrift Compact Protocol.
+func DecodeCompact(buf []byte, val interface{}) (int, error) {
+    return decoder.DecodeCompact(buf, val)
+}
+
+// DecodeCompactWithOptions is like DecodeCompact, but the decoding limits and WithFields
+// in options apply to this call.
+func DecodeCompactWithOptions(buf []byte, val interface{}, options ...Option) (int, error) {
+    return decoder.DecodeCompactWithOptions(buf, val, applyOptions(options))
+}
+
+func applyOptions(options []Option) opts.Options {
+    o := opts.GetDefaultOptions()
+
+    /* apply all the options */
+    for _, fn := range options {
+        fn(&o)
+    }
+
+    /* all done */
+    return o
+}
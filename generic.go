@@ -0,0 +1,138 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `fmt`
+    `reflect`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/binary/encoder`
+    `github.com/cloudwego/frugal/internal/opts`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+// Codec is a typed handle that encodes and decodes values of type T with a fixed protocol.
+// The encoder and decoder of T are resolved once when the handle is created, so every call
+// skips the per-call type lookup. A Codec is safe for concurrent use.
+type Codec[T any] struct {
+    opt opts.Options
+    typ *rt.GoType
+    pro defs.Protocol
+    enc encoder.Encoder
+    dec decoder.Decoder
+}
+
+// NewCodec creates a Codec for T with Thrift Binary Protocol. Any error in T is reported here
+// instead of on the first call to Encode, Decode or Size.
+//
+// The decoding limits (WithMaxContainerLen, WithMaxStringLen, WithMaxDepth and WithMaxTotalAlloc)
+// in options apply to every Decode call of the handle, the unspecified ones are taken from the
+// defaults at the time the handle is created.
+// WithFields in options makes every Decode call only decode the selected fields, and
+// WithCanonical makes every Encode call produce the canonical output.
+func NewCodec[T any](options ...Option) (*Codec[T], error) {
+    return newCodec[T](defs.Binary, options)
+}
+
+// NewCompactCodec is like NewCodec, but with Thrift Compact Protocol.
+func NewCompactCodec[T any](options ...Option) (*Codec[T], error) {
+    return newCodec[T](defs.Compact, options)
+}
+
+// For is like NewCodec, but panics if T cannot be encoded or decoded. It simplifies
+// initialization of package-level variables holding codecs.
+func For[T any](options ...Option) *Codec[T] {
+    return mustCodec(NewCodec[T](options...))
+}
+
+// ForCompact is like NewCompactCodec, but panics if T cannot be encoded or decoded.
+func ForCompact[T any](options ...Option) *Codec[T] {
+    return mustCodec(NewCompactCodec[T](options...))
+}
+
+func newCodec[T any](pt defs.Protocol, options []Option) (*Codec[T], error) {
+    var err error
+    var ret Codec[T]
+
+    /* apply all the options */
+    ret.opt = opts.GetDefaultOptions()
+    for _, fn := range options {
+        fn(&ret.opt)
+    }
+
+    /* resolve both programs of T */
+    vt := rt.UnpackType(reflect.TypeOf((*T)(nil)).Elem())
+    ret.typ, ret.pro = vt, pt
+    ret.enc, err = encoder.Resolve(vt, pt)
+
+    /* resolve the decoder only if the encoder succeeded */
+    if err == nil {
+        if len(ret.opt.Fields) == 0 {
+            ret.dec, err = decoder.Resolve(vt, pt)
+        } else {
+            ret.dec, err = decoder.ResolveSelection(vt, pt, ret.opt.Fields)
+        }
+    }
+
+    /* check for errors */
+    if err != nil {
+        return nil, err
+    } else {
+        return &ret, nil
+    }
+}
+
+func mustCodec[T any](cc *Codec[T], err error) *Codec[T] {
+    if err != nil {
+        panic(fmt.Errorf("frugal: cannot create codec: %w", err))
+    } else {
+        return cc
+    }
+}
+
+// Encode serializes v into buf, buf must be large enough to contain the entire serialization result.
+func (self *Codec[T]) Encode(buf []byte, v *T) (int, error) {
+    if v == nil {
+        return encoder.EncodeObject(buf, nil, v)
+    } else {
+        return self.enc.EncodeWithOptions(buf, nil, unsafe.Pointer(v), self.opt)
+    }
+}
+
+// Decode deserializes buf into v.
+func (self *Codec[T]) Decode(buf []byte, v *T) (int, error) {
+    if v == nil {
+        return decoder.DecodeObject(buf, v)
+    } else {
+        return self.dec.Decode(self.typ, self.pro, buf, unsafe.Pointer(v), self.opt)
+    }
+}
+
+// Size measures the encoded size of v.
+func (self *Codec[T]) Size(v *T) int {
+    if ret, err := self.Encode(nil, v); err != nil {
+        panic(fmt.Errorf("frugal: cannot measure encoded size: %w", err))
+    } else {
+        return ret
+    }
+}
@@ -0,0 +1,47 @@
+// +build !amd64
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package abi
+
+import (
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+/* only 64-bit platforms are supported */
+const (
+    PtrSize  = 8    // pointer size
+    PtrAlign = 8    // pointer alignment
+)
+
+// GenericABI is used on platforms without a code generator, functions are only
+// registered to be called by the emulator, so no calling convention is needed.
+type GenericABI struct{}
+
+func ArchCreateABI() GenericABI {
+    return GenericABI{}
+}
+
+func (GenericABI) RegisterMethod(_ int, mt rt.Method) int {
+    return mt.Id
+}
+
+func (GenericABI) RegisterFunction(_ int, fn interface{}) unsafe.Pointer {
+    return rt.FuncAddr(fn)
+}
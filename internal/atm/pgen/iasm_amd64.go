@@ -20,6 +20,7 @@ import (
     `math`
 
     `github.com/chenzhuoyu/iasm/x86_64`
+    `github.com/cloudwego/frugal/internal/rt`
 )
 
 const (
@@ -85,6 +86,14 @@ func toAddress(p *x86_64.Label) uintptr {
     }
 }
 
+func toReloc(head uintptr, tail uintptr) rt.Reloc {
+    if tail - head >= 10 {
+        return rt.Reloc { Off: tail - 8, Size: 8 }     // movabsq $imm64, %reg
+    } else {
+        return rt.Reloc { Off: tail - 4, Size: 4 }     // movq $imm32, %reg or movl $imm32, %reg
+    }
+}
+
 func isSimpleMem(v *x86_64.MemoryOperand) bool {
     return !v.Masked                        &&
             v.Broadcast == 0                &&
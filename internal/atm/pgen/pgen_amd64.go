@@ -266,8 +266,9 @@ var _OperandMask = [256]Operands {
 }
 
 type Func struct {
-    Code  []byte
-    Frame rt.Frame
+    Code   []byte
+    Frame  rt.Frame
+    Relocs []rt.Reloc
 }
 
 type CodeGen struct {
@@ -280,6 +281,7 @@ type CodeGen struct {
     defs []_DeferBlock
     stab []_SwitchTable
     abix _CodeGenExtension
+    rels [][2]*x86_64.Label
     jmps map[string]*x86_64.Label
     regs map[hir.Register]x86_64.Register64
 }
@@ -402,6 +404,12 @@ func (self *CodeGen) Generate(s hir.Program, sp uintptr) *Func {
     args := uintptr(self.ctxt.save())
     size := uintptr(self.ctxt.size())
 
+    /* absolute addresses in the code */
+    rels := make([]rt.Reloc, 0, len(self.rels))
+    for _, v := range self.rels {
+        rels = append(rels, toReloc(toAddress(v[0]), toAddress(v[1])))
+    }
+
     /* build the PC-SP tab */
     tab := []rt.Stack {
         { Sp:    0, Nb: head },
@@ -411,8 +419,9 @@ func (self *CodeGen) Generate(s hir.Program, sp uintptr) *Func {
 
     /* assemble the function */
     ret := &Func {
-        Code  : code,
-        Frame : rt.Frame {
+        Code   : code,
+        Relocs : rels,
+        Frame  : rt.Frame {
             SpTab     : tab,
             ArgSize   : args,
             ArgPtrs   : self.ctxt.ArgPtrs(),
@@ -432,6 +441,17 @@ func (self *CodeGen) later(ref *x86_64.Label, def func(*x86_64.Program)) {
     })
 }
 
+func (self *CodeGen) abs(p *x86_64.Program, fn func()) {
+    head := x86_64.CreateLabel("_abs_head")
+    tail := x86_64.CreateLabel("_abs_tail")
+
+    /* mark the instruction that loads the absolute address */
+    p.Link(head)
+    fn()
+    p.Link(tail)
+    self.rels = append(self.rels, [2]*x86_64.Label { head, tail })
+}
+
 func (self *CodeGen) translate(p *x86_64.Program, v *hir.Ir) {
     if p.Link(self.to(v)); v.Op != hir.OP_nop {
         if fp := translators[v.Op]; fp != nil {
@@ -652,10 +672,12 @@ var translators = [256]func(*CodeGen, *x86_64.Program, *hir.Ir) {
 
 func (self *CodeGen) translate_OP_ip(p *x86_64.Program, v *hir.Ir) {
     if v.Pd != hir.Pn {
-        if addr := uintptr(v.Pr); addr > math.MaxUint32 {
-            p.MOVQ(addr, self.r(v.Pd))
+        if addr := uintptr(v.Pr); addr == 0 {
+            p.MOVL(0, x86_64.Register32(self.r(v.Pd)))
+        } else if addr > math.MaxUint32 {
+            self.abs(p, func() { p.MOVQ(addr, self.r(v.Pd)) })
         } else {
-            p.MOVL(addr, x86_64.Register32(self.r(v.Pd)))
+            self.abs(p, func() { p.MOVL(addr, x86_64.Register32(self.r(v.Pd))) })
         }
     }
 }
@@ -231,3 +231,39 @@ func TestPGen_FunctionCall(t *testing.T) {
     require.Equal(t, 746, y)
     require.Equal(t, 20211206, z)
 }
+
+var relocTestVar int
+
+func TestPGen_Relocs(t *testing.T) {
+    p := hir.CreateBuilder()
+    h := hir.RegisterGCall(gcalltestfn, nil)
+    p.IP(&relocTestVar, hir.P0)
+    p.IP((*int)(nil), hir.P1)
+    p.LDAP(0, hir.P2)
+    p.SP(hir.P0, hir.P2, 0)
+    p.LDAQ(1, hir.R0)
+    p.GCALL(h).A0(hir.R0).R0(hir.R1).R1(hir.R2).R2(hir.R3)
+    p.BCOPY(hir.P0, hir.R1, hir.P2)
+    p.RET()
+    g := CreateCodeGen((func(**int, int))(nil))
+    r := g.Generate(p.Build(), 0)
+    fv := map[uintptr]bool {
+        uintptr(unsafe.Pointer(&relocTestVar)) : true,
+        uintptr(h.Func)                        : true,
+        uintptr(rtx.F_memmove)                 : true,
+        uintptr(rtx.F_gcWriteBarrier)          : true,
+        uintptr(rtx.V_pWriteBarrier)           : true,
+        uintptr(rtx.F_morestack_noctxt)        : true,
+    }
+    for _, rel := range r.Relocs {
+        var addr uintptr
+        if rel.Size == 8 {
+            addr = uintptr(*(*uint64)(unsafe.Pointer(&r.Code[rel.Off])))
+        } else {
+            addr = uintptr(*(*uint32)(unsafe.Pointer(&r.Code[rel.Off])))
+        }
+        require.True(t, fv[addr], "unexpected address %#x", addr)
+        delete(fv, addr)
+    }
+    require.Empty(t, fv)
+}
@@ -0,0 +1,48 @@
+// +build go1.21
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgen
+
+import (
+    `github.com/chenzhuoyu/iasm/x86_64`
+    `github.com/cloudwego/frugal/internal/atm/rtx`
+)
+
+func (self *CodeGen) wbCallWriteBarrier(p *x86_64.Program) {
+    lr := self.rindex(R11)
+
+    /* the buffer pointer is returned in R11 */
+    if lr != nil {
+        p.MOVQ(R11, self.ctxt.slot(lr))
+    }
+
+    /* reserve 2 slots in the write barrier buffer */
+    self.abs(p, func() { p.MOVQ(uintptr(rtx.F_gcWriteBarrier), RSI) })
+    p.CALLQ(RSI)
+
+    /* record the new and the old pointer, then do the actual store */
+    p.MOVQ(RAX, Ptr(R11, 0))
+    p.MOVQ(Ptr(RDI, 0), RSI)
+    p.MOVQ(RSI, Ptr(R11, 8))
+    p.MOVQ(RAX, Ptr(RDI, 0))
+
+    /* restore R11 if needed */
+    if lr != nil {
+        p.MOVQ(self.ctxt.slot(lr), R11)
+    }
+}
@@ -0,0 +1,29 @@
+// +build !go1.21
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pgen
+
+import (
+    `github.com/chenzhuoyu/iasm/x86_64`
+    `github.com/cloudwego/frugal/internal/atm/rtx`
+)
+
+func (self *CodeGen) wbCallWriteBarrier(p *x86_64.Program) {
+    self.abs(p, func() { p.MOVQ(uintptr(rtx.F_gcWriteBarrier), RSI) })
+    p.CALLQ(RSI)
+}
@@ -17,15 +17,9 @@
 package rtx
 
 import (
-    _ `unsafe`
-
     `github.com/cloudwego/frugal/internal/rt`
 )
 
-//go:nosplit
-//go:linkname gcWriteBarrier runtime.gcWriteBarrier
-func gcWriteBarrier()
-
 var (
     V_pWriteBarrier  = gcwbaddr()
     F_gcWriteBarrier = rt.FuncAddr(gcWriteBarrier)
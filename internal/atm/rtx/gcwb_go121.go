@@ -0,0 +1,30 @@
+// +build go1.21
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rtx
+
+import (
+    _ `unsafe`
+)
+
+// Since Go 1.21, the write barrier only reserves space in the write barrier buffer,
+// this one reserves 2 pointers, the caller records both the new and the old value.
+//
+//go:nosplit
+//go:linkname gcWriteBarrier runtime.gcWriteBarrier2
+func gcWriteBarrier()
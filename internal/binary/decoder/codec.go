@@ -0,0 +1,170 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `encoding/binary`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+func codec_call(cc *defs.Codec, p unsafe.Pointer, buf unsafe.Pointer, i int, n int) error {
+    return cc.Decode(rt.BytesFrom(unsafe.Pointer(uintptr(buf) + uintptr(i)), n, n), cc.Value(p))
+}
+
+func codec_decode(cc *defs.Codec, buf unsafe.Pointer, nb int, i int, p unsafe.Pointer, pt int, rs *RuntimeState) (int, error) {
+    var j int
+    var err error
+
+    /* select the decoder by the encoding */
+    if cc.Raw {
+        j, err = codec_decode_raw(cc, buf, nb, i, p, pt, rs)
+    } else if cc.FixedSize() < 0 {
+        j, err = codec_decode_str(cc, buf, nb, i, p, pt, rs)
+    } else {
+        j, err = codec_decode_val(cc, buf, nb, i, p, pt)
+    }
+
+    /* errors are reported at the beginning of the value */
+    if err != nil {
+        return i, err
+    } else {
+        return j, nil
+    }
+}
+
+func codec_decode_raw(cc *defs.Codec, buf unsafe.Pointer, nb int, i int, p unsafe.Pointer, pt int, rs *RuntimeState) (int, error) {
+    var n int
+    var sp = unsafe.Pointer(uintptr(buf) + uintptr(i))
+
+    /* find the end of the value with the skipper */
+    if defs.Protocol(pt) == defs.Compact {
+        n = cskip(buf, nb, i, int(cc.Wire.Compact()), false, 0, &rs.Lm) - i
+    } else {
+        n = do_skip((*_skipbuf_t)(&rs.Sk), sp, nb - i, cc.Wire, &rs.Lm)
+    }
+
+    /* check for errors */
+    if n < 0 {
+        return 0, error_skip(n, rs)
+    }
+
+    /* raw values are copied, which counts as allocation */
+    if uint64(n) > rs.Lm.Na {
+        return 0, limitError(LimitTotalAlloc, rs)
+    }
+
+    /* keep the encoded value */
+    rs.Lm.Na -= uint64(n)
+    return i + n, cc.RawValue(p).RawDecode(rt.BytesFrom(sp, n, n), defs.Protocol(pt))
+}
+
+func codec_decode_str(cc *defs.Codec, buf unsafe.Pointer, nb int, i int, p unsafe.Pointer, pt int, rs *RuntimeState) (int, error) {
+    var n int
+    var err error
+
+    /* read the length prefix */
+    if defs.Protocol(pt) == defs.Compact {
+        if i, n, err = compact_length(buf, nb, i, rs); err != nil {
+            return 0, err
+        }
+    } else {
+        if i + 4 > nb {
+            return 0, error_eof(i + 4 - nb)
+        }
+
+        /* check for the remaining bytes */
+        n = int(binary.BigEndian.Uint32(rt.BytesFrom(unsafe.Pointer(uintptr(buf) + uintptr(i)), 4, 4)))
+        i += 4
+
+        /* the value must be within the limit, and complete */
+        if uint64(n) > rs.Lm.Ns {
+            return 0, limitError(LimitStringLen, rs)
+        } else if n > nb - i {
+            return 0, error_eof(n - (nb - i))
+        }
+    }
+
+    /* decode the value */
+    if err = codec_call(cc, p, buf, i, n); err != nil {
+        return 0, err
+    } else {
+        return i + n, nil
+    }
+}
+
+func codec_decode_val(cc *defs.Codec, buf unsafe.Pointer, nb int, i int, p unsafe.Pointer, pt int) (int, error) {
+    var b [8]byte
+    var n = cc.FixedSize()
+
+    /* integers are zig-zag encoded varints in Compact Protocol */
+    if defs.Protocol(pt) == defs.Compact && n != 1 && cc.Wire != defs.T_double {
+        v, i := uvarint(buf, nb, i)
+        x := unzigzag(v)
+
+        /* check for errors */
+        if i < 0 {
+            return 0, error_varint(i)
+        }
+
+        /* convert to big-endian */
+        switch n {
+            case 2  : binary.BigEndian.PutUint16(b[:], uint16(x))
+            case 4  : binary.BigEndian.PutUint32(b[:], uint32(x))
+            case 8  : binary.BigEndian.PutUint64(b[:], uint64(x))
+            default : panic("can only store 2, 4 or 8 bytes at a time")
+        }
+
+        /* decode the value */
+        if err := cc.Decode(b[:n], cc.Value(p)); err != nil {
+            return 0, err
+        } else {
+            return i, nil
+        }
+    }
+
+    /* check for buffer size */
+    if i + n > nb {
+        return 0, error_eof(i + n - nb)
+    }
+
+    /* doubles are little-endian in Compact Protocol */
+    if defs.Protocol(pt) != defs.Compact || cc.Wire != defs.T_double {
+        if err := codec_call(cc, p, buf, i, n); err != nil {
+            return 0, err
+        } else {
+            return i + n, nil
+        }
+    }
+
+    /* convert to big-endian */
+    binary.BigEndian.PutUint64(b[:], binary.LittleEndian.Uint64(rt.BytesFrom(unsafe.Pointer(uintptr(buf) + uintptr(i)), 8, 8)))
+
+    /* decode the value */
+    if err := cc.Decode(b[:], cc.Value(p)); err != nil {
+        return 0, err
+    } else {
+        return i + n, nil
+    }
+}
+
+var (
+    F_codec_decode = hir.RegisterGCall(codec_decode, emu_gcall_codec_decode)
+)
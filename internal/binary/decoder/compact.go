@@ -0,0 +1,407 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package decoder
+
+import (
+    `math`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/utils`
+)
+
+func u8at(buf unsafe.Pointer, i int) int {
+    return int(*(*uint8)(unsafe.Pointer(uintptr(buf) + uintptr(i))))
+}
+
+func ctype(t int) int {
+    if t == defs.C_false {
+        return defs.C_true
+    } else {
+        return t
+    }
+}
+
+func uvarint(buf unsafe.Pointer, nb int, i int) (uint64, int) {
+    v := uint64(0)
+    s := uint(0)
+
+    /* 7 bits for each byte, at most 10 bytes */
+    for s < 64 {
+        if i >= nb {
+            return 0, EEOF
+        }
+
+        /* add to the result */
+        b := u8at(buf, i)
+        v |= uint64(b & 0x7f) << s
+
+        /* check for the last byte */
+        if i, s = i + 1, s + 7; b < 0x80 {
+            return v, i
+        }
+    }
+
+    /* more than 10 bytes */
+    return 0, EVARINT
+}
+
+func unzigzag(v uint64) int64 {
+    return int64(v >> 1) ^ -int64(v & 1)
+}
+
+func error_varint(e int) error {
+    switch e {
+        case EEOF    : return error_eof(1)
+        case EVARINT : return utils.EKindf(utils.ErrInvalidData, "frugal: malformed varint")
+        default      : return utils.EKindf(utils.ErrInvalidData, "frugal: error when reading varint: %d (unknown error)", e)
+    }
+}
+
+func error_size(n uint64) error {
+    return utils.EKindf(utils.ErrInvalidData, "frugal: invalid container size: %d", n)
+}
+
+func compact_varint(buf unsafe.Pointer, nb int, i int, p unsafe.Pointer, w int) (int, error) {
+    v, j := uvarint(buf, nb, i)
+    x := unzigzag(v)
+
+    /* check for errors */
+    if j < 0 {
+        return i, error_varint(j)
+    }
+
+    /* store the value */
+    switch w {
+        case 2  : *(*int16)(p) = int16(x)
+        case 4  : *(*int32)(p) = int32(x)
+        case 8  : *(*int64)(p) = x
+        default : panic("can only store 2, 4 or 8 bytes at a time")
+    }
+
+    /* all done */
+    return j, nil
+}
+
+func compact_uint(buf unsafe.Pointer, nb int, i int, w int) (int, uint64, error) {
+    v, j := uvarint(buf, nb, i)
+    x := uint64(unzigzag(v))
+
+    /* check for errors */
+    if j < 0 {
+        return i, 0, error_varint(j)
+    }
+
+    /* reinterpret the value as an unsigned integer of the wire width */
+    switch w {
+        case 2  : return j, uint64(uint16(x)), nil
+        case 4  : return j, uint64(uint32(x)), nil
+        case 8  : return j, x, nil
+        default : panic("can only load 2, 4 or 8 bytes at a time")
+    }
+}
+
+func compact_length(buf unsafe.Pointer, nb int, i int, rs *RuntimeState) (int, int, error) {
+    if n, j := uvarint(buf, nb, i); j < 0 {
+        return i, 0, error_varint(j)
+    } else if n > rs.Lm.Ns {
+        return i, 0, limitError(LimitStringLen, rs)
+    } else if n > uint64(nb - j) {
+        return i, 0, error_eof(int(n - uint64(nb - j)))
+    } else {
+        return j, int(n), nil
+    }
+}
+
+func compact_list_head(buf unsafe.Pointer, nb int, i int, et int, rs *RuntimeState) (int, int, error) {
+    var j int
+    var v int
+    var n uint64
+
+    /* the size and type byte */
+    if i >= nb {
+        return i, 0, error_eof(1)
+    }
+
+    /* large lists have their sizes encoded separately */
+    if v, j = u8at(buf, i), i + 1; v >> 4 != 0x0f {
+        n = uint64(v >> 4)
+    } else if n, j = uvarint(buf, nb, j); j < 0 {
+        return i, 0, error_varint(j)
+    } else if n > math.MaxInt32 {
+        return i, 0, error_size(n)
+    }
+
+    /* check for the container length, every element takes at least one byte */
+    if n > rs.Lm.Nc {
+        return i, 0, limitError(LimitContainerLen, rs)
+    } else if n > uint64(nb - j) {
+        return i, 0, error_eof(int(n - uint64(nb - j)))
+    }
+
+    /* check the element type */
+    if ctype(v & 0x0f) != et {
+        return i, 0, error_ctype(et, ctype(v & 0x0f))
+    } else {
+        return j, int(n), nil
+    }
+}
+
+func compact_map_head(buf unsafe.Pointer, nb int, i int, kv int, rs *RuntimeState) (int, int, error) {
+    var j int
+    var v int
+    var n uint64
+
+    /* the map size */
+    if n, j = uvarint(buf, nb, i); j < 0 {
+        return i, 0, error_varint(j)
+    } else if n > math.MaxInt32 {
+        return i, 0, error_size(n)
+    } else if n > rs.Lm.Nc {
+        return i, 0, limitError(LimitContainerLen, rs)
+    } else if n * 2 > uint64(nb - j) {
+        return i, 0, error_eof(int(n * 2 - uint64(nb - j)))
+    }
+
+    /* empty maps do not have the key and value types */
+    if n == 0 {
+        return j, 0, nil
+    }
+
+    /* the key and value types */
+    if j >= nb {
+        return i, 0, error_eof(1)
+    }
+
+    /* check the key and value types, and report the mismatched one */
+    if v, j = u8at(buf, j), j + 1; ctype(v >> 4) != kv >> 4 {
+        return i, 0, error_ctype(kv >> 4, ctype(v >> 4))
+    } else if ctype(v & 0x0f) != kv & 0x0f {
+        return i, 0, error_ctype(kv & 0x0f, ctype(v & 0x0f))
+    } else {
+        return j, int(n), nil
+    }
+}
+
+func compact_field(buf unsafe.Pointer, nb int, i int, id int) (int, int, int, error) {
+    var j int
+    var v int
+    var x uint64
+
+    /* the field header */
+    if i >= nb {
+        return i, 0, 0, error_eof(1)
+    }
+
+    /* check for STOP field, or the short form with a delta-encoded field ID */
+    if v, j = u8at(buf, i), i + 1; v == defs.C_stop {
+        return j, defs.C_stop, id, nil
+    } else if v >> 4 != 0 {
+        return j, v & 0x0f, id + v >> 4, nil
+    }
+
+    /* long form, the zigzag encoded field ID follows */
+    if x, j = uvarint(buf, nb, j); j < 0 {
+        return i, 0, 0, error_varint(j)
+    } else {
+        return j, v, int(uint16(unzigzag(x))), nil
+    }
+}
+
+func compact_skip(buf unsafe.Pointer, nb int, i int, t int, rs *RuntimeState) (int, error) {
+    if j := cskip(buf, nb, i, t, true, 0, &rs.Lm); j < 0 {
+        return i, error_skip(j, rs)
+    } else {
+        return j, nil
+    }
+}
+
+func cskip(buf unsafe.Pointer, nb int, i int, t int, field bool, sp int, lm *Limits) int {
+    if sp >= defs.StackSize {
+        return ESTACK
+    }
+
+    /* check for value types */
+    switch t {
+        default: {
+            return ETAG
+        }
+
+        /* booleans, carried by the type for fields */
+        case defs.C_true  : fallthrough
+        case defs.C_false : {
+            if field {
+                return i
+            } else {
+                return cskipn(nb, i, 1)
+            }
+        }
+
+        /* fixed size types */
+        case defs.C_byte   : return cskipn(nb, i, 1)
+        case defs.C_double : return cskipn(nb, i, 8)
+
+        /* varints */
+        case defs.C_i16 : fallthrough
+        case defs.C_i32 : fallthrough
+        case defs.C_i64 : {
+            _, i = uvarint(buf, nb, i)
+            return i
+        }
+
+        /* strings & binaries */
+        case defs.C_binary: {
+            if n, i := uvarint(buf, nb, i); i < 0 {
+                return i
+            } else if n > lm.Ns {
+                return ESIZE
+            } else if n > uint64(nb - i) {
+                return EEOF
+            } else {
+                return i + int(n)
+            }
+        }
+
+        /* structs */
+        case defs.C_struct: {
+            if uint64(sp) >= lm.Nd {
+                return EDEPTH
+            }
+
+            /* skip every field */
+            for {
+                if i >= nb {
+                    return EEOF
+                }
+
+                /* read the field header */
+                v := u8at(buf, i)
+                i++
+
+                /* check for the STOP field */
+                if v == defs.C_stop {
+                    return i
+                }
+
+                /* skip the field ID in the long form */
+                if v >> 4 == 0 {
+                    if _, i = uvarint(buf, nb, i); i < 0 {
+                        return i
+                    }
+                }
+
+                /* skip the field value */
+                if i = cskip(buf, nb, i, v & 0x0f, true, sp + 1, lm); i < 0 {
+                    return i
+                }
+            }
+        }
+
+        /* sets and lists */
+        case defs.C_set  : fallthrough
+        case defs.C_list : {
+            if uint64(sp) >= lm.Nd {
+                return EDEPTH
+            } else if i >= nb {
+                return EEOF
+            }
+
+            /* read the list header */
+            v := u8at(buf, i)
+            n := uint64(v >> 4)
+
+            /* large lists have their sizes encoded separately */
+            if i++; n == 0x0f {
+                if n, i = uvarint(buf, nb, i); i < 0 {
+                    return i
+                }
+            }
+
+            /* check for the container length */
+            if n > lm.Nc {
+                return ECOUNT
+            }
+
+            /* skip every element */
+            for ; n != 0; n-- {
+                if i = cskip(buf, nb, i, v & 0x0f, false, sp + 1, lm); i < 0 {
+                    return i
+                }
+            }
+
+            /* all done */
+            return i
+        }
+
+        /* maps */
+        case defs.C_map: {
+            var v int
+            var n uint64
+
+            /* check for the nesting depth */
+            if uint64(sp) >= lm.Nd {
+                return EDEPTH
+            }
+
+            /* read the map size */
+            if n, i = uvarint(buf, nb, i); i < 0 || n == 0 {
+                return i
+            } else if n > lm.Nc {
+                return ECOUNT
+            }
+
+            /* read the key and value types */
+            if i >= nb {
+                return EEOF
+            } else {
+                v, i = u8at(buf, i), i + 1
+            }
+
+            /* skip every key-value pair */
+            for ; n != 0; n-- {
+                if i = cskip(buf, nb, i, v >> 4, false, sp + 1, lm); i < 0 {
+                    return i
+                } else if i = cskip(buf, nb, i, v & 0x0f, false, sp + 1, lm); i < 0 {
+                    return i
+                }
+            }
+
+            /* all done */
+            return i
+        }
+    }
+}
+
+func cskipn(nb int, i int, n int) int {
+    if i + n > nb {
+        return EEOF
+    } else {
+        return i + n
+    }
+}
+
+var (
+    F_compact_varint    = hir.RegisterGCall(compact_varint, emu_gcall_compact_varint)
+    F_compact_uint      = hir.RegisterGCall(compact_uint, emu_gcall_compact_uint)
+    F_compact_length    = hir.RegisterGCall(compact_length, emu_gcall_compact_length)
+    F_compact_list_head = hir.RegisterGCall(compact_list_head, emu_gcall_compact_list_head)
+    F_compact_map_head  = hir.RegisterGCall(compact_map_head, emu_gcall_compact_map_head)
+    F_compact_field     = hir.RegisterGCall(compact_field, emu_gcall_compact_field)
+    F_compact_skip      = hir.RegisterGCall(compact_skip, emu_gcall_compact_skip)
+)
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package decoder
+
+import (
+    `github.com/cloudwego/frugal/internal/atm/hir`
+)
+
+func emu_gcall_compact_varint(ctx hir.CallContext) {
+    if !ctx.Verify("*ii*i", "i**") {
+        panic("invalid compact_varint call")
+    } else {
+        ret, err := compact_varint(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), ctx.Ap(3), int(ctx.Au(4)))
+        ctx.Ru(0, uint64(ret))
+        emu_seterr(ctx, 1, err)
+    }
+}
+
+func emu_gcall_compact_uint(ctx hir.CallContext) {
+    if !ctx.Verify("*iii", "ii**") {
+        panic("invalid compact_uint call")
+    } else {
+        ret, val, err := compact_uint(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), int(ctx.Au(3)))
+        ctx.Ru(0, uint64(ret))
+        ctx.Ru(1, val)
+        emu_seterr(ctx, 2, err)
+    }
+}
+
+func emu_gcall_compact_length(ctx hir.CallContext) {
+    if !ctx.Verify("*ii*", "ii**") {
+        panic("invalid compact_length call")
+    } else {
+        ret, nb, err := compact_length(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), (*RuntimeState)(ctx.Ap(3)))
+        ctx.Ru(0, uint64(ret))
+        ctx.Ru(1, uint64(nb))
+        emu_seterr(ctx, 2, err)
+    }
+}
+
+func emu_gcall_compact_list_head(ctx hir.CallContext) {
+    if !ctx.Verify("*iii*", "ii**") {
+        panic("invalid compact_list_head call")
+    } else {
+        ret, nb, err := compact_list_head(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), int(ctx.Au(3)), (*RuntimeState)(ctx.Ap(4)))
+        ctx.Ru(0, uint64(ret))
+        ctx.Ru(1, uint64(nb))
+        emu_seterr(ctx, 2, err)
+    }
+}
+
+func emu_gcall_compact_map_head(ctx hir.CallContext) {
+    if !ctx.Verify("*iii*", "ii**") {
+        panic("invalid compact_map_head call")
+    } else {
+        ret, nb, err := compact_map_head(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), int(ctx.Au(3)), (*RuntimeState)(ctx.Ap(4)))
+        ctx.Ru(0, uint64(ret))
+        ctx.Ru(1, uint64(nb))
+        emu_seterr(ctx, 2, err)
+    }
+}
+
+func emu_gcall_compact_field(ctx hir.CallContext) {
+    if !ctx.Verify("*iii", "iii**") {
+        panic("invalid compact_field call")
+    } else {
+        ret, tag, id, err := compact_field(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), int(ctx.Au(3)))
+        ctx.Ru(0, uint64(ret))
+        ctx.Ru(1, uint64(tag))
+        ctx.Ru(2, uint64(id))
+        emu_seterr(ctx, 3, err)
+    }
+}
+
+func emu_gcall_compact_skip(ctx hir.CallContext) {
+    if !ctx.Verify("*iii*", "i**") {
+        panic("invalid compact_skip call")
+    } else {
+        ret, err := compact_skip(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), int(ctx.Au(3)), (*RuntimeState)(ctx.Ap(4)))
+        ctx.Ru(0, uint64(ret))
+        emu_seterr(ctx, 1, err)
+    }
+}
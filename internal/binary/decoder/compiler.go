@@ -82,10 +82,18 @@ func (self Instr) IntSeq() (p []int) {
 func (self Instr) Disassemble() string {
     switch self.Op {
         case OP_int               : fallthrough
+        case OP_int_le            : fallthrough
+        case OP_varint            : fallthrough
         case OP_size              : fallthrough
+        case OP_ctr_load          : fallthrough
         case OP_seek              : fallthrough
+        case OP_unknown_clear     : fallthrough
+        case OP_unknown_skip      : fallthrough
+        case OP_unknown_cskip     : fallthrough
         case OP_struct_mark_tag   : return fmt.Sprintf("%-18s%d", self.Op, self.Iv)
-        case OP_type              : return fmt.Sprintf("%-18s%d", self.Op, self.Tx)
+        case OP_type              : fallthrough
+        case OP_list_head         : return fmt.Sprintf("%-18s%d", self.Op, self.Tx)
+        case OP_map_head          : return fmt.Sprintf("%-18s0x%02x", self.Op, self.Iv)
         case OP_deref             : fallthrough
         case OP_map_alloc         : fallthrough
         case OP_map_set_i8        : fallthrough
@@ -95,21 +103,34 @@ func (self Instr) Disassemble() string {
         case OP_map_set_str       : fallthrough
         case OP_map_set_enum      : fallthrough
         case OP_map_set_pointer   : fallthrough
+        case OP_map_key           : fallthrough
+        case OP_map_set           : fallthrough
         case OP_list_alloc        : fallthrough
-        case OP_construct         : fallthrough
-        case OP_defer             : return fmt.Sprintf("%-18s%s", self.Op, self.Vt)
+        case OP_construct         : return fmt.Sprintf("%-18s%s", self.Op, self.Vt)
+        case OP_uint              : fallthrough
+        case OP_varint_u          : return fmt.Sprintf("%-18s%s, %d", self.Op, self.Vt, self.Iv)
+        case OP_array             : fallthrough
+        case OP_defer             : return fmt.Sprintf("%-18s%s, %s", self.Op, self.Vt, defs.Protocol(self.Iv))
+        case OP_codec             : return fmt.Sprintf("%-18s%s, %s", self.Op, self.Codec().Type, defs.Protocol(self.Iv))
         case OP_ctr_is_zero       : fallthrough
         case OP_struct_is_stop    : fallthrough
+        case OP_struct_check_bool : fallthrough
         case OP_goto              : return fmt.Sprintf("%-18sL_%d", self.Op, self.To)
         case OP_struct_bitmap     : fallthrough
+        case OP_union_reset       : fallthrough
         case OP_struct_require    : return fmt.Sprintf("%-18s%s", self.Op, self.rtab())
-        case OP_struct_switch     : return fmt.Sprintf("%-18s%s", self.Op, self.stab())
+        case OP_struct_switch     : fallthrough
+        case OP_field_switch      : return fmt.Sprintf("%-18s%s", self.Op, self.stab())
         case OP_struct_check_type : return fmt.Sprintf("%-18s%d, L_%d", self.Op, self.Tx, self.To)
         case OP_initialize        : return fmt.Sprintf("%-18s*%p [%s]", self.Op, self.Fn, rt.FuncName(self.Fn))
         default                   : return self.Op.String()
     }
 }
 
+func (self Instr) Codec() *defs.Codec {
+    return (*defs.Codec)(self.Fn)
+}
+
 func mkins(op OpCode, dt defs.Tag, id uint16, to int, iv int64, sw []int, vt reflect.Type, fn unsafe.Pointer) Instr {
     return Instr {
         Op: op,
@@ -151,6 +172,15 @@ func (self *Program) rtt(op OpCode, vt reflect.Type)           { self.ins(mkins(
 func (self *Program) jsr(op OpCode, fn unsafe.Pointer)         { self.ins(mkins(op, 0, 0, 0, 0, nil, nil, fn)) }
 func (self *Program) jcc(op OpCode, vt defs.Tag, to int)       { self.ins(mkins(op, vt, 0, to, 0, nil, nil, nil)) }
 func (self *Program) req(op OpCode, vt reflect.Type, fv []int) { self.ins(mkins(op, 0, 0, 0, 0, fv, vt, nil)) }
+func (self *Program) cvt(op OpCode, vt reflect.Type, iv int64)  { self.ins(mkins(op, 0, 0, 0, iv, nil, vt, nil)) }
+
+func (self *Program) cdc(op OpCode, cc *defs.Codec, pt defs.Protocol) {
+    self.ins(mkins(op, 0, 0, 0, int64(pt), nil, nil, unsafe.Pointer(cc)))
+}
+
+func (self *Program) def(op OpCode, vt reflect.Type, pt defs.Protocol) {
+    self.ins(mkins(op, 0, 0, 0, int64(pt), nil, vt, nil))
+}
 
 func (self Program) Free() {
     freeProgram(self)
@@ -164,7 +194,7 @@ func (self Program) Disassemble() string {
     /* prescan to get all the labels */
     for _, ins := range self {
         if _OpBranches[ins.Op] {
-            if ins.Op != OP_struct_switch {
+            if !_OpSwitches[ins.Op] {
                 tab[ins.To] = true
             } else {
                 for _, v := range ins.IntSeq() {
@@ -203,8 +233,10 @@ func (self Program) Disassemble() string {
 
 type Compiler struct {
     o opts.Options
+    p defs.Protocol
     t map[reflect.Type]bool
     d map[reflect.Type]struct{}
+    s *Selection
 }
 
 func CreateCompiler() *Compiler {
@@ -222,7 +254,7 @@ func (self *Compiler) rescue(ep *error) {
 }
 
 func (self *Compiler) compileDef(p *Program, vt *defs.Type) {
-    p.rtt(OP_defer, vt.S)
+    p.def(OP_defer, vt.S, self.p)
     self.d[vt.S] = struct{}{}
 }
 
@@ -231,7 +263,7 @@ func (self *Compiler) compileOne(p *Program, sp int, vt *defs.Type) {
         self.compilePtr(p, sp, vt)
     } else if vt.T != defs.T_struct {
         self.compileRec(p, sp, vt)
-    } else if _, ok := self.t[vt.S]; !ok && self.o.CanInline(sp, p.pc()) {
+    } else if _, ok := self.t[vt.S]; self.s != nil || !ok && self.o.CanInline(sp, p.pc()) {
         self.compileTag(p, sp, vt)
     } else {
         self.compileDef(p, vt)
@@ -239,36 +271,72 @@ func (self *Compiler) compileOne(p *Program, sp int, vt *defs.Type) {
 }
 
 func (self *Compiler) compileTag(p *Program, sp int, vt *defs.Type) {
+    ok := self.t[vt.S]
     self.t[vt.S] = true
     self.compileRec(p, sp, vt)
-    delete(self.t, vt.S)
+
+    /* partially selected structs may be nested in themselves */
+    if !ok {
+        delete(self.t, vt.S)
+    }
+}
+
+func (self *Compiler) selectField(fv defs.Field) *Selection {
+    ps := self.s
+    self.s = ps.field(fv.Name)
+    return ps
+}
+
+func (self *Compiler) selectElem() *Selection {
+    ps := self.s
+    self.s = ps.element()
+    return ps
+}
+
+func uintSize(vt *defs.Type) int64 {
+    return int64(defs.GetIntSize(vt.V.T))
 }
 
 func (self *Compiler) compileRec(p *Program, sp int, vt *defs.Type) {
+    if self.s != nil {
+        self.s.check(vt)
+    }
+
+    /* Compact Protocol */
+    if self.p == defs.Compact {
+        self.compileCompactRec(p, sp, vt)
+        return
+    }
+
+    /* Binary Protocol */
     switch vt.T {
-        case defs.T_bool   : p.i64(OP_size, 1); p.i64(OP_int, 1)
-        case defs.T_i8     : p.i64(OP_size, 1); p.i64(OP_int, 1)
-        case defs.T_i16    : p.i64(OP_size, 2); p.i64(OP_int, 2)
-        case defs.T_i32    : p.i64(OP_size, 4); p.i64(OP_int, 4)
-        case defs.T_i64    : p.i64(OP_size, 8); p.i64(OP_int, 8)
-        case defs.T_double : p.i64(OP_size, 8); p.i64(OP_int, 8)
-        case defs.T_string : p.i64(OP_size, 4); p.add(OP_str)
-        case defs.T_binary : p.i64(OP_size, 4); p.add(OP_bin)
-        case defs.T_enum   : p.i64(OP_size, 4); p.add(OP_enum)
-        case defs.T_struct : self.compileStruct  (p, sp, vt)
-        case defs.T_map    : self.compileMap     (p, sp, vt)
-        case defs.T_set    : self.compileSetList (p, sp, vt.V)
-        case defs.T_list   : self.compileSetList (p, sp, vt.V)
-        default            : panic("unreachable")
+        case defs.T_bool     : p.i64(OP_size, 1); p.i64(OP_int, 1)
+        case defs.T_i8       : p.i64(OP_size, 1); p.i64(OP_int, 1)
+        case defs.T_i16      : p.i64(OP_size, 2); p.i64(OP_int, 2)
+        case defs.T_i32      : p.i64(OP_size, 4); p.i64(OP_int, 4)
+        case defs.T_i64      : p.i64(OP_size, 8); p.i64(OP_int, 8)
+        case defs.T_double   : p.i64(OP_size, 8); p.i64(OP_int, 8)
+        case defs.T_string   : p.i64(OP_size, 4); p.add(OP_str)
+        case defs.T_binary   : p.i64(OP_size, 4); p.add(OP_bin)
+        case defs.T_enum     : p.i64(OP_size, 4); p.add(OP_enum)
+        case defs.T_unsigned : p.i64(OP_size, uintSize(vt)); p.cvt(OP_uint, vt.S, uintSize(vt))
+        case defs.T_float    : p.i64(OP_size, 8); p.add(OP_float)
+        case defs.T_array    : p.i64(OP_size, 4); p.def(OP_array, vt.S, defs.Binary)
+        case defs.T_codec    : p.cdc(OP_codec, defs.CodecOf(vt), defs.Binary)
+        case defs.T_struct   : self.compileStruct  (p, sp, vt)
+        case defs.T_map      : self.compileMap     (p, sp, vt)
+        case defs.T_set      : self.compileSetList (p, sp, vt.V)
+        case defs.T_list     : self.compileSetList (p, sp, vt.V)
+        default              : panic("unreachable")
     }
 }
 
 func (self *Compiler) compilePtr(p *Program, sp int, vt *defs.Type) {
     p.use(sp)
-    p.add(OP_make_state)
+    p.add(OP_make_ptr_state)
     p.rtt(OP_deref, vt.V.S)
     self.compileOne(p, sp + 1, vt.V)
-    p.add(OP_drop_state)
+    p.add(OP_drop_ptr_state)
 }
 
 func (self *Compiler) compileMap(p *Program, sp int, vt *defs.Type) {
@@ -277,12 +345,11 @@ func (self *Compiler) compileMap(p *Program, sp int, vt *defs.Type) {
     p.tag(OP_type, vt.K.Tag())
     p.tag(OP_type, vt.V.Tag())
     p.add(OP_make_state)
-    p.add(OP_ctr_load)
+    p.i64(OP_ctr_load, wireSize(vt.K) + wireSize(vt.V))
     p.rtt(OP_map_alloc, vt.S)
     i := p.pc()
     p.add(OP_ctr_is_zero)
-    self.compileKey(p, sp + 1, vt)
-    self.compileOne(p, sp + 1, vt.V)
+    self.compileMapPair(p, sp + 1, vt, self.compileKey)
     p.add(OP_ctr_decr)
     p.jmp(OP_goto, i)
     p.pin(i)
@@ -290,6 +357,20 @@ func (self *Compiler) compileMap(p *Program, sp int, vt *defs.Type) {
     p.add(OP_drop_state)
 }
 
+func (self *Compiler) compileMapPair(p *Program, sp int, vt *defs.Type, key func(*Program, int, *defs.Type)) {
+    ps := self.selectElem()
+    ss := self.s
+
+    /* keys are always decoded entirely */
+    self.s = nil
+    key(p, sp, vt)
+
+    /* decode the selected values */
+    self.s = ss
+    self.compileOne(p, sp, vt.V)
+    self.s = ps
+}
+
 func (self *Compiler) compileKey(p *Program, sp int, vt *defs.Type) {
     switch vt.K.T {
         case defs.T_bool    : p.i64(OP_size, 1); p.rtt(OP_map_set_i8, vt.S)
@@ -307,6 +388,11 @@ func (self *Compiler) compileKey(p *Program, sp int, vt *defs.Type) {
 }
 
 func (self *Compiler) compileNoCopy(p *Program, sp int, vt *defs.Type) {
+    if self.s != nil {
+        self.s.check(vt)
+    }
+
+    /* no-copy strings and binaries */
     switch {
         default: {
             panic("invalid nocopy type: " + vt.String())
@@ -314,38 +400,43 @@ func (self *Compiler) compileNoCopy(p *Program, sp int, vt *defs.Type) {
 
         /* simple strings */
         case vt.T == defs.T_string: {
-            p.i64(OP_size, 4)
-            p.add(OP_str_nocopy)
+            self.compileNoCopyStr(p, OP_str_nocopy)
         }
 
         /* simple binaries */
         case vt.T == defs.T_binary: {
-            p.i64(OP_size, 4)
-            p.add(OP_bin_nocopy)
+            self.compileNoCopyStr(p, OP_bin_nocopy)
         }
 
         /* string pointers */
         case vt.T == defs.T_pointer && vt.V.T == defs.T_string: {
             p.use(sp)
-            p.add(OP_make_state)
+            p.add(OP_make_ptr_state)
             p.rtt(OP_deref, vt.V.S)
-            p.i64(OP_size, 4)
-            p.add(OP_str_nocopy)
-            p.add(OP_drop_state)
+            self.compileNoCopyStr(p, OP_str_nocopy)
+            p.add(OP_drop_ptr_state)
         }
 
         /* binary pointers */
         case vt.T == defs.T_pointer && vt.V.T == defs.T_binary: {
             p.use(sp)
-            p.add(OP_make_state)
+            p.add(OP_make_ptr_state)
             p.rtt(OP_deref, vt.V.S)
-            p.i64(OP_size, 4)
-            p.add(OP_bin_nocopy)
-            p.add(OP_drop_state)
+            self.compileNoCopyStr(p, OP_bin_nocopy)
+            p.add(OP_drop_ptr_state)
         }
     }
 }
 
+func (self *Compiler) compileNoCopyStr(p *Program, op OpCode) {
+    if self.p == defs.Compact {
+        p.i64(op, int64(defs.Compact))
+    } else {
+        p.i64(OP_size, 4)
+        p.add(op)
+    }
+}
+
 func (self *Compiler) compileKeyPtr(p *Program, sp int, vt *defs.Type) {
     pt := vt.K
     st := pt.V
@@ -363,8 +454,10 @@ func (self *Compiler) compileKeyPtr(p *Program, sp int, vt *defs.Type) {
 
 func (self *Compiler) compileStruct(p *Program, sp int, vt *defs.Type) {
     var fid int
+    var uid int
     var err error
     var req []int
+    var un bool
     var fvs []defs.Field
     var ifn unsafe.Pointer
 
@@ -373,12 +466,28 @@ func (self *Compiler) compileStruct(p *Program, sp int, vt *defs.Type) {
         panic(err)
     }
 
+    /* resolve the unknown fields collector */
+    if uid, err = defs.ResolveUnknownFields(vt.S); err != nil {
+        panic(err)
+    }
+
+    /* skip the unselected fields, instead of collecting them */
+    if self.s != nil {
+        fvs = self.s.filter(vt, fvs)
+        uid = -1
+    }
+
     /* empty struct */
-    if len(fvs) == 0 {
+    if len(fvs) == 0 && uid < 0 {
         p.add(OP_struct_ignore)
         return
     }
 
+    /* check for unions */
+    if un, err = defs.IsUnion(vt.S); err != nil {
+        panic(err)
+    }
+
     /* find the default initializer */
     if ifn, err = defs.GetDefaultInitializer(vt.S); err != nil {
         panic(err)
@@ -389,6 +498,11 @@ func (self *Compiler) compileStruct(p *Program, sp int, vt *defs.Type) {
         p.jsr(OP_initialize, ifn)
     }
 
+    /* discard the previously collected unknown fields, if any */
+    if uid >= 0 {
+        p.i64(OP_unknown_clear, int64(uid))
+    }
+
     /* find the maximum field IDs */
     for _, fv := range fvs {
         if fid = utils.MaxInt(fid, int(fv.ID)); fv.Spec == defs.Required {
@@ -405,6 +519,11 @@ func (self *Compiler) compileStruct(p *Program, sp int, vt *defs.Type) {
         p.tab(OP_struct_bitmap, req)
     }
 
+    /* unions accept at most one member, and the previously set one is discarded */
+    if un {
+        p.tab(OP_union_reset, unionMembers(fvs))
+    }
+
     /* switch jump buffer */
     i := p.pc()
     s := make([]int, fid + 1)
@@ -422,7 +541,15 @@ func (self *Compiler) compileStruct(p *Program, sp int, vt *defs.Type) {
     p.i64(OP_size, 2)
     p.tab(OP_struct_switch, s)
     k := p.pc()
-    p.add(OP_struct_skip)
+
+    /* skip or collect the unknown fields */
+    if uid < 0 {
+        p.add(OP_struct_skip)
+    } else {
+        p.i64(OP_unknown_skip, int64(uid))
+    }
+
+    /* read the next field */
     p.jmp(OP_goto, i)
 
     /* assemble every field */
@@ -435,15 +562,22 @@ func (self *Compiler) compileStruct(p *Program, sp int, vt *defs.Type) {
             p.i64(OP_struct_mark_tag, int64(fv.ID))
         }
 
+        /* count the union members */
+        if un {
+            p.add(OP_union_mark)
+        }
+
         /* seek to the field */
         off := int64(fv.F)
         p.i64(OP_seek, off)
 
         /* check for no-copy strings */
-        if fv.Opts & defs.NoCopy == 0 {
+        if ps := self.selectField(fv); fv.Opts & defs.NoCopy == 0 {
             self.compileOne(p, sp + 1, fv.Type)
+            self.s = ps
         } else if fv.Type.Tag() == defs.T_string {
             self.compileNoCopy(p, sp + 1, fv.Type)
+            self.s = ps
         } else {
             panic(`"nocopy" is only applicable to "string" or "binary" types`)
         }
@@ -469,12 +603,14 @@ func (self *Compiler) compileSetList(p *Program, sp int, et *defs.Type) {
     p.i64(OP_size, 5)
     p.tag(OP_type, et.Tag())
     p.add(OP_make_state)
-    p.add(OP_ctr_load)
+    p.i64(OP_ctr_load, wireSize(et))
     p.rtt(OP_list_alloc, et.S)
     i := p.pc()
     p.add(OP_ctr_is_zero)
     j := p.pc()
+    ps := self.selectElem()
     self.compileOne(p, sp + 1, et)
+    self.s = ps
     p.add(OP_ctr_decr)
     k := p.pc()
     p.add(OP_ctr_is_zero)
@@ -485,6 +621,28 @@ func (self *Compiler) compileSetList(p *Program, sp int, et *defs.Type) {
     p.add(OP_drop_state)
 }
 
+/* the fewest bytes a value of this type can take on the wire, used to
+ * reject container lengths that cannot possibly fit in the input */
+func wireSize(vt *defs.Type) int64 {
+    switch vt.Tag() {
+        case defs.T_i16    : return 2
+        case defs.T_i32    : return 4
+        case defs.T_i64    : return 8
+        case defs.T_double : return 8
+        case defs.T_string : return 4
+        case defs.T_map    : return 6
+        case defs.T_set    : return 5
+        case defs.T_list   : return 5
+        default            : return 1
+    }
+}
+
+func unionMembers(fvs []defs.Field) []int {
+    ret := make([]int, 0, len(fvs) * 2)
+    for _, fv := range fvs { ret = append(ret, fv.F, int(fv.Type.S.Size())) }
+    return ret
+}
+
 func (self *Compiler) Free() {
     freeCompiler(self)
 }
@@ -494,12 +652,22 @@ func (self *Compiler) Apply(o opts.Options) *Compiler {
     return self
 }
 
+func (self *Compiler) Select(s *Selection) *Compiler {
+    self.s = s
+    return self
+}
+
+func (self *Compiler) Protocol(p defs.Protocol) *Compiler {
+    self.p = p
+    return self
+}
+
 func (self *Compiler) Compile(vt reflect.Type) (_ Program, err error) {
     ret := newProgram()
-    vtp := defs.ParseType(vt, "")
-
-    /* catch the exceptions, and free the type */
     defer self.rescue(&err)
+
+    /* parse the type, and free it when done */
+    vtp := defs.ParseType(vt, "")
     defer vtp.Free()
 
     /* compile the actual type */
@@ -0,0 +1,291 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package decoder
+
+import (
+    `sort`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/utils`
+)
+
+func compactTag(vt *defs.Type) defs.Tag {
+    return defs.Tag(vt.Tag().Compact())
+}
+
+func (self *Compiler) compileCompactRec(p *Program, sp int, vt *defs.Type) {
+    switch vt.T {
+        case defs.T_bool     : p.i64(OP_size, 1); p.add(OP_bool)
+        case defs.T_i8       : p.i64(OP_size, 1); p.i64(OP_int, 1)
+        case defs.T_i16      : p.i64(OP_varint, 2)
+        case defs.T_i32      : p.i64(OP_varint, 4)
+        case defs.T_i64      : p.i64(OP_varint, 8)
+        case defs.T_double   : p.i64(OP_size, 8); p.i64(OP_int_le, 8)
+        case defs.T_string   : p.i64(OP_str, int64(defs.Compact))
+        case defs.T_binary   : p.i64(OP_bin, int64(defs.Compact))
+        case defs.T_enum     : p.i64(OP_varint, 8)
+        case defs.T_unsigned : self.compileCompactUnsigned (p, vt)
+        case defs.T_float    : p.i64(OP_size, 8); p.add(OP_float_le)
+        case defs.T_array    : p.def(OP_array, vt.S, defs.Compact)
+        case defs.T_codec    : p.cdc(OP_codec, defs.CodecOf(vt), defs.Compact)
+        case defs.T_struct   : self.compileCompactStruct   (p, sp, vt)
+        case defs.T_map      : self.compileCompactMap      (p, sp, vt)
+        case defs.T_set      : self.compileCompactSetList  (p, sp, vt.V)
+        case defs.T_list     : self.compileCompactSetList  (p, sp, vt.V)
+        default              : panic("unreachable")
+    }
+}
+
+func (self *Compiler) compileCompactUnsigned(p *Program, vt *defs.Type) {
+    if w := uintSize(vt); w != 1 {
+        p.cvt(OP_varint_u, vt.S, w)
+    } else {
+        p.i64(OP_size, 1)
+        p.cvt(OP_uint, vt.S, 1)
+    }
+}
+
+func (self *Compiler) compileCompactMap(p *Program, sp int, vt *defs.Type) {
+    p.use(sp)
+    p.add(OP_make_state)
+    p.i64(OP_map_head, int64(compactTag(vt.K) << 4 | compactTag(vt.V)))
+    p.rtt(OP_map_alloc, vt.S)
+    i := p.pc()
+    p.add(OP_ctr_is_zero)
+    self.compileMapPair(p, sp + 1, vt, self.compileCompactKey)
+    p.add(OP_ctr_decr)
+    p.jmp(OP_goto, i)
+    p.pin(i)
+    p.add(OP_map_close)
+    p.add(OP_drop_state)
+}
+
+func (self *Compiler) compileCompactKey(p *Program, sp int, vt *defs.Type) {
+    if vt.K.T == defs.T_pointer {
+        self.compileKeyPtr(p, sp, vt)
+        return
+    }
+
+    /* keys are decoded into the spill space before inserting */
+    p.rtt(OP_map_key, vt.S)
+
+    /* binary keys are stored as strings */
+    if vt.K.T == defs.T_binary {
+        p.i64(OP_str, int64(defs.Compact))
+    } else {
+        self.compileCompactRec(p, sp, vt.K)
+    }
+
+    /* insert the key into map */
+    p.rtt(OP_map_set, vt.S)
+}
+
+func (self *Compiler) compileCompactStruct(p *Program, sp int, vt *defs.Type) {
+    var fid int
+    var uid int
+    var err error
+    var req []int
+    var un bool
+    var fvs []defs.Field
+    var ifn unsafe.Pointer
+
+    /* resolve the fields */
+    if fvs, err = defs.ResolveFields(vt.S); err != nil {
+        panic(err)
+    }
+
+    /* resolve the unknown fields collector */
+    if uid, err = defs.ResolveUnknownFields(vt.S); err != nil {
+        panic(err)
+    }
+
+    /* skip the unselected fields, instead of collecting them */
+    if self.s != nil {
+        fvs = self.s.filter(vt, fvs)
+        uid = -1
+    }
+
+    /* empty struct */
+    if len(fvs) == 0 && uid < 0 {
+        p.i64(OP_struct_ignore, int64(defs.Compact))
+        return
+    }
+
+    /* check for unions */
+    if un, err = defs.IsUnion(vt.S); err != nil {
+        panic(err)
+    }
+
+    /* find the default initializer */
+    if ifn, err = defs.GetDefaultInitializer(vt.S); err != nil {
+        panic(err)
+    }
+
+    /* call the initializer if any */
+    if ifn != nil {
+        p.jsr(OP_initialize, ifn)
+    }
+
+    /* discard the previously collected unknown fields, if any */
+    if uid >= 0 {
+        p.i64(OP_unknown_clear, int64(uid))
+    }
+
+    /* find the maximum field IDs */
+    for _, fv := range fvs {
+        if fid = utils.MaxInt(fid, int(fv.ID)); fv.Spec == defs.Required {
+            req = append(req, int(fv.ID))
+        }
+    }
+
+    /* save the current state */
+    p.use(sp)
+    p.add(OP_make_state)
+
+    /* allocate bitmap for required fields, if needed */
+    if sort.Ints(req); len(req) != 0 {
+        p.tab(OP_struct_bitmap, req)
+    }
+
+    /* unions accept at most one member, and the previously set one is discarded */
+    if un {
+        p.tab(OP_union_reset, unionMembers(fvs))
+    }
+
+    /* switch jump buffer */
+    s := make([]int, fid + 1)
+
+    /* set the default branch */
+    for v := range s {
+        s[v] = -1
+    }
+
+    /* field IDs are delta-encoded, starting from zero */
+    p.add(OP_field_clear)
+    i := p.pc()
+
+    /* dispatch the next field */
+    p.add(OP_field_begin)
+    j := p.pc()
+    p.add(OP_struct_is_stop)
+    p.tab(OP_field_switch, s)
+    k := p.pc()
+
+    /* skip or collect the unknown fields */
+    if uid < 0 {
+        p.i64(OP_struct_skip, int64(defs.Compact))
+    } else {
+        p.i64(OP_unknown_cskip, int64(uid))
+    }
+
+    /* read the next field */
+    p.jmp(OP_goto, i)
+
+    /* assemble every field */
+    for _, fv := range fvs {
+        s[fv.ID] = p.pc()
+
+        /* booleans are carried by the field type */
+        if fv.Type.Tag() == defs.T_bool {
+            p.jmp(OP_struct_check_bool, k)
+        } else {
+            p.jcc(OP_struct_check_type, compactTag(fv.Type), k)
+        }
+
+        /* mark the field as seen, if needed */
+        if fv.Spec == defs.Required {
+            p.i64(OP_struct_mark_tag, int64(fv.ID))
+        }
+
+        /* count the union members */
+        if un {
+            p.add(OP_union_mark)
+        }
+
+        /* seek to the field */
+        off := int64(fv.F)
+        p.i64(OP_seek, off)
+
+        /* check for boolean fields and no-copy strings */
+        if ps := self.selectField(fv); fv.Type.Tag() == defs.T_bool {
+            self.compileCompactBool(p, sp + 1, fv.Type)
+            self.s = ps
+        } else if fv.Opts & defs.NoCopy == 0 {
+            self.compileOne(p, sp + 1, fv.Type)
+            self.s = ps
+        } else if fv.Type.Tag() == defs.T_string {
+            self.compileNoCopy(p, sp + 1, fv.Type)
+            self.s = ps
+        } else {
+            panic(`"nocopy" is only applicable to "string" or "binary" types`)
+        }
+
+        /* seek back to the beginning */
+        p.i64(OP_seek, -off)
+        p.jmp(OP_goto, i)
+    }
+
+    /* no required fields */
+    if p.pin(j); len(req) == 0 {
+        p.add(OP_drop_state)
+        return
+    }
+
+    /* check all the required fields */
+    p.req(OP_struct_require, vt.S, req)
+    p.add(OP_drop_state)
+}
+
+func (self *Compiler) compileCompactBool(p *Program, sp int, vt *defs.Type) {
+    if self.s != nil {
+        self.s.check(vt)
+    }
+
+    /* booleans are carried by the field type */
+    if vt.T != defs.T_pointer {
+        p.add(OP_field_bool)
+    } else {
+        p.use(sp)
+        p.add(OP_make_ptr_state)
+        p.rtt(OP_deref, vt.V.S)
+        p.add(OP_field_bool)
+        p.add(OP_drop_ptr_state)
+    }
+}
+
+func (self *Compiler) compileCompactSetList(p *Program, sp int, et *defs.Type) {
+    p.use(sp)
+    p.add(OP_make_state)
+    p.tag(OP_list_head, compactTag(et))
+    p.rtt(OP_list_alloc, et.S)
+    i := p.pc()
+    p.add(OP_ctr_is_zero)
+    j := p.pc()
+    ps := self.selectElem()
+    self.compileOne(p, sp + 1, et)
+    self.s = ps
+    p.add(OP_ctr_decr)
+    k := p.pc()
+    p.add(OP_ctr_is_zero)
+    p.i64(OP_seek, int64(et.S.Size()))
+    p.jmp(OP_goto, j)
+    p.pin(i)
+    p.pin(k)
+    p.add(OP_drop_state)
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `math`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/utils`
+)
+
+func float_narrow(p unsafe.Pointer, v uint64) error {
+    x := math.Float64frombits(v)
+    y := float32(x)
+
+    /* finite values must not overflow */
+    if math.IsInf(float64(y), 0) && !math.IsInf(x, 0) {
+        return utils.EKindf(utils.ErrInvalidData, "frugal: value %g overflows float32", x)
+    }
+
+    /* store the value */
+    *(*float32)(p) = y
+    return nil
+}
+
+var (
+    F_float_narrow = hir.RegisterGCall(float_narrow, emu_gcall_float_narrow)
+)
@@ -21,6 +21,7 @@ import (
     `sync/atomic`
     `unsafe`
 
+    `github.com/cloudwego/frugal/internal/binary/defs`
     `github.com/cloudwego/frugal/internal/opts`
     `github.com/cloudwego/frugal/internal/rt`
     `github.com/cloudwego/frugal/internal/utils`
@@ -35,6 +36,16 @@ type Decoder func (
     st  int,
 ) (int, error)
 
+type decodeFunc func (
+    vt  *rt.GoType,
+    buf unsafe.Pointer,
+    nb  int,
+    i   int,
+    p   unsafe.Pointer,
+    rs  *RuntimeState,
+    st  int,
+) (int, error)
+
 var (
     HitCount  uint64 = 0
     MissCount uint64 = 0
@@ -43,45 +54,111 @@ var (
 
 var (
     programCache = utils.CreateProgramCache()
+    compactCache = utils.CreateProgramCache()
 )
 
 func decode(vt *rt.GoType, buf unsafe.Pointer, nb int, i int, p unsafe.Pointer, rs *RuntimeState, st int) (int, error) {
     if dec, err := resolve(vt); err != nil {
-        return 0, err
+        return i, err
+    } else {
+        return dec(buf, nb, i, p, rs, st)
+    }
+}
+
+func decodeCompact(vt *rt.GoType, buf unsafe.Pointer, nb int, i int, p unsafe.Pointer, rs *RuntimeState, st int) (int, error) {
+    if dec, err := resolveCompact(vt); err != nil {
+        return i, err
     } else {
         return dec(buf, nb, i, p, rs, st)
     }
 }
 
+func (self Decoder) Decode(vt *rt.GoType, pt defs.Protocol, buf []byte, p unsafe.Pointer, o opts.Options) (int, error) {
+    st := newRuntimeState()
+    sl := (*rt.GoSlice)(unsafe.Pointer(&buf))
+
+    /* call the decoder with the limits */
+    st.setLimits(o)
+    ret, err := self(sl.Ptr, sl.Len, 0, p, st, 0)
+
+    /* locate the error if any, and return the state into pool */
+    err = decodeError(vt, pt, buf, st, err)
+    freeRuntimeState(st)
+    return ret, err
+}
+
+func Resolve(vt *rt.GoType, pt defs.Protocol) (Decoder, error) {
+    if pt == defs.Compact {
+        return resolveCompact(vt)
+    } else {
+        return resolve(vt)
+    }
+}
+
 func resolve(vt *rt.GoType) (Decoder, error) {
+    return resolveWith(programCache, vt, compile)
+}
+
+func resolveCompact(vt *rt.GoType) (Decoder, error) {
+    return resolveWith(compactCache, vt, compileCompact)
+}
+
+func resolveWith(pc *utils.ProgramCache, vt *rt.GoType, fn func(*rt.GoType) (interface{}, error)) (Decoder, error) {
     var err error
     var val interface{}
 
     /* fast-path: type is cached */
-    if val = programCache.Get(vt); val != nil {
+    if val = pc.Get(vt); val != nil {
         atomic.AddUint64(&HitCount, 1)
         return val.(Decoder), nil
     }
 
     /* record the cache miss, and compile the type */
     atomic.AddUint64(&MissCount, 1)
-    val, err = programCache.Compute(vt, compile)
+    val, err = pc.Compute(vt, fn)
 
     /* check for errors */
     if err != nil {
         return nil, err
     }
 
-    /* record the successful compilation */
+    /* record the successful compilation, and keep the cache within the budget */
     atomic.AddUint64(&TypeCount, 1)
+    shrink(pc)
     return val.(Decoder), nil
 }
 
+func shrink(pc *utils.ProgramCache) {
+    if n := pc.Shrink(opts.MaxCachedPrograms); n != 0 {
+        atomic.AddUint64(&TypeCount, ^uint64(n - 1))
+    }
+}
+
+// Evict removes the programs of vt from the caches, they will be compiled again on the next use.
+func Evict(vt *rt.GoType) {
+    for _, pc := range []*utils.ProgramCache { programCache, compactCache } {
+        if pc.Delete(vt) {
+            atomic.AddUint64(&TypeCount, ^uint64(0))
+        }
+    }
+
+    /* also remove the selections of vt */
+    evictSelection(vt)
+}
+
 func compile(vt *rt.GoType) (interface{}, error) {
     if pp, err := CreateCompiler().CompileAndFree(vt.Pack()); err != nil {
         return nil, err
     } else {
-        return Link(Translate(pp)), nil
+        return Link(pp), nil
+    }
+}
+
+func compileCompact(vt *rt.GoType) (interface{}, error) {
+    if pp, err := CreateCompiler().Protocol(defs.Compact).CompileAndFree(vt.Pack()); err != nil {
+        return nil, err
+    } else {
+        return Link(pp), nil
     }
 }
 
@@ -99,16 +176,16 @@ func mkcompile(ty map[reflect.Type]struct{}, opts opts.Options) func(*rt.GoType)
         if err != nil {
             return nil, err
         } else {
-            return Link(Translate(pp)), nil
+            return Link(pp), nil
         }
     }
 }
 
-type DecodeError struct {
+type InvalidUnmarshalError struct {
     vt *rt.GoType
 }
 
-func (self DecodeError) Error() string {
+func (self InvalidUnmarshalError) Error() string {
     if self.vt == nil {
         return "frugal: unmarshal to nil interface"
     } else if self.vt.Kind() == reflect.Ptr {
@@ -136,18 +213,35 @@ func Pretouch(vt *rt.GoType, opts opts.Options) (map[reflect.Type]struct{}, erro
         return nil, err
     }
 
-    /* add the type count */
+    /* add the type count, and keep the cache within the budget */
     atomic.AddUint64(&TypeCount, 1)
+    shrink(programCache)
     return ret, nil
 }
 
 func DecodeObject(buf []byte, val interface{}) (ret int, err error) {
+    return decodeObject(buf, val, decode, defs.Binary, opts.GetDefaultOptions())
+}
+
+func DecodeCompact(buf []byte, val interface{}) (ret int, err error) {
+    return decodeObject(buf, val, decodeCompact, defs.Compact, opts.GetDefaultOptions())
+}
+
+func DecodeObjectWithOptions(buf []byte, val interface{}, o opts.Options) (ret int, err error) {
+    return decodeObject(buf, val, decodeSelection(decode, defs.Binary, o.Fields), defs.Binary, o)
+}
+
+func DecodeCompactWithOptions(buf []byte, val interface{}, o opts.Options) (ret int, err error) {
+    return decodeObject(buf, val, decodeSelection(decodeCompact, defs.Compact, o.Fields), defs.Compact, o)
+}
+
+func decodeObject(buf []byte, val interface{}, fn decodeFunc, pt defs.Protocol, o opts.Options) (ret int, err error) {
     vv := rt.UnpackEface(val)
     vt := vv.Type
 
     /* check for nil interface */
     if vt == nil || vv.Value == nil || vt.Kind() != reflect.Ptr {
-        return 0, DecodeError { vt }
+        return 0, InvalidUnmarshalError { vt }
     }
 
     /* create a new runtime state */
@@ -155,8 +249,12 @@ func DecodeObject(buf []byte, val interface{}) (ret int, err error) {
     st := newRuntimeState()
     sl := (*rt.GoSlice)(unsafe.Pointer(&buf))
 
-    /* call the encoder, and return the runtime state into pool */
-    ret, err = decode(et, sl.Ptr, sl.Len, 0, vv.Value, st, 0)
+    /* call the decoder, and locate the error if any */
+    st.setLimits(o)
+    ret, err = fn(et, sl.Ptr, sl.Len, 0, vv.Value, st, 0)
+    err = decodeError(et, pt, buf, st, err)
+
+    /* return the runtime state into pool */
     freeRuntimeState(st)
     return
 }
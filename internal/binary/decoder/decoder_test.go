@@ -17,10 +17,17 @@
 package decoder
 
 import (
+    `encoding/binary`
+    `fmt`
+    `reflect`
     `testing`
     `unsafe`
 
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/binary/encoder`
+    `github.com/cloudwego/frugal/internal/opts`
     `github.com/cloudwego/frugal/internal/rt`
+    `github.com/cloudwego/frugal/internal/utils`
     `github.com/davecgh/go-spew/spew`
     `github.com/stretchr/testify/require`
 )
@@ -28,6 +35,7 @@ import (
 func TestDecoder_Decode(t *testing.T) {
     var v TranslatorTestStruct
     rs := new(RuntimeState)
+    rs.setLimits(opts.GetDefaultOptions())
     buf := []byte {
         0x02, 0x00, 0x00, 0x01, 0x03, 0x00, 0x01, 0x12, 0x04, 0x00, 0x02, 0x40, 0x28, 0xae, 0x14, 0x7a,
         0xe1, 0x47, 0xae, 0x06, 0x00, 0x03, 0x34, 0x56, 0x08, 0x00, 0x04, 0x12, 0x34, 0x56, 0x78, 0x0a,
@@ -81,6 +89,7 @@ type TestSimple struct {
 func TestDecoder_Simple(t *testing.T) {
     var v TestSimple
     rs := new(RuntimeState)
+    rs.setLimits(opts.GetDefaultOptions())
     buf := []byte {
         0x02, 0x00, 0x00, 0x01, 0x03, 0x00, 0x01, 0x12, 0x04, 0x00, 0x02, 0x40, 0x28, 0xae, 0x14, 0x7a,
         0xe1, 0x47, 0xae, 0x06, 0x00, 0x03, 0x34, 0x56, 0x08, 0x00, 0x04, 0x12, 0x34, 0x56, 0x78, 0x0a,
@@ -118,6 +127,7 @@ func (self *TestWithDefaultValue) InitDefault() {
 func TestDecoder_WithDefaultValue(t *testing.T) {
     var v TestWithDefaultValue
     rs := new(RuntimeState)
+    rs.setLimits(opts.GetDefaultOptions())
     buf := []byte { 0x0a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00 }
     sl := (*rt.GoSlice)(unsafe.Pointer(&buf))
     pos, err := decode(rt.UnpackEface(v).Type, sl.Ptr, sl.Len, 0, unsafe.Pointer(&v), rs, 0)
@@ -138,6 +148,7 @@ type TestNoCopyString struct {
 func TestDecoder_NoCopyString(t *testing.T) {
     var v TestNoCopyString
     rs := new(RuntimeState)
+    rs.setLimits(opts.GetDefaultOptions())
     buf := []byte {
         0x0b, 0, 1, 0, 0, 0, 5, 't', 'e', 's', 't', '1',
         0x0b, 0, 2, 0, 0, 0, 5, 't', 'e', 's', 't', '2',
@@ -168,3 +179,620 @@ func TestDecoder_NoCopyString(t *testing.T) {
     println("v.F: nocopy =", &(*v.F)[0])
     spew.Dump(v)
 }
+
+func newTranslatorTestStruct() TranslatorTestStruct {
+    return TranslatorTestStruct {
+        A: true,
+        B: 0x12,
+        C: 12.34,
+        D: 0x3456,
+        E: 0x12345678,
+        F: 0x66778899aabbccdd,
+        G: "hello, world",
+        H: []byte("testbytebuffer"),
+        I: []int32{0x11223344, 0x55667788, 3, 4, 5},
+        J: map[string]string{"asdf": "qwer", "zxcv": "hjkl"},
+        K: map[string]*TranslatorTestStruct{"foo": {B: -1}},
+    }
+}
+
+func TestDecoder_Compact(t *testing.T) {
+    var v TranslatorTestStruct
+    rs := new(RuntimeState)
+    rs.setLimits(opts.GetDefaultOptions())
+    exp := newTranslatorTestStruct()
+    buf := make([]byte, encoder.EncodedSizeCompact(exp))
+    _, err := encoder.EncodeCompact(buf, nil, exp)
+    require.NoError(t, err)
+    sl := (*rt.GoSlice)(unsafe.Pointer(&buf))
+    pos, err := decodeCompact(rt.UnpackEface(v).Type, sl.Ptr, sl.Len, 0, unsafe.Pointer(&v), rs, 0)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), pos)
+    require.Equal(t, int8(-1), v.K["foo"].B)
+    v.K, exp.K = nil, nil
+    require.Equal(t, exp, v)
+}
+
+type TestUnknownFields struct {
+    A int32  `frugal:"1,default,i32"`
+    U []byte `frugal:"_unknown"`
+}
+
+func TestDecoder_UnknownFields(t *testing.T) {
+    v := TestUnknownFields{U: []byte("stale")}
+    rs := new(RuntimeState)
+    rs.setLimits(opts.GetDefaultOptions())
+    buf := []byte {
+        0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x2a, 0x0a, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+        0x00, 0x07, 0x0b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x02, 0x68, 0x69, 0x00,
+    }
+    sl := (*rt.GoSlice)(unsafe.Pointer(&buf))
+    pos, err := decode(rt.UnpackEface(v).Type, sl.Ptr, sl.Len, 0, unsafe.Pointer(&v), rs, 0)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), pos)
+    require.Equal(t, int32(0x2a), v.A)
+    require.Equal(t, buf[7:27], v.U)
+}
+
+type TestCompactUnknownFields struct {
+    B int8   `frugal:"1,default,i8"`
+    U []byte `frugal:"_unknown"`
+}
+
+func TestDecoder_CompactUnknownFields(t *testing.T) {
+    v := newTranslatorTestStruct()
+    buf := make([]byte, encoder.EncodedSizeCompact(v))
+    _, err := encoder.EncodeCompact(buf, nil, v)
+    require.NoError(t, err)
+
+    /* the stale bytes must be discarded, and the headers are kept in the long form */
+    u := TestCompactUnknownFields{U: []byte("stale")}
+    pos, err := DecodeCompactWithOptions(buf, &u, opts.GetDefaultOptions())
+    require.NoError(t, err)
+    require.Equal(t, len(buf), pos)
+    require.Equal(t, int8(0x12), u.B)
+    require.Equal(t, []byte{0x01, 0x00, 0x07, 0x04}, u.U[:4])
+
+    /* encoding it back must give the same struct */
+    ret := make([]byte, encoder.EncodedSizeCompact(u))
+    n, err := encoder.EncodeCompact(ret, nil, u)
+    require.NoError(t, err)
+    require.Equal(t, len(ret), n)
+
+    /* decode with the full struct */
+    var w TranslatorTestStruct
+    pos, err = DecodeCompactWithOptions(ret, &w, opts.GetDefaultOptions())
+    require.NoError(t, err)
+    require.Equal(t, len(ret), pos)
+    require.Equal(t, int8(-1), w.K["foo"].B)
+    v.K, w.K = nil, nil
+    require.Equal(t, v, w)
+}
+
+type TestUnion struct {
+    _ struct{} `frugal:"union"`
+    A *int32   `frugal:"1,optional,i32"`
+    B *string  `frugal:"2,optional,string"`
+    C []int32  `frugal:"3,optional,list<i32>"`
+}
+
+func TestDecoder_Union(t *testing.T) {
+    var v TestUnion
+    buf := []byte { 0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x2a, 0x00 }
+    _, err := DecodeObject(buf, &v)
+    require.NoError(t, err)
+    require.Equal(t, int32(0x2a), *v.A)
+    buf = []byte {
+        0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x2a, 0x0b, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02, 0x68, 0x69,
+        0x00,
+    }
+    _, err = DecodeObject(buf, &TestUnion{})
+    require.Error(t, err)
+}
+
+func TestDecoder_UnionReused(t *testing.T) {
+    a, b := int32(1), "old"
+    v := TestUnion{A: &a, C: []int32{1, 2, 3}}
+    buf := []byte { 0x0b, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02, 0x68, 0x69, 0x00 }
+    _, err := DecodeObject(buf, &v)
+    require.NoError(t, err)
+    require.Equal(t, TestUnion{B: &[]string{"hi"}[0]}, v)
+
+    /* the same in Compact Protocol */
+    v = TestUnion{B: &b, C: []int32{1, 2, 3}}
+    _, err = DecodeCompact([]byte { 0x15, 0x54, 0x00 }, &v)
+    require.NoError(t, err)
+    require.Equal(t, TestUnion{A: &[]int32{0x2a}[0]}, v)
+
+    /* the list member is cleared entirely */
+    v = TestUnion{A: &a, B: &b}
+    _, err = DecodeObject([]byte { 0x0f, 0x00, 0x03, 0x08, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x07, 0x00 }, &v)
+    require.NoError(t, err)
+    require.Equal(t, TestUnion{C: []int32{7}}, v)
+}
+
+type TestUnsigned struct {
+    A uint32  `frugal:"1,default,i32,unsigned"`
+    B float32 `frugal:"2,default,double"`
+    C [4]byte `frugal:"3,default,binary"`
+    D uint8   `frugal:"4,default,i16,unsigned"`
+}
+
+func TestDecoder_Unsigned(t *testing.T) {
+    var v TestUnsigned
+    buf := []byte {
+        0x08, 0x00, 0x01, 0xff, 0xff, 0xff, 0xff, 0x04, 0x00, 0x02, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00,
+        0x00, 0x00, 0x0b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x04, 0x61, 0x62, 0x63, 0x64, 0x06, 0x00, 0x04,
+        0x00, 0xff, 0x00,
+    }
+    _, err := DecodeObject(buf, &v)
+    require.NoError(t, err)
+    require.Equal(t, TestUnsigned { A: 0xffffffff, B: 1.5, C: [4]byte { 'a', 'b', 'c', 'd' }, D: 0xff }, v)
+    _, err = DecodeObject([]byte { 0x06, 0x00, 0x04, 0x01, 0x00, 0x00 }, &TestUnsigned{})
+    require.Error(t, err)
+    _, err = DecodeObject([]byte { 0x0b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x02, 0x61, 0x62, 0x00 }, &TestUnsigned{})
+    require.Error(t, err)
+    _, err = DecodeObject([]byte { 0x04, 0x00, 0x02, 0x7f, 0xef, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00 }, &TestUnsigned{})
+    require.Error(t, err)
+}
+
+type TestMapLarge struct {
+    A int64    `frugal:"1,default,i64"`
+    B [16]int64
+}
+
+type TestMaps struct {
+    A map[float64]string       `frugal:"1,default,map<double:string>"`
+    B map[int64]TestMapLarge   `frugal:"2,default,map<i64:TestMapLarge>"`
+}
+
+func TestDecoder_Maps(t *testing.T) {
+    var v TestMaps
+    buf := []byte {
+        0x0d, 0x00, 0x01, 0x04, 0x0b, 0x00, 0x00, 0x00, 0x02, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00,
+        0x00, 0x00, 0x00, 0x00, 0x01, 0x61, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+        0x00, 0x01, 0x7a, 0x0d, 0x00, 0x02, 0x0a, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+        0x00, 0x00, 0x00, 0x07, 0x0a, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x09, 0x00,
+        0x00,
+    }
+    _, err := DecodeObject(buf, &v)
+    require.NoError(t, err)
+    require.Len(t, v.A, 2)
+    require.Equal(t, "a", v.A[1.5])
+    require.Equal(t, "z", v.A[0])
+    require.Equal(t, map[int64]TestMapLarge { 7: { A: 9 } }, v.B)
+}
+
+type TestCodecPoint struct {
+    X int16
+    Y int16
+}
+
+type TestCodec struct {
+    P TestCodecPoint  `frugal:"1,default,i32"`
+    Q *TestCodecPoint `frugal:"2,optional,i32"`
+}
+
+func init() {
+    err := defs.RegisterCodec(
+        reflect.TypeOf(TestCodecPoint{}),
+        "i32",
+        func(buf []byte, v interface{}) (int, error) {
+            panic("not implemented")
+        },
+        func(buf []byte, v interface{}) error {
+            if v.(*TestCodecPoint).X = int16(binary.BigEndian.Uint16(buf)); v.(*TestCodecPoint).X < 0 {
+                return fmt.Errorf("negative X")
+            }
+            v.(*TestCodecPoint).Y = int16(binary.BigEndian.Uint16(buf[2:]))
+            return nil
+        },
+        nil,
+    )
+    if err != nil {
+        panic(err)
+    }
+}
+
+func TestDecoder_Codec(t *testing.T) {
+    var v TestCodec
+    buf := []byte { 0x08, 0x00, 0x01, 0x00, 0x01, 0x00, 0x02, 0x08, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04, 0x00 }
+    _, err := DecodeObject(buf, &v)
+    require.NoError(t, err)
+    require.Equal(t, TestCodec { P: TestCodecPoint { X: 1, Y: 2 }, Q: &TestCodecPoint { X: 3, Y: 4 } }, v)
+    _, err = DecodeCompact([]byte { 0x15, 0x84, 0x80, 0x08, 0x00 }, &v)
+    require.NoError(t, err)
+    require.Equal(t, TestCodecPoint { X: 1, Y: 2 }, v.P)
+    _, err = DecodeObject([]byte { 0x08, 0x00, 0x01, 0xff, 0xff, 0x00, 0x00, 0x00 }, &v)
+    require.EqualError(t, causeOf(t, err), "negative X")
+    _, err = DecodeObject(buf[:5], &v)
+    require.IsType(t, EOFError(0), causeOf(t, err))
+}
+
+type TestRawValue struct {
+    B []byte
+    P defs.Protocol
+}
+
+func (self *TestRawValue) RawType() reflect.Type                              { return nil }
+func (self *TestRawValue) RawSize(pt defs.Protocol) (int, error)              { return len(self.B), nil }
+func (self *TestRawValue) RawEncode(buf []byte, pt defs.Protocol) (int, error) { return copy(buf, self.B), nil }
+func (self *TestRawValue) RawDecode(buf []byte, pt defs.Protocol) error        { self.B, self.P = append([]byte{}, buf...), pt; return nil }
+
+type TestRawCodec struct {
+    A int8         `frugal:"1,default,i8"`
+    R TestRawValue `frugal:"2,default,struct"`
+}
+
+func TestDecoder_RawCodec(t *testing.T) {
+    var v TestRawCodec
+    buf := []byte { 0x0c, 0x00, 0x02, 0x0b, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 'x', 0x00, 0x03, 0x00, 0x01, 0x05, 0x00 }
+    nb, err := DecodeObject(buf, &v)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), nb)
+    require.Equal(t, TestRawCodec { A: 5, R: TestRawValue { B: buf[3:12], P: defs.Binary } }, v)
+    nb, err = DecodeCompact([]byte { 0x2c, 0x18, 0x01, 'x', 0x00, 0x03, 0x02, 0x0a, 0x00 }, &v)
+    require.NoError(t, err)
+    require.Equal(t, 9, nb)
+    require.Equal(t, TestRawCodec { A: 10, R: TestRawValue { B: []byte { 0x18, 0x01, 'x', 0x00 }, P: defs.Compact } }, v)
+    _, err = DecodeObject(buf[:8], &v)
+    require.IsType(t, EOFError(0), causeOf(t, err))
+    _, err = DecodeObjectWithOptions(buf, &v, opts.Options { MaxTotalAlloc: 8 })
+    require.Equal(t, LimitError { LimitTotalAlloc, 8 }, causeOf(t, err))
+}
+
+type TestRawWireCodec struct {
+    L TestRawValue `frugal:"1,default,list<i32>"`
+    S TestRawValue `frugal:"2,default,string"`
+}
+
+func TestDecoder_RawWireCodec(t *testing.T) {
+    var v TestRawWireCodec
+    buf := []byte {
+        0x0f, 0x00, 0x01, 0x08, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x07,
+        0x0b, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 'x', 0x00,
+    }
+    nb, err := DecodeObject(buf, &v)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), nb)
+    require.Equal(t, buf[3:12], v.L.B)
+    require.Equal(t, buf[15:20], v.S.B)
+    buf[12] = 0x08
+    _, err = DecodeObject(buf, &v)
+    require.Error(t, err)
+}
+
+func TestDecoder_Resolve(t *testing.T) {
+    var v TranslatorTestStruct
+    buf := []byte {
+        0x03, 0x00, 0x01, 0x12, 0x08, 0x00, 0x04, 0x12, 0x34, 0x56, 0x78, 0x0b, 0x00, 0x06, 0x00, 0x00,
+        0x00, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x0f, 0x00, 0x08, 0x08, 0x00, 0x00, 0x00, 0x00, 0x0d,
+        0x00, 0x41, 0x0b, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x00,
+    }
+    vt := rt.UnpackEface(v).Type
+    dec, err := Resolve(vt, defs.Binary)
+    require.NoError(t, err)
+    pos, err := dec.Decode(vt, defs.Binary, buf, unsafe.Pointer(&v), opts.GetDefaultOptions())
+    require.NoError(t, err)
+    require.Equal(t, len(buf), pos)
+    require.Equal(t, int8(0x12), v.B)
+    require.Equal(t, int32(0x12345678), v.E)
+    require.Equal(t, "hello", v.G)
+    _, err = dec.Decode(vt, defs.Binary, buf[:len(buf) - 1], unsafe.Pointer(&v), opts.GetDefaultOptions())
+    require.Error(t, err)
+    _, err = Resolve(rt.UnpackType(reflect.TypeOf(make(chan int))), defs.Binary)
+    require.Error(t, err)
+}
+
+func TestDecoder_Evict(t *testing.T) {
+    var v TranslatorTestStruct
+    vt := rt.UnpackEface(v).Type
+    buf := []byte {
+        0x03, 0x00, 0x01, 0x12, 0x08, 0x00, 0x04, 0x12, 0x34, 0x56, 0x78, 0x0b, 0x00, 0x06, 0x00, 0x00,
+        0x00, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x0f, 0x00, 0x08, 0x08, 0x00, 0x00, 0x00, 0x00, 0x0d,
+        0x00, 0x41, 0x0b, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x00,
+    }
+    dec, err := Resolve(vt, defs.Binary)
+    require.NoError(t, err)
+    _, err = ResolveSelection(vt, defs.Binary, []string { "G" })
+    require.NoError(t, err)
+    Evict(vt)
+    require.Nil(t, programCache.Get(vt))
+    selectionCache.Range(func(key interface{}, _ interface{}) bool {
+        require.NotEqual(t, vt, key.(selectionKey).vt)
+        return true
+    })
+    pos, err := dec.Decode(vt, defs.Binary, buf, unsafe.Pointer(&v), opts.GetDefaultOptions())
+    require.NoError(t, err)
+    require.Equal(t, len(buf), pos)
+    v = TranslatorTestStruct{}
+    pos, err = DecodeObject(buf, &v)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), pos)
+    require.Equal(t, "hello", v.G)
+    require.NotNil(t, programCache.Get(vt))
+}
+
+func TestDecoder_MaxCachedPrograms(t *testing.T) {
+    pc := utils.CreateProgramCache()
+    old := opts.MaxCachedPrograms
+    opts.MaxCachedPrograms = 1
+    defer func() { opts.MaxCachedPrograms = old }()
+    _, err := resolveWith(pc, rt.UnpackEface(TranslatorTestStruct{}).Type, compile)
+    require.NoError(t, err)
+    _, err = resolveWith(pc, rt.UnpackEface(TestNoCopyString{}).Type, compile)
+    require.NoError(t, err)
+    require.Equal(t, 1, pc.Len())
+}
+
+func TestDecoder_UnexpectedEOF(t *testing.T) {
+    var v TestNoCopyString
+    buf := []byte { 0x0b, 0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x00 }
+    for i := 0; i < len(buf); i++ {
+        _, err := DecodeObject(buf[:i], &v)
+        require.Error(t, err)
+        require.IsType(t, EOFError(0), causeOf(t, err))
+        if i >= 7 && i < 12 {
+            require.Equal(t, EOFError(12 - i), causeOf(t, err))
+            require.Equal(t, 3, err.(*DecodeError).Offset)
+        }
+    }
+}
+
+type TestLimits struct {
+    A string          `frugal:"1,default,string"`
+    B []int32         `frugal:"2,default,list<i32>"`
+    C *TestLimits     `frugal:"3,optional,TestLimits"`
+    D map[int32]int32 `frugal:"4,default,map<i32:i32>"`
+}
+
+type TestLimitsSkip struct {
+    A string `frugal:"1,default,string"`
+}
+
+func TestDecoder_Limits(t *testing.T) {
+    buf := []byte {
+        0x0b, 0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x0f, 0x00, 0x02, 0x08,
+        0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03,
+        0x0c, 0x00, 0x03, 0x0d, 0x00, 0x04, 0x08, 0x08, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+        0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+    }
+    limits := func(fn func(o *opts.Options)) opts.Options {
+        o := opts.GetDefaultOptions()
+        fn(&o)
+        return o
+    }
+    for _, tc := range []struct {
+        o   opts.Options
+        err error
+    } {
+        { limits(func(o *opts.Options) {}), nil },
+        { limits(func(o *opts.Options) { o.MaxStringLen = 4 }), LimitError { LimitStringLen, 4 } },
+        { limits(func(o *opts.Options) { o.MaxStringLen = 5 }), nil },
+        { limits(func(o *opts.Options) { o.MaxContainerLen = 2 }), LimitError { LimitContainerLen, 2 } },
+        { limits(func(o *opts.Options) { o.MaxContainerLen = 3 }), nil },
+        { limits(func(o *opts.Options) { o.MaxDepth = 2 }), LimitError { LimitDepth, 2 } },
+        { limits(func(o *opts.Options) { o.MaxDepth = 3 }), nil },
+        { limits(func(o *opts.Options) { o.MaxTotalAlloc = 80 }), LimitError { LimitTotalAlloc, 80 } },
+        { limits(func(o *opts.Options) { o.MaxTotalAlloc = 81 }), nil },
+    } {
+        var v TestLimits
+        pos, err := DecodeObjectWithOptions(buf, &v, tc.o)
+        if tc.err != nil {
+            require.Equal(t, tc.err, causeOf(t, err))
+            continue
+        }
+        require.NoError(t, err)
+        require.Equal(t, len(buf), pos)
+        require.Equal(t, TestLimits {
+            A: "hello",
+            B: []int32{1, 2, 3},
+            C: &TestLimits { D: map[int32]int32{1: 2} },
+        }, v)
+    }
+    _, err := DecodeObjectWithOptions(buf, &TestLimitsSkip{}, limits(func(o *opts.Options) { o.MaxContainerLen = 2 }))
+    require.Equal(t, LimitError { LimitContainerLen, 2 }, causeOf(t, err))
+    _, err = DecodeObjectWithOptions(buf, &TestLimitsSkip{}, limits(func(o *opts.Options) { o.MaxDepth = 2 }))
+    require.Equal(t, LimitError { LimitDepth, 2 }, causeOf(t, err))
+    _, err = DecodeObjectWithOptions(buf, &TestLimitsSkip{}, limits(func(o *opts.Options) { o.MaxDepth = 3 }))
+    require.NoError(t, err)
+}
+
+func TestDecoder_CompactLimits(t *testing.T) {
+    buf := []byte { 0x18, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x19, 0x35, 0x02, 0x04, 0x06, 0x00 }
+    o := opts.GetDefaultOptions()
+    o.MaxStringLen = 4
+    _, err := DecodeCompactWithOptions(buf, &TestLimits{}, o)
+    require.Equal(t, LimitError { LimitStringLen, 4 }, causeOf(t, err))
+    o = opts.GetDefaultOptions()
+    o.MaxContainerLen = 2
+    _, err = DecodeCompactWithOptions(buf, &TestLimits{}, o)
+    require.Equal(t, LimitError { LimitContainerLen, 2 }, causeOf(t, err))
+    _, err = DecodeCompactWithOptions(buf, &TestLimitsSkip{}, o)
+    require.Equal(t, LimitError { LimitContainerLen, 2 }, causeOf(t, err))
+    o.MaxContainerLen = 3
+    _, err = DecodeCompactWithOptions(buf, &TestLimits{}, o)
+    require.NoError(t, err)
+    o = opts.GetDefaultOptions()
+    o.MaxDepth = 2
+    _, err = DecodeCompactWithOptions([]byte { 0x3c, 0x1c, 0x00, 0x00, 0x00 }, &TestLimitsSkip{}, o)
+    require.Equal(t, LimitError { LimitDepth, 2 }, causeOf(t, err))
+    o.MaxDepth = 3
+    _, err = DecodeCompactWithOptions([]byte { 0x3c, 0x1c, 0x00, 0x00, 0x00 }, &TestLimitsSkip{}, o)
+    require.NoError(t, err)
+}
+
+func TestDecoder_ContainerLength(t *testing.T) {
+    for _, tc := range []struct {
+        pt  defs.Protocol
+        buf []byte
+        err error
+    } {
+        { defs.Binary  , []byte { 0x0f, 0x00, 0x02, 0x08, 0x7f, 0xff, 0xff, 0xff, 0x00 }      , EOFError(8 + 0x7fffffff * 4 - 9) },
+        { defs.Binary  , []byte { 0x0d, 0x00, 0x04, 0x08, 0x08, 0x7f, 0xff, 0xff, 0xff, 0x00 }, EOFError(9 + 0x7fffffff * 8 - 10) },
+        { defs.Compact , []byte { 0x29, 0xf5, 0xff, 0xff, 0xff, 0x07, 0x00 }                  , EOFError(0xffffff - 1) },
+        { defs.Compact , []byte { 0x4b, 0xff, 0xff, 0xff, 0x07, 0x55, 0x00 }                  , EOFError(0xffffff * 2 - 2) },
+    } {
+        var err error
+        if tc.pt == defs.Binary {
+            _, err = DecodeObject(tc.buf, &TestLimits{})
+        } else {
+            _, err = DecodeCompact(tc.buf, &TestLimits{})
+        }
+        require.Equal(t, tc.err, causeOf(t, err))
+    }
+}
+
+func causeOf(t *testing.T, err error) error {
+    require.IsType(t, (*DecodeError)(nil), err)
+    return err.(*DecodeError).Err
+}
+
+type TestErrorItem struct {
+    Price int64 `frugal:"1,default,i64" thrift:"price,1"`
+}
+
+type TestErrorReq struct {
+    ID    int32            `frugal:"1,required,i32" thrift:"id,1"`
+    Items []*TestErrorItem `frugal:"2,default,list<TestErrorItem>" thrift:"items,2"`
+    Tags  map[string]int32 `frugal:"3,default,map<string:i32>" thrift:"tags,3"`
+}
+
+func decodeErrorOf(t *testing.T, buf []byte, pt defs.Protocol) *DecodeError {
+    var err error
+    var v TestErrorReq
+    if pt == defs.Compact {
+        _, err = DecodeCompact(buf, &v)
+    } else {
+        _, err = DecodeObject(buf, &v)
+    }
+    require.IsType(t, (*DecodeError)(nil), err)
+    require.Equal(t, reflect.TypeOf(v), err.(*DecodeError).Type)
+    return err.(*DecodeError)
+}
+
+func TestDecoder_ErrorLocation(t *testing.T) {
+    buf := []byte {
+        0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x07, 0x0f, 0x00, 0x02, 0x0c, 0x00, 0x00, 0x00, 0x02, 0x0a,
+        0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0a, 0x00, 0x01, 0x00, 0x00,
+        0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x0d, 0x00, 0x03, 0x0b, 0x08, 0x00, 0x00, 0x00, 0x01,
+        0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x00, 0x00, 0x05, 0x00,
+    }
+    var v TestErrorReq
+    _, err := DecodeObject(buf, &v)
+    require.NoError(t, err)
+    for _, tc := range []struct {
+        buf  []byte
+        path string
+        id   int
+        off  int
+    } {
+        { buf[:33], "TestErrorReq.items[1].price", 1, 30 },
+        { buf[:27], "TestErrorReq.items[1]", 2, 27 },
+        { buf[:20], "TestErrorReq.items[0].price", 1, 18 },
+        { buf[:56], "TestErrorReq.tags[0]", 3, 53 },
+        { buf[:50], "TestErrorReq.tags", 3, 42 },
+        { buf[:9], "TestErrorReq", -1, 8 },
+        { buf[7:], "TestErrorReq", -1, 51 },
+        { []byte { 0x08, 0x00, 0x09, 0x00, 0x00 }, "TestErrorReq.9", 9, 3 },
+    } {
+        de := decodeErrorOf(t, tc.buf, defs.Binary)
+        require.Equal(t, tc.path, de.Path)
+        require.Equal(t, tc.id, de.FieldID)
+        require.Equal(t, tc.off, de.Offset)
+    }
+    de := decodeErrorOf(t, buf[:33], defs.Binary)
+    require.Equal(t, EOFError(5), de.Err)
+    require.EqualError(t, de, "frugal: unexpected EOF: 5 bytes short (at TestErrorReq.items[1].price, offset 30)")
+    mm := append([]byte(nil), buf...)
+    mm[10] = 0x08
+    de = decodeErrorOf(t, mm, defs.Binary)
+    require.Equal(t, "TestErrorReq.items", de.Path)
+    require.Equal(t, 10, de.Offset)
+    require.Equal(t, "struct", de.Expected)
+    require.Equal(t, "i32", de.Actual)
+    require.EqualError(t, de.Err, "frugal: type mismatch: struct expected, got i32")
+}
+
+func TestDecoder_CompactErrorLocation(t *testing.T) {
+    buf := []byte {
+        0x15, 0x0e, 0x19, 0x2c, 0x16, 0x06, 0x00, 0x16, 0x08, 0x00, 0x1b, 0x01, 0x85, 0x01, 0x61, 0x0a,
+        0x00,
+    }
+    var v TestErrorReq
+    _, err := DecodeCompact(buf, &v)
+    require.NoError(t, err)
+    for _, tc := range []struct {
+        buf  []byte
+        path string
+        id   int
+        off  int
+    } {
+        { buf[:8], "TestErrorReq.items[1].price", 1, 8 },
+        { buf[:7], "TestErrorReq.items[1]", 2, 7 },
+        { buf[:15], "TestErrorReq.tags[0]", 3, 15 },
+        { buf[:12], "TestErrorReq.tags", 3, 11 },
+        { buf[2:], "TestErrorReq", -1, 15 },
+    } {
+        de := decodeErrorOf(t, tc.buf, defs.Compact)
+        require.Equal(t, tc.path, de.Path)
+        require.Equal(t, tc.id, de.FieldID)
+        require.Equal(t, tc.off, de.Offset)
+    }
+    mm := append([]byte(nil), buf...)
+    mm[3] = 0x25
+    de := decodeErrorOf(t, mm, defs.Compact)
+    require.Equal(t, "TestErrorReq.items", de.Path)
+    require.Equal(t, 3, de.Offset)
+    require.Equal(t, "struct", de.Expected)
+    require.Equal(t, "i32", de.Actual)
+    mm = append([]byte(nil), buf...)
+    mm[12] = 0x88
+    de = decodeErrorOf(t, mm, defs.Compact)
+    require.Equal(t, "TestErrorReq.tags", de.Path)
+    require.Equal(t, "i32", de.Expected)
+    require.Equal(t, "string", de.Actual)
+}
+
+type TestErrorTag struct {
+    A int32 `frugal:"1,default"`
+}
+
+func TestDecoder_ErrorKinds(t *testing.T) {
+    var v TestErrorReq
+    var u TestUnion
+    var s TestLimits
+    _, err := DecodeObject([]byte { 0x08, 0x00, 0x01, 0x00, 0x00 }, &v)
+    require.ErrorIs(t, err, utils.ErrTruncated)
+    _, err = DecodeObject([]byte { 0x0f, 0x00, 0x02, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00 }, &v)
+    require.ErrorIs(t, err, utils.ErrTypeMismatch)
+    _, err = DecodeObject([]byte { 0x00 }, &v)
+    require.ErrorIs(t, err, utils.ErrMissingRequired)
+    require.EqualError(t, causeOf(t, err), "frugal: missing required field 1 for type decoder.TestErrorReq")
+    _, err = DecodeObject([]byte { 0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x2a, 0x0b, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00 }, &u)
+    require.ErrorIs(t, err, utils.ErrInvalidData)
+    _, err = DecodeCompact([]byte { 0x15, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00 }, &v)
+    require.ErrorIs(t, err, utils.ErrInvalidData)
+    o := opts.GetDefaultOptions()
+    o.MaxStringLen = 4
+    _, err = DecodeObjectWithOptions([]byte { 0x0b, 0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x00 }, &s, o)
+    require.ErrorIs(t, err, utils.ErrLimitExceeded)
+    _, err = DecodeObject([]byte { 0x00 }, &TestErrorTag{})
+    require.ErrorIs(t, err, utils.ErrUnsupportedType)
+    _, err = Resolve(rt.UnpackType(reflect.TypeOf(make(chan int))), defs.Binary)
+    require.ErrorIs(t, err, utils.ErrUnsupportedType)
+    require.NotErrorIs(t, err, utils.ErrInvalidData)
+}
+
+func TestDecoder_PretouchMaxCachedPrograms(t *testing.T) {
+    old := opts.MaxCachedPrograms
+    opts.MaxCachedPrograms = 1
+    defer func() { opts.MaxCachedPrograms = old }()
+    for _, v := range []interface{} { TranslatorTestStruct{}, TestNoCopyString{} } {
+        vt := rt.UnpackEface(v).Type
+        Evict(vt)
+        _, err := Pretouch(vt, opts.GetDefaultOptions())
+        require.NoError(t, err)
+    }
+    require.Equal(t, 1, programCache.Len())
+}
@@ -19,34 +19,155 @@ package decoder
 import (
     `fmt`
     `math/bits`
+    `reflect`
 
     `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/binary/defs`
     `github.com/cloudwego/frugal/internal/rt`
+    `github.com/cloudwego/frugal/internal/utils`
 )
 
+type DecodeError struct {
+    Type     reflect.Type   // The type being decoded.
+    Path     string         // Path of the value that failed, like `Req.items[3].price`.
+    FieldID  int            // ID of the last field in the path, -1 if there is none.
+    Expected string         // Expected wire type, only for type mismatches.
+    Actual   string         // Actual wire type, only for type mismatches.
+    Offset   int            // Offset in the input where the failed value starts.
+    Err      error          // The underlying error.
+}
+
+func (self *DecodeError) Error() string {
+    return fmt.Sprintf("%s (at %s, offset %d)", self.Err.Error(), self.Path, self.Offset)
+}
+
+func (self *DecodeError) Unwrap() error {
+    return self.Err
+}
+
+type MismatchError struct {
+    Expected string
+    Actual   string
+}
+
+func (self MismatchError) Error() string {
+    return fmt.Sprintf("frugal: type mismatch: %s expected, got %s", self.Expected, self.Actual)
+}
+
+func (self MismatchError) Is(err error) bool {
+    return err == utils.ErrTypeMismatch
+}
+
+type EOFError int
+
+func (self EOFError) Error() string {
+    if self > 0 {
+        return fmt.Sprintf("frugal: unexpected EOF: %d bytes short", int(self))
+    } else {
+        return "frugal: unexpected EOF"
+    }
+}
+
+func (self EOFError) Is(err error) bool {
+    return err == utils.ErrTruncated
+}
+
+type Limit int
+
+const (
+    LimitContainerLen Limit = iota + 1
+    LimitStringLen
+    LimitDepth
+    LimitTotalAlloc
+)
+
+func (self Limit) String() string {
+    switch self {
+        case LimitContainerLen : return "container length"
+        case LimitStringLen    : return "string length"
+        case LimitDepth        : return "nesting depth"
+        case LimitTotalAlloc   : return "total allocation"
+        default                : return fmt.Sprintf("Limit(%d)", int(self))
+    }
+}
+
+type LimitError struct {
+    Limit Limit
+    Max   int
+}
+
+func (self LimitError) Error() string {
+    return fmt.Sprintf("frugal: %s exceeds the limit of %d", self.Limit, self.Max)
+}
+
+func (self LimitError) Is(err error) bool {
+    return err == utils.ErrLimitExceeded
+}
+
+func limitError(l Limit, rs *RuntimeState) error {
+    switch l {
+        case LimitContainerLen : return LimitError { l, int(rs.Lo.Nc) }
+        case LimitStringLen    : return LimitError { l, int(rs.Lo.Ns) }
+        case LimitDepth        : return LimitError { l, int(rs.Lo.Nd) }
+        case LimitTotalAlloc   : return LimitError { l, int(rs.Lo.Na) }
+        default                : panic("invalid limit")
+    }
+}
+
 //go:nosplit
 func error_eof(n int) error {
-    return fmt.Errorf("frugal: unexpected EOF: %d bytes short", n)
+    return EOFError(n)
 }
 
 //go:nosplit
-func error_skip(e int) error {
+func error_skip(e int, rs *RuntimeState) error {
     switch e {
-        case ETAG   : return fmt.Errorf("frugal: error when skipping fields: -1 (invalid tag)")
-        case EEOF   : return fmt.Errorf("frugal: error when skipping fields: -2 (unexpected EOF)")
-        case ESTACK : return fmt.Errorf("frugal: error when skipping fields: -3 (value nesting too deep)")
-        default     : return fmt.Errorf("frugal: error when skipping fields: %d (unknown error)", e)
+        case ETAG    : return utils.EKindf(utils.ErrInvalidData, "frugal: error when skipping fields: -1 (invalid tag)")
+        case EEOF    : return EOFError(0)
+        case ESTACK  : return utils.EKindf(utils.ErrInvalidData, "frugal: error when skipping fields: -3 (value nesting too deep)")
+        case EVARINT : return utils.EKindf(utils.ErrInvalidData, "frugal: error when skipping fields: -4 (malformed varint)")
+        case ECOUNT  : return limitError(LimitContainerLen, rs)
+        case ESIZE   : return limitError(LimitStringLen, rs)
+        case EDEPTH  : return limitError(LimitDepth, rs)
+        default      : return utils.EKindf(utils.ErrInvalidData, "frugal: error when skipping fields: %d (unknown error)", e)
     }
 }
 
+//go:nosplit
+func error_limit(l int, rs *RuntimeState) error {
+    return limitError(Limit(l), rs)
+}
+
 //go:nosplit
 func error_type(e uint8, t uint8) error {
-    return fmt.Errorf("frugal: type mismatch: %d expected, got %d", e, t)
+    return MismatchError { defs.Tag(e).String(), defs.Tag(t).String() }
+}
+
+func error_ctype(e int, t int) error {
+    return MismatchError { ctypeName(e), ctypeName(t) }
+}
+
+func ctypeName(v int) string {
+    if tag, ok := defs.CompactTag(uint8(v)); ok {
+        return tag.String()
+    } else {
+        return fmt.Sprintf("compact type %d", v)
+    }
 }
 
 //go:nosplit
 func error_missing(t *rt.GoType, i int, m uint64) error {
-    return fmt.Errorf("frugal: missing required field %d for type %s", i * 64 + bits.TrailingZeros64(m), t)
+    return utils.EKindf(utils.ErrMissingRequired, "frugal: missing required field %d for type %s", i * 64 + bits.TrailingZeros64(m), t)
+}
+
+//go:nosplit
+func error_range(v uint64, n int) error {
+    return utils.EKindf(utils.ErrInvalidData, "frugal: value %d overflows %d-bit unsigned integer", v, n * 8)
+}
+
+//go:nosplit
+func error_length(n int, m int) error {
+    return utils.EKindf(utils.ErrInvalidData, "frugal: fixed-size binary expects %d bytes, got %d", m, n)
 }
 
 var (
@@ -54,4 +175,7 @@ var (
     F_error_skip    = hir.RegisterGCall(error_skip, emu_gcall_error_skip)
     F_error_type    = hir.RegisterGCall(error_type, emu_gcall_error_type)
     F_error_missing = hir.RegisterGCall(error_missing, emu_gcall_error_missing)
+    F_error_range   = hir.RegisterGCall(error_range, emu_gcall_error_range)
+    F_error_length  = hir.RegisterGCall(error_length, emu_gcall_error_length)
+    F_error_limit   = hir.RegisterGCall(error_limit, emu_gcall_error_limit)
 )
@@ -38,10 +38,10 @@ func emu_gcall_error_eof(ctx hir.CallContext) {
 }
 
 func emu_gcall_error_skip(ctx hir.CallContext) {
-    if !ctx.Verify("i", "**") {
+    if !ctx.Verify("i*", "**") {
         panic("invalid error_skip call")
     } else {
-        emu_seterr(ctx, 0, error_skip(int(ctx.Au(0))))
+        emu_seterr(ctx, 0, error_skip(int(ctx.Au(0)), (*RuntimeState)(ctx.Ap(1))))
     }
 }
 
@@ -60,3 +60,27 @@ func emu_gcall_error_missing(ctx hir.CallContext) {
         emu_seterr(ctx, 0, error_missing((*rt.GoType)(ctx.Ap(0)), int(ctx.Au(1)), ctx.Au(2)))
     }
 }
+
+func emu_gcall_error_range(ctx hir.CallContext) {
+    if !ctx.Verify("ii", "**") {
+        panic("invalid error_range call")
+    } else {
+        emu_seterr(ctx, 0, error_range(ctx.Au(0), int(ctx.Au(1))))
+    }
+}
+
+func emu_gcall_error_length(ctx hir.CallContext) {
+    if !ctx.Verify("ii", "**") {
+        panic("invalid error_length call")
+    } else {
+        emu_seterr(ctx, 0, error_length(int(ctx.Au(0)), int(ctx.Au(1))))
+    }
+}
+
+func emu_gcall_error_limit(ctx hir.CallContext) {
+    if !ctx.Verify("i*", "**") {
+        panic("invalid error_limit call")
+    } else {
+        emu_seterr(ctx, 0, error_limit(int(ctx.Au(0)), (*RuntimeState)(ctx.Ap(1))))
+    }
+}
@@ -0,0 +1,1213 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `encoding/binary`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+/** Interpreter
+ *
+ *      Every instruction is compiled into a closure with all the operands resolved, which
+ *      runs the instruction and returns the index of the next one, or _PC_halt to stop.
+ */
+
+type (
+    _Op func(fr *_Frame) int
+)
+
+type _Frame struct {
+    buf unsafe.Pointer
+    nb  int
+    ic  int
+    wp  unsafe.Pointer
+    rs  *RuntimeState
+    st  int
+    tg  uint8
+    err error
+}
+
+const (
+    _PC_halt = -1
+)
+
+func (self *_Frame) at(i int) unsafe.Pointer {
+    return unsafe.Pointer(uintptr(self.buf) + uintptr(i))
+}
+
+func (self *_Frame) state() *StateItem {
+    return (*StateItem)(unsafe.Pointer(uintptr(unsafe.Pointer(self.rs)) + uintptr(self.st)))
+}
+
+func (self *_Frame) fail(err error) int {
+    self.err = err
+    return _PC_halt
+}
+
+func (self *_Frame) alloc(n uint64) bool {
+    if n > self.rs.Lm.Na {
+        return false
+    } else {
+        self.rs.Lm.Na -= n
+        return true
+    }
+}
+
+func (self *_Frame) u8() uint8 {
+    v := *(*uint8)(self.at(self.ic))
+    self.ic += 1
+    return v
+}
+
+func (self *_Frame) u16() uint16 {
+    v := binary.BigEndian.Uint16((*[2]byte)(self.at(self.ic))[:])
+    self.ic += 2
+    return v
+}
+
+func (self *_Frame) u32() uint32 {
+    v := binary.BigEndian.Uint32((*[4]byte)(self.at(self.ic))[:])
+    self.ic += 4
+    return v
+}
+
+func (self *_Frame) u64() uint64 {
+    v := binary.BigEndian.Uint64((*[8]byte)(self.at(self.ic))[:])
+    self.ic += 8
+    return v
+}
+
+func (self *_Frame) uint(w int64) uint64 {
+    switch w {
+        case 1  : return uint64(self.u8())
+        case 2  : return uint64(self.u16())
+        case 4  : return uint64(self.u32())
+        case 8  : return self.u64()
+        default : panic("can only convert 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func (self *_Frame) skip(t defs.Tag) (int, bool) {
+    n := do_skip((*_skipbuf_t)(&self.rs.Sk), self.at(self.ic), self.nb - self.ic, t, &self.rs.Lm)
+    return n, n >= 0
+}
+
+/* the length of strings and binaries, and the pointer to the content if not empty */
+
+func (self *_Frame) binstr(pt defs.Protocol) (p unsafe.Pointer, n int, err error) {
+    if pt == defs.Compact {
+        if self.ic, n, err = compact_length(self.buf, self.nb, self.ic, self.rs); err != nil || n == 0 {
+            return
+        }
+    } else {
+        if n = int(self.u32()); uint64(n) > self.rs.Lm.Ns {
+            return nil, 0, limitError(LimitStringLen, self.rs)
+        } else if self.ic + n > self.nb {
+            return nil, 0, error_eof(self.ic + n - self.nb)
+        } else if n == 0 {
+            return
+        }
+    }
+
+    /* move to the end of the content */
+    p = self.at(self.ic)
+    self.ic += n
+    return
+}
+
+func storeUint(p unsafe.Pointer, v uint64, n uintptr) {
+    switch n {
+        case 1  : *(*uint8)(p) = uint8(v)
+        case 2  : *(*uint16)(p) = uint16(v)
+        case 4  : *(*uint32)(p) = uint32(v)
+        case 8  : *(*uint64)(p) = v
+        default : panic("can only store 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func overflowUint(v uint64, n uintptr) bool {
+    return n < 8 && v >> (n * 8) != 0
+}
+
+func mkswitch(v Instr) []int {
+    return append([]int(nil), v.IntSeq()...)
+}
+
+func interpret(s Program) []_Op {
+    ret := make([]_Op, 0, len(s) + 1)
+
+    /* compile every instruction */
+    for i, v := range s {
+        ret = append(ret, interpreters[v.Op](v, i + 1))
+    }
+
+    /* running off the end of the program halts */
+    return append(ret, interpret_OP_halt(Instr{}, len(s) + 1))
+}
+
+var (
+    interpreters [256]func(Instr, int) _Op
+)
+
+/* the table is filled at init time, since the deferred ops refer back to the linker */
+func init() {
+    interpreters = [256]func(Instr, int) _Op {
+        OP_int               : interpret_OP_int,
+        OP_uint              : interpret_OP_uint,
+        OP_float             : interpret_OP_float,
+        OP_str               : interpret_OP_str,
+        OP_str_nocopy        : interpret_OP_str_nocopy,
+        OP_bin               : interpret_OP_bin,
+        OP_bin_nocopy        : interpret_OP_bin_nocopy,
+        OP_array             : interpret_OP_array,
+        OP_codec             : interpret_OP_codec,
+        OP_enum              : interpret_OP_enum,
+        OP_varint            : interpret_OP_varint,
+        OP_varint_u          : interpret_OP_varint_u,
+        OP_bool              : interpret_OP_bool,
+        OP_int_le            : interpret_OP_int_le,
+        OP_float_le          : interpret_OP_float_le,
+        OP_size              : interpret_OP_size,
+        OP_type              : interpret_OP_type,
+        OP_seek              : interpret_OP_seek,
+        OP_deref             : interpret_OP_deref,
+        OP_ctr_load          : interpret_OP_ctr_load,
+        OP_ctr_decr          : interpret_OP_ctr_decr,
+        OP_ctr_is_zero       : interpret_OP_ctr_is_zero,
+        OP_list_head         : interpret_OP_list_head,
+        OP_map_head          : interpret_OP_map_head,
+        OP_map_alloc         : interpret_OP_map_alloc,
+        OP_map_close         : interpret_OP_map_close,
+        OP_map_set_i8        : interpret_OP_map_set_i8,
+        OP_map_set_i16       : interpret_OP_map_set_i16,
+        OP_map_set_i32       : interpret_OP_map_set_i32,
+        OP_map_set_i64       : interpret_OP_map_set_i64,
+        OP_map_set_str       : interpret_OP_map_set_str,
+        OP_map_set_enum      : interpret_OP_map_set_enum,
+        OP_map_set_pointer   : interpret_OP_map_set_pointer,
+        OP_map_key           : interpret_OP_map_key,
+        OP_map_set           : interpret_OP_map_set,
+        OP_list_alloc        : interpret_OP_list_alloc,
+        OP_struct_skip       : interpret_OP_struct_skip,
+        OP_struct_ignore     : interpret_OP_struct_ignore,
+        OP_struct_bitmap     : interpret_OP_struct_bitmap,
+        OP_struct_switch     : interpret_OP_struct_switch,
+        OP_struct_require    : interpret_OP_struct_require,
+        OP_struct_is_stop    : interpret_OP_struct_is_stop,
+        OP_struct_mark_tag   : interpret_OP_struct_mark_tag,
+        OP_struct_read_type  : interpret_OP_struct_read_type,
+        OP_struct_check_type : interpret_OP_struct_check_type,
+        OP_struct_check_bool : interpret_OP_struct_check_bool,
+        OP_field_clear       : interpret_OP_field_clear,
+        OP_field_begin       : interpret_OP_field_begin,
+        OP_field_switch      : interpret_OP_field_switch,
+        OP_field_bool        : interpret_OP_field_bool,
+        OP_unknown_clear     : interpret_OP_unknown_clear,
+        OP_unknown_skip      : interpret_OP_unknown_skip,
+        OP_unknown_cskip     : interpret_OP_unknown_cskip,
+        OP_union_reset       : interpret_OP_union_reset,
+        OP_union_mark        : interpret_OP_union_mark,
+        OP_make_state        : interpret_OP_make_state,
+        OP_drop_state        : interpret_OP_drop_state,
+        OP_make_ptr_state    : interpret_OP_make_ptr_state,
+        OP_drop_ptr_state    : interpret_OP_drop_ptr_state,
+        OP_construct         : interpret_OP_construct,
+        OP_initialize        : interpret_OP_initialize,
+        OP_defer             : interpret_OP_defer,
+        OP_goto              : interpret_OP_goto,
+        OP_halt              : interpret_OP_halt,
+    }
+}
+
+func interpret_OP_int(v Instr, next int) _Op {
+    switch v.Iv {
+        case 1  : return func(fr *_Frame) int { *(*uint8)(fr.wp) = fr.u8(); return next }
+        case 2  : return func(fr *_Frame) int { *(*uint16)(fr.wp) = fr.u16(); return next }
+        case 4  : return func(fr *_Frame) int { *(*uint32)(fr.wp) = fr.u32(); return next }
+        case 8  : return func(fr *_Frame) int { *(*uint64)(fr.wp) = fr.u64(); return next }
+        default : panic("can only convert 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func interpret_OP_uint(v Instr, next int) _Op {
+    w := v.Iv
+    n := v.Vt.Size
+
+    /* check for overflows only if the wire type is wider */
+    if uintptr(w) <= n {
+        return func(fr *_Frame) int {
+            storeUint(fr.wp, fr.uint(w), n)
+            return next
+        }
+    }
+
+    /* narrowing conversions */
+    return func(fr *_Frame) int {
+        if x := fr.uint(w); overflowUint(x, n) {
+            return fr.fail(error_range(x, int(n)))
+        } else {
+            storeUint(fr.wp, x, n)
+            return next
+        }
+    }
+}
+
+func interpret_OP_float(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        if err := float_narrow(fr.wp, fr.u64()); err != nil {
+            return fr.fail(err)
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_float_le(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        x := binary.LittleEndian.Uint64((*[8]byte)(fr.at(fr.ic))[:])
+        fr.ic += 8
+
+        /* narrow the value */
+        if err := float_narrow(fr.wp, x); err != nil {
+            return fr.fail(err)
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_str(v Instr, next int) _Op {
+    pt := defs.Protocol(v.Iv)
+    return func(fr *_Frame) int {
+        sp := (*rt.GoString)(fr.wp)
+        sp.Ptr = nil
+
+        /* read the length */
+        p, n, err := fr.binstr(pt)
+        if err != nil {
+            return fr.fail(err)
+        }
+
+        /* empty strings do not allocate */
+        if n == 0 {
+            sp.Len = 0
+            return next
+        }
+
+        /* copy the content */
+        if !fr.alloc(uint64(n)) {
+            return fr.fail(limitError(LimitTotalAlloc, fr.rs))
+        } else {
+            *(*string)(fr.wp) = string(rt.BytesFrom(p, n, n))
+            return next
+        }
+    }
+}
+
+func interpret_OP_str_nocopy(v Instr, next int) _Op {
+    pt := defs.Protocol(v.Iv)
+    return func(fr *_Frame) int {
+        if p, n, err := fr.binstr(pt); err != nil {
+            (*rt.GoString)(fr.wp).Ptr = nil
+            return fr.fail(err)
+        } else {
+            *(*string)(fr.wp) = rt.StringFrom(p, n)
+            return next
+        }
+    }
+}
+
+func interpret_OP_bin(v Instr, next int) _Op {
+    pt := defs.Protocol(v.Iv)
+    return func(fr *_Frame) int {
+        p, n, err := fr.binstr(pt)
+        sp := (*rt.GoSlice)(fr.wp)
+
+        /* check for errors */
+        if sp.Ptr = unsafe.Pointer(&_V_zerovalue); err != nil {
+            return fr.fail(err)
+        }
+
+        /* empty binaries do not allocate */
+        if n == 0 {
+            sp.Len, sp.Cap = 0, 0
+            return next
+        }
+
+        /* check the allocation limit */
+        if !fr.alloc(uint64(n)) {
+            return fr.fail(limitError(LimitTotalAlloc, fr.rs))
+        }
+
+        /* copy the content */
+        sp.Ptr = mallocgc(uintptr(n), _T_byte, false)
+        sp.Len, sp.Cap = n, n
+        copy(rt.BytesFrom(sp.Ptr, n, n), rt.BytesFrom(p, n, n))
+        return next
+    }
+}
+
+func interpret_OP_bin_nocopy(v Instr, next int) _Op {
+    pt := defs.Protocol(v.Iv)
+    return func(fr *_Frame) int {
+        p, n, err := fr.binstr(pt)
+        sp := (*rt.GoSlice)(fr.wp)
+
+        /* check for errors */
+        if sp.Ptr = unsafe.Pointer(&_V_zerovalue); err != nil {
+            return fr.fail(err)
+        }
+
+        /* refer to the input buffer */
+        if n != 0 {
+            sp.Ptr = p
+        }
+
+        /* set the length */
+        sp.Len, sp.Cap = n, n
+        return next
+    }
+}
+
+func interpret_OP_array(v Instr, next int) _Op {
+    nb := int(v.Vt.Size)
+    pt := defs.Protocol(v.Iv)
+
+    /* the length must match the array size exactly */
+    return func(fr *_Frame) int {
+        if p, n, err := fr.binstr(pt); err != nil {
+            return fr.fail(err)
+        } else if n != nb {
+            return fr.fail(error_length(n, nb))
+        } else {
+            copy(rt.BytesFrom(fr.wp, n, n), rt.BytesFrom(p, n, n))
+            return next
+        }
+    }
+}
+
+func interpret_OP_codec(v Instr, next int) _Op {
+    pt := int(v.Iv)
+    cc := v.Codec()
+
+    /* decode with the codec */
+    return func(fr *_Frame) int {
+        if fr.ic, fr.err = codec_decode(cc, fr.buf, fr.nb, fr.ic, fr.wp, pt, fr.rs); fr.err != nil {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_enum(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        *(*int64)(fr.wp) = int64(int32(fr.u32()))
+        return next
+    }
+}
+
+func interpret_OP_varint(v Instr, next int) _Op {
+    w := int(v.Iv)
+    return func(fr *_Frame) int {
+        if fr.ic, fr.err = compact_varint(fr.buf, fr.nb, fr.ic, fr.wp, w); fr.err != nil {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_varint_u(v Instr, next int) _Op {
+    w := int(v.Iv)
+    n := v.Vt.Size
+
+    /* read the value, and store it */
+    return func(fr *_Frame) int {
+        var x uint64
+        var err error
+
+        /* read the value */
+        if fr.ic, x, err = compact_uint(fr.buf, fr.nb, fr.ic, w); err != nil {
+            return fr.fail(err)
+        }
+
+        /* check for overflows */
+        if uintptr(w) > n && overflowUint(x, n) {
+            return fr.fail(error_range(x, int(n)))
+        }
+
+        /* store the value */
+        storeUint(fr.wp, x, n)
+        return next
+    }
+}
+
+func interpret_OP_bool(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        *(*bool)(fr.wp) = fr.u8() == defs.C_true
+        return next
+    }
+}
+
+func interpret_OP_int_le(v Instr, next int) _Op {
+    switch v.Iv {
+        case 8  : break
+        default : panic("can only convert 8 bytes at a time")
+    }
+
+    /* copy the value as is */
+    return func(fr *_Frame) int {
+        *(*uint64)(fr.wp) = binary.LittleEndian.Uint64((*[8]byte)(fr.at(fr.ic))[:])
+        fr.ic += 8
+        return next
+    }
+}
+
+func interpret_OP_size(v Instr, next int) _Op {
+    n := int(v.Iv)
+    return func(fr *_Frame) int {
+        if fr.ic + n > fr.nb {
+            return fr.fail(error_eof(fr.ic + n - fr.nb))
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_type(v Instr, next int) _Op {
+    tx := uint8(v.Tx)
+    return func(fr *_Frame) int {
+        if tv := *(*uint8)(fr.at(fr.ic)); tv != tx {
+            return fr.fail(error_type(tx, tv))
+        } else {
+            fr.ic++
+            return next
+        }
+    }
+}
+
+func interpret_OP_seek(v Instr, next int) _Op {
+    d := uintptr(v.Iv)
+    return func(fr *_Frame) int {
+        fr.wp = unsafe.Pointer(uintptr(fr.wp) + d)
+        return next
+    }
+}
+
+func interpret_OP_deref(v Instr, next int) _Op {
+    vt := v.Vt
+    return func(fr *_Frame) int {
+        pp := (*unsafe.Pointer)(fr.wp)
+
+        /* allocate the value if needed */
+        if *pp == nil {
+            if !fr.alloc(uint64(vt.Size)) {
+                return fr.fail(limitError(LimitTotalAlloc, fr.rs))
+            } else {
+                *pp = mallocgc(vt.Size, vt, true)
+            }
+        }
+
+        /* move to the value */
+        fr.wp = *pp
+        return next
+    }
+}
+
+func interpret_OP_ctr_load(v Instr, next int) _Op {
+    m := uint64(v.Iv)
+    return func(fr *_Frame) int {
+        if n := uint64(fr.u32()); n > fr.rs.Lm.Nc {
+            return fr.fail(limitError(LimitContainerLen, fr.rs))
+        } else if e := uint64(fr.ic) + n * m; e > uint64(fr.nb) {
+            return fr.fail(error_eof(int(e - uint64(fr.nb))))
+        } else {
+            fr.state().Nb = n
+            return next
+        }
+    }
+}
+
+func interpret_OP_ctr_decr(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.state().Nb--
+        return next
+    }
+}
+
+func interpret_OP_ctr_is_zero(v Instr, next int) _Op {
+    to := v.To
+    return func(fr *_Frame) int {
+        if fr.state().Nb == 0 {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_list_head(v Instr, next int) _Op {
+    et := int(v.Tx)
+    return func(fr *_Frame) int {
+        var n int
+        var err error
+
+        /* read the list header */
+        if fr.ic, n, err = compact_list_head(fr.buf, fr.nb, fr.ic, et, fr.rs); err != nil {
+            return fr.fail(err)
+        } else {
+            fr.state().Nb = uint64(n)
+            return next
+        }
+    }
+}
+
+func interpret_OP_map_head(v Instr, next int) _Op {
+    kv := int(v.Iv)
+    return func(fr *_Frame) int {
+        var n int
+        var err error
+
+        /* read the map header */
+        if fr.ic, n, err = compact_map_head(fr.buf, fr.nb, fr.ic, kv, fr.rs); err != nil {
+            return fr.fail(err)
+        } else {
+            fr.state().Nb = uint64(n)
+            return next
+        }
+    }
+}
+
+func interpret_OP_map_alloc(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt)
+    nb := uint64(mt.Key.Size + mt.Elem.Size)
+
+    /* reserve the space for all the pairs */
+    return func(fr *_Frame) int {
+        st := fr.state()
+        mp := (**rt.GoMap)(fr.wp)
+
+        /* check the allocation limit */
+        if !fr.alloc(st.Nb * nb) {
+            return fr.fail(limitError(LimitTotalAlloc, fr.rs))
+        }
+
+        /* allocate the map */
+        *mp = makemap(mt, int(st.Nb), nil)
+        st.Mp = *mp
+        st.Ix = st.Nb
+        return next
+    }
+}
+
+func interpret_OP_map_close(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.state().Mp = nil
+        return next
+    }
+}
+
+func interpret_OP_map_set_i8(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt)
+    return func(fr *_Frame) int {
+        fr.wp = mapassign(mt, fr.state().Mp, fr.at(fr.ic))
+        fr.ic++
+        return next
+    }
+}
+
+func interpret_OP_map_set_i16(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt)
+    return func(fr *_Frame) int {
+        *(*uint16)(unsafe.Pointer(&fr.rs.Iv)) = fr.u16()
+        fr.wp = mapassign(mt, fr.state().Mp, unsafe.Pointer(&fr.rs.Iv))
+        return next
+    }
+}
+
+func interpret_OP_map_set_i32(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt)
+
+    /* fast-path: the value can be assigned with the key directly */
+    if mt.IsFastMap() {
+        return func(fr *_Frame) int {
+            fr.wp = mapassign_fast32(mt, fr.state().Mp, fr.u32())
+            return next
+        }
+    }
+
+    /* slow-path: spill the key first */
+    return func(fr *_Frame) int {
+        *(*uint32)(unsafe.Pointer(&fr.rs.Iv)) = fr.u32()
+        fr.wp = mapassign(mt, fr.state().Mp, unsafe.Pointer(&fr.rs.Iv))
+        return next
+    }
+}
+
+func interpret_OP_map_set_i64(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt)
+
+    /* fast-path: the value can be assigned with the key directly */
+    if mt.IsFastMap() {
+        return func(fr *_Frame) int {
+            fr.wp = mapassign_fast64(mt, fr.state().Mp, fr.u64())
+            return next
+        }
+    }
+
+    /* slow-path: spill the key first */
+    return func(fr *_Frame) int {
+        fr.rs.Iv = fr.u64()
+        fr.wp = mapassign(mt, fr.state().Mp, unsafe.Pointer(&fr.rs.Iv))
+        return next
+    }
+}
+
+func interpret_OP_map_set_str(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt)
+    fm := mt.IsFastMap()
+
+    /* read the key, and insert it into the map */
+    return func(fr *_Frame) int {
+        var k string
+        var n = int(fr.u32())
+
+        /* the key must be complete */
+        if fr.ic + n > fr.nb {
+            return fr.fail(error_eof(fr.ic + n - fr.nb))
+        }
+
+        /* copy the key if not empty */
+        if n != 0 {
+            k = string(rt.BytesFrom(fr.at(fr.ic), n, n))
+            fr.ic += n
+        }
+
+        /* fast-path: the value can be assigned with the key directly */
+        if fm {
+            fr.wp = mapassign_faststr(mt, fr.state().Mp, k)
+            return next
+        }
+
+        /* slow-path: spill the key first */
+        *(*string)(unsafe.Pointer(&fr.rs.Pr)) = k
+        fr.wp = mapassign(mt, fr.state().Mp, unsafe.Pointer(&fr.rs.Pr))
+        fr.rs.Pr = nil
+        return next
+    }
+}
+
+func interpret_OP_map_set_enum(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt)
+
+    /* fast-path: the value can be assigned with the key directly */
+    if mt.IsFastMap() {
+        return func(fr *_Frame) int {
+            fr.wp = mapassign_fast64(mt, fr.state().Mp, uint64(int32(fr.u32())))
+            return next
+        }
+    }
+
+    /* slow-path: spill the key first */
+    return func(fr *_Frame) int {
+        fr.rs.Iv = uint64(int32(fr.u32()))
+        fr.wp = mapassign(mt, fr.state().Mp, unsafe.Pointer(&fr.rs.Iv))
+        return next
+    }
+}
+
+func interpret_OP_map_set_pointer(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt)
+
+    /* fast-path: the value can be assigned with the key directly */
+    if mt.IsFastMap() {
+        return func(fr *_Frame) int {
+            fr.wp = mapassign_fast64ptr(mt, fr.state().Mp, fr.wp)
+            return next
+        }
+    }
+
+    /* slow-path: spill the key first */
+    return func(fr *_Frame) int {
+        fr.rs.Pr = fr.wp
+        fr.wp = mapassign(mt, fr.state().Mp, unsafe.Pointer(&fr.rs.Pr))
+        fr.rs.Pr = nil
+        return next
+    }
+}
+
+func interpret_OP_map_key(v Instr, next int) _Op {
+    d := uintptr(spillOffset(v.Vt))
+    return func(fr *_Frame) int {
+        fr.wp = unsafe.Pointer(uintptr(unsafe.Pointer(fr.rs)) + d)
+        return next
+    }
+}
+
+func interpret_OP_map_set(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt)
+    d := uintptr(spillOffset(v.Vt))
+
+    /* insert the spilled key */
+    return func(fr *_Frame) int {
+        fr.wp = mapassign(mt, fr.state().Mp, unsafe.Pointer(uintptr(unsafe.Pointer(fr.rs)) + d))
+        fr.rs.Pr = nil
+        fr.rs.Iv = 0
+        return next
+    }
+}
+
+func interpret_OP_list_alloc(v Instr, next int) _Op {
+    vt := v.Vt
+    return func(fr *_Frame) int {
+        st := fr.state()
+        n := int(st.Nb)
+        sp := (*rt.GoSlice)(fr.wp)
+
+        /* set the length, and check for the capacity */
+        if sp.Len = n; n == 0 {
+            if sp.Cap == 0 {
+                sp.Ptr = unsafe.Pointer(&_V_zerovalue)
+            }
+        } else if sp.Cap < n {
+            if !fr.alloc(uint64(n) * uint64(vt.Size)) {
+                return fr.fail(limitError(LimitTotalAlloc, fr.rs))
+            } else {
+                sp.Cap, sp.Ptr = n, mallocgc(uintptr(n) * vt.Size, vt, true)
+            }
+        }
+
+        /* move to the elements */
+        fr.wp = sp.Ptr
+        st.Ix = st.Nb
+        return next
+    }
+}
+
+func interpret_OP_struct_skip(v Instr, next int) _Op {
+    if defs.Protocol(v.Iv) == defs.Compact {
+        return interpret_OP_struct_skip_compact(false, next)
+    }
+
+    /* Binary Protocol */
+    return func(fr *_Frame) int {
+        if n, ok := fr.skip(defs.Tag(fr.tg)); !ok {
+            return fr.fail(error_skip(n, fr.rs))
+        } else {
+            fr.ic += n
+            return next
+        }
+    }
+}
+
+func interpret_OP_struct_ignore(v Instr, next int) _Op {
+    if defs.Protocol(v.Iv) == defs.Compact {
+        return interpret_OP_struct_skip_compact(true, next)
+    }
+
+    /* Binary Protocol */
+    return func(fr *_Frame) int {
+        fr.tg = uint8(defs.T_struct)
+
+        /* skip the whole struct */
+        if n, ok := fr.skip(defs.T_struct); !ok {
+            return fr.fail(error_skip(n, fr.rs))
+        } else {
+            fr.ic += n
+            return next
+        }
+    }
+}
+
+func interpret_OP_struct_skip_compact(ignore bool, next int) _Op {
+    return func(fr *_Frame) int {
+        if ignore {
+            fr.tg = defs.C_struct
+        }
+
+        /* skip the value */
+        if fr.ic, fr.err = compact_skip(fr.buf, fr.nb, fr.ic, int(fr.tg), fr.rs); fr.err != nil {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_struct_bitmap(v Instr, next int) _Op {
+    var ws []int
+    var buf FieldBitmap
+
+    /* add all the bits */
+    for _, i := range v.IntSeq() {
+        buf.Append(i)
+    }
+
+    /* words to clear, which contain the required fields */
+    for i, w := range buf {
+        if w != 0 {
+            ws = append(ws, i)
+        }
+    }
+
+    /* allocate a new bitmap */
+    return func(fr *_Frame) int {
+        fm := newFieldBitmap()
+        fr.state().Fm = fm
+
+        /* clear bits of required fields if any */
+        for _, i := range ws {
+            fm[i] = 0
+        }
+
+        /* all done */
+        return next
+    }
+}
+
+func interpret_OP_struct_switch(v Instr, next int) _Op {
+    tab := mkswitch(v)
+    return func(fr *_Frame) int {
+        if id := int(fr.u16()); id < len(tab) && tab[id] >= 0 {
+            return tab[id]
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_struct_require(v Instr, next int) _Op {
+    var ws []int
+    var ms []int64
+    var buf FieldBitmap
+
+    /* add all the bits */
+    for _, i := range v.IntSeq() {
+        buf.Append(i)
+    }
+
+    /* masks of each word, which contain the required fields */
+    for i, w := range buf {
+        if w != 0 {
+            ws = append(ws, i)
+            ms = append(ms, w)
+        }
+    }
+
+    /* test mask for each word if any */
+    vt := v.Vt
+    return func(fr *_Frame) int {
+        st := fr.state()
+        fm := st.Fm
+
+        /* all the required bits must be set */
+        for j, i := range ws {
+            if m := fm[i] & ms[j] ^ ms[j]; m != 0 {
+                return fr.fail(error_missing(vt, i, uint64(m)))
+            }
+        }
+
+        /* free the bitmap */
+        st.Fm = nil
+        fm.Free()
+        return next
+    }
+}
+
+func interpret_OP_struct_is_stop(v Instr, next int) _Op {
+    to := v.To
+    return func(fr *_Frame) int {
+        if fr.tg == 0 {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_struct_mark_tag(v Instr, next int) _Op {
+    i := v.Iv / 64
+    m := int64(1) << (v.Iv % 64)
+
+    /* set the bit of the field */
+    return func(fr *_Frame) int {
+        fr.state().Fm[i] |= m
+        return next
+    }
+}
+
+func interpret_OP_struct_read_type(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.tg = fr.u8()
+        fr.state().Ix = uint64(fr.ic)
+        return next
+    }
+}
+
+func interpret_OP_struct_check_type(v Instr, next int) _Op {
+    to := v.To
+    tx := uint8(v.Tx)
+
+    /* jump if the type mismatches */
+    return func(fr *_Frame) int {
+        if fr.tg != tx {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_struct_check_bool(v Instr, next int) _Op {
+    to := v.To
+    return func(fr *_Frame) int {
+        if fr.tg != defs.C_true && fr.tg != defs.C_false {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_field_clear(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.state().Nb = 0
+        return next
+    }
+}
+
+func interpret_OP_field_begin(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        st := fr.state()
+        st.Ix = uint64(fr.ic) + 1
+        ic, tg, id, err := compact_field(fr.buf, fr.nb, fr.ic, int(st.Nb))
+
+        /* check for errors */
+        if err != nil {
+            return fr.fail(err)
+        }
+
+        /* update the field tag and ID */
+        fr.ic, fr.tg = ic, uint8(tg)
+        st.Nb = uint64(id)
+        return next
+    }
+}
+
+func interpret_OP_field_switch(v Instr, next int) _Op {
+    tab := mkswitch(v)
+    return func(fr *_Frame) int {
+        if id := fr.state().Nb; id < uint64(len(tab)) && tab[id] >= 0 {
+            return tab[id]
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_field_bool(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        *(*bool)(fr.wp) = fr.tg == defs.C_true
+        return next
+    }
+}
+
+func interpret_OP_unknown_clear(v Instr, next int) _Op {
+    d := uintptr(v.Iv)
+    return func(fr *_Frame) int {
+        *(*[]byte)(unsafe.Pointer(uintptr(fr.wp) + d)) = nil
+        return next
+    }
+}
+
+func interpret_OP_unknown_skip(v Instr, next int) _Op {
+    d := uintptr(v.Iv)
+    return func(fr *_Frame) int {
+        n, ok := fr.skip(defs.Tag(fr.tg))
+        p := unsafe.Pointer(uintptr(fr.wp) + d)
+
+        /* check for errors */
+        if !ok {
+            return fr.fail(error_skip(n, fr.rs))
+        }
+
+        /* the kept bytes count as allocation */
+        if !fr.alloc(uint64(n + defs.FieldHeaderSize)) {
+            return fr.fail(limitError(LimitTotalAlloc, fr.rs))
+        }
+
+        /* keep the field header together with the value */
+        unknown_append(p, fr.at(fr.ic - defs.FieldHeaderSize), n + defs.FieldHeaderSize)
+        fr.ic += n
+        return next
+    }
+}
+
+func interpret_OP_unknown_cskip(v Instr, next int) _Op {
+    d := uintptr(v.Iv)
+    return func(fr *_Frame) int {
+        p := unsafe.Pointer(uintptr(fr.wp) + d)
+        fr.ic, fr.err = unknown_skip_compact(fr.buf, fr.nb, fr.ic, int(fr.tg), int(fr.state().Nb), p, fr.rs)
+
+        /* check for errors */
+        if fr.err != nil {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_union_reset(v Instr, next int) _Op {
+    fv := append([]int(nil), v.IntSeq()...)
+    return func(fr *_Frame) int {
+        for i := 0; i < len(fv); i += 2 {
+            if p := unsafe.Pointer(uintptr(fr.wp) + uintptr(fv[i])); fv[i + 1] == int(unsafe.Sizeof(p)) {
+                *(*unsafe.Pointer)(p) = nil
+            } else {
+                *(*[]byte)(p) = nil
+            }
+        }
+
+        /* reset the member counter */
+        fr.state().Un = 0
+        return next
+    }
+}
+
+func interpret_OP_union_mark(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        if st := fr.state(); st.Un != 0 {
+            return fr.fail(_E_union)
+        } else {
+            st.Un = 1
+            return next
+        }
+    }
+}
+
+func interpret_OP_make_state(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        if fr.rs.Lm.Nd == 0 {
+            return fr.fail(limitError(LimitDepth, fr.rs))
+        }
+
+        /* the new frame has no field or element yet */
+        if fr.rs.Lm.Nd--; fr.push(next) != next {
+            return _PC_halt
+        } else {
+            fr.state().Ix = 0
+            return next
+        }
+    }
+}
+
+func interpret_OP_drop_state(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.rs.Lm.Nd++
+        fr.pop()
+        return next
+    }
+}
+
+/* pointers do not appear on the wire, so they do not count towards the depth limit */
+
+func interpret_OP_make_ptr_state(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        return fr.push(next)
+    }
+}
+
+func interpret_OP_drop_ptr_state(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.pop()
+        return next
+    }
+}
+
+func (self *_Frame) push(next int) int {
+    if self.st >= int(StateMax) {
+        return self.fail(_E_overflow)
+    } else {
+        self.state().Wp = self.wp
+        self.st += int(StateSize)
+        return next
+    }
+}
+
+func (self *_Frame) pop() {
+    self.st -= int(StateSize)
+    st := self.state()
+    self.wp, st.Wp = st.Wp, nil
+}
+
+func interpret_OP_construct(v Instr, next int) _Op {
+    vt := v.Vt
+    return func(fr *_Frame) int {
+        if !fr.alloc(uint64(vt.Size)) {
+            return fr.fail(limitError(LimitTotalAlloc, fr.rs))
+        } else {
+            fr.wp = mallocgc(vt.Size, vt, true)
+            return next
+        }
+    }
+}
+
+func interpret_OP_initialize(v Instr, next int) _Op {
+    fn := toInitFn(v.Fn)
+    return func(fr *_Frame) int {
+        fn(fr.wp)
+        return next
+    }
+}
+
+func interpret_OP_defer(v Instr, next int) _Op {
+    vt := v.Vt
+    fn := decode
+
+    /* select the decoder of the protocol */
+    if defs.Protocol(v.Iv) == defs.Compact {
+        fn = decodeCompact
+    }
+
+    /* decode the value with its own program */
+    return func(fr *_Frame) int {
+        if fr.ic, fr.err = fn(vt, fr.buf, fr.nb, fr.ic, fr.wp, fr.rs, fr.st); fr.err != nil {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_goto(v Instr, _ int) _Op {
+    to := v.To
+    return func(_ *_Frame) int {
+        return to
+    }
+}
+
+func interpret_OP_halt(_ Instr, _ int) _Op {
+    return func(_ *_Frame) int {
+        return _PC_halt
+    }
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `reflect`
+    `testing`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/binary/encoder`
+    `github.com/cloudwego/frugal/internal/opts`
+    `github.com/cloudwego/frugal/internal/rt`
+    `github.com/stretchr/testify/require`
+)
+
+func interpTestLink(t *testing.T, vt reflect.Type, pt defs.Protocol) (Decoder, Decoder) {
+    p, err := CreateCompiler().Protocol(pt).Compile(vt)
+    require.NoError(t, err)
+    return link_emu(Translate(p)), link_interp(p)
+}
+
+func interpTestRun(dec Decoder, buf []byte, v unsafe.Pointer) (int, error) {
+    rs := new(RuntimeState)
+    rs.setLimits(opts.GetDefaultOptions())
+    sl := (*rt.GoSlice)(unsafe.Pointer(&buf))
+    return dec(sl.Ptr, sl.Len, 0, v, rs, 0)
+}
+
+func interpTestCompare(t *testing.T, buf []byte, v interface{}, pt defs.Protocol) {
+    vt := reflect.TypeOf(v)
+    emu, interp := interpTestLink(t, vt, pt)
+
+    /* every prefix of the buffer must fail or succeed in the same way, the interpreter may report
+     * the truncation earlier than the JIT, so the error details are not compared */
+    for i := 0; i <= len(buf); i++ {
+        v1 := reflect.New(vt)
+        v2 := reflect.New(vt)
+        p1, e1 := interpTestRun(emu, buf[:i], unsafe.Pointer(v1.Pointer()))
+        p2, e2 := interpTestRun(interp, buf[:i], unsafe.Pointer(v2.Pointer()))
+        require.Equal(t, e1 == nil, e2 == nil, "error mismatch with %d bytes: %v, %v", i, e1, e2)
+
+        /* compare the results only if succeeded */
+        if e1 == nil {
+            require.Equal(t, p1, p2, "position mismatch with %d bytes", i)
+            require.Equal(t, v1.Interface(), v2.Interface(), "value mismatch with %d bytes", i)
+        }
+    }
+}
+
+func TestInterpreter_Binary(t *testing.T) {
+    v := newTranslatorTestStruct()
+    buf := make([]byte, encoder.EncodedSize(v))
+    _, err := encoder.EncodeObject(buf, nil, v)
+    require.NoError(t, err)
+    interpTestCompare(t, buf, v, defs.Binary)
+}
+
+func TestInterpreter_Compact(t *testing.T) {
+    v := newTranslatorTestStruct()
+    buf := make([]byte, encoder.EncodedSizeCompact(v))
+    _, err := encoder.EncodeCompact(buf, nil, v)
+    require.NoError(t, err)
+    interpTestCompare(t, buf, v, defs.Compact)
+}
+
+func TestInterpreter_Errors(t *testing.T) {
+    var v TestUnknownFields
+    _, interp := interpTestLink(t, reflect.TypeOf(v), defs.Binary)
+    _, err := interpTestRun(interp, []byte { 0x03, 0x00, 0x01, 0x00 }, unsafe.Pointer(&v))
+    require.Error(t, err)
+    _, err = interpTestRun(interp, []byte { 0x08, 0x00, 0x01, 0x00 }, unsafe.Pointer(&v))
+    require.Error(t, err)
+    _, err = interpTestRun(interp, []byte { 0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x7f, 0x00 }, unsafe.Pointer(&v))
+    require.NoError(t, err)
+    require.Equal(t, int32(0x7f), v.A)
+}
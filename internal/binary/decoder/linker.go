@@ -18,6 +18,7 @@ package decoder
 
 import (
     `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/snapshot`
     `github.com/cloudwego/frugal/internal/utils`
 )
 
@@ -25,20 +26,38 @@ type Linker interface {
     Link(p hir.Program) Decoder
 }
 
+type SnapshotLinker interface {
+    Linker
+    Load(sp *snapshot.Program) Decoder
+    Generate(p hir.Program, sp *snapshot.Program)
+}
+
 var (
-    linker   Linker
-    F_decode *hir.CallHandle
+    linker           Linker
+    F_decode         *hir.CallHandle
+    F_decode_compact *hir.CallHandle
 )
 
 func init() {
     F_decode = hir.RegisterGCall(decode, emu_gcall_decode)
+    F_decode_compact = hir.RegisterGCall(decodeCompact, emu_gcall_decode_compact)
+}
+
+func Link(p Program) Decoder {
+    if utils.ForceEmulator {
+        return link_emu(Translate(p))
+    } else if linker == nil || utils.ForceInterpreter {
+        return link_interp(p)
+    } else {
+        return linker.Link(Translate(p))
+    }
 }
 
-func Link(p hir.Program) Decoder {
-    if linker == nil || utils.ForceEmulator {
-        return link_emu(p)
+func snapshotLinker() SnapshotLinker {
+    if sl, ok := linker.(SnapshotLinker); !ok || utils.ForceEmulator || utils.ForceInterpreter {
+        return nil
     } else {
-        return linker.Link(p)
+        return sl
     }
 }
 
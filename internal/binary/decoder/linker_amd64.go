@@ -1,3 +1,5 @@
+// +build !frugal_nojit
+
 /*
  * Copyright 2022 ByteDance Inc.
  *
@@ -17,11 +19,13 @@
 package decoder
 
 import (
+    `runtime`
     `unsafe`
 
     `github.com/cloudwego/frugal/internal/atm/hir`
     `github.com/cloudwego/frugal/internal/atm/pgen`
     `github.com/cloudwego/frugal/internal/loader`
+    `github.com/cloudwego/frugal/internal/snapshot`
 )
 
 type (
@@ -36,8 +40,26 @@ func init() {
     SetLinker(new(LinkerAMD64))
 }
 
+func collectable(fp loader.Function) Decoder {
+    fn := *(*Decoder)(unsafe.Pointer(&fp))
+    /* running code does not keep itself reachable, so the reference is held until the call returns */
+    return func(buf unsafe.Pointer, nb int, i int, p unsafe.Pointer, rs *RuntimeState, st int) (int, error) {
+        ret, err := fn(buf, nb, i, p, rs, st)
+        runtime.KeepAlive(fp)
+        return ret, err
+    }
+}
+
 func (LinkerAMD64) Link(p hir.Program) Decoder {
     fn := pgen.CreateCodeGen((Decoder)(nil)).Generate(p, _NativeStackSize)
-    fp := loader.Loader(fn.Code).Load("decoder", fn.Frame)
-    return *(*Decoder)(unsafe.Pointer(&fp))
+    return collectable(loader.Loader(fn.Code).LoadCollectable("decoder", fn.Frame))
+}
+
+func (LinkerAMD64) Load(sp *snapshot.Program) Decoder {
+    return collectable(loader.Loader(sp.Code).LoadCollectable("decoder", sp.Frame))
+}
+
+func (LinkerAMD64) Generate(p hir.Program, sp *snapshot.Program) {
+    fn := pgen.CreateCodeGen((Decoder)(nil)).Generate(p, _NativeStackSize)
+    sp.Code, sp.Frame, sp.Relocs = fn.Code, fn.Frame, fn.Relocs
 }
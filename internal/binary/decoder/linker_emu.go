@@ -43,8 +43,8 @@ func link_emu(prog hir.Program) Decoder {
     }
 }
 
-func emu_decode(ctx hir.CallContext) (int, error) {
-    return decode(
+func emu_decode(ctx hir.CallContext, fn decodeFunc) (int, error) {
+    return fn(
         (*rt.GoType)(ctx.Ap(0)),
         ctx.Ap(1),
         int(ctx.Au(2)),
@@ -66,6 +66,14 @@ func emu_gcall_decode(ctx hir.CallContext) {
     if !ctx.Verify("**ii**i", "i**") {
         panic("invalid decode call")
     } else {
-        emu_mkreturn(ctx)(emu_decode(ctx))
+        emu_mkreturn(ctx)(emu_decode(ctx, decode))
+    }
+}
+
+func emu_gcall_decode_compact(ctx hir.CallContext) {
+    if !ctx.Verify("**ii**i", "i**") {
+        panic("invalid decodeCompact call")
+    } else {
+        emu_mkreturn(ctx)(emu_decode(ctx, decodeCompact))
     }
 }
\ No newline at end of file
@@ -0,0 +1,50 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `unsafe`
+)
+
+func link_interp(prog Program) Decoder {
+    ops := interpret(prog)
+    return func(buf unsafe.Pointer, nb int, i int, p unsafe.Pointer, rs *RuntimeState, st int) (pos int, err error) {
+        fr := newFrame()
+        fr.buf = buf
+        fr.nb = nb
+        fr.ic = i
+        fr.wp = p
+        fr.rs = rs
+        fr.st = st
+
+        /* run until halt */
+        for pc := 0; pc != _PC_halt; {
+            pc = ops[pc](fr)
+        }
+
+        /* record where the first error occurred */
+        if fr.err != nil && rs.Es == 0 {
+            rs.Es = uint64(fr.st) + 1
+            rs.Ei = uint64(fr.ic)
+        }
+
+        /* return the frame into pool */
+        pos, err = fr.ic, fr.err
+        freeFrame(fr)
+        return
+    }
+}
@@ -0,0 +1,331 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `reflect`
+    `strconv`
+    `strings`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/opts`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+/** Error Locations
+ *
+ *      The decoder only records the state offset and the input offset of the first error, the
+ *      path is reconstructed afterwards by walking the type together with the state frames.
+ *      Struct frames keep the offset of the current field header, and container frames keep
+ *      the length, so the index of the current element is the length minus the remaining count.
+ *
+ *      The input offset may be anywhere within the failed value, so the reported offset is the
+ *      start of the value at the end of the path, found by parsing the input from the field
+ *      header and skipping the elements before the current one, which are known to be valid.
+ */
+
+type _Locator struct {
+    buf unsafe.Pointer
+    nb  int
+    pt  defs.Protocol
+    rs  *RuntimeState
+    nf  int
+    ei  int
+    vs  int
+    id  int
+    sb  strings.Builder
+}
+
+func decodeError(vt *rt.GoType, pt defs.Protocol, buf []byte, rs *RuntimeState, err error) error {
+    if err == nil || rs.Es == 0 {
+        return err
+    }
+
+    /* the type being decoded */
+    tv := vt.Pack()
+    sl := (*rt.GoSlice)(unsafe.Pointer(&buf))
+
+    /* the frames below the recorded state offset are all on the path */
+    lc := _Locator {
+        buf : sl.Ptr,
+        nb  : sl.Len,
+        pt  : pt,
+        rs  : rs,
+        nf  : int((rs.Es - 1) / uint64(StateSize)),
+        ei  : int(rs.Ei),
+        vs  : -1,
+        id  : -1,
+    }
+
+    /* walk the type, and build the path */
+    ty := defs.ParseType(tv, "")
+    lc.sb.WriteString(typeName(tv))
+    lc.walk(ty, 0)
+    ty.Free()
+
+    /* construct the error */
+    ret := &DecodeError {
+        Type    : tv,
+        Path    : lc.sb.String(),
+        FieldID : lc.id,
+        Offset  : lc.offset(),
+        Err     : err,
+    }
+
+    /* add the wire types of mismatches */
+    if me, ok := err.(MismatchError); ok {
+        ret.Expected = me.Expected
+        ret.Actual = me.Actual
+    }
+
+    /* all done */
+    return ret
+}
+
+func typeName(vt reflect.Type) string {
+    if vt.Name() != "" {
+        return vt.Name()
+    } else {
+        return vt.String()
+    }
+}
+
+func (self *_Locator) offset() int {
+    if self.vs >= 0 && self.vs <= self.ei {
+        return self.vs
+    } else {
+        return self.ei
+    }
+}
+
+func (self *_Locator) walk(vt *defs.Type, i int) {
+    for ; vt != nil && i < self.nf; i++ {
+        switch st := &self.rs.St[i + 1]; vt.T {
+            case defs.T_pointer : vt = vt.V
+            case defs.T_struct  : vt = self.field(vt, st, i == self.nf - 1)
+            case defs.T_map     : vt = self.elem(vt, st)
+            case defs.T_set     : vt = self.elem(vt, st)
+            case defs.T_list    : vt = self.elem(vt, st)
+            default             : return
+        }
+    }
+}
+
+func (self *_Locator) elem(vt *defs.Type, st *StateItem) *defs.Type {
+    if st.Ix == 0 || st.Nb == 0 || st.Nb > st.Ix {
+        return nil
+    }
+
+    /* find the element from the start of the container */
+    if self.vs >= 0 {
+        self.vs = self.element(vt.T, self.vs, st.Ix - st.Nb)
+    }
+
+    /* the index of the element, or the key-value pair for maps */
+    self.sb.WriteByte('[')
+    self.sb.WriteString(strconv.FormatUint(st.Ix - st.Nb, 10))
+    self.sb.WriteByte(']')
+
+    /* struct keys also have frames, which cannot be told apart from the values */
+    if vt.T == defs.T_map && vt.K.T == defs.T_pointer {
+        return nil
+    } else {
+        return vt.V
+    }
+}
+
+func (self *_Locator) field(vt *defs.Type, st *StateItem, last bool) *defs.Type {
+    var ok bool
+    var id int
+    var tag int
+    var pos int
+
+    /* errors between fields are located by the input offset */
+    self.vs = -1
+
+    /* no field has been read yet */
+    if st.Ix == 0 {
+        return nil
+    }
+
+    /* parse the field header, the Compact Protocol field ID is kept in the frame */
+    if self.pt == defs.Compact {
+        tag, pos, ok = self.compactHeader(int(st.Ix - 1))
+        id = int(st.Nb)
+    } else {
+        tag, id, pos, ok = self.binaryHeader(int(st.Ix - 1))
+    }
+
+    /* STOP field or incomplete header */
+    if !ok {
+        return nil
+    }
+
+    /* the innermost frame may also fail between fields, like on missing required fields */
+    if last {
+        if end := self.skip(tag, pos, true); self.ei < pos || end >= 0 && self.ei >= end {
+            return nil
+        }
+    }
+
+    /* add the field to the path */
+    self.vs = pos
+    self.id = id
+    self.sb.WriteByte('.')
+
+    /* find the field by ID */
+    if fv, err := defs.ResolveFields(vt.S); err == nil {
+        for _, f := range fv {
+            if int(f.ID) == id {
+                self.sb.WriteString(f.Name)
+                return f.Type
+            }
+        }
+    }
+
+    /* unknown fields are named by their IDs */
+    self.sb.WriteString(strconv.Itoa(id))
+    return nil
+}
+
+func (self *_Locator) binaryHeader(i int) (int, int, int, bool) {
+    if i + 3 > self.nb {
+        return 0, 0, 0, false
+    } else if tag := u8at(self.buf, i); tag == 0 {
+        return 0, 0, 0, false
+    } else {
+        return tag, u8at(self.buf, i + 1) << 8 | u8at(self.buf, i + 2), i + 3, true
+    }
+}
+
+func (self *_Locator) compactHeader(i int) (int, int, bool) {
+    if i >= self.nb {
+        return 0, 0, false
+    }
+
+    /* check for STOP field, or the short form */
+    if v := u8at(self.buf, i); v == defs.C_stop {
+        return 0, 0, false
+    } else if v >> 4 != 0 {
+        return v & 0x0f, i + 1, true
+    }
+
+    /* long form, the field ID follows */
+    if _, j := uvarint(self.buf, self.nb, i + 1); j < 0 {
+        return 0, 0, false
+    } else {
+        return u8at(self.buf, i) & 0x0f, j, true
+    }
+}
+
+/* the start of the current element of the container at i, or -1 if it cannot be found */
+
+func (self *_Locator) element(t defs.Tag, i int, n uint64) int {
+    var ok bool
+    var kt int
+    var et int
+
+    /* parse the container header */
+    if self.pt == defs.Compact {
+        kt, et, i, ok = self.compactContainer(t, i)
+    } else {
+        kt, et, i, ok = self.binaryContainer(t, i)
+    }
+
+    /* skip the elements before it, which have been decoded already */
+    for ; ok && n > 0; n-- {
+        if t == defs.T_map {
+            i = self.skip(kt, i, false)
+        }
+        if i >= 0 {
+            i = self.skip(et, i, false)
+        }
+        ok = i >= 0
+    }
+
+    /* the header is incomplete, or the input is malformed */
+    if !ok {
+        return -1
+    }
+
+    /* the value of the key-value pair, if the key has been decoded */
+    if t == defs.T_map {
+        if end := self.skip(kt, i, false); end >= 0 && self.ei >= end {
+            return end
+        }
+    }
+
+    /* the element, or the key-value pair */
+    return i
+}
+
+func (self *_Locator) binaryContainer(t defs.Tag, i int) (int, int, int, bool) {
+    if t == defs.T_map {
+        if i + 6 > self.nb {
+            return 0, 0, 0, false
+        } else {
+            return u8at(self.buf, i), u8at(self.buf, i + 1), i + 6, true
+        }
+    } else {
+        if i + 5 > self.nb {
+            return 0, 0, 0, false
+        } else {
+            return 0, u8at(self.buf, i), i + 5, true
+        }
+    }
+}
+
+func (self *_Locator) compactContainer(t defs.Tag, i int) (int, int, int, bool) {
+    if i >= self.nb {
+        return 0, 0, 0, false
+    }
+
+    /* empty maps do not have the key and value types */
+    if t == defs.T_map {
+        if n, j := uvarint(self.buf, self.nb, i); j < 0 || n == 0 || j >= self.nb {
+            return 0, 0, 0, false
+        } else {
+            return u8at(self.buf, j) >> 4, u8at(self.buf, j) & 0x0f, j + 1, true
+        }
+    }
+
+    /* large lists have their sizes encoded separately */
+    if v := u8at(self.buf, i); v >> 4 != 0x0f {
+        return 0, v & 0x0f, i + 1, true
+    } else if _, j := uvarint(self.buf, self.nb, i + 1); j < 0 {
+        return 0, 0, 0, false
+    } else {
+        return 0, v & 0x0f, j, true
+    }
+}
+
+/* the end of the value, or a negative number if it is malformed */
+
+func (self *_Locator) skip(tag int, i int, field bool) int {
+    lm := makeLimits(opts.Options{})
+    sp := unsafe.Pointer(uintptr(self.buf) + uintptr(i))
+
+    /* skip the value with the protocol */
+    if self.pt == defs.Compact {
+        return cskip(self.buf, self.nb, i, tag, field, 0, &lm)
+    } else if n := do_skip((*_skipbuf_t)(&self.rs.Sk), sp, self.nb - i, defs.Tag(tag), &lm); n < 0 {
+        return n
+    } else {
+        return i + n
+    }
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `os`
+    `os/exec`
+    `strings`
+    `testing`
+)
+
+func TestMain(m *testing.M) {
+    ret := m.Run()
+
+    /* run everything again with the interpreter, unless a backend is selected explicitly */
+    if ret == 0 && linker != nil && os.Getenv("FRUGAL_BACKEND") == "" {
+        ret = runWithBackend("interp")
+    }
+
+    /* exit with the result */
+    os.Exit(ret)
+}
+
+func runWithBackend(name string) int {
+    var args []string
+    var cmd  *exec.Cmd
+
+    /* output files are written by the first run only */
+    for _, v := range os.Args[1:] {
+        if !strings.Contains(v, "profile") && !strings.HasPrefix(v, "-test.testlogfile") {
+            args = append(args, v)
+        }
+    }
+
+    /* re-execute the test binary */
+    cmd        = exec.Command(os.Args[0], args...)
+    cmd.Env    = append(os.Environ(), "FRUGAL_BACKEND=" + name)
+    cmd.Stdout = os.Stdout
+    cmd.Stderr = os.Stderr
+
+    /* the exit code of the child is the result */
+    if err := cmd.Run(); err != nil {
+        return 1
+    } else {
+        return 0
+    }
+}
@@ -13,306 +13,382 @@ TEXT ·__native_entry__(SB), NOSPLIT, $0
 	WORD $0x9090; BYTE $0x90                   // .p2align 4, 0x90
 
 _do_skip:
-	BYTE $0x55                                 // pushq        %rbp
-	WORD $0x8948; BYTE $0xe5                   // movq         %rsp, %rbp
 	WORD $0x5741                               // pushq        %r15
+	WORD $0xc031                               // xorl         %eax, %eax
+	WORD $0x3145; BYTE $0xc9                   // xorl         %r9d, %r9d
 	WORD $0x5641                               // pushq        %r14
+	WORD $0x5541                               // pushq        %r13
 	WORD $0x5441                               // pushq        %r12
+	BYTE $0x55                                 // pushq        %rbp
+	LONG $0x000001bd; BYTE $0x00               // movl         $1, %ebp
 	BYTE $0x53                                 // pushq        %rbx
+	WORD $0xdb31                               // xorl         %ebx, %ebx
 	LONG $0x000447c7; WORD $0x0000; BYTE $0x00 // movl         $0, $4(%rdi)
+	LONG $0x1f148d4c                           // leaq         (%rdi,%rbx), %r10
 	WORD $0x0f88                               // movb         %cl, (%rdi)
-	WORD $0x3145; BYTE $0xff                   // xorl         %r15d, %r15d
-	LONG $0x7e058d4c; WORD $0x0003; BYTE $0x00 // leaq         $894(%rip), %r8  /* LJTI0_0(%rip) */
-	LONG $0xbb0d8d4c; WORD $0x0003; BYTE $0x00 // leaq         $955(%rip), %r9  /* _SkipSizeFixed(%rip) */
-	LONG $0xb4158d4c; WORD $0x0004; BYTE $0x00 // leaq         $1204(%rip), %r10  /* _WireTags(%rip) */
-	WORD $0x3145; BYTE $0xdb                   // xorl         %r11d, %r11d
-	BYTE $0x90                                 // .p2align 4, 0x90
-
-LBB0_1:
-	LONG $0xffc0c748; WORD $0xffff; BYTE $0xff // movq         $-1, %rax
-	WORD $0x598d; BYTE $0x01                   // leal         $1(%rcx), %ebx
-	WORD $0xfb80; BYTE $0x10                   // cmpb         $16, %bl
-	LONG $0x0342870f; WORD $0x0000             // ja           LBB0_57, $834(%rip)
-	WORD $0xb60f; BYTE $0xdb                   // movzbl       %bl, %ebx
-	LONG $0x981c6349                           // movslq       (%r8,%rbx,4), %rbx
-	WORD $0x014c; BYTE $0xc3                   // addq         %r8, %rbx
-	JMP  BX
-
-LBB0_3:
-	WORD $0xb60f; BYTE $0xc1       // movzbl       %cl, %eax
-	LONG $0x0cbe0f4a; BYTE $0x08   // movsbq       (%rax,%r9), %rcx
+	WORD $0xf980; BYTE $0x0d                   // cmpb         $13, %cl
+	WORD $0x7d74                               // je           LBB0_2, $125(%rip)
+
+LBB0_74:
+	LONG $0x0112870f; WORD $0x0000             // ja           LBB0_3, $274(%rip)
+	WORD $0xf980; BYTE $0x0c                   // cmpb         $12, %cl
+	LONG $0x0269840f; WORD $0x0000             // je           LBB0_4, $617(%rip)
+	WORD $0xf980; BYTE $0x01                   // cmpb         $1, %cl
+	LONG $0x01bd860f; WORD $0x0000             // jbe          LBB0_51, $445(%rip)
+	WORD $0x8949; BYTE $0xeb                   // movq         %rbp, %r11
+	LONG $0xe1b60f44                           // movzbl       %cl, %r12d
+	WORD $0xd349; BYTE $0xe3                   // salq         %cl, %r11
+	LONG $0x5cc3f741; WORD $0x0005; BYTE $0x00 // testl        $1372, %r11d
+	LONG $0x01a1840f; WORD $0x0000             // je           LBB0_73, $417(%rip)
+	LONG $0xa20d8d48; WORD $0x0004; BYTE $0x00 // leaq         $1186(%rip), %rcx  /* _SkipSizeFixed(%rip) */
+	LONG $0x0cbe0f4a; BYTE $0x21               // movsbq       (%rcx,%r12), %rcx
+	WORD $0x3948; BYTE $0xd1                   // cmpq         %rdx, %rcx
+	LONG $0x00c48f0f; WORD $0x0000             // jg           LBB0_53, $196(%rip)
+
+LBB0_70:
+	LONG $0x045a8b45               // movl         $4(%r10), %r11d
+	WORD $0x8545; BYTE $0xdb       // testl        %r11d, %r11d
+	LONG $0x019f850f; WORD $0x0000 // jne          LBB0_22, $415(%rip)
+	LONG $0x01e88348               // subq         $1, %rax
 	WORD $0x2948; BYTE $0xca       // subq         %rcx, %rdx
-	LONG $0x03168c0f; WORD $0x0000 // jl           LBB0_55, $790(%rip)
-
-LBB0_4:
-	LONG $0xff448b42; BYTE $0x04   // movl         $4(%rdi,%r15,8), %eax
-	WORD $0xc085                   // testl        %eax, %eax
-	LONG $0x0013840f; WORD $0x0000 // je           LBB0_6, $19(%rip)
-	WORD $0xc083; BYTE $0xff       // addl         $-1, %eax
-	LONG $0xff448942; BYTE $0x04   // movl         %eax, $4(%rdi,%r15,8)
-	LONG $0x00000ae9; BYTE $0x00   // jmp          LBB0_7, $10(%rip)
-	LONG $0x90909090; WORD $0x9090 // .p2align 4, 0x90
-
-LBB0_6:
-	LONG $0xffc78349 // addq         $-1, %r15
+	WORD $0x0149; BYTE $0xc9       // addq         %rcx, %r9
+	WORD $0x0148; BYTE $0xce       // addq         %rcx, %rsi
 
-LBB0_7:
-	WORD $0x0149; BYTE $0xcb // addq         %rcx, %r11
+LBB0_12:
+	LONG $0xfff88348               // cmpq         $-1, %rax
+	LONG $0x0174840f; WORD $0x0000 // je           LBB0_1, $372(%rip)
+	QUAD $0x00000000c51c8d48       // leaq         $0(,%rax,8), %rbx
 
-LBB0_8:
-	WORD $0x854d; BYTE $0xff       // testq        %r15, %r15
-	LONG $0x02f2880f; WORD $0x0000 // js           LBB0_56, $754(%rip)
-	WORD $0x0148; BYTE $0xce       // addq         %rcx, %rsi
-	LONG $0x0cb60f42; BYTE $0xff   // movzbl       (%rdi,%r15,8), %ecx
-	LONG $0xffff93e9; BYTE $0xff   // jmp          LBB0_1, $-109(%rip)
+LBB0_13:
+	LONG $0xc70cb60f         // movzbl       (%rdi,%rax,8), %ecx
+	LONG $0x1f148d4c         // leaq         (%rdi,%rbx), %r10
+	WORD $0xf980; BYTE $0x0d // cmpb         $13, %cl
+	WORD $0x8375             // jne          LBB0_74, $-125(%rip)
 
-LBB0_10:
+LBB0_2:
+	LONG $0x10403b49                           // cmpq         $16(%r8), %rax
+	LONG $0x0421830f; WORD $0x0000             // jnb          LBB0_49, $1057(%rip)
 	LONG $0x05fa8348                           // cmpq         $5, %rdx
-	LONG $0x02cf8c0f; WORD $0x0000             // jl           LBB0_55, $719(%rip)
-	WORD $0xb60f; BYTE $0x1e                   // movzbl       (%rsi), %ebx
-	WORD $0xb60f; BYTE $0xcb                   // movzbl       %bl, %ecx
-	LONG $0x113c8042; BYTE $0x00               // cmpb         $0, (%rcx,%r10)
-	LONG $0x02cd840f; WORD $0x0000             // je           LBB0_57, $717(%rip)
-	WORD $0x468b; BYTE $0x01                   // movl         $1(%rsi), %eax
-	WORD $0xc085                               // testl        %eax, %eax
-	LONG $0x015f840f; WORD $0x0000             // je           LBB0_36, $351(%rip)
-	WORD $0xc80f                               // bswapl       %eax
-	LONG $0x0cbe0f4a; BYTE $0x09               // movsbq       (%rcx,%r9), %rcx
-	WORD $0x8548; BYTE $0xc9                   // testq        %rcx, %rcx
-	LONG $0x0169840f; WORD $0x0000             // je           LBB0_38, $361(%rip)
-	LONG $0xc8af0f48                           // imulq        %rax, %rcx
-	LONG $0x05c18348                           // addq         $5, %rcx
-	LONG $0xfec0c748; WORD $0xffff; BYTE $0xff // movq         $-2, %rax
-	WORD $0x2948; BYTE $0xca                   // subq         %rcx, %rdx
-	LONG $0xff758d0f; WORD $0xffff             // jge          LBB0_4, $-139(%rip)
-	LONG $0x000295e9; BYTE $0x00               // jmp          LBB0_57, $661(%rip)
+	WORD $0x7b7e                               // jle          LBB0_53, $123(%rip)
+	LONG $0x1eb60f44                           // movzbl       (%rsi), %r11d
+	WORD $0x4e8b; BYTE $0x02                   // movl         $2(%rsi), %ecx
+	LONG $0x3d358d4c; WORD $0x0005; BYTE $0x00 // leaq         $1341(%rip), %r14  /* _WireTags(%rip) */
+	LONG $0x3eb70f44                           // movzwl       (%rsi), %r15d
+	LONG $0x66b60f44; BYTE $0x01               // movzbl       $1(%rsi), %r12d
+	LONG $0x1e3c8043; BYTE $0x00               // cmpb         $0, (%r14,%r11)
+	WORD $0xc90f                               // bswapl       %ecx
+	WORD $0x8941; BYTE $0xcd                   // movl         %ecx, %r13d
+	LONG $0x0121840f; WORD $0x0000             // je           LBB0_51, $289(%rip)
+	LONG $0x263c8043; BYTE $0x00               // cmpb         $0, (%r14,%r12)
+	LONG $0x0116840f; WORD $0x0000             // je           LBB0_51, $278(%rip)
+	WORD $0x394d; BYTE $0x28                   // cmpq         %r13, (%r8)
+	LONG $0x03ec820f; WORD $0x0000             // jb           LBB0_52, $1004(%rip)
+	WORD $0x854d; BYTE $0xed                   // testq        %r13, %r13
+	LONG $0x0347840f; WORD $0x0000             // je           LBB0_75, $839(%rip)
+	LONG $0x00358d4c; WORD $0x0004; BYTE $0x00 // leaq         $1024(%rip), %r14  /* _SkipSizeFixed(%rip) */
+	LONG $0x1cbe0f4f; BYTE $0x1e               // movsbq       (%r14,%r11), %r11
+	LONG $0x24be0f4f; BYTE $0x26               // movsbq       (%r14,%r12), %r12
+	WORD $0x854d; BYTE $0xdb                   // testq        %r11, %r11
+	LONG $0x02cd840f; WORD $0x0000             // je           LBB0_21, $717(%rip)
+	WORD $0x854d; BYTE $0xe4                   // testq        %r12, %r12
+	LONG $0x02c4840f; WORD $0x0000             // je           LBB0_21, $708(%rip)
+	WORD $0x014d; BYTE $0xe3                   // addq         %r12, %r11
+	LONG $0xddaf0f4d                           // imulq        %r13, %r11
+	LONG $0x064b8d49                           // leaq         $6(%r11), %rcx
+	WORD $0x3948; BYTE $0xd1                   // cmpq         %rdx, %rcx
+	LONG $0xff3c8e0f; WORD $0xffff             // jle          LBB0_70, $-196(%rip)
 
-LBB0_16:
-	LONG $0x44b60f42; WORD $0x02ff             // movzbl       $2(%rdi,%r15,8), %eax
-	LONG $0xff4c8b42; BYTE $0x04               // movl         $4(%rdi,%r15,8), %ecx
-	WORD $0xc985                               // testl        %ecx, %ecx
-	LONG $0x015b840f; WORD $0x0000             // je           LBB0_39, $347(%rip)
-	WORD $0xc183; BYTE $0xff                   // addl         $-1, %ecx
-	LONG $0xff4c8942; BYTE $0x04               // movl         %ecx, $4(%rdi,%r15,8)
-	WORD $0xc1f6; BYTE $0x01                   // testb        $1, %cl
-	LONG $0x4cb60f42; WORD $0x01ff             // movzbl       $1(%rdi,%r15,8), %ecx
-	WORD $0xb60f; BYTE $0xc0                   // movzbl       %al, %eax
-	WORD $0x440f; BYTE $0xc1                   // cmovel       %ecx, %eax
-	LONG $0xfeff8149; WORD $0x0003; BYTE $0x00 // cmpq         $1022, %r15
-	LONG $0x01488e0f; WORD $0x0000             // jle          LBB0_40, $328(%rip)
-	LONG $0x000262e9; BYTE $0x00               // jmp          LBB0_18, $610(%rip)
-
-LBB0_19:
-	LONG $0xfec0c748; WORD $0xffff; BYTE $0xff // movq         $-2, %rax
+LBB0_53:
+	LONG $0xfec1c749; WORD $0xffff; BYTE $0xff // movq         $-2, %r9
+	LONG $0x0000c8e9; BYTE $0x00               // jmp          LBB0_1, $200(%rip)
+	LONG $0x00401f0f                           // .p2align 4, 0x90
+
+LBB0_3:
+	WORD $0xf980; BYTE $0xfe       // cmpb         $-2, %cl
+	LONG $0x01e7840f; WORD $0x0000 // je           LBB0_8, $487(%rip)
+	WORD $0xf980; BYTE $0xff       // cmpb         $-1, %cl
+	WORD $0x1575                   // jne          LBB0_76, $21(%rip)
+	LONG $0x044a8b41               // movl         $4(%r10), %ecx
+	WORD $0xc985                   // testl        %ecx, %ecx
+	LONG $0x0106850f; WORD $0x0000 // jne          LBB0_23, $262(%rip)
+
+LBB0_68:
+	LONG $0x01e88348             // subq         $1, %rax
+	LONG $0xffff23e9; BYTE $0xff // jmp          LBB0_12, $-221(%rip)
+
+LBB0_76:
+	WORD $0xe983; BYTE $0x0e                   // subl         $14, %ecx
+	WORD $0xf980; BYTE $0x01                   // cmpb         $1, %cl
+	LONG $0x008e870f; WORD $0x0000             // ja           LBB0_51, $142(%rip)
+	LONG $0x10403b49                           // cmpq         $16(%r8), %rax
+	LONG $0x0357830f; WORD $0x0000             // jnb          LBB0_49, $855(%rip)
 	LONG $0x04fa8348                           // cmpq         $4, %rdx
-	LONG $0x02488c0f; WORD $0x0000             // jl           LBB0_57, $584(%rip)
-	WORD $0x0e8b                               // movl         (%rsi), %ecx
+	WORD $0xb17e                               // jle          LBB0_53, $-79(%rip)
+	LONG $0x1eb60f44                           // movzbl       (%rsi), %r11d
+	WORD $0x4e8b; BYTE $0x01                   // movl         $1(%rsi), %ecx
+	LONG $0x73258d4c; WORD $0x0004; BYTE $0x00 // leaq         $1139(%rip), %r12  /* _WireTags(%rip) */
+	LONG $0x1c3c8043; BYTE $0x00               // cmpb         $0, (%r12,%r11)
 	WORD $0xc90f                               // bswapl       %ecx
-	LONG $0x04c18348                           // addq         $4, %rcx
-	WORD $0x2948; BYTE $0xca                   // subq         %rcx, %rdx
-	LONG $0xff128d0f; WORD $0xffff             // jge          LBB0_4, $-238(%rip)
-	LONG $0x000232e9; BYTE $0x00               // jmp          LBB0_57, $562(%rip)
+	WORD $0x894d; BYTE $0xdd                   // movq         %r11, %r13
+	WORD $0x8941; BYTE $0xce                   // movl         %ecx, %r14d
+	WORD $0x6174                               // je           LBB0_51, $97(%rip)
+	WORD $0x394d; BYTE $0x30                   // cmpq         %r14, (%r8)
+	LONG $0x0337820f; WORD $0x0000             // jb           LBB0_52, $823(%rip)
+	WORD $0x854d; BYTE $0xf6                   // testq        %r14, %r14
+	LONG $0x0272840f; WORD $0x0000             // je           LBB0_77, $626(%rip)
+	LONG $0x4b258d4c; WORD $0x0003; BYTE $0x00 // leaq         $843(%rip), %r12  /* _SkipSizeFixed(%rip) */
+	LONG $0x1cbe0f4f; BYTE $0x1c               // movsbq       (%r12,%r11), %r11
+	WORD $0x854d; BYTE $0xdb                   // testq        %r11, %r11
+	LONG $0x01dd840f; WORD $0x0000             // je           LBB0_28, $477(%rip)
+	LONG $0xdeaf0f4d                           // imulq        %r14, %r11
+	LONG $0x05c38349                           // addq         $5, %r11
+	WORD $0x3949; BYTE $0xd3                   // cmpq         %rdx, %r11
+	LONG $0xff5c8f0f; WORD $0xffff             // jg           LBB0_53, $-164(%rip)
+	LONG $0x044a8b41                           // movl         $4(%r10), %ecx
+	WORD $0xc985                               // testl        %ecx, %ecx
+	LONG $0x02c0850f; WORD $0x0000             // jne          LBB0_29, $704(%rip)
+	LONG $0x01e88348                           // subq         $1, %rax
+	WORD $0x294c; BYTE $0xda                   // subq         %r11, %rdx
+	WORD $0x014d; BYTE $0xd9                   // addq         %r11, %r9
+	WORD $0x014c; BYTE $0xde                   // addq         %r11, %rsi
+	LONG $0xfffe94e9; BYTE $0xff               // jmp          LBB0_12, $-364(%rip)
+	LONG $0x441f0f66; WORD $0x0000             // .p2align 3, 0x90
+
+LBB0_73:
+	WORD $0xf980; BYTE $0x0b // cmpb         $11, %cl
+	WORD $0x3374             // je           LBB0_7, $51(%rip)
+
+LBB0_51:
+	LONG $0xffc1c749; WORD $0xffff; BYTE $0xff // movq         $-1, %r9
+
+LBB0_1:
+	BYTE $0x5b                     // popq         %rbx
+	WORD $0x894c; BYTE $0xc8       // movq         %r9, %rax
+	BYTE $0x5d                     // popq         %rbp
+	WORD $0x5c41                   // popq         %r12
+	WORD $0x5d41                   // popq         %r13
+	WORD $0x5e41                   // popq         %r14
+	WORD $0x5f41                   // popq         %r15
+	BYTE $0xc3                     // retq
+	LONG $0x441f0f66; WORD $0x0000 // .p2align 3, 0x90
 
 LBB0_22:
+	LONG $0x01eb8341             // subl         $1, %r11d
+	WORD $0x2948; BYTE $0xca     // subq         %rcx, %rdx
+	WORD $0x0149; BYTE $0xc9     // addq         %rcx, %r9
+	WORD $0x0148; BYTE $0xce     // addq         %rcx, %rsi
+	LONG $0x045a8945             // movl         %r11d, $4(%r10)
+	LONG $0xfffe6ae9; BYTE $0xff // jmp          LBB0_13, $-406(%rip)
+	WORD $0x9066                 // .p2align 4, 0x90
+
+LBB0_7:
+	LONG $0x03fa8348               // cmpq         $3, %rdx
+	LONG $0xfef68e0f; WORD $0xffff // jle          LBB0_53, $-266(%rip)
+	WORD $0x0e8b                   // movl         (%rsi), %ecx
+	WORD $0xc90f                   // bswapl       %ecx
+	WORD $0xc989                   // movl         %ecx, %ecx
+	LONG $0x08483949               // cmpq         %rcx, $8(%r8)
+	LONG $0x029e820f; WORD $0x0000 // jb           LBB0_36, $670(%rip)
+	LONG $0x04c18348               // addq         $4, %rcx
+	WORD $0x3948; BYTE $0xd1       // cmpq         %rdx, %rcx
+	LONG $0xfe158e0f; WORD $0xffff // jle          LBB0_70, $-491(%rip)
+	LONG $0xfffed4e9; BYTE $0xff   // jmp          LBB0_53, $-300(%rip)
+	LONG $0x00401f0f               // .p2align 4, 0x90
+
+LBB0_23:
+	WORD $0xe983; BYTE $0x01       // subl         $1, %ecx
+	LONG $0x5ab60f45; BYTE $0x01   // movzbl       $1(%r10), %r11d
+	LONG $0x044a8941               // movl         %ecx, $4(%r10)
+	LONG $0x52b60f45; BYTE $0x02   // movzbl       $2(%r10), %r10d
+	WORD $0xe183; BYTE $0x01       // andl         $1, %ecx
+	LONG $0xd3450f45               // cmovne       %r11d, %r10d
+	LONG $0x01c08348               // addq         $1, %rax
+	LONG $0x03ff3d48; WORD $0x0000 // cmpq         $1023, %rax
+	LONG $0x01388e0f; WORD $0x0000 // jle          LBB0_78, $312(%rip)
+
+LBB0_25:
+	LONG $0xfdc1c749; WORD $0xffff; BYTE $0xff // movq         $-3, %r9
+	BYTE $0x5b                                 // popq         %rbx
+	BYTE $0x5d                                 // popq         %rbp
+	WORD $0x894c; BYTE $0xc8                   // movq         %r9, %rax
+	WORD $0x5c41                               // popq         %r12
+	WORD $0x5d41                               // popq         %r13
+	WORD $0x5e41                               // popq         %r14
+	WORD $0x5f41                               // popq         %r15
+	BYTE $0xc3                                 // retq
+	WORD $0x1f0f; BYTE $0x00                   // .p2align 4, 0x90
+
+LBB0_4:
+	LONG $0x10403b49                           // cmpq         $16(%r8), %rax
+	LONG $0x0226830f; WORD $0x0000             // jnb          LBB0_49, $550(%rip)
 	WORD $0x8548; BYTE $0xd2                   // testq        %rdx, %rdx
-	LONG $0x021a8e0f; WORD $0x0000             // jle          LBB0_55, $538(%rip)
-	WORD $0xb60f; BYTE $0x0e                   // movzbl       (%rsi), %ecx
+	LONG $0xfe7d8e0f; WORD $0xffff             // jle          LBB0_53, $-387(%rip)
+	LONG $0x1eb60f44                           // movzbl       (%rsi), %r11d
+	WORD $0x8445; BYTE $0xdb                   // testb        %r11b, %r11b
+	LONG $0x0140840f; WORD $0x0000             // je           LBB0_79, $320(%rip)
+	LONG $0xcbb60f41                           // movzbl       %r11b, %ecx
+	LONG $0x35158d4c; WORD $0x0003; BYTE $0x00 // leaq         $821(%rip), %r10  /* _WireTags(%rip) */
+	LONG $0x0a3c8041; BYTE $0x00               // cmpb         $0, (%r10,%rcx)
+	LONG $0xff27840f; WORD $0xffff             // je           LBB0_51, $-217(%rip)
+	LONG $0x23158d4c; WORD $0x0002; BYTE $0x00 // leaq         $547(%rip), %r10  /* _SkipSizeFixed(%rip) */
+	LONG $0x0cbe0f49; BYTE $0x0a               // movsbq       (%r10,%rcx), %rcx
 	WORD $0x8548; BYTE $0xc9                   // testq        %rcx, %rcx
-	LONG $0x0123840f; WORD $0x0000             // je           LBB0_41, $291(%rip)
-	LONG $0x113c8042; BYTE $0x00               // cmpb         $0, (%rcx,%r10)
-	LONG $0x0212840f; WORD $0x0000             // je           LBB0_57, $530(%rip)
-	LONG $0x1cbe0f4a; BYTE $0x09               // movsbq       (%rcx,%r9), %rbx
-	WORD $0x8548; BYTE $0xdb                   // testq        %rbx, %rbx
-	LONG $0x0183840f; WORD $0x0000             // je           LBB0_48, $387(%rip)
-	LONG $0x03c38348                           // addq         $3, %rbx
-	LONG $0xfec0c748; WORD $0xffff; BYTE $0xff // movq         $-2, %rax
-	WORD $0x2948; BYTE $0xda                   // subq         %rbx, %rdx
-	LONG $0x01f08c0f; WORD $0x0000             // jl           LBB0_57, $496(%rip)
-	WORD $0x0149; BYTE $0xdb                   // addq         %rbx, %r11
-	WORD $0x0148; BYTE $0xde                   // addq         %rbx, %rsi
-	LONG $0x0cb60f42; BYTE $0xff               // movzbl       (%rdi,%r15,8), %ecx
-	LONG $0xfffe8be9; BYTE $0xff               // jmp          LBB0_1, $-373(%rip)
+	LONG $0x008d850f; WORD $0x0000             // jne          LBB0_80, $141(%rip)
+	LONG $0x03fa8348                           // cmpq         $3, %rdx
+	LONG $0xfe3b8e0f; WORD $0xffff             // jle          LBB0_53, $-453(%rip)
+	LONG $0x01c08348                           // addq         $1, %rax
+	LONG $0x03ff3d48; WORD $0x0000             // cmpq         $1023, %rax
+	WORD $0x877f                               // jg           LBB0_25, $-121(%rip)
+	LONG $0x1f4c8d48; BYTE $0x08               // leaq         $8(%rdi,%rbx), %rcx
+	LONG $0x03ea8348                           // subq         $3, %rdx
+	LONG $0x03c18349                           // addq         $3, %r9
+	LONG $0x03c68348                           // addq         $3, %rsi
+	WORD $0x8844; BYTE $0x19                   // movb         %r11b, (%rcx)
+	QUAD $0x00000000c51c8d48                   // leaq         $0(,%rax,8), %rbx
+	LONG $0x000441c7; WORD $0x0000; BYTE $0x00 // movl         $0, $4(%rcx)
+	LONG $0xfffd6fe9; BYTE $0xff               // jmp          LBB0_13, $-657(%rip)
+	LONG $0x00801f0f; WORD $0x0000; BYTE $0x00 // .p2align 4, 0x90
 
-LBB0_28:
-	LONG $0x06fa8348                           // cmpq         $6, %rdx
-	LONG $0x01c78c0f; WORD $0x0000             // jl           LBB0_55, $455(%rip)
-	LONG $0x36b60f44                           // movzbl       (%rsi), %r14d
-	LONG $0xdeb60f41                           // movzbl       %r14b, %ebx
-	LONG $0x133c8042; BYTE $0x00               // cmpb         $0, (%rbx,%r10)
-	LONG $0x01c3840f; WORD $0x0000             // je           LBB0_57, $451(%rip)
-	LONG $0x66b60f44; BYTE $0x01               // movzbl       $1(%rsi), %r12d
-	LONG $0xccb60f41                           // movzbl       %r12b, %ecx
-	LONG $0x113c8042; BYTE $0x00               // cmpb         $0, (%rcx,%r10)
-	LONG $0x01af840f; WORD $0x0000             // je           LBB0_57, $431(%rip)
-	WORD $0x468b; BYTE $0x02                   // movl         $2(%rsi), %eax
-	WORD $0xc085                               // testl        %eax, %eax
-	LONG $0x00c4840f; WORD $0x0000             // je           LBB0_43, $196(%rip)
-	WORD $0xc80f                               // bswapl       %eax
-	LONG $0x1cbe0f4a; BYTE $0x0b               // movsbq       (%rbx,%r9), %rbx
-	WORD $0x8548; BYTE $0xdb                   // testq        %rbx, %rbx
-	LONG $0x00ce840f; WORD $0x0000             // je           LBB0_45, $206(%rip)
-	LONG $0x0cb60f42; BYTE $0x09               // movzbl       (%rcx,%r9), %ecx
-	WORD $0xc984                               // testb        %cl, %cl
-	LONG $0x00c1840f; WORD $0x0000             // je           LBB0_45, $193(%rip)
-	LONG $0xc9be0f48                           // movsbq       %cl, %rcx
-	WORD $0x0148; BYTE $0xd9                   // addq         %rbx, %rcx
-	LONG $0xc8af0f48                           // imulq        %rax, %rcx
-	LONG $0x06c18348                           // addq         $6, %rcx
-	LONG $0xfec0c748; WORD $0xffff; BYTE $0xff // movq         $-2, %rax
-	WORD $0x2948; BYTE $0xca                   // subq         %rcx, %rdx
-	LONG $0xfe438d0f; WORD $0xffff             // jge          LBB0_4, $-445(%rip)
-	LONG $0x000163e9; BYTE $0x00               // jmp          LBB0_57, $355(%rip)
+LBB0_8:
+	LONG $0x044a8b41               // movl         $4(%r10), %ecx
+	WORD $0xc985                   // testl        %ecx, %ecx
+	LONG $0xfe1e840f; WORD $0xffff // je           LBB0_68, $-482(%rip)
+	WORD $0xe983; BYTE $0x01       // subl         $1, %ecx
+	LONG $0x01c08348               // addq         $1, %rax
+	LONG $0x044a8941               // movl         %ecx, $4(%r10)
+	LONG $0x4ab60f41; BYTE $0x02   // movzbl       $2(%r10), %ecx
+	LONG $0x03ff3d48; WORD $0x0000 // cmpq         $1023, %rax
+	LONG $0xff308f0f; WORD $0xffff // jg           LBB0_25, $-208(%rip)
+	LONG $0x1f548d4c; BYTE $0x08   // leaq         $8(%rdi,%rbx), %r10
+	QUAD $0x00000000c51c8d48       // leaq         $0(,%rax,8), %rbx
+	WORD $0x8841; BYTE $0x0a       // movb         %cl, (%r10)
+	QUAD $0x000000000442c741       // movl         $0, $4(%r10)
+	LONG $0xfffd23e9; BYTE $0xff   // jmp          LBB0_13, $-733(%rip)
+	WORD $0x1f0f; BYTE $0x00       // .p2align 3, 0x90
+
+LBB0_80:
+	LONG $0x02518d4c                     // leaq         $2(%rcx), %r10
+	WORD $0x3949; BYTE $0xd2             // cmpq         %rdx, %r10
+	LONG $0xfdab8d0f; WORD $0xffff       // jge          LBB0_53, $-597(%rip)
+	LONG $0x03c18348                     // addq         $3, %rcx
+	WORD $0x2948; BYTE $0xca             // subq         %rcx, %rdx
+	WORD $0x0149; BYTE $0xc9             // addq         %rcx, %r9
+	WORD $0x0148; BYTE $0xce             // addq         %rcx, %rsi
+	LONG $0xfffd01e9; BYTE $0xff         // jmp          LBB0_13, $-767(%rip)
+	QUAD $0x00000000841f0f66; BYTE $0x00 // .p2align 4, 0x90
 
-LBB0_36:
-	LONG $0xff448b42; BYTE $0x04   // movl         $4(%rdi,%r15,8), %eax
-	WORD $0xc085                   // testl        %eax, %eax
-	LONG $0x00bf840f; WORD $0x0000 // je           LBB0_46, $191(%rip)
-	WORD $0xc083; BYTE $0xff       // addl         $-1, %eax
-	LONG $0xff448942; BYTE $0x04   // movl         %eax, $4(%rdi,%r15,8)
-	LONG $0x0000b6e9; BYTE $0x00   // jmp          LBB0_47, $182(%rip)
-
-LBB0_38:
-	LONG $0xff1c8842             // movb         %bl, (%rdi,%r15,8)
-	WORD $0xc083; BYTE $0xff     // addl         $-1, %eax
-	LONG $0xff448942; BYTE $0x04 // movl         %eax, $4(%rdi,%r15,8)
-	LONG $0xfbc28348             // addq         $-5, %rdx
-	LONG $0x05c38349             // addq         $5, %r11
+LBB0_28:
+	LONG $0x026a8845             // movb         %r13b, $2(%r10)
+	LONG $0x05ea8348             // subq         $5, %rdx
+	LONG $0x05c18349             // addq         $5, %r9
 	LONG $0x05c68348             // addq         $5, %rsi
-	LONG $0x0cb60f42; BYTE $0xff // movzbl       (%rdi,%r15,8), %ecx
-	LONG $0xfffdd2e9; BYTE $0xff // jmp          LBB0_1, $-558(%rip)
-
-LBB0_39:
-	LONG $0xffc78349                           // addq         $-1, %r15
-	LONG $0xfeff8149; WORD $0x0003; BYTE $0x00 // cmpq         $1022, %r15
-	LONG $0x011f8f0f; WORD $0x0000             // jg           LBB0_18, $287(%rip)
-
-LBB0_40:
-	LONG $0xff448842; BYTE $0x08         // movb         %al, $8(%rdi,%r15,8)
-	QUAD $0x0000000cff44c742; BYTE $0x00 // movl         $0, $12(%rdi,%r15,8)
-	LONG $0x01c78349                     // addq         $1, %r15
-	LONG $0x0cb60f42; BYTE $0xff         // movzbl       (%rdi,%r15,8), %ecx
-	LONG $0xfffda5e9; BYTE $0xff         // jmp          LBB0_1, $-603(%rip)
-
-LBB0_41:
-	LONG $0xff448b42; BYTE $0x04   // movl         $4(%rdi,%r15,8), %eax
-	WORD $0xc085                   // testl        %eax, %eax
-	LONG $0x00b2840f; WORD $0x0000 // je           LBB0_51, $178(%rip)
-	WORD $0xc083; BYTE $0xff       // addl         $-1, %eax
-	LONG $0xff448942; BYTE $0x04   // movl         %eax, $4(%rdi,%r15,8)
-	LONG $0x0000a9e9; BYTE $0x00   // jmp          LBB0_52, $169(%rip)
-
-LBB0_43:
-	LONG $0xff448b42; BYTE $0x04   // movl         $4(%rdi,%r15,8), %eax
-	WORD $0xc085                   // testl        %eax, %eax
-	LONG $0x00ae840f; WORD $0x0000 // je           LBB0_53, $174(%rip)
-	WORD $0xc083; BYTE $0xff       // addl         $-1, %eax
-	LONG $0xff448942; BYTE $0x04   // movl         %eax, $4(%rdi,%r15,8)
-	LONG $0x0000a5e9; BYTE $0x00   // jmp          LBB0_54, $165(%rip)
-
-LBB0_45:
-	LONG $0xff748846; BYTE $0x01 // movb         %r14b, $1(%rdi,%r15,8)
-	LONG $0xff648846; BYTE $0x02 // movb         %r12b, $2(%rdi,%r15,8)
-	LONG $0xff04c642; BYTE $0xff // movb         $-1, (%rdi,%r15,8)
-	WORD $0xc001                 // addl         %eax, %eax
-	WORD $0xc083; BYTE $0xff     // addl         $-1, %eax
-	LONG $0xff448942; BYTE $0x04 // movl         %eax, $4(%rdi,%r15,8)
-	LONG $0xfac28348             // addq         $-6, %rdx
-	LONG $0x06c38349             // addq         $6, %r11
+	LONG $0xfe02c641             // movb         $-2, (%r10)
+	LONG $0x044a8941             // movl         %ecx, $4(%r10)
+	LONG $0xfffcdbe9; BYTE $0xff // jmp          LBB0_13, $-805(%rip)
+	WORD $0x1f0f; BYTE $0x00     // .p2align 4, 0x90
+
+LBB0_78:
+	LONG $0x1f4c8d48; BYTE $0x08               // leaq         $8(%rdi,%rbx), %rcx
+	QUAD $0x00000000c51c8d48                   // leaq         $0(,%rax,8), %rbx
+	WORD $0x8844; BYTE $0x11                   // movb         %r10b, (%rcx)
+	LONG $0x000441c7; WORD $0x0000; BYTE $0x00 // movl         $0, $4(%rcx)
+	LONG $0xfffcbce9; BYTE $0xff               // jmp          LBB0_13, $-836(%rip)
+	LONG $0x00401f0f                           // .p2align 4, 0x90
+
+LBB0_21:
+	WORD $0xc901                 // addl         %ecx, %ecx
+	LONG $0x7a894566; BYTE $0x01 // movw         %r15w, $1(%r10)
+	LONG $0x06ea8348             // subq         $6, %rdx
+	LONG $0x06c18349             // addq         $6, %r9
+	LONG $0xff02c641             // movb         $-1, (%r10)
 	LONG $0x06c68348             // addq         $6, %rsi
-	LONG $0x0cb60f42; BYTE $0xff // movzbl       (%rdi,%r15,8), %ecx
-	LONG $0xfffd42e9; BYTE $0xff // jmp          LBB0_1, $-702(%rip)
-
-LBB0_46:
-	LONG $0xffc78349 // addq         $-1, %r15
-
-LBB0_47:
-	LONG $0xfbc28348             // addq         $-5, %rdx
-	LONG $0x05c38349             // addq         $5, %r11
-	LONG $0x000005b9; BYTE $0x00 // movl         $5, %ecx
-	LONG $0xfffd83e9; BYTE $0xff // jmp          LBB0_8, $-637(%rip)
+	LONG $0x044a8941             // movl         %ecx, $4(%r10)
+	LONG $0xfffc98e9; BYTE $0xff // jmp          LBB0_13, $-872(%rip)
+
+LBB0_79:
+	LONG $0x044a8b41             // movl         $4(%r10), %ecx
+	WORD $0xc985                 // testl        %ecx, %ecx
+	WORD $0x5875                 // jne          LBB0_16, $88(%rip)
+	LONG $0x01e88348             // subq         $1, %rax
+	LONG $0x01ea8348             // subq         $1, %rdx
+	LONG $0x01c18349             // addq         $1, %r9
+	LONG $0x01c68348             // addq         $1, %rsi
+	LONG $0xfffc69e9; BYTE $0xff // jmp          LBB0_12, $-919(%rip)
+	WORD $0x1f0f; BYTE $0x00     // .p2align 4, 0x90
+
+LBB0_77:
+	LONG $0x044a8b41             // movl         $4(%r10), %ecx
+	WORD $0xc985                 // testl        %ecx, %ecx
+	WORD $0x5875                 // jne          LBB0_27, $88(%rip)
+	LONG $0x01e88348             // subq         $1, %rax
+	LONG $0x05ea8348             // subq         $5, %rdx
+	LONG $0x05c18349             // addq         $5, %r9
+	LONG $0x05c68348             // addq         $5, %rsi
+	LONG $0xfffc49e9; BYTE $0xff // jmp          LBB0_12, $-951(%rip)
+	WORD $0x1f0f; BYTE $0x00     // .p2align 4, 0x90
+
+LBB0_75:
+	LONG $0x044a8b41             // movl         $4(%r10), %ecx
+	WORD $0xc985                 // testl        %ecx, %ecx
+	WORD $0x7075                 // jne          LBB0_20, $112(%rip)
+	LONG $0x01e88348             // subq         $1, %rax
+	LONG $0x06ea8348             // subq         $6, %rdx
+	LONG $0x06c18349             // addq         $6, %r9
+	LONG $0x06c68348             // addq         $6, %rsi
+	LONG $0xfffc29e9; BYTE $0xff // jmp          LBB0_12, $-983(%rip)
+	WORD $0x1f0f; BYTE $0x00     // .p2align 4, 0x90
 
-LBB0_48:
-	LONG $0xfec0c748; WORD $0xffff; BYTE $0xff // movq         $-2, %rax
-	LONG $0x04fa8348                           // cmpq         $4, %rdx
-	LONG $0x00708c0f; WORD $0x0000             // jl           LBB0_57, $112(%rip)
-	LONG $0xfeff8149; WORD $0x0003; BYTE $0x00 // cmpq         $1022, %r15
-	LONG $0x006c8f0f; WORD $0x0000             // jg           LBB0_18, $108(%rip)
-	LONG $0xff4c8842; BYTE $0x08               // movb         %cl, $8(%rdi,%r15,8)
-	QUAD $0x0000000cff44c742; BYTE $0x00       // movl         $0, $12(%rdi,%r15,8)
-	LONG $0x01c78349                           // addq         $1, %r15
-	LONG $0xfdc28348                           // addq         $-3, %rdx
-	LONG $0x03c38349                           // addq         $3, %r11
-	LONG $0x03c68348                           // addq         $3, %rsi
-	LONG $0x0cb60f42; BYTE $0xff               // movzbl       (%rdi,%r15,8), %ecx
-	LONG $0xfffce6e9; BYTE $0xff               // jmp          LBB0_1, $-794(%rip)
+LBB0_16:
+	WORD $0xe983; BYTE $0x01     // subl         $1, %ecx
+	LONG $0x01ea8348             // subq         $1, %rdx
+	LONG $0x01c18349             // addq         $1, %r9
+	LONG $0x01c68348             // addq         $1, %rsi
+	LONG $0x044a8941             // movl         %ecx, $4(%r10)
+	LONG $0xfffc20e9; BYTE $0xff // jmp          LBB0_13, $-992(%rip)
+	QUAD $0x0000000000841f0f     // .p2align 4, 0x90
+
+LBB0_27:
+	WORD $0xe983; BYTE $0x01     // subl         $1, %ecx
+	LONG $0x05ea8348             // subq         $5, %rdx
+	LONG $0x05c18349             // addq         $5, %r9
+	LONG $0x05c68348             // addq         $5, %rsi
+	LONG $0x044a8941             // movl         %ecx, $4(%r10)
+	LONG $0xfffc00e9; BYTE $0xff // jmp          LBB0_13, $-1024(%rip)
+	QUAD $0x0000000000841f0f     // .p2align 4, 0x90
+
+LBB0_29:
+	WORD $0xe983; BYTE $0x01     // subl         $1, %ecx
+	WORD $0x294c; BYTE $0xda     // subq         %r11, %rdx
+	WORD $0x014d; BYTE $0xd9     // addq         %r11, %r9
+	WORD $0x014c; BYTE $0xde     // addq         %r11, %rsi
+	LONG $0x044a8941             // movl         %ecx, $4(%r10)
+	LONG $0xfffbe3e9; BYTE $0xff // jmp          LBB0_13, $-1053(%rip)
+	WORD $0x1f0f; BYTE $0x00     // .p2align 3, 0x90
+
+LBB0_20:
+	WORD $0xe983; BYTE $0x01     // subl         $1, %ecx
+	LONG $0x06ea8348             // subq         $6, %rdx
+	LONG $0x06c18349             // addq         $6, %r9
+	LONG $0x06c68348             // addq         $6, %rsi
+	LONG $0x044a8941             // movl         %ecx, $4(%r10)
+	LONG $0xfffbc8e9; BYTE $0xff // jmp          LBB0_13, $-1080(%rip)
 
-LBB0_51:
-	LONG $0xffc78349 // addq         $-1, %r15
+LBB0_49:
+	LONG $0xf9c1c749; WORD $0xffff; BYTE $0xff // movq         $-7, %r9
+	LONG $0xfffd28e9; BYTE $0xff               // jmp          LBB0_1, $-728(%rip)
 
 LBB0_52:
-	LONG $0xffc28348             // addq         $-1, %rdx
-	LONG $0x01c38349             // addq         $1, %r11
-	LONG $0x000001b9; BYTE $0x00 // movl         $1, %ecx
-	LONG $0xfffd27e9; BYTE $0xff // jmp          LBB0_8, $-729(%rip)
+	LONG $0xfbc1c749; WORD $0xffff; BYTE $0xff // movq         $-5, %r9
+	LONG $0xfffd1ce9; BYTE $0xff               // jmp          LBB0_1, $-740(%rip)
 
-LBB0_53:
-	LONG $0xffc78349 // addq         $-1, %r15
-
-LBB0_54:
-	LONG $0xfac28348             // addq         $-6, %rdx
-	LONG $0x06c38349             // addq         $6, %r11
-	LONG $0x000006b9; BYTE $0x00 // movl         $6, %ecx
-	LONG $0xfffd11e9; BYTE $0xff // jmp          LBB0_8, $-751(%rip)
-
-LBB0_55:
-	LONG $0xfec0c748; WORD $0xffff; BYTE $0xff // movq         $-2, %rax
-	LONG $0x000003e9; BYTE $0x00               // jmp          LBB0_57, $3(%rip)
-
-LBB0_56:
-	WORD $0x894c; BYTE $0xd8 // movq         %r11, %rax
-
-LBB0_57:
-	BYTE $0x5b   // popq         %rbx
-	WORD $0x5c41 // popq         %r12
-	WORD $0x5e41 // popq         %r14
-	WORD $0x5f41 // popq         %r15
-	BYTE $0x5d   // popq         %rbp
-	BYTE $0xc3   // retq
-
-LBB0_18:
-	LONG $0xfdc0c748; WORD $0xffff; BYTE $0xff // movq         $-3, %rax
-	LONG $0xffffebe9; BYTE $0xff               // jmp          LBB0_57, $-21(%rip)
-	WORD $0x9090                               // .p2align 2, 0x90
-
-	// .set L0_0_set_16, LBB0_16-LJTI0_0
-	// .set L0_0_set_57, LBB0_57-LJTI0_0
-	// .set L0_0_set_3, LBB0_3-LJTI0_0
-	// .set L0_0_set_19, LBB0_19-LJTI0_0
-	// .set L0_0_set_22, LBB0_22-LJTI0_0
-	// .set L0_0_set_28, LBB0_28-LJTI0_0
-	// .set L0_0_set_10, LBB0_10-LJTI0_0
-LJTI0_0:
-	LONG $0xfffffd54 // .long L0_0_set_16
-	LONG $0xffffffe9 // .long L0_0_set_57
-	LONG $0xffffffe9 // .long L0_0_set_57
-	LONG $0xfffffcb3 // .long L0_0_set_3
-	LONG $0xfffffcb3 // .long L0_0_set_3
-	LONG $0xfffffcb3 // .long L0_0_set_3
-	LONG $0xffffffe9 // .long L0_0_set_57
-	LONG $0xfffffcb3 // .long L0_0_set_3
-	LONG $0xffffffe9 // .long L0_0_set_57
-	LONG $0xfffffcb3 // .long L0_0_set_3
-	LONG $0xffffffe9 // .long L0_0_set_57
-	LONG $0xfffffcb3 // .long L0_0_set_3
-	LONG $0xfffffd90 // .long L0_0_set_19
-	LONG $0xfffffdb7 // .long L0_0_set_22
-	LONG $0xfffffe09 // .long L0_0_set_28
-	LONG $0xfffffd01 // .long L0_0_set_10
-	LONG $0xfffffd01 // .long L0_0_set_10
-
-	// .p2align 4, 0x00
+LBB0_36:
+	LONG $0xfac1c749; WORD $0xffff; BYTE $0xff // movq         $-6, %r9
+	LONG $0xfffd10e9; BYTE $0xff               // jmp          LBB0_1, $-752(%rip)
+	QUAD $0x0000841f0f2e6666; LONG $0x90000000 // .p2align 5, 0x90
+
+	// .p2align 5, 0x00
 _SkipSizeFixed:
-	QUAD $0x0002000801010000; WORD $0x0004; BYTE $0x08 // .ascii 11, '\x00\x00\x01\x01\x08\x00\x02\x00\x04\x00\x08'
+	QUAD $0x0002000801010000; QUAD $0x0000000000080004 // .ascii 16, '\x00\x00\x01\x01\x08\x00\x02\x00\x04\x00\x08\x00\x00\x00\x00\x00'
 	QUAD $0x0000000000000000; QUAD $0x0000000000000000 // .space 16, '\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00'
 	QUAD $0x0000000000000000; QUAD $0x0000000000000000 // .space 16, '\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00'
 	QUAD $0x0000000000000000; QUAD $0x0000000000000000 // .space 16, '\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00'
@@ -328,9 +404,8 @@ _SkipSizeFixed:
 	QUAD $0x0000000000000000; QUAD $0x0000000000000000 // .space 16, '\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00'
 	QUAD $0x0000000000000000; QUAD $0x0000000000000000 // .space 16, '\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00'
 	QUAD $0x0000000000000000; QUAD $0x0000000000000000 // .space 16, '\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00'
-	LONG $0x00000000; BYTE $0x00                       // .space 5, '\x00\x00\x00\x00\x00'
 
-	// .p2align 4, 0x00
+	// .p2align 5, 0x00
 _WireTags:
 	QUAD $0x0001000101010000; QUAD $0x0101010101010001 // .ascii 16, '\x00\x00\x01\x01\x01\x00\x01\x00\x01\x00\x01\x01\x01\x01\x01\x01'
 	QUAD $0x0000000000000000; QUAD $0x0000000000000000 // .space 16, '\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00'
@@ -354,7 +429,7 @@ TEXT ·__do_skip(SB), NOSPLIT | NOFRAME, $0 - 0
 
 _entry:
 	MOVQ (TLS), R14
-	LEAQ -40(SP), R12
+	LEAQ -48(SP), R12
 	CMPQ R12, 16(R14)
 	JBE  _stack_grow
 
@@ -13,7 +13,7 @@ var (
 )
 
 const (
-    _stack__do_skip = 40
+    _stack__do_skip = 48
 )
 
 var (
@@ -24,11 +24,20 @@ type OpCode uint8
 
 const (
     OP_int OpCode = iota
+    OP_uint
+    OP_float
     OP_str
     OP_str_nocopy
     OP_bin
     OP_bin_nocopy
+    OP_array
+    OP_codec
     OP_enum
+    OP_varint
+    OP_varint_u
+    OP_bool
+    OP_int_le
+    OP_float_le
     OP_size
     OP_type
     OP_seek
@@ -36,6 +45,8 @@ const (
     OP_ctr_load
     OP_ctr_decr
     OP_ctr_is_zero
+    OP_list_head
+    OP_map_head
     OP_map_alloc
     OP_map_close
     OP_map_set_i8
@@ -45,6 +56,8 @@ const (
     OP_map_set_str
     OP_map_set_enum
     OP_map_set_pointer
+    OP_map_key
+    OP_map_set
     OP_list_alloc
     OP_struct_skip
     OP_struct_ignore
@@ -55,8 +68,20 @@ const (
     OP_struct_mark_tag
     OP_struct_read_type
     OP_struct_check_type
+    OP_struct_check_bool
+    OP_field_clear
+    OP_field_begin
+    OP_field_switch
+    OP_field_bool
+    OP_unknown_clear
+    OP_unknown_skip
+    OP_unknown_cskip
+    OP_union_reset
+    OP_union_mark
     OP_make_state
     OP_drop_state
+    OP_make_ptr_state
+    OP_drop_ptr_state
     OP_construct
     OP_initialize
     OP_defer
@@ -66,11 +91,20 @@ const (
 
 var _OpNames = [256]string {
     OP_int               : "int",
+    OP_uint              : "uint",
+    OP_float             : "float",
     OP_str               : "str",
     OP_str_nocopy        : "str_nocopy",
     OP_bin               : "bin",
     OP_bin_nocopy        : "bin_nocopy",
+    OP_array             : "array",
+    OP_codec             : "codec",
     OP_enum              : "enum",
+    OP_varint            : "varint",
+    OP_varint_u          : "varint_u",
+    OP_bool              : "bool",
+    OP_int_le            : "int_le",
+    OP_float_le          : "float_le",
     OP_size              : "size",
     OP_type              : "type",
     OP_seek              : "seek",
@@ -78,6 +112,8 @@ var _OpNames = [256]string {
     OP_ctr_load          : "ctr_load",
     OP_ctr_decr          : "ctr_decr",
     OP_ctr_is_zero       : "ctr_is_zero",
+    OP_list_head         : "list_head",
+    OP_map_head          : "map_head",
     OP_map_alloc         : "map_alloc",
     OP_map_close         : "map_close",
     OP_map_set_i8        : "map_set_i8",
@@ -87,6 +123,8 @@ var _OpNames = [256]string {
     OP_map_set_str       : "map_set_str",
     OP_map_set_enum      : "map_set_enum",
     OP_map_set_pointer   : "map_set_pointer",
+    OP_map_key           : "map_key",
+    OP_map_set           : "map_set",
     OP_list_alloc        : "list_alloc",
     OP_struct_skip       : "struct_skip",
     OP_struct_ignore     : "struct_ignore",
@@ -97,8 +135,20 @@ var _OpNames = [256]string {
     OP_struct_mark_tag   : "struct_mark_tag",
     OP_struct_read_type  : "struct_read_type",
     OP_struct_check_type : "struct_check_type",
+    OP_struct_check_bool : "struct_check_bool",
+    OP_field_clear       : "field_clear",
+    OP_field_begin       : "field_begin",
+    OP_field_switch      : "field_switch",
+    OP_field_bool        : "field_bool",
+    OP_unknown_clear     : "unknown_clear",
+    OP_unknown_skip      : "unknown_skip",
+    OP_unknown_cskip     : "unknown_cskip",
+    OP_union_reset       : "union_reset",
+    OP_union_mark        : "union_mark",
     OP_make_state        : "make_state",
     OP_drop_state        : "drop_state",
+    OP_make_ptr_state    : "make_ptr_state",
+    OP_drop_ptr_state    : "drop_ptr_state",
     OP_construct         : "construct",
     OP_initialize        : "initialize",
     OP_defer             : "defer",
@@ -111,9 +161,16 @@ var _OpBranches = [256]bool {
     OP_struct_switch     : true,
     OP_struct_is_stop    : true,
     OP_struct_check_type : true,
+    OP_struct_check_bool : true,
+    OP_field_switch      : true,
     OP_goto              : true,
 }
 
+var _OpSwitches = [256]bool {
+    OP_struct_switch : true,
+    OP_field_switch  : true,
+}
+
 func (self OpCode) String() string {
     if _OpNames[self] != "" {
         return _OpNames[self]
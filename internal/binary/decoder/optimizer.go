@@ -110,7 +110,7 @@ func (self *GraphBuilder) block(p Program, i int, bb *BasicBlock) {
     }
 
     /* also include the branch instruction */
-    if bb.End++; p[i].Op != OP_struct_switch {
+    if bb.End++; !_OpSwitches[p[i].Op] {
         bb.Link = append(bb.Link, self.branch(p, p[i].To))
     } else {
         for _, v := range p[i].IntSeq() {
@@ -227,7 +227,7 @@ func Optimize(p Program) Program {
     for _, bb := range ctx.buf {
         if end := bb.End; bb.Src != end {
             if ins := &bb.P[end - 1]; _OpBranches[ins.Op] {
-                if ins.Op != OP_struct_switch {
+                if !_OpSwitches[ins.Op] {
                     ins.To = ctx.refs[ins.To]
                 } else {
                     for i, v := range ins.IntSeq() {
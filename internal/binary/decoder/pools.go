@@ -20,6 +20,7 @@ import (
     `reflect`
     `sync`
 
+    `github.com/cloudwego/frugal/internal/binary/defs`
     `github.com/cloudwego/frugal/internal/opts`
     `github.com/cloudwego/frugal/internal/rt`
 )
@@ -31,6 +32,7 @@ var (
     graphBuilderPool   sync.Pool
     runtimeStatePool   sync.Pool
     optimizerStatePool sync.Pool
+    framePool          sync.Pool
 )
 
 func newProgram() Program {
@@ -49,7 +51,7 @@ func newCompiler() *Compiler {
     if v := compilerPool.Get(); v == nil {
         return allocCompiler()
     } else {
-        return resetCompiler(v.(*Compiler))
+        return resetCompiler(v.(*Compiler)).Protocol(defs.Binary)
     }
 }
 
@@ -66,6 +68,7 @@ func allocCompiler() *Compiler {
 }
 
 func resetCompiler(p *Compiler) *Compiler {
+    p.s = nil
     p.o = opts.GetDefaultOptions()
     rt.MapClear(p.t)
     rt.MapClear(p.d)
@@ -118,9 +121,23 @@ func newRuntimeState() *RuntimeState {
 }
 
 func freeRuntimeState(p *RuntimeState) {
+    p.Es = 0
     runtimeStatePool.Put(p)
 }
 
+func newFrame() *_Frame {
+    if v := framePool.Get(); v != nil {
+        return v.(*_Frame)
+    } else {
+        return new(_Frame)
+    }
+}
+
+func freeFrame(p *_Frame) {
+    *p = _Frame{}
+    framePool.Put(p)
+}
+
 func newOptimizerState() *_OptimizerState {
     if v := optimizerStatePool.Get(); v == nil {
         return allocOptimizerState()
@@ -0,0 +1,270 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `fmt`
+    `sort`
+    `strings`
+    `sync`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+// Selection is a tree of the field paths to decode, a nil Selection selects everything.
+type Selection struct {
+    all    bool
+    path   string
+    elem   *Selection
+    fields map[string]*Selection
+}
+
+// ParseSelection parses field paths like "user.id" and "items[*].price" into a Selection,
+// where "[*]" selects the elements of a list or set, or the values of a map.
+func ParseSelection(paths []string) (*Selection, error) {
+    ret := new(Selection)
+
+    /* must select something */
+    if len(paths) == 0 {
+        return nil, fmt.Errorf("frugal: empty field selection")
+    }
+
+    /* add every path */
+    for _, path := range paths {
+        if err := ret.add(path); err != nil {
+            return nil, err
+        }
+    }
+
+    /* all done */
+    return ret, nil
+}
+
+// CanonicalSelection returns the sorted and deduplicated copy of paths.
+func CanonicalSelection(paths []string) []string {
+    ret := append([]string(nil), paths...)
+    sort.Strings(ret)
+
+    /* remove the duplicated paths */
+    for i := len(ret) - 1; i > 0; i-- {
+        if ret[i] == ret[i - 1] {
+            ret = append(ret[:i], ret[i + 1:]...)
+        }
+    }
+
+    /* all done */
+    return ret
+}
+
+func (self *Selection) add(path string) error {
+    node := self
+    segs := strings.Split(path, ".")
+
+    /* walk through every segment */
+    for _, seg := range segs {
+        name := strings.TrimRight(seg, "[*]")
+        rest := seg[len(name):]
+
+        /* validate the segment */
+        if name == "" || strings.ContainsAny(name, "[*]") || strings.Count(rest, "[*]") * 3 != len(rest) {
+            return fmt.Errorf("frugal: invalid field path %q", path)
+        }
+
+        /* select the struct field */
+        if node = node.addField(name); node == nil {
+            return fmt.Errorf("frugal: conflicting field path %q", path)
+        }
+
+        /* select the container elements */
+        for i := 0; i < len(rest); i += 3 {
+            if node = node.addElem(); node == nil {
+                return fmt.Errorf("frugal: conflicting field path %q", path)
+            }
+        }
+    }
+
+    /* everything below the path is selected */
+    node.all = true
+    return nil
+}
+
+func (self *Selection) addField(name string) *Selection {
+    if self.elem != nil {
+        return nil
+    }
+
+    /* create the field map on demand */
+    if self.fields == nil {
+        self.fields = make(map[string]*Selection)
+    }
+
+    /* add the field if not exists */
+    if self.fields[name] == nil {
+        self.fields[name] = &Selection { path: self.join(name) }
+    }
+
+    /* all done */
+    return self.fields[name]
+}
+
+func (self *Selection) addElem() *Selection {
+    if self.fields != nil {
+        return nil
+    }
+
+    /* add the element if not exists */
+    if self.elem == nil {
+        self.elem = &Selection { path: self.path + "[*]" }
+    }
+
+    /* all done */
+    return self.elem
+}
+
+func (self *Selection) field(name string) *Selection {
+    if self == nil {
+        return nil
+    } else {
+        return self.fields[name].partial()
+    }
+}
+
+func (self *Selection) element() *Selection {
+    if self == nil {
+        return nil
+    } else {
+        return self.elem.partial()
+    }
+}
+
+func (self *Selection) join(name string) string {
+    if self.path == "" {
+        return name
+    } else {
+        return self.path + "." + name
+    }
+}
+
+func (self *Selection) partial() *Selection {
+    if self == nil || self.all {
+        return nil
+    } else {
+        return self
+    }
+}
+
+func (self *Selection) filter(vt *defs.Type, fvs []defs.Field) []defs.Field {
+    ret := make([]defs.Field, 0, len(self.fields))
+    names := make(map[string]bool, len(self.fields))
+
+    /* keep only the selected fields */
+    for _, fv := range fvs {
+        if _, ok := self.fields[fv.Name]; ok {
+            ret = append(ret, fv)
+            names[fv.Name] = true
+        }
+    }
+
+    /* every selected field must exist */
+    for name := range self.fields {
+        if !names[name] {
+            panic(fmt.Errorf("frugal: field path %s does not exist in %s", self.join(name), vt.S))
+        }
+    }
+
+    /* all done */
+    return ret
+}
+
+func (self *Selection) check(vt *defs.Type) {
+    switch vt.T {
+        case defs.T_struct : if self.elem == nil { return }
+        case defs.T_map    : if self.fields == nil { return }
+        case defs.T_set    : if self.fields == nil { return }
+        case defs.T_list   : if self.fields == nil { return }
+    }
+
+    /* other types cannot be partially selected */
+    panic(fmt.Errorf("frugal: field path %s cannot select into %s", self.path, vt))
+}
+
+type selectionKey struct {
+    vt *rt.GoType
+    pt defs.Protocol
+    fs string
+}
+
+var (
+    selectionCache sync.Map
+)
+
+// ResolveSelection resolves the decoder of vt which only decodes the fields selected by
+// the canonical paths, other fields are skipped over.
+func ResolveSelection(vt *rt.GoType, pt defs.Protocol, paths []string) (Decoder, error) {
+    key := selectionKey {
+        vt: vt,
+        pt: pt,
+        fs: strings.Join(paths, ","),
+    }
+
+    /* fast-path: selection is cached */
+    if val, ok := selectionCache.Load(key); ok {
+        return val.(Decoder), nil
+    }
+
+    /* parse the selection */
+    sel, err := ParseSelection(paths)
+    if err != nil {
+        return nil, err
+    }
+
+    /* compile the selected fields */
+    pp, err := CreateCompiler().Protocol(pt).Select(sel).CompileAndFree(vt.Pack())
+    if err != nil {
+        return nil, err
+    }
+
+    /* link the program, concurrent compilations may race, keep the first one */
+    val, _ := selectionCache.LoadOrStore(key, Link(pp))
+    return val.(Decoder), nil
+}
+
+func evictSelection(vt *rt.GoType) {
+    selectionCache.Range(func(key interface{}, _ interface{}) bool {
+        if key.(selectionKey).vt == vt {
+            selectionCache.Delete(key)
+        }
+        return true
+    })
+}
+
+func decodeSelection(fn decodeFunc, pt defs.Protocol, paths []string) decodeFunc {
+    if len(paths) == 0 {
+        return fn
+    }
+
+    /* resolve the selected decoder of the type */
+    return func(vt *rt.GoType, buf unsafe.Pointer, nb int, i int, p unsafe.Pointer, rs *RuntimeState, st int) (int, error) {
+        if dec, err := ResolveSelection(vt, pt, paths); err != nil {
+            return 0, err
+        } else {
+            return dec(buf, nb, i, p, rs, st)
+        }
+    }
+}
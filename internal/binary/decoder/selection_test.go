@@ -0,0 +1,117 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `testing`
+
+    `github.com/cloudwego/frugal/internal/binary/encoder`
+    `github.com/cloudwego/frugal/internal/opts`
+    `github.com/cloudwego/frugal/iov`
+    `github.com/stretchr/testify/require`
+)
+
+type SelectionTestUser struct {
+    ID   int64  `frugal:"1,default,i64" thrift:"id,1"`
+    Name string `frugal:"2,default,string" thrift:"name,2"`
+}
+
+type SelectionTestItem struct {
+    Price float64  `frugal:"1,default,double"`
+    Tags  []string `frugal:"2,default,list<string>"`
+}
+
+type SelectionTestStruct struct {
+    User  *SelectionTestUser            `frugal:"1,default,SelectionTestUser" thrift:"user,1"`
+    Items []*SelectionTestItem          `frugal:"2,default,list<SelectionTestItem>" thrift:"items,2"`
+    Index map[string]*SelectionTestItem `frugal:"3,default,map<string:SelectionTestItem>" thrift:"index,3"`
+    Note  string                        `frugal:"4,required,string" thrift:"note,4"`
+    Flag  bool                          `frugal:"5,default,bool" thrift:"flag,5"`
+}
+
+var selectionTestValue = SelectionTestStruct {
+    User  : &SelectionTestUser { ID: 12, Name: "foo" },
+    Items : []*SelectionTestItem {{ Price: 1.5, Tags: []string { "a" } }, { Price: 2.5, Tags: []string {} }},
+    Index : map[string]*SelectionTestItem { "x": { Price: 3.5, Tags: []string { "b", "c" } } },
+    Note  : "bar",
+    Flag  : true,
+}
+
+func TestSelection_Decode(t *testing.T) {
+    for _, v := range []struct { name string; enc func(interface{}) ([]byte, error); dec func([]byte, interface{}, opts.Options) (int, error) } {
+        { "binary"  , selectionTestEncode(encoder.EncodedSize, encoder.EncodeObject)         , DecodeObjectWithOptions },
+        { "compact" , selectionTestEncode(encoder.EncodedSizeCompact, encoder.EncodeCompact) , DecodeCompactWithOptions },
+    } {
+        t.Run(v.name, func(t *testing.T) {
+            buf, err := v.enc(selectionTestValue)
+            require.NoError(t, err)
+            o := opts.GetDefaultOptions()
+            o.Fields = CanonicalSelection([]string { "user.id", "items[*].Price", "index[*].Tags", "user.id" })
+            var ret SelectionTestStruct
+            nb, err := v.dec(buf, &ret, o)
+            require.NoError(t, err)
+            require.Equal(t, len(buf), nb)
+            require.Equal(t, SelectionTestStruct {
+                User  : &SelectionTestUser { ID: 12 },
+                Items : []*SelectionTestItem {{ Price: 1.5 }, { Price: 2.5 }},
+                Index : map[string]*SelectionTestItem { "x": { Tags: []string { "b", "c" } } },
+            }, ret)
+            o.Fields = CanonicalSelection([]string { "flag", "items", "user.name" })
+            ret = SelectionTestStruct{}
+            _, err = v.dec(buf, &ret, o)
+            require.NoError(t, err)
+            require.Equal(t, SelectionTestStruct {
+                User  : &SelectionTestUser { Name: "foo" },
+                Items : selectionTestValue.Items,
+                Flag  : true,
+            }, ret)
+        })
+    }
+}
+
+func TestSelection_Errors(t *testing.T) {
+    for _, v := range [][]string {
+        {},
+        { "" },
+        { "user..id" },
+        { "items[*" },
+        { "items[x]" },
+        { "[*]" },
+        { "user.id", "user[*]" },
+    } {
+        _, err := ParseSelection(v)
+        require.Error(t, err, "%q", v)
+    }
+    buf, err := selectionTestEncode(encoder.EncodedSize, encoder.EncodeObject)(selectionTestValue)
+    require.NoError(t, err)
+    for _, v := range []string { "missing", "user.missing", "note.x", "note[*]", "user[*]", "items.Price", "flag.x" } {
+        o := opts.GetDefaultOptions()
+        o.Fields = []string { v }
+        _, err = DecodeObjectWithOptions(buf, new(SelectionTestStruct), o)
+        require.Error(t, err, v)
+        _, err = DecodeCompactWithOptions(buf, new(SelectionTestStruct), o)
+        require.Error(t, err, v)
+    }
+}
+
+func selectionTestEncode(size func(interface{}) int, enc func([]byte, iov.BufferWriter, interface{}) (int, error)) func(interface{}) ([]byte, error) {
+    return func(v interface{}) ([]byte, error) {
+        buf := make([]byte, size(v))
+        nb, err := enc(buf, nil, v)
+        return buf[:nb], err
+    }
+}
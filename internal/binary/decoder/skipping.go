@@ -17,15 +17,47 @@
 package decoder
 
 import (
+    `unsafe`
+
     `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/opts`
+    `github.com/cloudwego/frugal/internal/rt`
 )
 
 const (
-    ETAG   = -1
-    EEOF   = -2
-    ESTACK = -3
+    ETAG    = -1
+    EEOF    = -2
+    ESTACK  = -3
+    EVARINT = -4
+    ECOUNT  = -5
+    ESIZE   = -6
+    EDEPTH  = -7
 )
 
 var (
     C_skip = hir.RegisterCCall(archSkippingFn(), emu_ccall_skip)
 )
+
+// Skip validates the Binary Protocol encoded value of type t at the beginning of buf
+// with the same rules (and limits) of the skipper used by the decoder, and returns the
+// size of the value.
+func Skip(buf []byte, t defs.Tag, o opts.Options) (int, error) {
+    st := newRuntimeState()
+    sl := (*rt.GoSlice)(unsafe.Pointer(&buf))
+
+    /* call the skipper with the limits */
+    st.setLimits(o)
+    ret := do_skip((*_skipbuf_t)(&st.Sk), sl.Ptr, sl.Len, t, &st.Lm)
+
+    /* convert the error if any */
+    if ret < 0 {
+        err := error_skip(ret, st)
+        freeRuntimeState(st)
+        return 0, err
+    }
+
+    /* return the state into pool */
+    freeRuntimeState(st)
+    return ret, nil
+}
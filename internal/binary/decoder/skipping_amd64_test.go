@@ -0,0 +1,112 @@
+// +build !frugal_nojit
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `reflect`
+    `testing`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/opts`
+    `github.com/cloudwego/frugal/internal/rt`
+    `github.com/stretchr/testify/require`
+)
+
+type SkippingTestStruct struct {
+    A int8 `frugal:"1,default,i8"`
+}
+
+type SkippingTestUnknown struct {
+    A int8   `frugal:"1,default,i8"`
+    U []byte `frugal:"_unknown"`
+}
+
+func skippingTestLink(t *testing.T, vt reflect.Type) (Decoder, Decoder) {
+    p, err := CreateCompiler().Compile(vt)
+    require.NoError(t, err)
+    return LinkerAMD64{}.Link(Translate(p)), link_emu(Translate(p))
+}
+
+func skippingTestCompare(t *testing.T, jit Decoder, emu Decoder, vt reflect.Type, buf []byte, o opts.Options) error {
+    v1 := reflect.New(vt)
+    v2 := reflect.New(vt)
+    p1, e1 := jit.Decode(rt.UnpackType(vt), defs.Binary, buf, unsafe.Pointer(v1.Pointer()), o)
+    p2, e2 := emu.Decode(rt.UnpackType(vt), defs.Binary, buf, unsafe.Pointer(v2.Pointer()), o)
+
+    /* the native skipper must give exactly the same result as the emulated one */
+    require.Equal(t, e1, e2, "error mismatch with %+v", o)
+    require.Equal(t, p1, p2, "position mismatch with %+v", o)
+    require.Equal(t, v1.Interface(), v2.Interface(), "value mismatch with %+v", o)
+    return e1
+}
+
+func TestSkipping_NativeLimits(t *testing.T) {
+    for _, buf := range [][]byte {
+        { 0x0b, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03, 0x61, 0x62, 0x63, 0x00 },
+        { 0x0f, 0x00, 0x02, 0x08, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03, 0x00 },
+        { 0x0e, 0x00, 0x02, 0x0b, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x00, 0x00, 0x03, 0x61, 0x62, 0x63, 0x00 },
+        { 0x0d, 0x00, 0x02, 0x03, 0x03, 0x00, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x00 },
+        { 0x0d, 0x00, 0x02, 0x0b, 0x03, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x61, 0x01, 0x00, 0x00, 0x00, 0x02, 0x61, 0x62, 0x02, 0x00 },
+        { 0x0c, 0x00, 0x02, 0x0c, 0x00, 0x01, 0x0f, 0x00, 0x01, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00 },
+        { 0x0f, 0x00, 0x02, 0x0f, 0x00, 0x00, 0x00, 0x02, 0x0d, 0x00, 0x00, 0x00, 0x01, 0x03, 0x0b, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00 },
+    } {
+        vt := reflect.TypeOf(SkippingTestStruct{})
+        jit, emu := skippingTestLink(t, vt)
+
+        /* try every combination around the sizes in the input */
+        for nc := 0; nc <= 4; nc++ {
+            for ns := 0; ns <= 4; ns++ {
+                for nd := 0; nd <= 5; nd++ {
+                    o := opts.GetDefaultOptions()
+                    o.MaxContainerLen = nc
+                    o.MaxStringLen = ns
+                    o.MaxDepth = nd
+
+                    /* every truncated input must also give the same error */
+                    for i := 0; i <= len(buf); i++ {
+                        skippingTestCompare(t, jit, emu, vt, buf[:i], o)
+                    }
+                }
+            }
+        }
+    }
+}
+
+func TestSkipping_NativeUnknownAlloc(t *testing.T) {
+    buf := []byte {
+        0x0b, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03, 0x61, 0x62, 0x63, 0x0f, 0x00, 0x03, 0x03, 0x00, 0x00,
+        0x00, 0x02, 0x01, 0x02, 0x00,
+    }
+    vt := reflect.TypeOf(SkippingTestUnknown{})
+    jit, emu := skippingTestLink(t, vt)
+
+    /* the unknown fields are 20 bytes in total, including the field headers */
+    for na := 1; na <= 22; na++ {
+        o := opts.GetDefaultOptions()
+        o.MaxTotalAlloc = na
+        err := skippingTestCompare(t, jit, emu, vt, buf, o)
+        if na < 20 {
+            require.Error(t, err)
+            require.Equal(t, LimitError { LimitTotalAlloc, na }, err.(*DecodeError).Err)
+        } else {
+            require.NoError(t, err)
+        }
+    }
+}
@@ -38,7 +38,8 @@ var _SkipSizeFixed = [256]int {
 }
 
 const (
-    _T_map_pair defs.Tag = 0xff
+    _T_map_pair  defs.Tag = 0xff
+    _T_list_elem defs.Tag = 0xfe
 )
 
 func u32be(s unsafe.Pointer) int {
@@ -70,7 +71,7 @@ func mvbuf(s *unsafe.Pointer, n *int, r *int, nb int) {
     *s = unsafe.Pointer(uintptr(*s) + uintptr(nb))
 }
 
-func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag) (rv int) {
+func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag, lm *Limits) (rv int) {
     sp := 0
     st[0].T = t
 
@@ -100,6 +101,8 @@ func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag) (rv int) {
             case defs.T_string: {
                 if n < 4 {
                     return EEOF
+                } else if uint64(u32be(s)) > lm.Ns {
+                    return ESIZE
                 } else if nb := u32be(s) + 4; n < nb {
                     return EEOF
                 } else {
@@ -113,6 +116,11 @@ func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag) (rv int) {
                 var nb int
                 var vt defs.Tag
 
+                /* check for the nesting depth */
+                if uint64(sp) >= lm.Nd {
+                    return EDEPTH
+                }
+
                 /* must have at least 1 byte */
                 if n < 1 {
                     return EEOF
@@ -156,6 +164,11 @@ func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag) (rv int) {
                 var kt defs.Tag
                 var vt defs.Tag
 
+                /* check for the nesting depth */
+                if uint64(sp) >= lm.Nd {
+                    return EDEPTH
+                }
+
                 /* must have at least 6 bytes */
                 if n < 6 {
                     return EEOF
@@ -171,6 +184,11 @@ func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag) (rv int) {
                     return ETAG
                 }
 
+                /* check for the container length */
+                if uint64(np) > lm.Nc {
+                    return ECOUNT
+                }
+
                 /* empty map */
                 if np == 0 {
                     stpop(st, &sp)
@@ -193,18 +211,20 @@ func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag) (rv int) {
                 st[sp].K = kt
                 st[sp].V = vt
                 st[sp].T = _T_map_pair
-                st[sp].N = uint32(np) * 2 - 1
+                st[sp].N = uint32(np) * 2
                 mvbuf(&s, &n, &rv, 6)
             }
 
-            /* map pairs */
+            /* map pairs, the elements are pushed onto stack, so they are one level deeper than the map */
             case _T_map_pair: {
-                if vt := st[sp].V; stpop(st, &sp) || st[sp].N & 1 != 0 {
-                    if !stadd(st, &sp, vt) {
+                if stpop(st, &sp) {
+                    continue
+                } else if st[sp].N & 1 != 0 {
+                    if !stadd(st, &sp, st[sp].K) {
                         return ESTACK
                     }
                 } else {
-                    if !stadd(st, &sp, st[sp].K) {
+                    if !stadd(st, &sp, st[sp].V) {
                         return ESTACK
                     }
                 }
@@ -216,6 +236,11 @@ func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag) (rv int) {
                 var nv int
                 var et defs.Tag
 
+                /* check for the nesting depth */
+                if uint64(sp) >= lm.Nd {
+                    return EDEPTH
+                }
+
                 /* must have at least 5 bytes */
                 if n < 5 {
                     return EEOF
@@ -230,6 +255,11 @@ func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag) (rv int) {
                     return ETAG
                 }
 
+                /* check for the container length */
+                if uint64(nv) > lm.Nc {
+                    return ECOUNT
+                }
+
                 /* empty sequence */
                 if nv == 0 {
                     stpop(st, &sp)
@@ -249,10 +279,18 @@ func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag) (rv int) {
                 }
 
                 /* set to parse the elements */
-                st[sp].T = et
-                st[sp].N = uint32(nv) - 1
+                st[sp].V = et
+                st[sp].T = _T_list_elem
+                st[sp].N = uint32(nv)
                 mvbuf(&s, &n, &rv, 5)
             }
+
+            /* list elements, also one level deeper than the list */
+            case _T_list_elem: {
+                if !stpop(st, &sp) && !stadd(st, &sp, st[sp].V) {
+                    return ESTACK
+                }
+            }
         }
     }
 
@@ -261,9 +299,9 @@ func do_skip(st *_skipbuf_t, s unsafe.Pointer, n int, t defs.Tag) (rv int) {
 }
 
 func emu_ccall_skip(ctx hir.CallContext) {
-    if !ctx.Verify("**ii", "i") {
+    if !ctx.Verify("**ii*", "i") {
         panic("invalid skip call")
     } else {
-        ctx.Ru(0, uint64(do_skip((*_skipbuf_t)(ctx.Ap(0)), ctx.Ap(1), int(ctx.Au(2)), defs.Tag(ctx.Au(3)))))
+        ctx.Ru(0, uint64(do_skip((*_skipbuf_t)(ctx.Ap(0)), ctx.Ap(1), int(ctx.Au(2)), defs.Tag(ctx.Au(3)), (*Limits)(ctx.Ap(4)))))
     }
 }
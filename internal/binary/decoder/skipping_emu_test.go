@@ -21,13 +21,18 @@ import (
     `unsafe`
 
     `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/opts`
     `github.com/cloudwego/frugal/internal/rt`
 )
 
 func run_skipping_emu(t *testing.T, v []byte, exp int, tag defs.Tag) {
+    run_skipping_emu_limits(t, v, exp, tag, makeLimits(opts.Options{}))
+}
+
+func run_skipping_emu_limits(t *testing.T, v []byte, exp int, tag defs.Tag, lm Limits) {
     var sb _skipbuf_t
     mm := *(*rt.GoSlice)(unsafe.Pointer(&v))
-    rv := do_skip(&sb, mm.Ptr, mm.Len, tag)
+    rv := do_skip(&sb, mm.Ptr, mm.Len, tag, &lm)
     if rv != exp {
         if rv >= 0 && exp < 0 {
             t.Errorf("got %d while expecting error %d", rv, exp)
@@ -180,3 +185,35 @@ func TestSkippingEmu_SkipSetOrListButGotErrors(t *testing.T) {
     run_skipping_emu(t, []byte{9, 0, 0, 0, 1, 2}       , ETAG, defs.T_list)
     run_skipping_emu(t, []byte("\x0b\x00\x00\x00\x01") , EEOF, defs.T_list)
 }
+
+func TestSkippingEmu_Limits(t *testing.T) {
+    lm := makeLimits(opts.Options { MaxContainerLen: 2, MaxStringLen: 3, MaxDepth: 2 })
+    run_skipping_emu_limits(t, []byte{0, 0, 0, 3, 'a', 'b', 'c'}                     , 7, defs.T_string, lm)
+    run_skipping_emu_limits(t, []byte{0, 0, 0, 4, 'a', 'b', 'c', 'd'}                , ESIZE, defs.T_string, lm)
+    run_skipping_emu_limits(t, []byte{3, 0, 0, 0, 2, 1, 2}                           , 7, defs.T_list, lm)
+    run_skipping_emu_limits(t, []byte{3, 0, 0, 0, 3, 1, 2, 3}                        , ECOUNT, defs.T_list, lm)
+    run_skipping_emu_limits(t, []byte{3, 0xff, 0xff, 0xff, 0xff}                     , ECOUNT, defs.T_set, lm)
+    run_skipping_emu_limits(t, []byte{3, 3, 0, 0, 0, 3, 1, 2, 3, 4, 5, 6}            , ECOUNT, defs.T_map, lm)
+    run_skipping_emu_limits(t, []byte{12, 0, 1, 0, 0}                                , 5, defs.T_struct, lm)
+    run_skipping_emu_limits(t, []byte{12, 0, 1, 12, 0, 1, 0, 0, 0}                   , EDEPTH, defs.T_struct, lm)
+    run_skipping_emu_limits(t, []byte{13, 0, 1, 3, 12, 0, 0, 0, 1, 1, 12, 0, 1, 0, 0}, EDEPTH, defs.T_struct, lm)
+    run_skipping_emu_limits(t, []byte{15, 0, 1, 3, 0, 0, 0, 2, 1, 2, 0}             , 11, defs.T_struct, lm)
+    run_skipping_emu_limits(t, []byte{12, 0, 0, 0, 2, 0, 0}                          , 7, defs.T_list, lm)
+    run_skipping_emu_limits(t, []byte{15, 0, 0, 0, 1, 3, 0, 0, 0, 1, 1}              , 11, defs.T_list, lm)
+    run_skipping_emu_limits(t, []byte{15, 0, 0, 0, 1, 15, 0, 0, 0, 1, 3, 0, 0, 0, 1} , EDEPTH, defs.T_list, lm)
+    run_skipping_emu_limits(t, []byte{15, 0, 1, 12, 0, 0, 0, 1, 0, 0}               , EDEPTH, defs.T_struct, lm)
+}
+
+func TestSkipping_Skip(t *testing.T) {
+    if n, err := Skip([]byte{8, 0, 1, 0, 0, 0, 1, 0, 0xff}, defs.T_struct, opts.GetDefaultOptions()); err != nil || n != 8 {
+        t.Fatalf("got (%d, %v) while expecting (8, nil)", n, err)
+    }
+    if _, err := Skip([]byte{8, 0, 1, 0, 0}, defs.T_struct, opts.GetDefaultOptions()); err != EOFError(0) {
+        t.Fatalf("got %v while expecting EOF", err)
+    }
+    if _, err := Skip([]byte{11, 0, 1, 0, 0, 0, 4, 'a', 'b', 'c', 'd', 0}, defs.T_struct, opts.Options { MaxStringLen: 3 }); err == nil {
+        t.Fatal("expecting the string limit to be exceeded")
+    } else if le, ok := err.(LimitError); !ok || le.Limit != LimitStringLen || le.Max != 3 {
+        t.Fatalf("got unexpected error %v", err)
+    }
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `sync/atomic`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/rt`
+    `github.com/cloudwego/frugal/internal/snapshot`
+    `github.com/cloudwego/frugal/internal/utils`
+)
+
+// Snapshot compiles all the cached types again into machine code that can be saved, and
+// appends them to progs.
+func Snapshot(progs []snapshot.Program) ([]snapshot.Program, error) {
+    sl := snapshotLinker()
+    if sl == nil {
+        return nil, snapshot.ErrUnsupported
+    }
+
+    /* compile every type in both protocols */
+    for _, pt := range []defs.Protocol { defs.Binary, defs.Compact } {
+        for _, vt := range cacheOf(pt).Keys() {
+            pp, err := CreateCompiler().Protocol(pt).CompileAndFree(vt.Pack())
+            if err != nil {
+                return nil, err
+            }
+
+            /* generate the machine code */
+            sp := snapshot.Program {
+                Kind     : snapshot.Decoder,
+                Type     : vt,
+                Protocol : pt,
+            }
+
+            /* add to the program list */
+            sl.Generate(Translate(pp), &sp)
+            progs = append(progs, sp)
+        }
+    }
+
+    /* all done */
+    return progs, nil
+}
+
+// Restore loads the program from a snapshot into the cache, unless the type has been compiled.
+func Restore(sp *snapshot.Program) error {
+    sl := snapshotLinker()
+    if sl == nil {
+        return snapshot.ErrUnsupported
+    }
+
+    /* load the program if not cached */
+    _, err := cacheOf(sp.Protocol).Compute(sp.Type, func(_ *rt.GoType) (interface{}, error) {
+        atomic.AddUint64(&TypeCount, 1)
+        return sl.Load(sp), nil
+    })
+
+    /* all done */
+    return err
+}
+
+func cacheOf(pt defs.Protocol) *utils.ProgramCache {
+    if pt == defs.Compact {
+        return compactCache
+    } else {
+        return programCache
+    }
+}
@@ -17,9 +17,12 @@
 package decoder
 
 import (
+    `math`
+    `reflect`
     `unsafe`
 
     `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/opts`
     `github.com/cloudwego/frugal/internal/rt`
 )
 
@@ -28,12 +31,24 @@ const (
     MpOffset = int64(unsafe.Offsetof(StateItem{}.Mp))
     WpOffset = int64(unsafe.Offsetof(StateItem{}.Wp))
     FmOffset = int64(unsafe.Offsetof(StateItem{}.Fm))
+    UnOffset = int64(unsafe.Offsetof(StateItem{}.Un))
+    IxOffset = int64(unsafe.Offsetof(StateItem{}.Ix))
 )
 
 const (
     SkOffset = int64(unsafe.Offsetof(RuntimeState{}.Sk))
     PrOffset = int64(unsafe.Offsetof(RuntimeState{}.Pr))
     IvOffset = int64(unsafe.Offsetof(RuntimeState{}.Iv))
+    LmOffset = int64(unsafe.Offsetof(RuntimeState{}.Lm))
+    EsOffset = int64(unsafe.Offsetof(RuntimeState{}.Es))
+    EiOffset = int64(unsafe.Offsetof(RuntimeState{}.Ei))
+)
+
+const (
+    NcOffset = LmOffset + int64(unsafe.Offsetof(Limits{}.Nc))
+    NsOffset = LmOffset + int64(unsafe.Offsetof(Limits{}.Ns))
+    NdOffset = LmOffset + int64(unsafe.Offsetof(Limits{}.Nd))
+    NaOffset = LmOffset + int64(unsafe.Offsetof(Limits{}.Na))
 )
 
 const (
@@ -48,11 +63,37 @@ type SkipItem struct {
     N uint32
 }
 
+type Limits struct {
+    Nc uint64   // Maximum container length.
+    Ns uint64   // Maximum string or binary length.
+    Nd uint64   // Remaining nesting depth.
+    Na uint64   // Remaining allocation budget in bytes.
+}
+
+func makeLimits(o opts.Options) Limits {
+    return Limits {
+        Nc : limitOf(o.MaxContainerLen),
+        Ns : limitOf(o.MaxStringLen),
+        Nd : limitOf(o.MaxDepth),
+        Na : limitOf(o.MaxTotalAlloc),
+    }
+}
+
+func limitOf(v int) uint64 {
+    if v <= 0 {
+        return math.MaxUint64
+    } else {
+        return uint64(v)
+    }
+}
+
 type StateItem struct {
     Nb uint64
     Mp *rt.GoMap
     Wp unsafe.Pointer
     Fm *FieldBitmap
+    Un uint64
+    Ix uint64   // Offset of the current field header plus one for structs, or the length for containers.
 }
 
 type RuntimeState struct {
@@ -60,4 +101,22 @@ type RuntimeState struct {
     Sk [defs.StackSize]SkipItem     // Skip buffer, used for non-recursive skipping
     Pr unsafe.Pointer               // Pointer spill space, used for non-fast string or pointer map access.
     Iv uint64                       // Integer spill space, used for non-fast string map access.
+    Lm Limits                       // Remaining limits, shared with the native skipper.
+    Lo Limits                       // Configured limits, used for error reporting.
+    Es uint64                       // State offset of the first error plus one, zero if no error occurred.
+    Ei uint64                       // Input offset of the first error.
+}
+
+func (self *RuntimeState) setLimits(o opts.Options) {
+    self.Lo = makeLimits(o)
+    self.Lm = self.Lo
+}
+
+func spillOffset(vt *rt.GoType) int64 {
+    /* string keys use both `Pr` and `Iv` as the string header */
+    if rt.MapType(vt).Key.Kind() == reflect.String {
+        return PrOffset
+    } else {
+        return IvOffset
+    }
 }
@@ -20,12 +20,13 @@ import (
     `unsafe`
 
     `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/rt`
 )
 
-//go:noescape
-//go:linkname slicebytetostring runtime.slicebytetostring
 //goland:noinspection GoUnusedParameter
-func slicebytetostring(buf unsafe.Pointer, ptr unsafe.Pointer, n int) string
+func slicebytetostring(buf unsafe.Pointer, ptr unsafe.Pointer, n int) string {
+    return string(rt.BytesFrom(ptr, n, n))
+}
 
 var (
     F_slicebytetostring = hir.RegisterGCall(slicebytetostring, emu_gcall_slicebytetostring)
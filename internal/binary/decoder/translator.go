@@ -17,12 +17,12 @@
 package decoder
 
 import (
-    `fmt`
     `reflect`
 
     `github.com/cloudwego/frugal/internal/atm/hir`
     `github.com/cloudwego/frugal/internal/binary/defs`
     `github.com/cloudwego/frugal/internal/rt`
+    `github.com/cloudwego/frugal/internal/utils`
 )
 
 /** Function Prototype
@@ -78,19 +78,30 @@ const (
     LB_type     = "_type"
     LB_skip     = "_skip"
     LB_error    = "_error"
+    LB_return   = "_return"
     LB_missing  = "_missing"
     LB_overflow = "_overflow"
+    LB_union    = "_union"
+    LB_range    = "_range"
+    LB_length   = "_length"
+    LB_limit    = "_limit"
+    LB_depth    = "_depth"
+    LB_string   = "_string"
+    LB_memory   = "_memory"
+    LB_count    = "_count"
 )
 
 var (
     _T_byte      *rt.GoType
     _E_overflow  error
+    _E_union     error
     _V_zerovalue uint64
 )
 
 func init() {
     _T_byte     = rt.UnpackType(reflect.TypeOf(byte(0)))
-    _E_overflow = fmt.Errorf("frugal: decoder stack overflow")
+    _E_overflow = utils.EKindf(utils.ErrInvalidData, "frugal: decoder stack overflow")
+    _E_union    = utils.EKindf(utils.ErrInvalidData, "frugal: more than one field of a union is set")
 }
 
 func Translate(s Program) hir.Program {
@@ -121,6 +132,7 @@ func errors(p *hir.Builder) {
     p.Label (LB_skip)
     p.GCALL (F_error_skip).
       A0    (TR).
+      A1    (RS).
       R0    (ET).
       R1    (EP)
     p.JMP   (LB_error)
@@ -132,8 +144,44 @@ func errors(p *hir.Builder) {
       R0    (ET).
       R1    (EP)
     p.JMP   (LB_error)
+    p.Label (LB_range)
+    p.GCALL (F_error_range).
+      A0    (TR).
+      A1    (UR).
+      R0    (ET).
+      R1    (EP)
+    p.JMP   (LB_error)
+    p.Label (LB_length)
+    p.GCALL (F_error_length).
+      A0    (TR).
+      A1    (UR).
+      R0    (ET).
+      R1    (EP)
+    p.JMP   (LB_error)
+    p.Label (LB_count)
+    p.IQ    (int64(LimitContainerLen), UR)
+    p.JMP   (LB_limit)
+    p.Label (LB_string)
+    p.IQ    (int64(LimitStringLen), UR)
+    p.JMP   (LB_limit)
+    p.Label (LB_depth)
+    p.IQ    (int64(LimitDepth), UR)
+    p.JMP   (LB_limit)
+    p.Label (LB_memory)
+    p.IQ    (int64(LimitTotalAlloc), UR)
+    p.Label (LB_limit)
+    p.GCALL (F_error_limit).
+      A0    (UR).
+      A1    (RS).
+      R0    (ET).
+      R1    (EP)
+    p.JMP   (LB_error)
     p.Label (LB_overflow)
     p.IP    (&_E_overflow, TP)
+    p.JMP   ("_basic_error")
+    p.Label (LB_union)
+    p.IP    (&_E_union, TP)
+    p.Label ("_basic_error")
     p.LP    (TP, 0, ET)
     p.LP    (TP, 8, EP)
     p.JMP   (LB_error)
@@ -160,20 +208,36 @@ func epilogue(p *hir.Builder) {
     p.Label (LB_halt)
     p.MOVP  (hir.Pn, ET)
     p.MOVP  (hir.Pn, EP)
-    p.Label (LB_error)
+    p.Label (LB_return)
     p.RET   ().
       R0    (IC).
       R1    (ET).
       R2    (EP)
+    p.Label (LB_error)
+    p.LQ    (RS, EsOffset, TR)
+    p.BNE   (TR, hir.Rz, LB_return)
+    p.ADDI  (ST, 1, TR)
+    p.SQ    (TR, RS, EsOffset)
+    p.SQ    (IC, RS, EiOffset)
+    p.JMP   (LB_return)
 }
 
 var translators = [256]func(*hir.Builder, Instr) {
     OP_int               : translate_OP_int,
+    OP_uint              : translate_OP_uint,
+    OP_float             : translate_OP_float,
     OP_str               : translate_OP_str,
     OP_str_nocopy        : translate_OP_str_nocopy,
     OP_bin               : translate_OP_bin,
     OP_bin_nocopy        : translate_OP_bin_nocopy,
+    OP_array             : translate_OP_array,
+    OP_codec             : translate_OP_codec,
     OP_enum              : translate_OP_enum,
+    OP_varint            : translate_OP_varint,
+    OP_varint_u          : translate_OP_varint_u,
+    OP_bool              : translate_OP_bool,
+    OP_int_le            : translate_OP_int_le,
+    OP_float_le          : translate_OP_float_le,
     OP_size              : translate_OP_size,
     OP_type              : translate_OP_type,
     OP_seek              : translate_OP_seek,
@@ -181,6 +245,8 @@ var translators = [256]func(*hir.Builder, Instr) {
     OP_ctr_load          : translate_OP_ctr_load,
     OP_ctr_decr          : translate_OP_ctr_decr,
     OP_ctr_is_zero       : translate_OP_ctr_is_zero,
+    OP_list_head         : translate_OP_list_head,
+    OP_map_head          : translate_OP_map_head,
     OP_map_alloc         : translate_OP_map_alloc,
     OP_map_close         : translate_OP_map_close,
     OP_map_set_i8        : translate_OP_map_set_i8,
@@ -190,6 +256,8 @@ var translators = [256]func(*hir.Builder, Instr) {
     OP_map_set_str       : translate_OP_map_set_str,
     OP_map_set_enum      : translate_OP_map_set_enum,
     OP_map_set_pointer   : translate_OP_map_set_pointer,
+    OP_map_key           : translate_OP_map_key,
+    OP_map_set           : translate_OP_map_set,
     OP_list_alloc        : translate_OP_list_alloc,
     OP_struct_skip       : translate_OP_struct_skip,
     OP_struct_ignore     : translate_OP_struct_ignore,
@@ -200,8 +268,20 @@ var translators = [256]func(*hir.Builder, Instr) {
     OP_struct_mark_tag   : translate_OP_struct_mark_tag,
     OP_struct_read_type  : translate_OP_struct_read_type,
     OP_struct_check_type : translate_OP_struct_check_type,
+    OP_struct_check_bool : translate_OP_struct_check_bool,
+    OP_field_clear       : translate_OP_field_clear,
+    OP_field_begin       : translate_OP_field_begin,
+    OP_field_switch      : translate_OP_field_switch,
+    OP_field_bool        : translate_OP_field_bool,
+    OP_unknown_clear     : translate_OP_unknown_clear,
+    OP_unknown_skip      : translate_OP_unknown_skip,
+    OP_unknown_cskip     : translate_OP_unknown_cskip,
+    OP_union_reset       : translate_OP_union_reset,
+    OP_union_mark        : translate_OP_union_mark,
     OP_make_state        : translate_OP_make_state,
     OP_drop_state        : translate_OP_drop_state,
+    OP_make_ptr_state    : translate_OP_make_ptr_state,
+    OP_drop_ptr_state    : translate_OP_drop_ptr_state,
     OP_construct         : translate_OP_construct,
     OP_initialize        : translate_OP_initialize,
     OP_defer             : translate_OP_defer,
@@ -219,17 +299,74 @@ func translate_OP_int(p *hir.Builder, v Instr) {
     }
 }
 
-func translate_OP_str(p *hir.Builder, _ Instr) {
-    p.SP    (hir.Pn, WP, 0)
+func translate_OP_uint(p *hir.Builder, v Instr) {
     p.ADDP  (IP, IC, EP)
-    p.ADDI  (IC, 4, IC)
-    p.LL    (EP, 0, TR)
-    p.SWAPL (TR, TR)
-    p.LDAQ  (ARG_nb, UR)
-    p.BLTU  (UR, TR, LB_eof)
-    p.BEQ   (TR, hir.Rz, "_empty_{n}")
-    p.ADDPI (EP, 4, EP)
-    p.ADD   (IC, TR, IC)
+    translate_OP_uint_load(p, v.Iv)
+    p.ADDI  (IC, v.Iv, IC)
+    translate_OP_uint_store(p, v)
+}
+
+func translate_OP_uint_load(p *hir.Builder, w int64) {
+    switch w {
+        case 1  : p.LB(EP, 0, TR)
+        case 2  : p.LW(EP, 0, TR); p.SWAPW(TR, TR)
+        case 4  : p.LL(EP, 0, TR); p.SWAPL(TR, TR)
+        case 8  : p.LQ(EP, 0, TR); p.SWAPQ(TR, TR)
+        default : panic("can only convert 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func translate_OP_uint_store(p *hir.Builder, v Instr) {
+    n := int64(v.Vt.Size)
+    w := v.Iv
+
+    /* check for overflows if the wire type is wider */
+    if w > n {
+        p.SHRI  (TR, n * 8, UR)
+        p.BEQ   (UR, hir.Rz, "_ok_{n}")
+        p.IQ    (n, UR)
+        p.JMP   (LB_range)
+        p.Label ("_ok_{n}")
+    }
+
+    /* store the value */
+    switch n {
+        case 1  : p.SB(TR, WP, 0)
+        case 2  : p.SW(TR, WP, 0)
+        case 4  : p.SL(TR, WP, 0)
+        case 8  : p.SQ(TR, WP, 0)
+        default : panic("can only store 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func translate_OP_float(p *hir.Builder, _ Instr) {
+    p.ADDP  (IP, IC, EP)
+    p.LQ    (EP, 0, TR)
+    p.SWAPQ (TR, TR)
+    p.ADDI  (IC, 8, IC)
+    translate_OP_float_narrow(p)
+}
+
+func translate_OP_float_le(p *hir.Builder, _ Instr) {
+    p.ADDP  (IP, IC, EP)
+    p.LQ    (EP, 0, TR)
+    p.ADDI  (IC, 8, IC)
+    translate_OP_float_narrow(p)
+}
+
+func translate_OP_float_narrow(p *hir.Builder) {
+    p.GCALL (F_float_narrow).
+      A0    (WP).
+      A1    (TR).
+      R0    (ET).
+      R1    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+}
+
+func translate_OP_str(p *hir.Builder, v Instr) {
+    p.SP    (hir.Pn, WP, 0)
+    translate_OP_binstr_length(p, v)
+    translate_OP_alloc_check(p)
     p.GCALL (F_slicebytetostring).
       A0    (hir.Pn).
       A1    (EP).
@@ -241,23 +378,16 @@ func translate_OP_str(p *hir.Builder, _ Instr) {
     p.SQ    (TR, WP, 8)
 }
 
-func translate_OP_str_nocopy(p *hir.Builder, _ Instr) {
+func translate_OP_str_nocopy(p *hir.Builder, v Instr) {
     p.SP    (hir.Pn, WP, 0)
-    translate_OP_binstr_nocopy(p)
+    translate_OP_binstr_nocopy(p, v)
 }
 
-func translate_OP_bin(p *hir.Builder, _ Instr) {
+func translate_OP_bin(p *hir.Builder, v Instr) {
     p.IP    (&_V_zerovalue, TP)
     p.SP    (TP, WP, 0)
-    p.ADDP  (IP, IC, EP)
-    p.ADDI  (IC, 4, IC)
-    p.LL    (EP, 0, TR)
-    p.SWAPL (TR, TR)
-    p.LDAQ  (ARG_nb, UR)
-    p.BLTU  (UR, TR, LB_eof)
-    p.BEQ   (TR, hir.Rz, "_empty_{n}")
-    p.ADDPI (EP, 4, EP)
-    p.ADD   (IC, TR, IC)
+    translate_OP_binstr_length(p, v)
+    translate_OP_alloc_check(p)
     p.IP    (_T_byte, TP)
     p.GCALL (F_mallocgc).
       A0    (TR).
@@ -271,26 +401,99 @@ func translate_OP_bin(p *hir.Builder, _ Instr) {
     p.SQ    (TR, WP, 16)
 }
 
-func translate_OP_bin_nocopy(p *hir.Builder, _ Instr) {
+func translate_OP_bin_nocopy(p *hir.Builder, v Instr) {
     p.IP    (&_V_zerovalue, TP)
     p.SP    (TP, WP, 0)
-    translate_OP_binstr_nocopy(p)
+    translate_OP_binstr_nocopy(p, v)
     p.SQ    (TR, WP, 16)
 }
 
-func translate_OP_binstr_nocopy(p *hir.Builder) {
+func translate_OP_array(p *hir.Builder, v Instr) {
+    n := int64(v.Vt.Size)
+    translate_OP_binstr_length(p, v)
+
+    /* the length must match the array size exactly */
+    p.Label ("_empty_{n}")
+    p.IQ    (n, UR)
+    p.BNE   (TR, UR, LB_length)
+
+    /* copy the bytes, if any */
+    if n != 0 {
+        p.BCOPY(EP, TR, WP)
+    }
+}
+
+func translate_OP_codec(p *hir.Builder, v Instr) {
+    p.IP    (v.Codec(), TP)
+    p.LDAQ  (ARG_nb, TR)
+    p.IQ    (v.Iv, UR)
+    p.GCALL (F_codec_decode).
+      A0    (TP).
+      A1    (IP).
+      A2    (TR).
+      A3    (IC).
+      A4    (WP).
+      A5    (UR).
+      A6    (RS).
+      R0    (IC).
+      R1    (ET).
+      R2    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+}
+
+func translate_OP_binstr_nocopy(p *hir.Builder, v Instr) {
+    translate_OP_binstr_length(p, v)
+    p.SP    (EP, WP, 0)
+    p.Label ("_empty_{n}")
+    p.SQ    (TR, WP, 8)
+}
+
+func translate_OP_binstr_length(p *hir.Builder, v Instr) {
+    if defs.Protocol(v.Iv) == defs.Compact {
+        translate_OP_binstr_length_compact(p)
+    } else {
+        translate_OP_binstr_length_binary(p)
+    }
+}
+
+func translate_OP_binstr_length_binary(p *hir.Builder) {
     p.ADDP  (IP, IC, EP)
     p.ADDI  (IC, 4, IC)
     p.LL    (EP, 0, TR)
     p.SWAPL (TR, TR)
+    p.LQ    (RS, NsOffset, UR)
+    p.BLTU  (UR, TR, LB_string)
+    p.ADD   (IC, TR, TR)
     p.LDAQ  (ARG_nb, UR)
     p.BLTU  (UR, TR, LB_eof)
+    p.SUB   (TR, IC, TR)
     p.BEQ   (TR, hir.Rz, "_empty_{n}")
     p.ADDPI (EP, 4, EP)
     p.ADD   (IC, TR, IC)
-    p.SP    (EP, WP, 0)
-    p.Label ("_empty_{n}")
-    p.SQ    (TR, WP, 8)
+}
+
+func translate_OP_binstr_length_compact(p *hir.Builder) {
+    p.LDAQ  (ARG_nb, UR)
+    p.GCALL (F_compact_length).
+      A0    (IP).
+      A1    (UR).
+      A2    (IC).
+      A3    (RS).
+      R0    (IC).
+      R1    (TR).
+      R2    (ET).
+      R3    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+    p.BEQ   (TR, hir.Rz, "_empty_{n}")
+    p.ADDP  (IP, IC, EP)
+    p.ADD   (IC, TR, IC)
+}
+
+func translate_OP_alloc_check(p *hir.Builder) {
+    p.LQ    (RS, NaOffset, UR)
+    p.BLTU  (UR, TR, LB_memory)
+    p.SUB   (UR, TR, UR)
+    p.SQ    (UR, RS, NaOffset)
 }
 
 func translate_OP_enum(p *hir.Builder, _ Instr) {
@@ -302,8 +505,57 @@ func translate_OP_enum(p *hir.Builder, _ Instr) {
     p.ADDI  (IC, 4, IC)
 }
 
+func translate_OP_varint(p *hir.Builder, v Instr) {
+    p.LDAQ  (ARG_nb, TR)
+    p.IQ    (v.Iv, UR)
+    p.GCALL (F_compact_varint).
+      A0    (IP).
+      A1    (TR).
+      A2    (IC).
+      A3    (WP).
+      A4    (UR).
+      R0    (IC).
+      R1    (ET).
+      R2    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+}
+
+func translate_OP_varint_u(p *hir.Builder, v Instr) {
+    p.LDAQ  (ARG_nb, TR)
+    p.IQ    (v.Iv, UR)
+    p.GCALL (F_compact_uint).
+      A0    (IP).
+      A1    (TR).
+      A2    (IC).
+      A3    (UR).
+      R0    (IC).
+      R1    (TR).
+      R2    (ET).
+      R3    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+    translate_OP_uint_store(p, v)
+}
+
+func translate_OP_bool(p *hir.Builder, _ Instr) {
+    p.ADDP  (IP, IC, EP)
+    p.LB    (EP, 0, TR)
+    p.IB    (defs.C_true, UR)
+    p.BEQ   (TR, UR, "_true_{n}")
+    p.MOV   (hir.Rz, UR)
+    p.Label ("_true_{n}")
+    p.SB    (UR, WP, 0)
+    p.ADDI  (IC, 1, IC)
+}
+
+func translate_OP_int_le(p *hir.Builder, v Instr) {
+    switch v.Iv {
+        case 8  : p.ADDP(IP, IC, EP); p.LQ(EP, 0, TR); p.SQ(TR, WP, 0); p.ADDI(IC, 8, IC)
+        default : panic("can only convert 8 bytes at a time")
+    }
+}
+
 func translate_OP_size(p *hir.Builder, v Instr) {
-    p.IQ    (v.Iv, TR)
+    p.ADDI  (IC, v.Iv, TR)
     p.LDAQ  (ARG_nb, UR)
     p.BLTU  (UR, TR, LB_eof)
 }
@@ -323,9 +575,10 @@ func translate_OP_seek(p *hir.Builder, v Instr) {
 func translate_OP_deref(p *hir.Builder, v Instr) {
     p.LQ    (WP, 0, TR)
     p.BNE   (TR, hir.Rz, "_skip_{n}")
+    p.IQ    (int64(v.Vt.Size), TR)
+    translate_OP_alloc_check(p)
     p.IB    (1, UR)
     p.IP    (v.Vt, TP)
-    p.IQ    (int64(v.Vt.Size), TR)
     p.GCALL (F_mallocgc).
       A0    (TR).
       A1    (TP).
@@ -336,13 +589,19 @@ func translate_OP_deref(p *hir.Builder, v Instr) {
     p.LP    (WP, 0, WP)
 }
 
-func translate_OP_ctr_load(p *hir.Builder, _ Instr) {
+func translate_OP_ctr_load(p *hir.Builder, v Instr) {
     p.ADDP  (IP, IC, EP)
     p.ADDI  (IC, 4, IC)
     p.LL    (EP, 0, TR)
     p.SWAPL (TR, TR)
+    p.LQ    (RS, NcOffset, UR)
+    p.BLTU  (UR, TR, LB_count)
     p.ADDP  (RS, ST, TP)
     p.SQ    (TR, TP, NbOffset)
+    p.MULI  (TR, v.Iv, TR)
+    p.ADD   (IC, TR, TR)
+    p.LDAQ  (ARG_nb, UR)
+    p.BLTU  (UR, TR, LB_eof)
 }
 
 func translate_OP_ctr_decr(p *hir.Builder, _ Instr) {
@@ -358,9 +617,54 @@ func translate_OP_ctr_is_zero(p *hir.Builder, v Instr) {
     p.BEQ   (TR, hir.Rz, p.At(v.To))
 }
 
+func translate_OP_list_head(p *hir.Builder, v Instr) {
+    p.LDAQ  (ARG_nb, TR)
+    p.IB    (int8(v.Tx), UR)
+    p.GCALL (F_compact_list_head).
+      A0    (IP).
+      A1    (TR).
+      A2    (IC).
+      A3    (UR).
+      A4    (RS).
+      R0    (IC).
+      R1    (TR).
+      R2    (ET).
+      R3    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+    p.ADDP  (RS, ST, TP)
+    p.SQ    (TR, TP, NbOffset)
+}
+
+func translate_OP_map_head(p *hir.Builder, v Instr) {
+    p.LDAQ  (ARG_nb, TR)
+    p.IQ    (v.Iv, UR)
+    p.GCALL (F_compact_map_head).
+      A0    (IP).
+      A1    (TR).
+      A2    (IC).
+      A3    (UR).
+      A4    (RS).
+      R0    (IC).
+      R1    (TR).
+      R2    (ET).
+      R3    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+    p.ADDP  (RS, ST, TP)
+    p.SQ    (TR, TP, NbOffset)
+}
+
 func translate_OP_map_alloc(p *hir.Builder, v Instr) {
+    mt := rt.MapType(v.Vt)
+    nb := int64(mt.Key.Size + mt.Elem.Size)
+
+    /* reserve the space for all the pairs */
     p.ADDP  (RS, ST, TP)
     p.LQ    (TP, NbOffset, TR)
+    p.MULI  (TR, nb, TR)
+    translate_OP_alloc_check(p)
+
+    /* allocate the map */
+    p.LQ    (TP, NbOffset, TR)
     p.IP    (v.Vt, ET)
     p.GCALL (F_makemap).
       A0    (ET).
@@ -370,6 +674,8 @@ func translate_OP_map_alloc(p *hir.Builder, v Instr) {
     p.SP    (TP, WP, 0)
     p.ADDP  (RS, ST, EP)
     p.SP    (TP, EP, MpOffset)
+    p.LQ    (EP, NbOffset, TR)
+    p.SQ    (TR, EP, IxOffset)
 }
 
 func translate_OP_map_close(p *hir.Builder, _ Instr) {
@@ -472,7 +778,7 @@ func translate_OP_map_set_i64_fast(p *hir.Builder, v Instr) {
 
 func translate_OP_map_set_i64_safe(p *hir.Builder, v Instr) {
     p.ADDP  (IP, IC, ET)
-    p.ADDI  (IC, 2, IC)
+    p.ADDI  (IC, 8, IC)
     p.ADDP  (RS, ST, TP)
     p.LP    (TP, MpOffset, EP)
     p.LQ    (ET, 0, TR)
@@ -631,6 +937,24 @@ func translate_OP_map_set_pointer_safe(p *hir.Builder, v Instr) {
     p.SP    (hir.Pn, RS, PrOffset)
 }
 
+func translate_OP_map_key(p *hir.Builder, v Instr) {
+    p.ADDPI (RS, spillOffset(v.Vt), WP)
+}
+
+func translate_OP_map_set(p *hir.Builder, v Instr) {
+    p.ADDP  (RS, ST, TP)
+    p.LP    (TP, MpOffset, EP)
+    p.ADDPI (RS, spillOffset(v.Vt), TP)
+    p.IP    (v.Vt, ET)
+    p.GCALL (F_mapassign).
+      A0    (ET).
+      A1    (EP).
+      A2    (TP).
+      R0    (WP)
+    p.SP    (hir.Pn, RS, PrOffset)
+    p.SQ    (hir.Rz, RS, IvOffset)
+}
+
 func translate_OP_list_alloc(p *hir.Builder, v Instr) {
     p.ADDP  (RS, ST, TP)
     p.LQ    (TP, NbOffset, TR)
@@ -645,9 +969,10 @@ func translate_OP_list_alloc(p *hir.Builder, v Instr) {
     p.Label ("_alloc_{n}")
     p.BGEU  (UR, TR, "_done_{n}")
     p.SQ    (TR, WP, 16)
+    p.MULI  (TR, int64(v.Vt.Size), TR)
+    translate_OP_alloc_check(p)
     p.IB    (1, UR)
     p.IP    (v.Vt, TP)
-    p.MULI  (TR, int64(v.Vt.Size), TR)
     p.GCALL (F_mallocgc).
       A0    (TR).
       A1    (TP).
@@ -655,11 +980,21 @@ func translate_OP_list_alloc(p *hir.Builder, v Instr) {
       R0    (TP)
     p.SP    (TP, WP, 0)
     p.Label ("_done_{n}")
+    p.ADDP  (RS, ST, TP)
+    p.LQ    (TP, NbOffset, TR)
+    p.SQ    (TR, TP, IxOffset)
     p.LP    (WP, 0, WP)
 }
 
-func translate_OP_struct_skip(p *hir.Builder, _ Instr) {
+func translate_OP_struct_skip(p *hir.Builder, v Instr) {
+    if defs.Protocol(v.Iv) == defs.Compact {
+        translate_OP_struct_skip_compact(p)
+        return
+    }
+
+    /* Binary Protocol */
     p.ADDPI (RS, SkOffset, TP)
+    p.ADDPI (RS, LmOffset, ET)
     p.LDAQ  (ARG_nb, TR)
     p.SUB   (TR, IC, TR)
     p.ADDP  (IP, IC, EP)
@@ -668,13 +1003,22 @@ func translate_OP_struct_skip(p *hir.Builder, _ Instr) {
       A1    (EP).
       A2    (TR).
       A3    (TG).
+      A4    (ET).
       R0    (TR)
     p.BLT   (TR, hir.Rz, LB_skip)
     p.ADD   (IC, TR, IC)
 }
 
-func translate_OP_struct_ignore(p *hir.Builder, _ Instr) {
+func translate_OP_struct_ignore(p *hir.Builder, v Instr) {
+    if defs.Protocol(v.Iv) == defs.Compact {
+        p.IB    (defs.C_struct, TG)
+        translate_OP_struct_skip_compact(p)
+        return
+    }
+
+    /* Binary Protocol */
     p.ADDPI (RS, SkOffset, TP)
+    p.ADDPI (RS, LmOffset, ET)
     p.LDAQ  (ARG_nb, TR)
     p.SUB   (TR, IC, TR)
     p.ADDP  (IP, IC, EP)
@@ -684,11 +1028,26 @@ func translate_OP_struct_ignore(p *hir.Builder, _ Instr) {
       A1    (EP).
       A2    (TR).
       A3    (TG).
+      A4    (ET).
       R0    (TR)
     p.BLT   (TR, hir.Rz, LB_skip)
     p.ADD   (IC, TR, IC)
 }
 
+func translate_OP_struct_skip_compact(p *hir.Builder) {
+    p.LDAQ  (ARG_nb, TR)
+    p.GCALL (F_compact_skip).
+      A0    (IP).
+      A1    (TR).
+      A2    (IC).
+      A3    (TG).
+      A4    (RS).
+      R0    (IC).
+      R1    (ET).
+      R2    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+}
+
 func translate_OP_struct_bitmap(p *hir.Builder, v Instr) {
     buf := newFieldBitmap()
     buf.Clear()
@@ -784,6 +1143,8 @@ func translate_OP_struct_read_type(p *hir.Builder, _ Instr) {
     p.ADDP  (IP, IC, EP)
     p.ADDI  (IC, 1, IC)
     p.LB    (EP, 0, TG)
+    p.ADDP  (RS, ST, TP)
+    p.SQ    (IC, TP, IxOffset)
 }
 
 func translate_OP_struct_check_type(p *hir.Builder, v Instr) {
@@ -791,7 +1152,162 @@ func translate_OP_struct_check_type(p *hir.Builder, v Instr) {
     p.BNE   (TG, TR, p.At(v.To))
 }
 
-func translate_OP_make_state(p *hir.Builder, _ Instr) {
+func translate_OP_struct_check_bool(p *hir.Builder, v Instr) {
+    p.IB    (defs.C_true, TR)
+    p.BEQ   (TG, TR, "_ok_{n}")
+    p.IB    (defs.C_false, TR)
+    p.BNE   (TG, TR, p.At(v.To))
+    p.Label ("_ok_{n}")
+}
+
+func translate_OP_field_clear(p *hir.Builder, _ Instr) {
+    p.ADDP  (RS, ST, TP)
+    p.SQ    (hir.Rz, TP, NbOffset)
+}
+
+func translate_OP_field_begin(p *hir.Builder, _ Instr) {
+    p.ADDP  (RS, ST, TP)
+    p.ADDI  (IC, 1, TR)
+    p.SQ    (TR, TP, IxOffset)
+    p.LQ    (TP, NbOffset, UR)
+    p.LDAQ  (ARG_nb, TR)
+    p.GCALL (F_compact_field).
+      A0    (IP).
+      A1    (TR).
+      A2    (IC).
+      A3    (UR).
+      R0    (IC).
+      R1    (TG).
+      R2    (TR).
+      R3    (ET).
+      R4    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+    p.ADDP  (RS, ST, TP)
+    p.SQ    (TR, TP, NbOffset)
+}
+
+func translate_OP_field_switch(p *hir.Builder, v Instr) {
+    stab := v.IntSeq()
+    ptab := make([]string, v.Iv)
+
+    /* convert the switch table */
+    for i, to := range stab {
+        if to >= 0 {
+            ptab[i] = p.At(to)
+        }
+    }
+
+    /* load and dispatch the field */
+    p.ADDP  (RS, ST, TP)
+    p.LQ    (TP, NbOffset, TR)
+    p.BSW   (TR, ptab)
+}
+
+func translate_OP_field_bool(p *hir.Builder, _ Instr) {
+    p.IB    (defs.C_true, UR)
+    p.BEQ   (TG, UR, "_true_{n}")
+    p.MOV   (hir.Rz, UR)
+    p.Label ("_true_{n}")
+    p.SB    (UR, WP, 0)
+}
+
+func translate_OP_unknown_clear(p *hir.Builder, v Instr) {
+    p.ADDPI (WP, v.Iv, TP)
+    p.SP    (hir.Pn, TP, 0)
+    p.SQ    (hir.Rz, TP, 8)
+    p.SQ    (hir.Rz, TP, 16)
+}
+
+func translate_OP_unknown_skip(p *hir.Builder, v Instr) {
+    p.ADDPI (RS, SkOffset, TP)
+    p.ADDPI (RS, LmOffset, ET)
+    p.LDAQ  (ARG_nb, TR)
+    p.SUB   (TR, IC, TR)
+    p.ADDP  (IP, IC, EP)
+    p.CCALL (C_skip).
+      A0    (TP).
+      A1    (EP).
+      A2    (TR).
+      A3    (TG).
+      A4    (ET).
+      R0    (TR)
+    p.BLT   (TR, hir.Rz, LB_skip)
+    p.ADDP  (IP, IC, EP)
+    p.ADDPI (EP, -defs.FieldHeaderSize, EP)
+    p.ADD   (IC, TR, IC)
+    p.ADDI  (TR, defs.FieldHeaderSize, TR)
+    translate_OP_alloc_check(p)
+    p.ADDPI (WP, v.Iv, TP)
+    p.GCALL (F_unknown_append).
+      A0    (TP).
+      A1    (EP).
+      A2    (TR)
+}
+
+func translate_OP_unknown_cskip(p *hir.Builder, v Instr) {
+    p.ADDP  (RS, ST, TP)
+    p.LQ    (TP, NbOffset, UR)
+    p.LDAQ  (ARG_nb, TR)
+    p.ADDPI (WP, v.Iv, TP)
+    p.GCALL (F_unknown_skip_compact).
+      A0    (IP).
+      A1    (TR).
+      A2    (IC).
+      A3    (TG).
+      A4    (UR).
+      A5    (TP).
+      A6    (RS).
+      R0    (IC).
+      R1    (ET).
+      R2    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+}
+
+func translate_OP_union_reset(p *hir.Builder, v Instr) {
+    fv := v.IntSeq()
+
+    /* clear all the members, the slices also need their lengths and capacities cleared */
+    for i := 0; i < len(fv); i += 2 {
+        p.SP    (hir.Pn, WP, int64(fv[i]))
+
+        /* clear the rest of the member */
+        for j := 8; j < fv[i + 1]; j += 8 {
+            p.SQ(hir.Rz, WP, int64(fv[i] + j))
+        }
+    }
+
+    /* reset the member counter */
+    p.ADDP  (RS, ST, TP)
+    p.SQ    (hir.Rz, TP, UnOffset)
+}
+
+func translate_OP_union_mark(p *hir.Builder, _ Instr) {
+    p.ADDP  (RS, ST, TP)
+    p.LQ    (TP, UnOffset, TR)
+    p.BNE   (TR, hir.Rz, LB_union)
+    p.IQ    (1, TR)
+    p.SQ    (TR, TP, UnOffset)
+}
+
+func translate_OP_make_state(p *hir.Builder, v Instr) {
+    p.LQ    (RS, NdOffset, TR)
+    p.BEQ   (TR, hir.Rz, LB_depth)
+    p.SUBI  (TR, 1, TR)
+    p.SQ    (TR, RS, NdOffset)
+    translate_OP_make_ptr_state(p, v)
+    p.SQ    (hir.Rz, TP, StateSize + IxOffset)
+}
+
+func translate_OP_drop_state(p *hir.Builder, v Instr) {
+    p.LQ    (RS, NdOffset, TR)
+    p.ADDI  (TR, 1, TR)
+    p.SQ    (TR, RS, NdOffset)
+    translate_OP_drop_ptr_state(p, v)
+}
+
+/* pointers do not appear on the wire, so they do not count towards the depth limit */
+
+func translate_OP_make_ptr_state(p *hir.Builder, _ Instr) {
     p.IQ    (StateMax, TR)
     p.BGEU  (ST, TR, LB_overflow)
     p.ADDP  (RS, ST, TP)
@@ -799,7 +1315,7 @@ func translate_OP_make_state(p *hir.Builder, _ Instr) {
     p.ADDI  (ST, StateSize, ST)
 }
 
-func translate_OP_drop_state(p *hir.Builder, _ Instr) {
+func translate_OP_drop_ptr_state(p *hir.Builder, _ Instr) {
     p.SUBI  (ST, StateSize, ST)
     p.ADDP  (RS, ST, TP)
     p.LP    (TP, WpOffset, WP)
@@ -807,9 +1323,10 @@ func translate_OP_drop_state(p *hir.Builder, _ Instr) {
 }
 
 func translate_OP_construct(p *hir.Builder, v Instr) {
+    p.IQ    (int64(v.Vt.Size), TR)
+    translate_OP_alloc_check(p)
     p.IB    (1, UR)
     p.IP    (v.Vt, TP)
-    p.IQ    (int64(v.Vt.Size), TR)
     p.GCALL (F_mallocgc).
       A0    (TR).
       A1    (TP).
@@ -825,7 +1342,7 @@ func translate_OP_initialize(p *hir.Builder, v Instr) {
 func translate_OP_defer(p *hir.Builder, v Instr) {
     p.IP    (v.Vt, TP)
     p.LDAQ  (ARG_nb, TR)
-    p.GCALL (F_decode).
+    p.GCALL (decoderOf(v)).
       A0    (TP).
       A1    (IP).
       A2    (TR).
@@ -839,6 +1356,14 @@ func translate_OP_defer(p *hir.Builder, v Instr) {
     p.BNEP  (ET, hir.Pn, LB_error)
 }
 
+func decoderOf(v Instr) *hir.CallHandle {
+    if defs.Protocol(v.Iv) == defs.Compact {
+        return F_decode_compact
+    } else {
+        return F_decode
+    }
+}
+
 func translate_OP_goto(p *hir.Builder, v Instr) {
     p.JMP   (p.At(v.To))
 }
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `encoding/binary`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+func unknown_append(p unsafe.Pointer, b unsafe.Pointer, n int) {
+    v := (*[]byte)(p)
+    *v = append(*v, rt.BytesFrom(b, n, n)...)
+}
+
+func unknown_skip_compact(buf unsafe.Pointer, nb int, i int, t int, id int, p unsafe.Pointer, rs *RuntimeState) (int, error) {
+    var j int
+    var n int
+    var err error
+    var hdr [4]byte
+
+    /* skip the value */
+    if j, err = compact_skip(buf, nb, i, t, rs); err != nil {
+        return i, err
+    }
+
+    /* the short form of field header is relative to the previous field, so the long form is always kept */
+    x := int64(int16(id))
+    n = binary.PutUvarint(hdr[1:], uint64((x << 1) ^ (x >> 63))) + 1
+
+    /* the kept bytes count as allocation */
+    if m := uint64(n + j - i); m > rs.Lm.Na {
+        return i, limitError(LimitTotalAlloc, rs)
+    } else {
+        rs.Lm.Na -= m
+    }
+
+    /* keep the field header together with the value */
+    v := (*[]byte)(p)
+    hdr[0] = byte(t)
+    *v = append(append(*v, hdr[:n]...), rt.BytesFrom(unsafe.Pointer(uintptr(buf) + uintptr(i)), j - i, j - i)...)
+    return j, nil
+}
+
+var (
+    F_unknown_append       = hir.RegisterGCall(unknown_append, emu_gcall_unknown_append)
+    F_unknown_skip_compact = hir.RegisterGCall(unknown_skip_compact, emu_gcall_unknown_skip_compact)
+)
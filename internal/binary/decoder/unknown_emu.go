@@ -0,0 +1,39 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package decoder
+
+import (
+    `github.com/cloudwego/frugal/internal/atm/hir`
+)
+
+func emu_gcall_unknown_append(ctx hir.CallContext) {
+    if !ctx.Verify("**i", "") {
+        panic("invalid unknown_append call")
+    } else {
+        unknown_append(ctx.Ap(0), ctx.Ap(1), int(ctx.Au(2)))
+    }
+}
+
+func emu_gcall_unknown_skip_compact(ctx hir.CallContext) {
+    if !ctx.Verify("*iiii**", "i**") {
+        panic("invalid unknown_skip_compact call")
+    } else {
+        ret, err := unknown_skip_compact(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), int(ctx.Au(3)), int(ctx.Au(4)), ctx.Ap(5), (*RuntimeState)(ctx.Ap(6)))
+        ctx.Ru(0, uint64(ret))
+        emu_seterr(ctx, 1, err)
+    }
+}
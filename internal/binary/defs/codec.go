@@ -0,0 +1,257 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package defs
+
+import (
+    `reflect`
+    `sync`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/rt`
+    `github.com/cloudwego/frugal/internal/utils`
+)
+
+type Codec struct {
+    Type   reflect.Type
+    Wire   Tag
+    Raw    bool
+    Elem   reflect.Type
+    Encode func(buf []byte, val interface{}) (int, error)
+    Decode func(buf []byte, val interface{}) error
+    Size   func(val interface{}) int
+    ptr    *rt.GoType
+}
+
+// RawValue is implemented by pointers to the types that keep Thrift values in the encoded form,
+// or adapted to it with RegisterRawAdapter. Their codecs are created on demand, and work with the
+// entire encoded value in the protocol being used, instead of the wire representation.
+type RawValue interface {
+    RawType() reflect.Type
+    RawSize(pt Protocol) (int, error)
+    RawEncode(buf []byte, pt Protocol) (int, error)
+    RawDecode(buf []byte, pt Protocol) error
+}
+
+var codecWireTypes = map[string]Tag {
+    "i8"     : T_i8,
+    "byte"   : T_i8,
+    "i16"    : T_i16,
+    "i32"    : T_i32,
+    "i64"    : T_i64,
+    "double" : T_double,
+    "string" : T_string,
+    "binary" : T_binary,
+}
+
+var (
+    codecsLock = new(sync.RWMutex)
+    codecsTab  = make(map[reflect.Type]*Codec)
+)
+
+var rawWireTypes = map[string]Tag {
+    "i8"     : T_i8,
+    "byte"   : T_i8,
+    "i16"    : T_i16,
+    "i32"    : T_i32,
+    "i64"    : T_i64,
+    "double" : T_double,
+    "string" : T_string,
+    "binary" : T_string,
+    "struct" : T_struct,
+    "map"    : T_map,
+    "set"    : T_set,
+    "list"   : T_list,
+}
+
+type rawCodecKey struct {
+    vt reflect.Type
+    wt Tag
+}
+
+var (
+    rawValueType = reflect.TypeOf((*RawValue)(nil)).Elem()
+    rawCodecsTab = make(map[rawCodecKey]*Codec)
+)
+
+var (
+    rawAdapterType reflect.Type
+    rawAdapterFunc func(v interface{}) RawValue
+)
+
+// RegisterRawAdapter makes the types whose pointers implement the interface type it raw values,
+// which are converted to RawValue with fn. It's for the public types like frugal.Lazy[T], which
+// cannot implement RawValue without exporting its methods. It must be called during init.
+func RegisterRawAdapter(it reflect.Type, fn func(v interface{}) RawValue) {
+    rawAdapterType = it
+    rawAdapterFunc = fn
+}
+
+// FixedSize returns the size of the wire value in Binary Protocol, or -1 for strings, binaries and raw values.
+func (self *Codec) FixedSize() int {
+    if self.Raw {
+        return -1
+    }
+
+    /* fixed-size wire types */
+    switch self.Wire {
+        case T_i8     : return 1
+        case T_i16    : return 2
+        case T_i32    : return 4
+        case T_i64    : return 8
+        case T_double : return 8
+        default       : return -1
+    }
+}
+
+// Value converts p, which points to a value of the codec type, to an interface{} holding the pointer.
+func (self *Codec) Value(p unsafe.Pointer) interface{} {
+    return *(*interface{})(unsafe.Pointer(&rt.GoEface {
+        Type  : self.ptr,
+        Value : p,
+    }))
+}
+
+// RawValue converts p, which points to a value of the raw codec type, to a RawValue.
+func (self *Codec) RawValue(p unsafe.Pointer) RawValue {
+    return rawValueOf(self.Value(p))
+}
+
+// LookupCodec returns the codec of vt, raw values use the "struct" wire type.
+func LookupCodec(vt reflect.Type) *Codec {
+    codecsLock.RLock()
+    cc := codecsTab[vt]
+    codecsLock.RUnlock()
+
+    /* create the codec for raw values on demand */
+    if cc == nil && isRawValue(vt) {
+        cc = lookupRawCodec(vt, T_struct)
+    }
+
+    /* all done */
+    return cc
+}
+
+// CodecOf returns the codec of vt, which must be a T_codec type. Raw values may have
+// different wire types in different fields, and have one codec for each of them.
+func CodecOf(vt *Type) *Codec {
+    if cc := LookupCodec(vt.S); !cc.Raw || cc.Wire == vt.V.T {
+        return cc
+    } else {
+        return lookupRawCodec(vt.S, vt.V.T)
+    }
+}
+
+func isRawValue(vt reflect.Type) bool {
+    if vt.Kind() == reflect.Ptr {
+        return false
+    } else if pt := reflect.PtrTo(vt); pt.Implements(rawValueType) {
+        return true
+    } else {
+        return rawAdapterType != nil && pt.Implements(rawAdapterType)
+    }
+}
+
+func rawValueOf(v interface{}) RawValue {
+    if rv, ok := v.(RawValue); ok {
+        return rv
+    } else {
+        return rawAdapterFunc(v)
+    }
+}
+
+func lookupRawCodec(vt reflect.Type, wt Tag) *Codec {
+    key := rawCodecKey { vt, wt }
+    codecsLock.RLock()
+    cc := rawCodecsTab[key]
+    codecsLock.RUnlock()
+
+    /* check for the cached codec */
+    if cc != nil {
+        return cc
+    }
+
+    /* create a new one */
+    cc = &Codec {
+        Type : vt,
+        Wire : wt,
+        Raw  : true,
+        Elem : rawValueOf(reflect.New(vt).Interface()).RawType(),
+        ptr  : rt.UnpackType(reflect.PtrTo(vt)),
+    }
+
+    /* add to the codec table */
+    codecsLock.Lock()
+    defer codecsLock.Unlock()
+
+    /* keep the existing one, if any */
+    if ret, ok := rawCodecsTab[key]; ok {
+        return ret
+    } else {
+        rawCodecsTab[key] = cc
+        return cc
+    }
+}
+
+func RegisterCodec(
+    vt   reflect.Type,
+    wt   string,
+    enc  func(buf []byte, val interface{}) (int, error),
+    dec  func(buf []byte, val interface{}) error,
+    size func(val interface{}) int,
+) error {
+    var ok bool
+    var tag Tag
+
+    /* only named non-pointer types can have codecs */
+    if vt == nil || vt.Kind() == reflect.Ptr || vt.PkgPath() == "" {
+        return utils.EKindf(utils.ErrUnsupportedType, "frugal: codecs can only be registered for named non-pointer types, not %v", vt)
+    }
+
+    /* check for the wire type */
+    if tag, ok = codecWireTypes[wt]; !ok {
+        return utils.EKindf(utils.ErrUnsupportedType, "frugal: invalid wire type %q for codec of %s", wt, vt)
+    }
+
+    /* check for the functions */
+    if enc == nil || dec == nil {
+        return utils.EKindf(utils.ErrUnsupportedType, "frugal: codec of %s must have both encoder and decoder", vt)
+    } else if size == nil && (tag == T_string || tag == T_binary) {
+        return utils.EKindf(utils.ErrUnsupportedType, "frugal: codec of %s must have a size function for wire type %s", vt, wt)
+    }
+
+    /* create the codec */
+    cc := &Codec {
+        Type   : vt,
+        Wire   : tag,
+        Encode : enc,
+        Decode : dec,
+        Size   : size,
+        ptr    : rt.UnpackType(reflect.PtrTo(vt)),
+    }
+
+    /* add to the codec table */
+    codecsLock.Lock()
+    defer codecsLock.Unlock()
+
+    /* each type can only have one codec */
+    if _, ok = codecsTab[vt]; ok {
+        return utils.EKindf(utils.ErrUnsupportedType, "frugal: duplicated codec for type %s", vt)
+    } else {
+        codecsTab[vt] = cc
+        return nil
+    }
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package defs
+
+import (
+    `encoding/binary`
+    `reflect`
+    `testing`
+    `time`
+
+    `github.com/cloudwego/frugal/internal/utils`
+    `github.com/stretchr/testify/require`
+)
+
+type CodecTestType int64
+
+func TestCodec_Register(t *testing.T) {
+    enc := func(buf []byte, v interface{}) (int, error) { binary.BigEndian.PutUint32(buf, uint32(*v.(*CodecTestType))); return 4, nil }
+    dec := func(buf []byte, v interface{}) error { *v.(*CodecTestType) = CodecTestType(binary.BigEndian.Uint32(buf)); return nil }
+    require.ErrorIs(t, RegisterCodec(reflect.TypeOf(int64(0)), "i32", enc, dec, nil), utils.ErrUnsupportedType)
+    require.ErrorIs(t, RegisterCodec(reflect.TypeOf(new(CodecTestType)), "i32", enc, dec, nil), utils.ErrUnsupportedType)
+    require.ErrorIs(t, RegisterCodec(reflect.TypeOf(CodecTestType(0)), "bool", enc, dec, nil), utils.ErrUnsupportedType)
+    require.ErrorIs(t, RegisterCodec(reflect.TypeOf(CodecTestType(0)), "binary", enc, dec, nil), utils.ErrUnsupportedType)
+    require.ErrorIs(t, RegisterCodec(reflect.TypeOf(CodecTestType(0)), "i32", nil, dec, nil), utils.ErrUnsupportedType)
+    require.NoError(t, RegisterCodec(reflect.TypeOf(CodecTestType(0)), "i32", enc, dec, nil))
+    require.ErrorIs(t, RegisterCodec(reflect.TypeOf(CodecTestType(0)), "i32", enc, dec, nil), utils.ErrUnsupportedType)
+    require.Equal(t, 4, LookupCodec(reflect.TypeOf(CodecTestType(0))).FixedSize())
+    require.Nil(t, LookupCodec(reflect.TypeOf(time.Time{})))
+    require.Equal(t, -1, GetSize(reflect.TypeOf(CodecTestType(0))))
+    require.Equal(t, "i32(defs.CodecTestType)", ParseType(reflect.TypeOf(CodecTestType(0)), "i32").String())
+    require.Equal(t, "list<i32(defs.CodecTestType)>", ParseType(reflect.TypeOf([]CodecTestType(nil)), "list<i32>").String())
+    require.Panics(t, func() { ParseType(reflect.TypeOf(CodecTestType(0)), "i64") })
+}
+
+type CodecTestRaw struct{}
+
+func (self *CodecTestRaw) RawType() reflect.Type                              { return reflect.TypeOf(time.Time{}) }
+func (self *CodecTestRaw) RawSize(pt Protocol) (int, error)                   { return 0, nil }
+func (self *CodecTestRaw) RawEncode(buf []byte, pt Protocol) (int, error)     { return 0, nil }
+func (self *CodecTestRaw) RawDecode(buf []byte, pt Protocol) error            { return nil }
+
+func TestCodec_Raw(t *testing.T) {
+    cc := LookupCodec(reflect.TypeOf(CodecTestRaw{}))
+    require.NotNil(t, cc)
+    require.True(t, cc.Raw)
+    require.Equal(t, T_struct, cc.Wire)
+    require.Equal(t, -1, cc.FixedSize())
+    require.Equal(t, reflect.TypeOf(time.Time{}), cc.Elem)
+    require.Same(t, cc, LookupCodec(reflect.TypeOf(CodecTestRaw{})))
+    require.Nil(t, LookupCodec(reflect.TypeOf(new(CodecTestRaw))))
+    require.Equal(t, "CodecTestRaw(defs.CodecTestRaw)", ParseType(reflect.TypeOf(CodecTestRaw{}), "struct").String())
+    require.Panics(t, func() { ParseType(reflect.TypeOf(CodecTestRaw{}), "binary") })
+}
+
+type CodecTestOpaque struct{}
+
+func (self *CodecTestOpaque) RawType() reflect.Type                          { return nil }
+func (self *CodecTestOpaque) RawSize(pt Protocol) (int, error)               { return 0, nil }
+func (self *CodecTestOpaque) RawEncode(buf []byte, pt Protocol) (int, error) { return 0, nil }
+func (self *CodecTestOpaque) RawDecode(buf []byte, pt Protocol) error        { return nil }
+
+func TestCodec_RawWireTypes(t *testing.T) {
+    vt := reflect.TypeOf(CodecTestOpaque{})
+    for def, wt := range map[string]Tag {
+        ""                        : T_struct,
+        "byte"                    : T_i8,
+        "i32"                     : T_i32,
+        "binary"                  : T_string,
+        "struct"                  : T_struct,
+        "list"                    : T_list,
+        "set<i64>"                : T_set,
+        "map<string:list<i8>>"    : T_map,
+    } {
+        tv := ParseType(vt, def)
+        cc := CodecOf(tv)
+        require.Equal(t, wt, tv.Tag(), def)
+        require.Equal(t, wt, cc.Wire, def)
+        require.True(t, cc.Raw)
+        require.Same(t, cc, CodecOf(ParseType(vt, def)))
+    }
+    require.Equal(t, T_list, ParseType(reflect.TypeOf([]CodecTestOpaque(nil)), "list<list<i32>>").V.Tag())
+    require.Panics(t, func() { ParseType(vt, "bool") })
+    require.Panics(t, func() { ParseType(vt, "Foo") })
+    require.Panics(t, func() { ParseType(vt, "list<i32") })
+}
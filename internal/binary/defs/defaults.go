@@ -17,9 +17,10 @@
 package defs
 
 import (
-    `fmt`
     `reflect`
     `unsafe`
+
+    `github.com/cloudwego/frugal/internal/utils`
 )
 
 type DefaultInitializer interface {
@@ -42,11 +43,11 @@ func GetDefaultInitializer(vt reflect.Type) (unsafe.Pointer, error) {
 
     /* find the default initializer method */
     if mt, ok = et.MethodByName("InitDefault"); ok {
-        return nil, fmt.Errorf("implementation of `InitDefault()` must have a pointer receiver: %s", mt.Type)
+        return nil, utils.EKindf(utils.ErrUnsupportedType, "implementation of `InitDefault()` must have a pointer receiver: %s", mt.Type)
     } else if mt, ok = pt.MethodByName("InitDefault"); !ok {
         return nil, nil
     } else if mt.Type.NumIn() != 1 || mt.Type.NumOut() != 0 {
-        return nil, fmt.Errorf("invalid implementation of `InitDefault()`: %s", mt.Type)
+        return nil, utils.EKindf(utils.ErrUnsupportedType, "invalid implementation of `InitDefault()`: %s", mt.Type)
     } else {
         return *(*[2]*unsafe.Pointer)(unsafe.Pointer(&mt.Func))[1], nil
     }
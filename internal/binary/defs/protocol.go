@@ -0,0 +1,100 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package defs
+
+import (
+    `fmt`
+)
+
+type Protocol uint8
+
+const (
+    Binary Protocol = iota
+    Compact
+)
+
+func (self Protocol) String() string {
+    switch self {
+        case Binary  : return "binary"
+        case Compact : return "compact"
+        default      : return fmt.Sprintf("Protocol(%d)", self)
+    }
+}
+
+/** Thrift Compact Protocol Types **/
+
+const (
+    C_stop   = 0x00
+    C_true   = 0x01
+    C_false  = 0x02
+    C_byte   = 0x03
+    C_i16    = 0x04
+    C_i32    = 0x05
+    C_i64    = 0x06
+    C_double = 0x07
+    C_binary = 0x08
+    C_list   = 0x09
+    C_set    = 0x0a
+    C_map    = 0x0b
+    C_struct = 0x0c
+)
+
+var compactTypes = [256]uint8 {
+    T_bool   : C_true,
+    T_i8     : C_byte,
+    T_double : C_double,
+    T_i16    : C_i16,
+    T_i32    : C_i32,
+    T_i64    : C_i64,
+    T_string : C_binary,
+    T_struct : C_struct,
+    T_map    : C_map,
+    T_set    : C_set,
+    T_list   : C_list,
+}
+
+var wireTypes = [16]Tag {
+    C_true   : T_bool,
+    C_false  : T_bool,
+    C_byte   : T_i8,
+    C_i16    : T_i16,
+    C_i32    : T_i32,
+    C_i64    : T_i64,
+    C_double : T_double,
+    C_binary : T_string,
+    C_list   : T_list,
+    C_set    : T_set,
+    C_map    : T_map,
+    C_struct : T_struct,
+}
+
+// CompactTag returns the wire tag of a Compact Protocol type, the second
+// return value is false if v is not a valid type.
+func CompactTag(v uint8) (Tag, bool) {
+    if v >= 16 || wireTypes[v] == 0 {
+        return 0, false
+    } else {
+        return wireTypes[v], true
+    }
+}
+
+// Compact returns the Compact Protocol type of a wire tag, booleans are
+// represented as C_true, since the value is carried by the type itself.
+func (self Tag) Compact() uint8 {
+    return compactTypes[self]
+}
@@ -24,6 +24,8 @@ import (
     `strconv`
     `strings`
     `sync`
+
+    `github.com/cloudwego/frugal/internal/utils`
 )
 
 type (
@@ -33,6 +35,7 @@ type (
 
 const (
     NoCopy Options = 1 << iota
+    Unsigned
 )
 
 const (
@@ -41,6 +44,11 @@ const (
     Optional
 )
 
+const (
+    UnionMarker   = "union"
+    UnknownFields = "_unknown"
+)
+
 func (self Options) String() string {
     nb := bits.OnesCount8(uint8(self))
     ret := make([]string, 0, nb)
@@ -50,6 +58,11 @@ func (self Options) String() string {
         ret = append(ret, "nocopy")
     }
 
+    /* check for "unsigned" option */
+    if self & Unsigned != 0 {
+        ret = append(ret, "unsigned")
+    }
+
     /* join them together */
     return fmt.Sprintf(
         "{%s}",
@@ -69,21 +82,43 @@ func (self Requiredness) String() string {
 type Field struct {
     F       int
     ID      uint16
+    Name    string
     Type    *Type
     Opts    Options
     Spec    Requiredness
     Default reflect.Value
 }
 
+type _Fields struct {
+    fv []Field
+    uf int
+    un bool
+}
+
 var (
     fieldsLock  = new(sync.RWMutex)
-    fieldsCache = make(map[reflect.Type][]Field)
+    fieldsCache = make(map[reflect.Type]_Fields)
 )
 
 func ResolveFields(vt reflect.Type) ([]Field, error) {
+    fv, err := resolveFields(vt)
+    return fv.fv, err
+}
+
+func ResolveUnknownFields(vt reflect.Type) (int, error) {
+    fv, err := resolveFields(vt)
+    return fv.uf, err
+}
+
+func IsUnion(vt reflect.Type) (bool, error) {
+    fv, err := resolveFields(vt)
+    return fv.un, err
+}
+
+func resolveFields(vt reflect.Type) (_Fields, error) {
     var ok bool
     var ex error
-    var fv []Field
+    var fv _Fields
 
     /* attempt to find in cache */
     fieldsLock.RLock()
@@ -105,8 +140,18 @@ func ResolveFields(vt reflect.Type) ([]Field, error) {
     }
 
     /* still not found, do the actual resolving */
-    if fv, ex = doResolveFields(vt); ex != nil {
-        return nil, ex
+    if fv.fv, ex = doResolveFields(vt); ex != nil {
+        return _Fields{}, utils.EKind(utils.ErrUnsupportedType, ex)
+    }
+
+    /* find the unknown fields collector, if any */
+    if fv.uf, ex = doResolveUnknownFields(vt); ex != nil {
+        return _Fields{}, utils.EKind(utils.ErrUnsupportedType, ex)
+    }
+
+    /* check for unions */
+    if fv.un, ex = doResolveUnion(vt, fv.fv); ex != nil {
+        return _Fields{}, utils.EKind(utils.ErrUnsupportedType, ex)
     }
 
     /* update cache */
@@ -146,8 +191,8 @@ func doResolveFields(vt reflect.Type) ([]Field, error) {
             continue
         }
 
-        /* ignore fields that does not declare the "frugal" tag */
-        if tv, ok = sf.Tag.Lookup("frugal"); !ok {
+        /* ignore fields that does not declare the "frugal" tag, or the unknown fields collector */
+        if tv, ok = sf.Tag.Lookup("frugal"); !ok || strings.TrimSpace(tv) == UnknownFields {
             continue
         }
 
@@ -176,16 +221,6 @@ func doResolveFields(vt reflect.Type) ([]Field, error) {
             return nil, fmt.Errorf("duplicated field ID %d for field %s.%s", id, vt, sf.Name)
         }
 
-        /* only optional fields or structs can be pointers */
-        if pt = ParseType(sf.Type, strings.TrimSpace(ft[2])); rx != Optional && pt.T == T_pointer && pt.V.T != T_struct {
-            return nil, fmt.Errorf("only optional fields or structs can be pointers, not %s: %s.%s", sf.Type, vt, sf.Name)
-        }
-
-        /* check for nested pointers */
-        if pt.T == T_pointer && pt.V.T == T_pointer {
-            return nil, fmt.Errorf("struct fields cannot have nested pointers: %s.%s", vt, sf.Name)
-        }
-
         /* scan for the options */
         for _, opt := range ft[3:] {
             switch opt {
@@ -195,17 +230,39 @@ func doResolveFields(vt reflect.Type) ([]Field, error) {
 
                 /* "nocopy" option enables zero-copy string decoding */
                 case "nocopy": {
-                    if pt.Tag() != T_string {
-                        return nil, fmt.Errorf(`"nocopy" is only applicable to "string" and "binary" types, not %s`, pt)
-                    } else if fv & NoCopy != 0 {
+                    if fv & NoCopy != 0 {
                         return nil, fmt.Errorf(`duplicated option "nocopy" for field %s.%s`, vt, sf.Name)
                     } else {
                         fv |= NoCopy
                     }
                 }
+
+                /* "unsigned" option maps unsigned integers to signed integer types */
+                case "unsigned": {
+                    if fv & Unsigned != 0 {
+                        return nil, fmt.Errorf(`duplicated option "unsigned" for field %s.%s`, vt, sf.Name)
+                    } else {
+                        fv |= Unsigned
+                    }
+                }
             }
         }
 
+        /* only optional fields or structs can be pointers */
+        if pt = ParseFieldType(sf.Type, strings.TrimSpace(ft[2]), fv); rx != Optional && pt.T == T_pointer && pt.V.T != T_struct {
+            return nil, fmt.Errorf("only optional fields or structs can be pointers, not %s: %s.%s", sf.Type, vt, sf.Name)
+        }
+
+        /* check for nested pointers */
+        if pt.T == T_pointer && pt.V.T == T_pointer {
+            return nil, fmt.Errorf("struct fields cannot have nested pointers: %s.%s", vt, sf.Name)
+        }
+
+        /* zero-copy decoding only works with variable-length strings and binaries */
+        if fv & NoCopy != 0 && (pt.Tag() != T_string || pt.T == T_array || pt.T == T_pointer && pt.V.T == T_array) {
+            return nil, fmt.Errorf(`"nocopy" is only applicable to "string" and "binary" types, not %s`, pt)
+        }
+
         /* get the default value if any */
         if mem.IsValid() {
             rv = mem.FieldByIndex(sf.Index)
@@ -215,6 +272,7 @@ func doResolveFields(vt reflect.Type) ([]Field, error) {
         ret = append(ret, Field {
             F       : int(sf.Offset),
             ID      : uint16(id),
+            Name    : fieldName(sf),
             Type    : pt,
             Opts    : fv,
             Spec    : rx,
@@ -226,3 +284,92 @@ func doResolveFields(vt reflect.Type) ([]Field, error) {
     sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
     return ret, nil
 }
+
+func fieldName(sf reflect.StructField) string {
+    tv, ok := sf.Tag.Lookup("thrift")
+    nm := strings.TrimSpace(strings.Split(tv, ",")[0])
+
+    /* use the Thrift IDL name if specified, otherwise the Go field name */
+    if ok && nm != "" {
+        return nm
+    } else {
+        return sf.Name
+    }
+}
+
+func doResolveUnknownFields(vt reflect.Type) (int, error) {
+    ret := -1
+    num := vt.NumField()
+
+    /* find the field that declares the "_unknown" tag */
+    for i := 0; i < num; i++ {
+        var ok bool
+        var tv string
+        var sf reflect.StructField
+
+        /* extract the field, ignore anonymous or private fields */
+        if sf = vt.Field(i); sf.Anonymous || sf.PkgPath != "" {
+            continue
+        }
+
+        /* ignore fields that are not the unknown fields collector */
+        if tv, ok = sf.Tag.Lookup("frugal"); !ok || strings.TrimSpace(tv) != UnknownFields {
+            continue
+        }
+
+        /* must be a byte slice */
+        if sf.Type != bytesType {
+            return -1, fmt.Errorf("unknown fields must be []byte, not %s: %s.%s", sf.Type, vt, sf.Name)
+        }
+
+        /* there can be at most one collector */
+        if ret >= 0 {
+            return -1, fmt.Errorf("duplicated unknown fields for field %s.%s", vt, sf.Name)
+        }
+
+        /* record the field offset */
+        ret = int(sf.Offset)
+    }
+
+    /* all done */
+    return ret, nil
+}
+
+func doResolveUnion(vt reflect.Type, fv []Field) (bool, error) {
+    var ok bool
+    var tv string
+    var sf reflect.StructField
+
+    /* find the blank field that declares the "union" tag */
+    for i := 0; i < vt.NumField() && !ok; i++ {
+        if sf = vt.Field(i); sf.Name == "_" {
+            tv, _ = sf.Tag.Lookup("frugal")
+            ok = strings.TrimSpace(tv) == UnionMarker
+        }
+    }
+
+    /* not a union */
+    if !ok {
+        return false, nil
+    }
+
+    /* union members must be optional, and can be checked against nil */
+    for _, f := range fv {
+        switch f.Type.T {
+            case T_pointer : break
+            case T_binary  : break
+            case T_map     : break
+            case T_set     : break
+            case T_list    : break
+            default        : return false, fmt.Errorf("union members must be nullable, not %s: field %d of %s", f.Type, f.ID, vt)
+        }
+
+        /* check for requiredness */
+        if f.Spec != Optional {
+            return false, fmt.Errorf("union members must be optional, not %s: field %d of %s", f.Spec, f.ID, vt)
+        }
+    }
+
+    /* all done */
+    return true, nil
+}
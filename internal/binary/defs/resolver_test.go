@@ -19,6 +19,7 @@ package defs
 import (
     `reflect`
     `testing`
+    `unsafe`
 
     `github.com/davecgh/go-spew/spew`
     `github.com/stretchr/testify/require`
@@ -37,3 +38,59 @@ func TestResolver_StringOptions(t *testing.T) {
     spew.Config.DisablePointerMethods = true
     spew.Dump(ret)
 }
+
+type UnknownFieldsCollector struct {
+    A int32  `frugal:"1,default,i32"`
+    U []byte `frugal:"_unknown"`
+}
+
+type UnknownFieldsInvalid struct {
+    U string `frugal:"_unknown"`
+}
+
+func TestResolver_UnknownFields(t *testing.T) {
+    var vv UnknownFieldsCollector
+    ret, err := ResolveFields(reflect.TypeOf(vv))
+    require.NoError(t, err)
+    require.Len(t, ret, 1)
+    off, err := ResolveUnknownFields(reflect.TypeOf(vv))
+    require.NoError(t, err)
+    require.Equal(t, int(unsafe.Offsetof(vv.U)), off)
+    _, err = ResolveFields(reflect.TypeOf(UnknownFieldsInvalid{}))
+    require.Error(t, err)
+}
+
+type UnionFields struct {
+    _ struct{} `frugal:"union"`
+    A *int32   `frugal:"1,optional,i32"`
+    B []byte   `frugal:"2,optional,binary"`
+}
+
+type UnionFieldsInvalid struct {
+    _ struct{} `frugal:"union"`
+    A int32    `frugal:"1,default,i32"`
+}
+
+func TestResolver_Union(t *testing.T) {
+    un, err := IsUnion(reflect.TypeOf(UnionFields{}))
+    require.NoError(t, err)
+    require.True(t, un)
+    un, err = IsUnion(reflect.TypeOf(NoCopyStringFields{}))
+    require.NoError(t, err)
+    require.False(t, un)
+    _, err = ResolveFields(reflect.TypeOf(UnionFieldsInvalid{}))
+    require.Error(t, err)
+}
+
+type NamedFields struct {
+    A int32 `frugal:"1,default,i32" thrift:"field_a,1,default"`
+    B int32 `frugal:"2,default,i32"`
+}
+
+func TestResolver_FieldNames(t *testing.T) {
+    ret, err := ResolveFields(reflect.TypeOf(NamedFields{}))
+    require.NoError(t, err)
+    require.Len(t, ret, 2)
+    require.Equal(t, "field_a", ret[0].Name)
+    require.Equal(t, "B", ret[1].Name)
+}
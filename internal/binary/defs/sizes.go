@@ -18,16 +18,22 @@ package defs
 
 import (
     `reflect`
-
-    `github.com/cloudwego/frugal/internal/utils`
 )
 
 const (
-    IntSize   = 4 << (^uint(0) >> 63)
-    StackSize = 1024
+    IntSize         = 4 << (^uint(0) >> 63)
+    StackSize       = 1024
+    FieldHeaderSize = 3     // field type and ID, in the Binary Protocol
 )
 
+// GetSize returns the fixed Binary Protocol size of vt, or -1 if the size is variable or
+// depends on the field annotations, like unsigned integers, float32 and byte arrays.
 func GetSize(vt reflect.Type) int {
+    if LookupCodec(vt) != nil {
+        return -1
+    }
+
+    /* check for the type kind */
     switch vt.Kind() {
         case reflect.Bool    : return 1
         case reflect.Int     : return IntSize
@@ -35,14 +41,14 @@ func GetSize(vt reflect.Type) int {
         case reflect.Int16   : return 2
         case reflect.Int32   : return 4
         case reflect.Int64   : return measureInt64(vt)
-        case reflect.Uint    : panic(utils.ENotSupp(vt, "int"))
-        case reflect.Uint8   : panic(utils.ENotSupp(vt, "int8"))
-        case reflect.Uint16  : panic(utils.ENotSupp(vt, "int16"))
-        case reflect.Uint32  : panic(utils.ENotSupp(vt, "int32"))
-        case reflect.Uint64  : panic(utils.ENotSupp(vt, "int64"))
-        case reflect.Float32 : panic(utils.ENotSupp(vt, "float64"))
+        case reflect.Uint    : return -1
+        case reflect.Uint8   : return -1
+        case reflect.Uint16  : return -1
+        case reflect.Uint32  : return -1
+        case reflect.Uint64  : return -1
+        case reflect.Float32 : return -1
         case reflect.Float64 : return 8
-        case reflect.Array   : panic(utils.ENotSupp(vt, "[]" + vt.Elem().String()))
+        case reflect.Array   : return -1
         case reflect.Map     : return -1
         case reflect.Ptr     : return -1
         case reflect.Slice   : return -1
@@ -52,6 +58,16 @@ func GetSize(vt reflect.Type) int {
     }
 }
 
+func GetIntSize(t Tag) int {
+    switch t {
+        case T_i8  : return 1
+        case T_i16 : return 2
+        case T_i32 : return 4
+        case T_i64 : return 8
+        default    : panic("invalid integer type tag")
+    }
+}
+
 func measureInt64(vt reflect.Type) int {
     if vt == i64type {
         return 8
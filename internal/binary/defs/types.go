@@ -29,20 +29,24 @@ import (
 type Tag uint8
 
 const (
-    T_bool    Tag = 2
-    T_i8      Tag = 3
-    T_double  Tag = 4
-    T_i16     Tag = 6
-    T_i32     Tag = 8
-    T_i64     Tag = 10
-    T_string  Tag = 11
-    T_struct  Tag = 12
-    T_map     Tag = 13
-    T_set     Tag = 14
-    T_list    Tag = 15
-    T_enum    Tag = 0x80
-    T_binary  Tag = 0x81
-    T_pointer Tag = 0x82
+    T_bool     Tag = 2
+    T_i8       Tag = 3
+    T_double   Tag = 4
+    T_i16      Tag = 6
+    T_i32      Tag = 8
+    T_i64      Tag = 10
+    T_string   Tag = 11
+    T_struct   Tag = 12
+    T_map      Tag = 13
+    T_set      Tag = 14
+    T_list     Tag = 15
+    T_enum     Tag = 0x80
+    T_binary   Tag = 0x81
+    T_pointer  Tag = 0x82
+    T_unsigned Tag = 0x83
+    T_float    Tag = 0x84
+    T_array    Tag = 0x85
+    T_codec    Tag = 0x86
 )
 
 var wireTags = [256]bool {
@@ -59,6 +63,20 @@ var wireTags = [256]bool {
     T_list   : true,
 }
 
+var tagNames = [256]string {
+    T_bool   : "bool",
+    T_i8     : "i8",
+    T_double : "double",
+    T_i16    : "i16",
+    T_i32    : "i32",
+    T_i64    : "i64",
+    T_string : "string",
+    T_struct : "struct",
+    T_map    : "map",
+    T_set    : "set",
+    T_list   : "list",
+}
+
 var keywordTab = [256]string {
     T_bool   : "bool",
     T_i8     : "i8 byte",
@@ -73,7 +91,8 @@ var keywordTab = [256]string {
 }
 
 var (
-    i64type = reflect.TypeOf(int64(0))
+    i64type   = reflect.TypeOf(int64(0))
+    bytesType = reflect.TypeOf([]byte(nil))
 )
 
 func T_int() Tag {
@@ -88,6 +107,14 @@ func (self Tag) IsWireTag() bool {
     return wireTags[self]
 }
 
+func (self Tag) String() string {
+    if tagNames[self] != "" {
+        return tagNames[self]
+    } else {
+        return fmt.Sprintf("Tag(%d)", self)
+    }
+}
+
 type Type struct {
     T Tag
     K *Type
@@ -114,10 +141,14 @@ func resetType(p *Type) *Type {
 
 func (self *Type) Tag() Tag {
     switch self.T {
-        case T_enum    : return T_i32
-        case T_binary  : return T_string
-        case T_pointer : return self.V.Tag()
-        default        : return self.T
+        case T_enum     : return T_i32
+        case T_binary   : return T_string
+        case T_pointer  : return self.V.Tag()
+        case T_unsigned : return self.V.T
+        case T_float    : return T_double
+        case T_array    : return T_string
+        case T_codec    : return self.V.Tag()
+        default         : return self.T
     }
 }
 
@@ -127,21 +158,25 @@ func (self *Type) Free() {
 
 func (self *Type) String() string {
     switch self.T {
-        case T_bool    : return "bool"
-        case T_i8      : return "i8"
-        case T_double  : return "double"
-        case T_i16     : return "i16"
-        case T_i32     : return "i32"
-        case T_i64     : return "i64"
-        case T_string  : return "string"
-        case T_struct  : return self.S.Name()
-        case T_map     : return fmt.Sprintf("map<%s:%s>", self.K.String(), self.V.String())
-        case T_set     : return fmt.Sprintf("set<%s>", self.V.String())
-        case T_list    : return fmt.Sprintf("list<%s>", self.V.String())
-        case T_enum    : return "enum"
-        case T_binary  : return "binary"
-        case T_pointer : return "*" + self.V.String()
-        default        : return fmt.Sprintf("Type(Tag(%d))", self.T)
+        case T_bool     : return "bool"
+        case T_i8       : return "i8"
+        case T_double   : return "double"
+        case T_i16      : return "i16"
+        case T_i32      : return "i32"
+        case T_i64      : return "i64"
+        case T_string   : return "string"
+        case T_struct   : return self.S.Name()
+        case T_map      : return fmt.Sprintf("map<%s:%s>", self.K.String(), self.V.String())
+        case T_set      : return fmt.Sprintf("set<%s>", self.V.String())
+        case T_list     : return fmt.Sprintf("list<%s>", self.V.String())
+        case T_enum     : return "enum"
+        case T_binary   : return "binary"
+        case T_pointer  : return "*" + self.V.String()
+        case T_unsigned : return "unsigned " + self.V.String()
+        case T_float    : return "float"
+        case T_array    : return fmt.Sprintf("binary[%d]", self.S.Len())
+        case T_codec    : return fmt.Sprintf("%s(%s)", self.V.String(), self.S)
+        default         : return fmt.Sprintf("Type(Tag(%d))", self.T)
     }
 }
 
@@ -175,8 +210,12 @@ func (self *Type) IsSimpleType() bool {
 }
 
 func ParseType(vt reflect.Type, def string) *Type {
+    return ParseFieldType(vt, def, 0)
+}
+
+func ParseFieldType(vt reflect.Type, def string, opts Options) *Type {
     var i int
-    return doParseType(vt, def, &i, true)
+    return doParseType(vt, def, &i, true, opts)
 }
 
 func isident(c byte) bool {
@@ -233,7 +272,7 @@ func mkMistyped(pos int, src string, tv string, tag Tag, vt reflect.Type) utils.
     }
 }
 
-func doParseType(vt reflect.Type, def string, i *int, allowPtrs bool) *Type {
+func doParseType(vt reflect.Type, def string, i *int, allowPtrs bool, opts Options) *Type {
     tag := Tag(0)
     ret := newType()
 
@@ -241,10 +280,15 @@ func doParseType(vt reflect.Type, def string, i *int, allowPtrs bool) *Type {
     if allowPtrs && vt.Kind() == reflect.Ptr {
         ret.S = vt
         ret.T = T_pointer
-        ret.V = doParseType(vt.Elem(), def, i, false)
+        ret.V = doParseType(vt.Elem(), def, i, false, opts)
         return ret
     }
 
+    /* types with user-defined codecs */
+    if cc := LookupCodec(vt); cc != nil {
+        return doParseCodec(vt, def, i, cc, ret)
+    }
+
     /* check for value kind */
     switch vt.Kind() {
         case reflect.Bool    : tag = T_bool
@@ -253,14 +297,14 @@ func doParseType(vt reflect.Type, def string, i *int, allowPtrs bool) *Type {
         case reflect.Int16   : tag = T_i16
         case reflect.Int32   : tag = T_i32
         case reflect.Int64   : tag = T_i64
-        case reflect.Uint    : panic(utils.ENotSupp(vt, "int"))
-        case reflect.Uint8   : panic(utils.ENotSupp(vt, "int8"))
-        case reflect.Uint16  : panic(utils.ENotSupp(vt, "int16"))
-        case reflect.Uint32  : panic(utils.ENotSupp(vt, "int32"))
-        case reflect.Uint64  : panic(utils.ENotSupp(vt, "int64"))
-        case reflect.Float32 : panic(utils.ENotSupp(vt, "float64"))
+        case reflect.Uint    : return doParseUnsigned(vt, def, i, opts, ret, "int")
+        case reflect.Uint8   : return doParseUnsigned(vt, def, i, opts, ret, "int8")
+        case reflect.Uint16  : return doParseUnsigned(vt, def, i, opts, ret, "int16")
+        case reflect.Uint32  : return doParseUnsigned(vt, def, i, opts, ret, "int32")
+        case reflect.Uint64  : return doParseUnsigned(vt, def, i, opts, ret, "int64")
+        case reflect.Float32 : return doParseFloat(vt, def, i, ret)
         case reflect.Float64 : tag = T_double
-        case reflect.Array   : panic(utils.ENotSupp(vt, "[]" + vt.Elem().String()))
+        case reflect.Array   : return doParseArray(vt, def, i, ret)
         case reflect.Map     : tag = T_map
         case reflect.Slice   : break
         case reflect.String  : tag = T_string
@@ -275,7 +319,7 @@ func doParseType(vt reflect.Type, def string, i *int, allowPtrs bool) *Type {
         } else if def == "" {
             panic(utils.ESetList(*i, def, et))
         } else {
-            return doParseSlice(vt, et, def, i, ret)
+            return doParseSlice(vt, et, def, i, ret, opts)
         }
     }
 
@@ -307,7 +351,7 @@ func doParseType(vt reflect.Type, def string, i *int, allowPtrs bool) *Type {
     /* parse the key type */
     vi := *i
     kt := vt.Key()
-    ret.K = doParseType(kt, def, i, true)
+    ret.K = doParseType(kt, def, i, true, opts)
 
     /* validate map key */
     if !ret.K.IsKeyType() {
@@ -323,7 +367,7 @@ func doParseType(vt reflect.Type, def string, i *int, allowPtrs bool) *Type {
 
     /* parse the value type */
     et := vt.Elem()
-    ret.V = doParseType(et, def, i, true)
+    ret.V = doParseType(et, def, i, true, opts)
 
     /* map end */
     if def != "" {
@@ -338,7 +382,7 @@ func doParseType(vt reflect.Type, def string, i *int, allowPtrs bool) *Type {
     return ret
 }
 
-func doParseSlice(vt reflect.Type, et reflect.Type, def string, i *int, rt *Type) *Type {
+func doParseSlice(vt reflect.Type, et reflect.Type, def string, i *int, rt *Type, opts Options) *Type {
     tk := nextToken(def, i)
     tp := *i - len(tk)
 
@@ -357,7 +401,7 @@ func doParseSlice(vt reflect.Type, et reflect.Type, def string, i *int, rt *Type
     }
 
     /* set or list element */
-    rt.V = doParseType(et, def, i, true)
+    rt.V = doParseType(et, def, i, true, opts)
     tk   = nextToken(def, i)
 
     /* list or set end */
@@ -370,6 +414,110 @@ func doParseSlice(vt reflect.Type, et reflect.Type, def string, i *int, rt *Type
     return rt
 }
 
+func doParseCodec(vt reflect.Type, def string, i *int, cc *Codec, rt *Type) *Type {
+    wt := cc.Wire
+
+    /* raw values without their own types can be of any wire type */
+    if def != "" && cc.Raw && cc.Elem == nil {
+        wt = doParseRawWire(vt, def, i)
+    } else if def != "" {
+        if tv := nextToken(def, i); !strings.Contains(keywordTab[cc.Wire], tv) {
+            panic(mkMistyped(*i - len(tv), def, tv, cc.Wire, vt))
+        }
+    }
+
+    /* the wire type is kept as the element */
+    rt.S = vt
+    rt.T = T_codec
+    rt.V = newType()
+    rt.V.S = vt
+    rt.V.T = wt
+    return rt
+}
+
+func doParseRawWire(vt reflect.Type, def string, i *int) Tag {
+    tv := nextToken(def, i)
+    wt, ok := rawWireTypes[tv]
+
+    /* must be a valid wire type */
+    if !ok {
+        panic(utils.ESyntax(*i - len(tv), def, fmt.Sprintf("wire type expected for raw values of %s, got %s", vt, tv)))
+    }
+
+    /* the type arguments of containers are accepted but not used */
+    if p := *i; (wt == T_map || wt == T_set || wt == T_list) && readToken(def, &p, true) == "<" {
+        for n := 0; ; {
+            switch nextToken(def, i) {
+                case "<" : n++
+                case ">" : if n--; n == 0 { return wt }
+            }
+        }
+    }
+
+    /* all done */
+    return wt
+}
+
+func doParseUnsigned(vt reflect.Type, def string, i *int, opts Options, rt *Type, alt string) *Type {
+    var wt Tag
+    var tk string
+
+    /* unsigned integers must be enabled explicitly with the "unsigned" option */
+    if def == "" || opts & Unsigned == 0 {
+        panic(utils.ENotSupp(vt, alt))
+    }
+
+    /* the wire type must be a signed integer type */
+    switch tk = nextToken(def, i); tk {
+        case "i8"   : wt = T_i8
+        case "byte" : wt = T_i8
+        case "i16"  : wt = T_i16
+        case "i32"  : wt = T_i32
+        case "i64"  : wt = T_i64
+        default     : panic(utils.ESyntax(*i - len(tk), def, "integer type expected for unsigned integers"))
+    }
+
+    /* the wire type is kept as the element */
+    rt.S = vt
+    rt.T = T_unsigned
+    rt.V = newType()
+    rt.V.S = vt
+    rt.V.T = wt
+    return rt
+}
+
+func doParseFloat(vt reflect.Type, def string, i *int, rt *Type) *Type {
+    if def == "" {
+        panic(utils.ENotSupp(vt, "float64"))
+    }
+
+    /* float32 can only be transferred as double */
+    if tv := nextToken(def, i); tv != "double" {
+        panic(mkMistyped(*i - len(tv), def, tv, T_double, vt))
+    }
+
+    /* set the type */
+    rt.S = vt
+    rt.T = T_float
+    return rt
+}
+
+func doParseArray(vt reflect.Type, def string, i *int, rt *Type) *Type {
+    if def == "" || !utils.IsByteType(vt.Elem()) {
+        panic(utils.ENotSupp(vt, "[]" + vt.Elem().String()))
+    }
+
+    /* byte arrays can only be transferred as binary */
+    if tv := nextToken(def, i); tv != "binary" {
+        panic(mkMistyped(*i - len(tv), def, tv, T_binary, vt))
+    }
+
+    /* set the type */
+    rt.S = vt
+    rt.T = T_array
+    return rt
+}
+
 func doMatchStruct(vt reflect.Type, def string, i *int, tv *string) bool {
     sp := *i
     tn := vt.Name()
@@ -20,6 +20,8 @@ import (
     `fmt`
     `reflect`
     `testing`
+
+    `github.com/stretchr/testify/require`
 )
 
 func TestTypes_Parsing(t *testing.T) {
@@ -33,3 +35,13 @@ func TestTypes_MapKeyType(t *testing.T) {
     tt := ParseType(reflect.TypeOf(v), "map<foo.SliceHeader:i64>")
     fmt.Println(tt)
 }
+
+func TestTypes_Unsigned(t *testing.T) {
+    require.Panics(t, func() { ParseType(reflect.TypeOf(uint32(0)), "i32") })
+    require.Panics(t, func() { ParseFieldType(reflect.TypeOf(uint32(0)), "string", Unsigned) })
+    require.Equal(t, "unsigned i32", ParseFieldType(reflect.TypeOf(uint32(0)), "i32", Unsigned).String())
+    require.Equal(t, "list<unsigned i16>", ParseFieldType(reflect.TypeOf([]uint16(nil)), "list<i16>", Unsigned).String())
+    require.Equal(t, "float", ParseType(reflect.TypeOf(float32(0)), "double").String())
+    require.Equal(t, "binary[16]", ParseType(reflect.TypeOf([16]byte{}), "binary").String())
+    require.Panics(t, func() { ParseType(reflect.TypeOf([4]int32{}), "binary") })
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoder
+
+import (
+    `encoding/binary`
+    `fmt`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+func error_codec(cc *defs.Codec, n int, m int) error {
+    return fmt.Errorf("frugal: codec of %s wrote %d bytes, %d expected", cc.Type, n, m)
+}
+
+func codec_call(cc *defs.Codec, p unsafe.Pointer, buf unsafe.Pointer, n int) error {
+    if m, err := cc.Encode(rt.BytesFrom(buf, n, n), cc.Value(p)); err != nil {
+        return err
+    } else if m != n {
+        return error_codec(cc, m, n)
+    } else {
+        return nil
+    }
+}
+
+func codec_length(cc *defs.Codec, p unsafe.Pointer) (int, error) {
+    if n := cc.Size(cc.Value(p)); n < 0 {
+        return 0, fmt.Errorf("frugal: codec of %s returned a negative size: %d", cc.Type, n)
+    } else {
+        return n, nil
+    }
+}
+
+func codec_varint(cc *defs.Codec, p unsafe.Pointer) (uint64, error) {
+    var v uint64
+    var b [8]byte
+
+    /* encode the value into the temporary buffer */
+    n := cc.FixedSize()
+    err := codec_call(cc, p, unsafe.Pointer(&b), n)
+
+    /* check for errors */
+    if err != nil {
+        return 0, err
+    }
+
+    /* load the big-endian value */
+    switch n {
+        case 2  : v = uint64(binary.BigEndian.Uint16(b[:]))
+        case 4  : v = uint64(binary.BigEndian.Uint32(b[:]))
+        case 8  : v = binary.BigEndian.Uint64(b[:])
+        default : panic("can only load 2, 4 or 8 bytes at a time")
+    }
+
+    /* sign-extend and zig-zag encode the value */
+    return zigzag(signext(v, n)), nil
+}
+
+func codec_size(cc *defs.Codec, p unsafe.Pointer, pt int) (int, error) {
+    var n int
+    var v uint64
+    var err error
+
+    /* raw values are written as is */
+    if cc.Raw {
+        return codec_raw_size(cc, p, pt)
+    }
+
+    /* fixed-size types, integers are variable-length in Compact Protocol */
+    if n = cc.FixedSize(); n >= 0 {
+        if defs.Protocol(pt) != defs.Compact || n == 1 || cc.Wire == defs.T_double {
+            return n, nil
+        } else if v, err = codec_varint(cc, p); err != nil {
+            return 0, err
+        } else {
+            return uvlen(v), nil
+        }
+    }
+
+    /* strings and binaries, with the length prefix */
+    if n, err = codec_length(cc, p); err != nil {
+        return 0, err
+    } else if defs.Protocol(pt) == defs.Compact {
+        return uvlen(uint64(n)) + n, nil
+    } else {
+        return n + 4, nil
+    }
+}
+
+func codec_raw_size(cc *defs.Codec, p unsafe.Pointer, pt int) (int, error) {
+    if n, err := cc.RawValue(p).RawSize(defs.Protocol(pt)); err != nil {
+        return 0, err
+    } else if n < 0 {
+        return 0, fmt.Errorf("frugal: raw value of %s returned a negative size: %d", cc.Type, n)
+    } else {
+        return n, nil
+    }
+}
+
+func codec_encode(cc *defs.Codec, p unsafe.Pointer, buf unsafe.Pointer, i int, nb int, pt int) (int, error) {
+    if cc.Raw {
+        return codec_encode_raw(cc, p, buf, i, nb, pt)
+    } else if cc.FixedSize() < 0 {
+        return codec_encode_str(cc, p, buf, i, nb, pt)
+    } else {
+        return codec_encode_val(cc, p, buf, i, nb, pt)
+    }
+}
+
+func codec_encode_raw(cc *defs.Codec, p unsafe.Pointer, buf unsafe.Pointer, i int, nb int, pt int) (int, error) {
+    var m int
+    var n int
+    var err error
+
+    /* measure the value */
+    if n, err = codec_raw_size(cc, p, pt); err != nil {
+        return 0, err
+    }
+
+    /* check for buffer space */
+    if i + n > nb {
+        return i + n, nil
+    }
+
+    /* write the value */
+    if m, err = cc.RawValue(p).RawEncode(rt.BytesFrom(unsafe.Pointer(uintptr(buf) + uintptr(i)), n, n), defs.Protocol(pt)); err != nil {
+        return 0, err
+    } else if m != n {
+        return 0, error_codec(cc, m, n)
+    } else {
+        return i + n, nil
+    }
+}
+
+func codec_encode_str(cc *defs.Codec, p unsafe.Pointer, buf unsafe.Pointer, i int, nb int, pt int) (int, error) {
+    var k int
+    var n int
+    var err error
+
+    /* measure the value */
+    if n, err = codec_length(cc, p); err != nil {
+        return 0, err
+    }
+
+    /* size of the length prefix */
+    if defs.Protocol(pt) == defs.Compact {
+        k = uvlen(uint64(n))
+    } else {
+        k = 4
+    }
+
+    /* check for buffer space */
+    if i + k + n > nb {
+        return i + k + n, nil
+    }
+
+    /* write the length prefix */
+    if dp := unsafe.Pointer(uintptr(buf) + uintptr(i)); k == 4 {
+        binary.BigEndian.PutUint32(rt.BytesFrom(dp, 4, 4), uint32(n))
+    } else {
+        uvput(dp, uint64(n))
+    }
+
+    /* write the value */
+    if err = codec_call(cc, p, unsafe.Pointer(uintptr(buf) + uintptr(i + k)), n); err != nil {
+        return 0, err
+    } else {
+        return i + k + n, nil
+    }
+}
+
+func codec_encode_val(cc *defs.Codec, p unsafe.Pointer, buf unsafe.Pointer, i int, nb int, pt int) (int, error) {
+    n := cc.FixedSize()
+    dp := unsafe.Pointer(uintptr(buf) + uintptr(i))
+
+    /* integers are zig-zag encoded varints in Compact Protocol */
+    if defs.Protocol(pt) == defs.Compact && n != 1 && cc.Wire != defs.T_double {
+        if v, err := codec_varint(cc, p); err != nil {
+            return 0, err
+        } else if i + uvlen(v) > nb {
+            return i + uvlen(v), nil
+        } else {
+            return i + uvput(dp, v), nil
+        }
+    }
+
+    /* check for buffer space */
+    if i + n > nb {
+        return i + n, nil
+    }
+
+    /* write the value */
+    if err := codec_call(cc, p, dp, n); err != nil {
+        return 0, err
+    }
+
+    /* doubles are little-endian in Compact Protocol */
+    if defs.Protocol(pt) == defs.Compact && cc.Wire == defs.T_double {
+        b := rt.BytesFrom(dp, n, n)
+        binary.LittleEndian.PutUint64(b, binary.BigEndian.Uint64(b))
+    }
+
+    /* all done */
+    return i + n, nil
+}
+
+var (
+    F_codec_size   = hir.RegisterGCall(codec_size, emu_gcall_codec_size)
+    F_codec_encode = hir.RegisterGCall(codec_encode, emu_gcall_codec_encode)
+)
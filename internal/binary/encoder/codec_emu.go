@@ -0,0 +1,38 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoder
+
+import (
+    `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+)
+
+func emu_gcall_codec_size(ctx hir.CallContext) {
+    if !ctx.Verify("**i", "i**") {
+        panic("invalid codec_size call")
+    } else {
+        emu_setret(ctx)(codec_size((*defs.Codec)(ctx.Ap(0)), ctx.Ap(1), int(ctx.Au(2))))
+    }
+}
+
+func emu_gcall_codec_encode(ctx hir.CallContext) {
+    if !ctx.Verify("***iii", "i**") {
+        panic("invalid codec_encode call")
+    } else {
+        emu_setret(ctx)(codec_encode((*defs.Codec)(ctx.Ap(0)), ctx.Ap(1), ctx.Ap(2), int(ctx.Au(3)), int(ctx.Au(4)), int(ctx.Au(5))))
+    }
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package encoder
+
+import (
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/atm/hir`
+)
+
+const (
+    MaxVarint16 = 3
+    MaxVarint32 = 5
+    MaxVarint64 = 10
+)
+
+func loadint(p unsafe.Pointer, w int) int64 {
+    switch w {
+        case 1  : return int64(*(*int8)(p))
+        case 2  : return int64(*(*int16)(p))
+        case 4  : return int64(*(*int32)(p))
+        case 8  : return *(*int64)(p)
+        default : panic("can only load 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func signext(v uint64, w int) int64 {
+    switch w {
+        case 1  : return int64(int8(v))
+        case 2  : return int64(int16(v))
+        case 4  : return int64(int32(v))
+        case 8  : return int64(v)
+        default : panic("can only extend 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func zigzag(v int64) uint64 {
+    return uint64(v << 1) ^ uint64(v >> 63)
+}
+
+func uvlen(v uint64) int {
+    n := 1
+    v >>= 7
+
+    /* 7 bits for each byte */
+    for v != 0 {
+        n++
+        v >>= 7
+    }
+
+    /* all done */
+    return n
+}
+
+func uvput(buf unsafe.Pointer, v uint64) int {
+    n := 0
+    p := (*[MaxVarint64]byte)(buf)
+
+    /* 7 bits at a time, least significant group first */
+    for v >= 0x80 {
+        p[n] = byte(v) | 0x80
+        v >>= 7
+        n++
+    }
+
+    /* the last byte */
+    p[n] = byte(v)
+    return n + 1
+}
+
+func u8append(buf unsafe.Pointer, i int, nb int, v byte) int {
+    if i >= nb {
+        return i + 1
+    } else {
+        *(*byte)(unsafe.Pointer(uintptr(buf) + uintptr(i))) = v
+        return i + 1
+    }
+}
+
+func uvappend(buf unsafe.Pointer, i int, nb int, v uint64) int {
+    if n := uvlen(v); i + n > nb {
+        return i + n
+    } else {
+        return i + uvput(unsafe.Pointer(uintptr(buf) + uintptr(i)), v)
+    }
+}
+
+func compact_varint(buf unsafe.Pointer, i int, nb int, p unsafe.Pointer, w int) int {
+    return uvappend(buf, i, nb, zigzag(loadint(p, w)))
+}
+
+func compact_uint(buf unsafe.Pointer, i int, nb int, v uint64, w int) int {
+    return uvappend(buf, i, nb, zigzag(signext(v, w)))
+}
+
+func compact_uvarint(buf unsafe.Pointer, i int, nb int, v uint64) int {
+    return uvappend(buf, i, nb, v)
+}
+
+func compact_map_head(buf unsafe.Pointer, i int, nb int, n int, kv int) int {
+    if n == 0 {
+        return u8append(buf, i, nb, 0)
+    } else {
+        return u8append(buf, uvappend(buf, i, nb, uint64(n)), nb, byte(kv))
+    }
+}
+
+func compact_list_head(buf unsafe.Pointer, i int, nb int, n int, et int) int {
+    if n < 15 {
+        return u8append(buf, i, nb, byte(n << 4 | et))
+    } else {
+        return uvappend(buf, u8append(buf, i, nb, byte(0xf0 | et)), nb, uint64(n))
+    }
+}
+
+func compact_field_head(buf unsafe.Pointer, i int, nb int, p unsafe.Pointer, id int, tt int) int {
+    dv := id - *(*int)(p)
+    *(*int)(p) = id
+
+    /* short form, delta encoded field ID with the type */
+    if dv > 0 && dv <= 15 {
+        return u8append(buf, i, nb, byte(dv << 4 | tt))
+    } else {
+        return uvappend(buf, u8append(buf, i, nb, byte(tt)), nb, zigzag(int64(int16(id))))
+    }
+}
+
+func compact_varint_size(p unsafe.Pointer, w int) int {
+    return uvlen(zigzag(loadint(p, w)))
+}
+
+func compact_uint_size(v uint64, w int) int {
+    return uvlen(zigzag(signext(v, w)))
+}
+
+func compact_uvarint_size(v uint64) int {
+    return uvlen(v)
+}
+
+func compact_field_size(p unsafe.Pointer, id int) int {
+    dv := id - *(*int)(p)
+    *(*int)(p) = id
+
+    /* short form, or the long form with a zigzag varint field ID */
+    if dv > 0 && dv <= 15 {
+        return 1
+    } else {
+        return uvlen(zigzag(int64(int16(id)))) + 1
+    }
+}
+
+func compact_map_size(n int) int {
+    if n == 0 {
+        return 1
+    } else {
+        return uvlen(uint64(n)) + 1
+    }
+}
+
+func compact_list_size(n int) int {
+    if n < 15 {
+        return 1
+    } else {
+        return uvlen(uint64(n)) + 1
+    }
+}
+
+var (
+    F_compact_varint       = hir.RegisterGCall(compact_varint, emu_gcall_compact_varint)
+    F_compact_uint         = hir.RegisterGCall(compact_uint, emu_gcall_compact_uint)
+    F_compact_uvarint      = hir.RegisterGCall(compact_uvarint, emu_gcall_compact_uvarint)
+    F_compact_map_head     = hir.RegisterGCall(compact_map_head, emu_gcall_compact_map_head)
+    F_compact_list_head    = hir.RegisterGCall(compact_list_head, emu_gcall_compact_list_head)
+    F_compact_field_head   = hir.RegisterGCall(compact_field_head, emu_gcall_compact_field_head)
+    F_compact_varint_size  = hir.RegisterGCall(compact_varint_size, emu_gcall_compact_varint_size)
+    F_compact_uint_size    = hir.RegisterGCall(compact_uint_size, emu_gcall_compact_uint_size)
+    F_compact_uvarint_size = hir.RegisterGCall(compact_uvarint_size, emu_gcall_compact_uvarint_size)
+    F_compact_field_size   = hir.RegisterGCall(compact_field_size, emu_gcall_compact_field_size)
+    F_compact_map_size     = hir.RegisterGCall(compact_map_size, emu_gcall_compact_map_size)
+    F_compact_list_size    = hir.RegisterGCall(compact_list_size, emu_gcall_compact_list_size)
+)
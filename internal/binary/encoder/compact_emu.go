@@ -0,0 +1,118 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package encoder
+
+import (
+    `github.com/cloudwego/frugal/internal/atm/hir`
+)
+
+func emu_gcall_compact_varint(ctx hir.CallContext) {
+    if !ctx.Verify("*ii*i", "i") {
+        panic("invalid compact_varint call")
+    } else {
+        ctx.Ru(0, uint64(compact_varint(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), ctx.Ap(3), int(ctx.Au(4)))))
+    }
+}
+
+func emu_gcall_compact_uint(ctx hir.CallContext) {
+    if !ctx.Verify("*iiii", "i") {
+        panic("invalid compact_uint call")
+    } else {
+        ctx.Ru(0, uint64(compact_uint(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), ctx.Au(3), int(ctx.Au(4)))))
+    }
+}
+
+func emu_gcall_compact_uvarint(ctx hir.CallContext) {
+    if !ctx.Verify("*iii", "i") {
+        panic("invalid compact_uvarint call")
+    } else {
+        ctx.Ru(0, uint64(compact_uvarint(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), ctx.Au(3))))
+    }
+}
+
+func emu_gcall_compact_map_head(ctx hir.CallContext) {
+    if !ctx.Verify("*iiii", "i") {
+        panic("invalid compact_map_head call")
+    } else {
+        ctx.Ru(0, uint64(compact_map_head(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), int(ctx.Au(3)), int(ctx.Au(4)))))
+    }
+}
+
+func emu_gcall_compact_list_head(ctx hir.CallContext) {
+    if !ctx.Verify("*iiii", "i") {
+        panic("invalid compact_list_head call")
+    } else {
+        ctx.Ru(0, uint64(compact_list_head(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), int(ctx.Au(3)), int(ctx.Au(4)))))
+    }
+}
+
+func emu_gcall_compact_field_head(ctx hir.CallContext) {
+    if !ctx.Verify("*ii*ii", "i") {
+        panic("invalid compact_field_head call")
+    } else {
+        ctx.Ru(0, uint64(compact_field_head(ctx.Ap(0), int(ctx.Au(1)), int(ctx.Au(2)), ctx.Ap(3), int(ctx.Au(4)), int(ctx.Au(5)))))
+    }
+}
+
+func emu_gcall_compact_varint_size(ctx hir.CallContext) {
+    if !ctx.Verify("*i", "i") {
+        panic("invalid compact_varint_size call")
+    } else {
+        ctx.Ru(0, uint64(compact_varint_size(ctx.Ap(0), int(ctx.Au(1)))))
+    }
+}
+
+func emu_gcall_compact_uint_size(ctx hir.CallContext) {
+    if !ctx.Verify("ii", "i") {
+        panic("invalid compact_uint_size call")
+    } else {
+        ctx.Ru(0, uint64(compact_uint_size(ctx.Au(0), int(ctx.Au(1)))))
+    }
+}
+
+func emu_gcall_compact_uvarint_size(ctx hir.CallContext) {
+    if !ctx.Verify("i", "i") {
+        panic("invalid compact_uvarint_size call")
+    } else {
+        ctx.Ru(0, uint64(compact_uvarint_size(ctx.Au(0))))
+    }
+}
+
+func emu_gcall_compact_field_size(ctx hir.CallContext) {
+    if !ctx.Verify("*i", "i") {
+        panic("invalid compact_field_size call")
+    } else {
+        ctx.Ru(0, uint64(compact_field_size(ctx.Ap(0), int(ctx.Au(1)))))
+    }
+}
+
+func emu_gcall_compact_map_size(ctx hir.CallContext) {
+    if !ctx.Verify("i", "i") {
+        panic("invalid compact_map_size call")
+    } else {
+        ctx.Ru(0, uint64(compact_map_size(int(ctx.Au(0)))))
+    }
+}
+
+func emu_gcall_compact_list_size(ctx hir.CallContext) {
+    if !ctx.Verify("i", "i") {
+        panic("invalid compact_list_size call")
+    } else {
+        ctx.Ru(0, uint64(compact_list_size(int(ctx.Au(0)))))
+    }
+}
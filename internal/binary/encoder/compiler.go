@@ -43,6 +43,10 @@ func (self Instr) Vt() *rt.GoType {
     return (*rt.GoType)(self.Pr)
 }
 
+func (self Instr) Codec() *defs.Codec {
+    return (*defs.Codec)(self.Pr)
+}
+
 func (self Instr) Str() string {
     return rt.StringFrom(self.Pr, int(self.Uv))
 }
@@ -63,34 +67,62 @@ func (self Instr) Quad(i int64) int64 {
     return *(*int64)(unsafe.Pointer(uintptr(self.Pr) + uintptr(i)))
 }
 
+func (self Instr) IntSeq() (p []int) {
+    (*rt.GoSlice)(unsafe.Pointer(&p)).Cap = int(self.Iv)
+    (*rt.GoSlice)(unsafe.Pointer(&p)).Len = int(self.Iv)
+    (*rt.GoSlice)(unsafe.Pointer(&p)).Ptr = self.Pr
+    return
+}
+
 func (self Instr) Disassemble() string {
     switch self.Op {
-        case OP_size_check    : fallthrough
-        case OP_size_const    : fallthrough
-        case OP_size_map      : fallthrough
-        case OP_seek          : fallthrough
-        case OP_sint          : fallthrough
-        case OP_length        : return fmt.Sprintf("%-18s%d", self.Op, self.Iv)
-        case OP_size_dyn      : fallthrough
-        case OP_memcpy_be     : return fmt.Sprintf("%-18s%d, %d", self.Op, self.Uv, self.Iv)
-        case OP_size_defer    : fallthrough
-        case OP_defer         : fallthrough
-        case OP_map_begin     : fallthrough
-        case OP_unique        : return fmt.Sprintf("%-18s%s", self.Op, self.Vt())
-        case OP_byte          : return fmt.Sprintf("%-18s0x%02x", self.Op, self.Iv)
-        case OP_word          : return fmt.Sprintf("%-18s0x%04x", self.Op, self.Iv)
-        case OP_long          : return fmt.Sprintf("%-18s0x%08x", self.Op, self.Iv)
-        case OP_quad          : return fmt.Sprintf("%-18s0x%016x", self.Op, self.Iv)
-        case OP_map_if_next   : fallthrough
-        case OP_map_if_empty  : fallthrough
-        case OP_list_if_next  : fallthrough
-        case OP_list_if_empty : fallthrough
-        case OP_goto          : fallthrough
-        case OP_if_nil        : fallthrough
-        case OP_if_hasbuf     : return fmt.Sprintf("%-18sL_%d", self.Op, self.To)
-        case OP_if_eq_imm     : return fmt.Sprintf("%-18s%d:%d, L_%d", self.Op, self.Iv, self.Uv, self.To)
-        case OP_if_eq_str     : return fmt.Sprintf("%-18s%q, L_%d", self.Op, self.Str(), self.To)
-        default               : return self.Op.String()
+        case OP_size_check     : fallthrough
+        case OP_size_const     : fallthrough
+        case OP_size_map       : fallthrough
+        case OP_size_varint    : fallthrough
+        case OP_size_field     : fallthrough
+        case OP_seek           : fallthrough
+        case OP_sint           : fallthrough
+        case OP_sint_le        : fallthrough
+        case OP_varint         : fallthrough
+        case OP_length_uv      : fallthrough
+        case OP_size_length_uv : fallthrough
+        case OP_memcpy         : fallthrough
+        case OP_length         : return fmt.Sprintf("%-18s%d", self.Op, self.Iv)
+        case OP_size_dyn       : fallthrough
+        case OP_size_varint_u  : fallthrough
+        case OP_uint           : fallthrough
+        case OP_varint_u       : fallthrough
+        case OP_field_head     : fallthrough
+        case OP_memcpy_be      : fallthrough
+        case OP_memcpy_le      : return fmt.Sprintf("%-18s%d, %d", self.Op, self.Uv, self.Iv)
+        case OP_size_defer     : fallthrough
+        case OP_defer          : return fmt.Sprintf("%-18s%s, %s", self.Op, self.Vt(), defs.Protocol(self.Iv))
+        case OP_size_codec     : fallthrough
+        case OP_codec          : return fmt.Sprintf("%-18s%s, %s", self.Op, self.Codec().Type, defs.Protocol(self.Iv))
+        case OP_map_begin      : fallthrough
+        case OP_map_sort_begin : fallthrough
+        case OP_set_sort_begin : fallthrough
+        case OP_unique         : return fmt.Sprintf("%-18s%s", self.Op, self.Vt())
+        case OP_union_check    : return fmt.Sprintf("%-18s%v", self.Op, self.IntSeq())
+        case OP_bool           : fallthrough
+        case OP_map_head       : fallthrough
+        case OP_list_head      : fallthrough
+        case OP_byte           : return fmt.Sprintf("%-18s0x%02x", self.Op, self.Iv)
+        case OP_word           : return fmt.Sprintf("%-18s0x%04x", self.Op, self.Iv)
+        case OP_long           : return fmt.Sprintf("%-18s0x%08x", self.Op, self.Iv)
+        case OP_quad           : return fmt.Sprintf("%-18s0x%016x", self.Op, self.Iv)
+        case OP_map_if_next    : fallthrough
+        case OP_map_if_empty   : fallthrough
+        case OP_list_if_next   : fallthrough
+        case OP_list_if_empty  : fallthrough
+        case OP_goto           : fallthrough
+        case OP_if_nil         : fallthrough
+        case OP_if_canonical   : fallthrough
+        case OP_if_hasbuf      : return fmt.Sprintf("%-18sL_%d", self.Op, self.To)
+        case OP_if_eq_imm      : return fmt.Sprintf("%-18s%d:%d, L_%d", self.Op, self.Iv, self.Uv, self.To)
+        case OP_if_eq_str      : return fmt.Sprintf("%-18s%q, L_%d", self.Op, self.Str(), self.To)
+        default                : return self.Op.String()
     }
 }
 
@@ -110,6 +142,15 @@ func (self *Program) i64(op OpCode, iv int64)           { self.ins(Instr { Op: o
 func (self *Program) str(op OpCode, sv string)          { self.ins(Instr { Op: op, Iv: int64(len(sv)), Pr: rt.StringPtr(sv) }) }
 func (self *Program) rtt(op OpCode, vt reflect.Type)    { self.ins(Instr { Op: op, Pr: unsafe.Pointer(rt.UnpackType(vt)) }) }
 func (self *Program) dyn(op OpCode, uv int32, iv int64) { self.ins(Instr { Op: op, Uv: uv, Iv: iv }) }
+func (self *Program) tab(op OpCode, tv []int)           { self.ins(Instr { Op: op, Iv: int64(len(tv)), Pr: (*rt.GoSlice)(unsafe.Pointer(&tv)).Ptr }) }
+
+func (self *Program) cdc(op OpCode, cc *defs.Codec, pt defs.Protocol) {
+    self.ins(Instr { Op: op, Iv: int64(pt), Pr: unsafe.Pointer(cc) })
+}
+
+func (self *Program) def(op OpCode, vt reflect.Type, pt defs.Protocol) {
+    self.ins(Instr { Op: op, Iv: int64(pt), Pr: unsafe.Pointer(rt.UnpackType(vt)) })
+}
 
 func (self Program) Free() {
     freeProgram(self)
@@ -146,6 +187,7 @@ func (self Program) Disassemble() string {
 
 type Compiler struct {
     o opts.Options
+    p defs.Protocol
     t map[reflect.Type]bool
 }
 
@@ -172,12 +214,17 @@ func (self *Compiler) Apply(o opts.Options) *Compiler {
     return self
 }
 
+func (self *Compiler) Protocol(p defs.Protocol) *Compiler {
+    self.p = p
+    return self
+}
+
 func (self *Compiler) Compile(vt reflect.Type) (_ Program, err error) {
     ret := newProgram()
-    vtp := defs.ParseType(vt, "")
-
-    /* catch the exceptions, and free the type */
     defer self.rescue(&err)
+
+    /* parse the type, and free it when done */
+    vtp := defs.ParseType(vt, "")
     defer vtp.Free()
 
     /* object measuring */
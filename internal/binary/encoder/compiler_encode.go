@@ -35,7 +35,7 @@ func (self *Compiler) compile(p *Program, sp int, vt *defs.Type, startpc int) {
 
     /* check for loops */
     if self.t[rt] || !self.o.CanInline(sp, (p.pc() - startpc) * 2) {
-        p.rtt(OP_defer, rt)
+        p.def(OP_defer, rt, self.p)
         return
     }
 
@@ -46,22 +46,32 @@ func (self *Compiler) compile(p *Program, sp int, vt *defs.Type, startpc int) {
 }
 
 func (self *Compiler) compileOne(p *Program, sp int, vt *defs.Type, startpc int) {
+    if self.p == defs.Compact {
+        self.compileCompact(p, sp, vt, startpc)
+        return
+    }
+
+    /* Binary Protocol */
     switch vt.T {
-        case defs.T_bool    : p.i64(OP_size_check, 1); p.i64(OP_sint, 1)
-        case defs.T_i8      : p.i64(OP_size_check, 1); p.i64(OP_sint, 1)
-        case defs.T_i16     : p.i64(OP_size_check, 2); p.i64(OP_sint, 2)
-        case defs.T_i32     : p.i64(OP_size_check, 4); p.i64(OP_sint, 4)
-        case defs.T_i64     : p.i64(OP_size_check, 8); p.i64(OP_sint, 8)
-        case defs.T_enum    : p.i64(OP_size_check, 4); p.i64(OP_sint, 4)
-        case defs.T_double  : p.i64(OP_size_check, 8); p.i64(OP_sint, 8)
-        case defs.T_string  : p.i64(OP_size_check, 4); p.i64(OP_length, abi.PtrSize); p.dyn(OP_memcpy_be, abi.PtrSize, 1)
-        case defs.T_binary  : p.i64(OP_size_check, 4); p.i64(OP_length, abi.PtrSize); p.dyn(OP_memcpy_be, abi.PtrSize, 1)
-        case defs.T_map     : self.compileMap(p, sp, vt, startpc)
-        case defs.T_set     : self.compileSeq(p, sp, vt, startpc, true)
-        case defs.T_list    : self.compileSeq(p, sp, vt, startpc, false)
-        case defs.T_struct  : self.compileStruct(p, sp, vt, startpc)
-        case defs.T_pointer : self.compilePtr(p, sp, vt, startpc)
-        default             : panic("unreachable")
+        case defs.T_bool     : p.i64(OP_size_check, 1); p.i64(OP_sint, 1)
+        case defs.T_i8       : p.i64(OP_size_check, 1); p.i64(OP_sint, 1)
+        case defs.T_i16      : p.i64(OP_size_check, 2); p.i64(OP_sint, 2)
+        case defs.T_i32      : p.i64(OP_size_check, 4); p.i64(OP_sint, 4)
+        case defs.T_i64      : p.i64(OP_size_check, 8); p.i64(OP_sint, 8)
+        case defs.T_enum     : p.i64(OP_size_check, 4); p.i64(OP_sint, 4)
+        case defs.T_double   : p.i64(OP_size_check, 8); p.i64(OP_sint, 8)
+        case defs.T_string   : p.i64(OP_size_check, 4); p.i64(OP_length, abi.PtrSize); p.dyn(OP_memcpy_be, abi.PtrSize, 1)
+        case defs.T_binary   : p.i64(OP_size_check, 4); p.i64(OP_length, abi.PtrSize); p.dyn(OP_memcpy_be, abi.PtrSize, 1)
+        case defs.T_unsigned : p.i64(OP_size_check, uintSize(vt)); p.dyn(OP_uint, int32(vt.S.Size()), uintSize(vt))
+        case defs.T_float    : p.i64(OP_size_check, 8); p.add(OP_float)
+        case defs.T_array    : p.i64(OP_size_check, 4 + int64(vt.S.Len())); p.i64(OP_long, int64(vt.S.Len())); p.i64(OP_memcpy, int64(vt.S.Len()))
+        case defs.T_codec    : p.cdc(OP_codec, defs.CodecOf(vt), defs.Binary)
+        case defs.T_map      : self.compileMap(p, sp, vt, startpc)
+        case defs.T_set      : self.compileSeq(p, sp, vt, startpc, true)
+        case defs.T_list     : self.compileSeq(p, sp, vt, startpc, false)
+        case defs.T_struct   : self.compileStruct(p, sp, vt, startpc)
+        case defs.T_pointer  : self.compilePtr(p, sp, vt, startpc)
+        default              : panic("unreachable")
     }
 }
 
@@ -95,13 +105,13 @@ func (self *Compiler) compileMap(p *Program, sp int, vt *defs.Type, startpc int)
     j := p.pc()
     p.add(OP_map_if_empty)
     p.add(OP_make_state)
-    p.rtt(OP_map_begin, vt.S)
+    p.rtt(OP_map_sort_begin, vt.S)
     k := p.pc()
     p.add(OP_map_key)
     self.compile(p, sp + 1, kt, startpc)
     p.add(OP_map_value)
     self.compile(p, sp + 1, et, startpc)
-    p.add(OP_map_next)
+    p.add(OP_map_sort_next)
     p.jmp(OP_map_if_next, k)
     p.add(OP_drop_state)
 
@@ -116,6 +126,7 @@ func (self *Compiler) compileMap(p *Program, sp int, vt *defs.Type, startpc int)
 
 func (self *Compiler) compileSeq(p *Program, sp int, vt *defs.Type, startpc int, verifyUnique bool) {
     nb := -1
+    ci := -1
     et := vt.V
 
     /* 5-byte set or list header */
@@ -143,9 +154,16 @@ func (self *Compiler) compileSeq(p *Program, sp int, vt *defs.Type, startpc int,
         p.rtt(OP_unique, et.S)
     }
 
+    /* sets are sorted in canonical mode */
+    if verifyUnique && et.IsKeyType() {
+        ci = p.pc()
+        p.add(OP_if_canonical)
+    }
+
     /* check if this is the special case */
     if nb != -1 {
         p.dyn(OP_memcpy_be, abi.PtrSize, int64(nb))
+        self.compileSortedSet(p, sp, et, startpc, ci)
         p.pin(i)
         return
     }
@@ -164,12 +182,39 @@ func (self *Compiler) compileSeq(p *Program, sp int, vt *defs.Type, startpc int,
     p.add(OP_list_decr)
     p.jmp(OP_list_if_next, r)
     p.add(OP_drop_state)
+    self.compileSortedSet(p, sp, et, startpc, ci)
+    p.pin(i)
+    p.pin(j)
+}
+
+func (self *Compiler) compileSortedSet(p *Program, sp int, et *defs.Type, startpc int, ci int) {
+    if ci < 0 {
+        return
+    }
+
+    /* skip over the sorted path */
+    i := p.pc()
+    p.add(OP_goto)
+    p.pin(ci)
+
+    /* encode the elements in sorted order */
+    j := p.pc()
+    p.add(OP_list_if_empty)
+    p.add(OP_make_state)
+    p.rtt(OP_set_sort_begin, et.S)
+    k := p.pc()
+    p.add(OP_map_key)
+    self.compile(p, sp + 1, et, startpc)
+    p.add(OP_map_sort_next)
+    p.jmp(OP_map_if_next, k)
+    p.add(OP_drop_state)
     p.pin(i)
     p.pin(j)
 }
 
 func (self *Compiler) compileStruct(p *Program, sp int, vt *defs.Type, startpc int) {
     var err error
+    var uid int
     var fvs []defs.Field
 
     /* resolve the field */
@@ -177,6 +222,18 @@ func (self *Compiler) compileStruct(p *Program, sp int, vt *defs.Type, startpc i
         panic(err)
     }
 
+    /* resolve the unknown fields collector */
+    if uid, err = defs.ResolveUnknownFields(vt.S); err != nil {
+        panic(err)
+    }
+
+    /* unions must have exactly one member set */
+    if un, err := defs.IsUnion(vt.S); err != nil {
+        panic(err)
+    } else if un {
+        p.tab(OP_union_check, unionOffsets(fvs))
+    }
+
     /* compile every field */
     for _, fv := range fvs {
         p.tag(sp)
@@ -185,6 +242,13 @@ func (self *Compiler) compileStruct(p *Program, sp int, vt *defs.Type, startpc i
         p.i64(OP_seek, -int64(fv.F))
     }
 
+    /* copy the unknown fields verbatim, if any */
+    if uid >= 0 {
+        p.i64(OP_seek, int64(uid))
+        p.dyn(OP_memcpy_be, abi.PtrSize, 1)
+        p.i64(OP_seek, -int64(uid))
+    }
+
     /* add the STOP field */
     p.i64(OP_size_check, 1)
     p.i64(OP_byte, 0)
@@ -197,15 +261,17 @@ func (self *Compiler) compileStructField(p *Program, sp int, fv defs.Field, star
         }
 
         /* non-pointer types */
-        case defs.T_bool   : fallthrough
-        case defs.T_i8     : fallthrough
-        case defs.T_double : fallthrough
-        case defs.T_i16    : fallthrough
-        case defs.T_i32    : fallthrough
-        case defs.T_i64    : fallthrough
-        case defs.T_string : fallthrough
-        case defs.T_enum   : fallthrough
-        case defs.T_binary : {
+        case defs.T_bool     : fallthrough
+        case defs.T_i8       : fallthrough
+        case defs.T_double   : fallthrough
+        case defs.T_i16      : fallthrough
+        case defs.T_i32      : fallthrough
+        case defs.T_i64      : fallthrough
+        case defs.T_string   : fallthrough
+        case defs.T_enum     : fallthrough
+        case defs.T_binary   : fallthrough
+        case defs.T_unsigned : fallthrough
+        case defs.T_float    : {
             if fv.Default.IsValid() && fv.Spec == defs.Optional {
                 self.compileStructDefault(p, sp, fv, startpc)
             } else {
@@ -213,8 +279,10 @@ func (self *Compiler) compileStructField(p *Program, sp int, fv defs.Field, star
             }
         }
 
-        /* struct types, only available in hand-written structs */
-        case defs.T_struct: {
+        /* struct types, only available in hand-written structs, fixed-size byte arrays and custom codecs */
+        case defs.T_array  : fallthrough
+        case defs.T_codec  : fallthrough
+        case defs.T_struct : {
             self.compileStructRequired(p, sp, fv, startpc)
         }
 
@@ -248,16 +316,18 @@ func (self *Compiler) compileStructDefault(p *Program, sp int, fv defs.Field, st
 
     /* check for default values */
     switch t {
-        case defs.T_bool   : p.dyn(OP_if_eq_imm, 1, bool2i64(fv.Default.Bool()))
-        case defs.T_i8     : p.dyn(OP_if_eq_imm, 1, fv.Default.Int())
-        case defs.T_double : p.dyn(OP_if_eq_imm, 8, int64(math.Float64bits(fv.Default.Float())))
-        case defs.T_i16    : p.dyn(OP_if_eq_imm, 2, fv.Default.Int())
-        case defs.T_i32    : p.dyn(OP_if_eq_imm, 4, fv.Default.Int())
-        case defs.T_i64    : p.dyn(OP_if_eq_imm, 8, fv.Default.Int())
-        case defs.T_string : p.str(OP_if_eq_str, fv.Default.String())
-        case defs.T_enum   : p.dyn(OP_if_eq_imm, 4, fv.Default.Int())
-        case defs.T_binary : p.str(OP_if_eq_str, mem2str(fv.Default.Bytes()))
-        default            : panic("unreachable")
+        case defs.T_bool     : p.dyn(OP_if_eq_imm, 1, bool2i64(fv.Default.Bool()))
+        case defs.T_i8       : p.dyn(OP_if_eq_imm, 1, fv.Default.Int())
+        case defs.T_double   : p.dyn(OP_if_eq_imm, 8, int64(math.Float64bits(fv.Default.Float())))
+        case defs.T_i16      : p.dyn(OP_if_eq_imm, 2, fv.Default.Int())
+        case defs.T_i32      : p.dyn(OP_if_eq_imm, 4, fv.Default.Int())
+        case defs.T_i64      : p.dyn(OP_if_eq_imm, 8, fv.Default.Int())
+        case defs.T_string   : p.str(OP_if_eq_str, fv.Default.String())
+        case defs.T_enum     : p.dyn(OP_if_eq_imm, 4, fv.Default.Int())
+        case defs.T_binary   : p.str(OP_if_eq_str, mem2str(fv.Default.Bytes()))
+        case defs.T_unsigned : p.dyn(OP_if_eq_imm, int32(fv.Type.S.Size()), int64(fv.Default.Uint()))
+        case defs.T_float    : p.dyn(OP_if_eq_imm, 4, int64(math.Float32bits(float32(fv.Default.Float()))))
+        default              : panic("unreachable")
     }
 
     /* compile if it's not the default value */
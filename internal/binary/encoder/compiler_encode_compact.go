@@ -0,0 +1,469 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package encoder
+
+import (
+    `math`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/atm/abi`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+)
+
+func compactHeader(fv defs.Field, id int) []byte {
+    var mm [MaxVarint64 + 1]byte
+    var dv = int(fv.ID) - id
+    var tt = fv.Type.Tag().Compact()
+
+    /* short form, delta encoded field ID with the type */
+    if id >= 0 && dv > 0 && dv <= 15 {
+        return []byte { byte(dv << 4) | tt }
+    }
+
+    /* long form, type followed by a zigzag varint field ID */
+    mm[0] = tt
+    nb := uvput(unsafe.Pointer(&mm[1]), zigzag(int64(int16(fv.ID))))
+    return append([]byte(nil), mm[:nb + 1]...)
+}
+
+func compactOptional(fv defs.Field) bool {
+    switch fv.Type.T {
+        case defs.T_array   : return false
+        case defs.T_codec   : return false
+        case defs.T_struct  : return false
+        case defs.T_map     : return fv.Spec == defs.Optional
+        case defs.T_set     : return fv.Spec == defs.Optional
+        case defs.T_list    : return fv.Spec == defs.Optional
+        case defs.T_pointer : return fv.Spec == defs.Optional
+        default             : return fv.Spec == defs.Optional && fv.Default.IsValid()
+    }
+}
+
+func compactTracked(fvs []defs.Field) bool {
+    for i := 1; i < len(fvs); i++ {
+        if compactOptional(fvs[i - 1]) {
+            return true
+        }
+    }
+    return false
+}
+
+func compactDefault(p *Program, fv defs.Field) {
+    switch fv.Type.T {
+        case defs.T_bool     : p.dyn(OP_if_eq_imm, 1, bool2i64(fv.Default.Bool()))
+        case defs.T_i8       : p.dyn(OP_if_eq_imm, 1, fv.Default.Int())
+        case defs.T_double   : p.dyn(OP_if_eq_imm, 8, int64(math.Float64bits(fv.Default.Float())))
+        case defs.T_i16      : p.dyn(OP_if_eq_imm, 2, fv.Default.Int())
+        case defs.T_i32      : p.dyn(OP_if_eq_imm, 4, fv.Default.Int())
+        case defs.T_i64      : p.dyn(OP_if_eq_imm, 8, fv.Default.Int())
+        case defs.T_string   : p.str(OP_if_eq_str, fv.Default.String())
+        case defs.T_enum     : p.dyn(OP_if_eq_imm, 4, fv.Default.Int())
+        case defs.T_binary   : p.str(OP_if_eq_str, mem2str(fv.Default.Bytes()))
+        case defs.T_unsigned : p.dyn(OP_if_eq_imm, int32(fv.Type.S.Size()), int64(fv.Default.Uint()))
+        case defs.T_float    : p.dyn(OP_if_eq_imm, 4, int64(math.Float32bits(float32(fv.Default.Float()))))
+        default              : panic("unreachable")
+    }
+}
+
+func (self *Compiler) compileCompact(p *Program, sp int, vt *defs.Type, startpc int) {
+    switch vt.T {
+        case defs.T_bool     : p.i64(OP_size_check, 1); p.i64(OP_bool, defs.C_true)
+        case defs.T_i8       : p.i64(OP_size_check, 1); p.i64(OP_sint, 1)
+        case defs.T_i16      : p.i64(OP_varint, 2)
+        case defs.T_i32      : p.i64(OP_varint, 4)
+        case defs.T_i64      : p.i64(OP_varint, 8)
+        case defs.T_enum     : p.i64(OP_varint, 4)
+        case defs.T_double   : p.i64(OP_size_check, 8); p.i64(OP_sint_le, 8)
+        case defs.T_string   : p.i64(OP_length_uv, abi.PtrSize); p.dyn(OP_memcpy_be, abi.PtrSize, 1)
+        case defs.T_binary   : p.i64(OP_length_uv, abi.PtrSize); p.dyn(OP_memcpy_be, abi.PtrSize, 1)
+        case defs.T_unsigned : self.compileCompactUnsigned(p, vt)
+        case defs.T_float    : p.i64(OP_size_check, 8); p.add(OP_float_le)
+        case defs.T_array    : self.compileCompactArray(p, vt)
+        case defs.T_codec    : p.cdc(OP_codec, defs.CodecOf(vt), defs.Compact)
+        case defs.T_map      : self.compileCompactMap(p, sp, vt, startpc)
+        case defs.T_set      : self.compileCompactSeq(p, sp, vt, startpc, true)
+        case defs.T_list     : self.compileCompactSeq(p, sp, vt, startpc, false)
+        case defs.T_struct   : self.compileCompactStruct(p, sp, vt, startpc)
+        case defs.T_pointer  : self.compilePtr(p, sp, vt, startpc)
+        default              : panic("unreachable")
+    }
+}
+
+func (self *Compiler) compileCompactUnsigned(p *Program, vt *defs.Type) {
+    if nb := uintSize(vt); nb != 1 {
+        p.dyn(OP_varint_u, int32(vt.S.Size()), nb)
+    } else {
+        p.i64(OP_size_check, 1)
+        p.dyn(OP_uint, int32(vt.S.Size()), 1)
+    }
+}
+
+func (self *Compiler) compileCompactArray(p *Program, vt *defs.Type) {
+    var nb int
+    var mm [MaxVarint64]byte
+
+    /* the varint length is a constant */
+    nb = uvput(unsafe.Pointer(&mm), uint64(vt.S.Len()))
+    p.i64(OP_size_check, int64(nb + vt.S.Len()))
+
+    /* write the length and the bytes */
+    for _, v := range mm[:nb] {
+        p.i64(OP_byte, int64(v))
+    }
+
+    /* copy the array, if not empty */
+    if vt.S.Len() != 0 {
+        p.i64(OP_memcpy, int64(vt.S.Len()))
+    }
+}
+
+func (self *Compiler) compileCompactMap(p *Program, sp int, vt *defs.Type, startpc int) {
+    kt := vt.K
+    et := vt.V
+
+    /* check for nil maps */
+    p.tag(sp)
+    i := p.pc()
+    p.add(OP_if_nil)
+
+    /* variable-length map header */
+    p.i64(OP_map_head, int64(kt.Tag().Compact() << 4 | et.Tag().Compact()))
+    j := p.pc()
+    p.add(OP_map_if_empty)
+
+    /* encode the map */
+    p.add(OP_make_state)
+    p.rtt(OP_map_sort_begin, vt.S)
+    k := p.pc()
+    p.add(OP_map_key)
+    self.compile(p, sp + 1, kt, startpc)
+    p.add(OP_map_value)
+    self.compile(p, sp + 1, et, startpc)
+    p.add(OP_map_sort_next)
+    p.jmp(OP_map_if_next, k)
+    p.add(OP_drop_state)
+
+    /* nil maps are encoded as empty maps */
+    r := p.pc()
+    p.add(OP_goto)
+    p.pin(i)
+    p.i64(OP_size_check, 1)
+    p.i64(OP_byte, 0)
+    p.pin(j)
+    p.pin(r)
+}
+
+func (self *Compiler) compileCompactSeq(p *Program, sp int, vt *defs.Type, startpc int, verifyUnique bool) {
+    ci := -1
+    et := vt.V
+    tt := et.Tag().Compact()
+
+    /* variable-length set or list header */
+    p.tag(sp)
+    p.i64(OP_list_head, int64(tt))
+
+    /* check for nil slice */
+    i := p.pc()
+    p.add(OP_if_nil)
+
+    /* check for uniqueness if needed */
+    if verifyUnique {
+        p.rtt(OP_unique, et.S)
+    }
+
+    /* sets are sorted in canonical mode */
+    if verifyUnique && et.IsKeyType() {
+        ci = p.pc()
+        p.add(OP_if_canonical)
+    }
+
+    /* special case of primitive sets or lists, which can be copied directly */
+    switch et.T {
+        case defs.T_i8     : p.dyn(OP_memcpy_be, abi.PtrSize, 1); self.compileSortedSet(p, sp, et, startpc, ci); p.pin(i); return
+        case defs.T_double : p.dyn(OP_memcpy_le, abi.PtrSize, 8); self.compileSortedSet(p, sp, et, startpc, ci); p.pin(i); return
+    }
+
+    /* complex sets or lists */
+    j := p.pc()
+    p.add(OP_list_if_empty)
+    p.add(OP_make_state)
+    p.add(OP_list_begin)
+    k := p.pc()
+    p.add(OP_goto)
+    r := p.pc()
+    p.i64(OP_seek, int64(et.S.Size()))
+    p.pin(k)
+    self.compile(p, sp + 1, et, startpc)
+    p.add(OP_list_decr)
+    p.jmp(OP_list_if_next, r)
+    p.add(OP_drop_state)
+    self.compileSortedSet(p, sp, et, startpc, ci)
+    p.pin(i)
+    p.pin(j)
+}
+
+func (self *Compiler) compileCompactStruct(p *Program, sp int, vt *defs.Type, startpc int) {
+    var id int
+    var uid int
+    var tr bool
+    var err error
+    var fvs []defs.Field
+
+    /* resolve the field */
+    if fvs, err = defs.ResolveFields(vt.S); err != nil {
+        panic(err)
+    }
+
+    /* resolve the unknown fields collector */
+    if uid, err = defs.ResolveUnknownFields(vt.S); err != nil {
+        panic(err)
+    }
+
+    /* unions must have exactly one member set */
+    if un, err := defs.IsUnion(vt.S); err != nil {
+        panic(err)
+    } else if un {
+        p.tab(OP_union_check, unionOffsets(fvs))
+    }
+
+    /* the last written field ID is only known at runtime if any field follows an optional one,
+     * it is kept in a state of its own, so the nested structs cannot overwrite it */
+    if tr = compactTracked(fvs); tr {
+        id = -1
+        p.add(OP_make_state)
+        p.add(OP_field_reset)
+    }
+
+    /* compile every field, keep track of the last written field ID for delta encoding */
+    for _, fv := range fvs {
+        p.tag(sp)
+        p.i64(OP_seek, int64(fv.F))
+        id = self.compileCompactStructField(p, sp + 1, fv, id, startpc)
+        p.i64(OP_seek, -int64(fv.F))
+
+        /* the headers are all written at runtime */
+        if tr {
+            id = -1
+        }
+    }
+
+    /* drop the field ID state */
+    if tr {
+        p.add(OP_drop_state)
+    }
+
+    /* copy the unknown fields verbatim, if any, they always use the long form of field headers */
+    if uid >= 0 {
+        p.i64(OP_seek, int64(uid))
+        p.dyn(OP_memcpy_be, abi.PtrSize, 1)
+        p.i64(OP_seek, -int64(uid))
+    }
+
+    /* add the STOP field */
+    p.i64(OP_size_check, 1)
+    p.i64(OP_byte, 0)
+}
+
+func (self *Compiler) compileCompactStructField(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    switch fv.Type.T {
+        default: {
+            panic("fatal: invalid field type: " + fv.Type.String())
+        }
+
+        /* non-pointer types */
+        case defs.T_bool     : fallthrough
+        case defs.T_i8       : fallthrough
+        case defs.T_double   : fallthrough
+        case defs.T_i16      : fallthrough
+        case defs.T_i32      : fallthrough
+        case defs.T_i64      : fallthrough
+        case defs.T_string   : fallthrough
+        case defs.T_enum     : fallthrough
+        case defs.T_binary   : fallthrough
+        case defs.T_unsigned : fallthrough
+        case defs.T_float    : {
+            if fv.Default.IsValid() && fv.Spec == defs.Optional {
+                return self.compileCompactStructDefault(p, sp, fv, id, startpc)
+            } else {
+                return self.compileCompactStructRequired(p, sp, fv, id, startpc)
+            }
+        }
+
+        /* struct types, only available in hand-written structs, fixed-size byte arrays and custom codecs */
+        case defs.T_array  : fallthrough
+        case defs.T_codec  : fallthrough
+        case defs.T_struct : {
+            return self.compileCompactStructRequired(p, sp, fv, id, startpc)
+        }
+
+        /* sequencial types */
+        case defs.T_map  : fallthrough
+        case defs.T_set  : fallthrough
+        case defs.T_list : {
+            if fv.Spec == defs.Optional {
+                return self.compileCompactStructIterable(p, sp, fv, id, startpc)
+            } else {
+                return self.compileCompactStructRequired(p, sp, fv, id, startpc)
+            }
+        }
+
+        /* pointers */
+        case defs.T_pointer: {
+            if fv.Spec == defs.Optional {
+                return self.compileCompactStructOptional(p, sp, fv, id, startpc)
+            } else if fv.Type.V.T == defs.T_struct {
+                return self.compileCompactStructPointer(p, sp, fv, id, startpc)
+            } else {
+                panic("fatal: non-optional non-struct pointers")
+            }
+        }
+    }
+}
+
+func (self *Compiler) compileCompactStructDefault(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    i := p.pc()
+    compactDefault(p, fv)
+    self.compileCompactStructFieldBegin(p, fv, id)
+    self.compileCompactStructValue(p, sp, fv.Type, startpc)
+    p.pin(i)
+    return -1
+}
+
+func (self *Compiler) compileCompactStructPointer(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    i := p.pc()
+    p.add(OP_if_nil)
+    self.compileCompactStructFieldBegin(p, fv, id)
+    p.add(OP_make_state)
+    p.add(OP_deref)
+    self.compile(p, sp + 1, fv.Type.V, startpc)
+    p.add(OP_drop_state)
+    j := p.pc()
+    p.add(OP_goto)
+    p.pin(i)
+    self.compileCompactStructFieldBegin(p, fv, id)
+    p.i64(OP_size_check, 1)
+    p.i64(OP_byte, 0)
+    p.pin(j)
+    return int(fv.ID)
+}
+
+func (self *Compiler) compileCompactStructIterable(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    i := p.pc()
+    p.add(OP_if_nil)
+    self.compileCompactStructFieldBegin(p, fv, id)
+    self.compile(p, sp, fv.Type, startpc)
+    p.pin(i)
+    return -1
+}
+
+func (self *Compiler) compileCompactStructOptional(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    i := p.pc()
+    p.add(OP_if_nil)
+
+    /* boolean values are carried by the field header */
+    if fv.Type.V.T == defs.T_bool {
+        self.compileCompactStructOptionalBool(p, fv, id)
+        p.pin(i)
+        return -1
+    }
+
+    /* the header is written with the state of the struct */
+    self.compileCompactStructFieldBegin(p, fv, id)
+    p.add(OP_make_state)
+    p.add(OP_deref)
+    self.compile(p, sp + 1, fv.Type.V, startpc)
+    p.add(OP_drop_state)
+    p.pin(i)
+    return -1
+}
+
+func (self *Compiler) compileCompactStructOptionalBool(p *Program, fv defs.Field, id int) {
+    p.add(OP_make_state)
+    p.add(OP_deref)
+
+    /* the field ID is known, so the header is a constant */
+    if id >= 0 {
+        self.compileCompactStructFieldBegin(p, fv, id)
+        p.add(OP_drop_state)
+        return
+    }
+
+    /* the field ID is tracked with the state of the struct, so the header is written after dropping the state */
+    i := p.pc()
+    p.dyn(OP_if_eq_imm, 1, 0)
+    p.add(OP_drop_state)
+    p.dyn(OP_field_head, defs.C_true, int64(fv.ID))
+    j := p.pc()
+    p.add(OP_goto)
+    p.pin(i)
+    p.add(OP_drop_state)
+    p.dyn(OP_field_head, defs.C_false, int64(fv.ID))
+    p.pin(j)
+}
+
+func (self *Compiler) compileCompactStructRequired(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    self.compileCompactStructFieldBegin(p, fv, id)
+    self.compileCompactStructValue(p, sp, fv.Type, startpc)
+    return int(fv.ID)
+}
+
+func (self *Compiler) compileCompactStructValue(p *Program, sp int, vt *defs.Type, startpc int) {
+    if vt.T != defs.T_bool {
+        self.compile(p, sp, vt, startpc)
+    }
+}
+
+func (self *Compiler) compileCompactStructFieldBegin(p *Program, fv defs.Field, id int) {
+    if id < 0 {
+        self.compileCompactStructFieldHead(p, fv)
+        return
+    }
+
+    /* the field ID is known, so the header is a constant */
+    hdr := compactHeader(fv, id)
+    p.i64(OP_size_check, int64(len(hdr)))
+
+    /* boolean values are carried by the field type */
+    if fv.Type.Tag() != defs.T_bool {
+        p.i64(OP_byte, int64(hdr[0]))
+    } else {
+        p.i64(OP_bool, int64(hdr[0]))
+    }
+
+    /* the optional field ID */
+    for _, v := range hdr[1:] {
+        p.i64(OP_byte, int64(v))
+    }
+}
+
+func (self *Compiler) compileCompactStructFieldHead(p *Program, fv defs.Field) {
+    if fv.Type.Tag() != defs.T_bool {
+        p.dyn(OP_field_head, int32(fv.Type.Tag().Compact()), int64(fv.ID))
+        return
+    }
+
+    /* boolean values are carried by the field type */
+    i := p.pc()
+    p.dyn(OP_if_eq_imm, 1, 0)
+    p.dyn(OP_field_head, defs.C_true, int64(fv.ID))
+    j := p.pc()
+    p.add(OP_goto)
+    p.pin(i)
+    p.dyn(OP_field_head, defs.C_false, int64(fv.ID))
+    p.pin(j)
+}
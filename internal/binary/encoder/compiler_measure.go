@@ -35,7 +35,7 @@ func (self *Compiler) measure(p *Program, sp int, vt *defs.Type, startpc int) {
 
     /* check for loops with inlining depth limit */
     if self.t[rt] || !self.o.CanInline(sp, (p.pc() - startpc) * 2) {
-        p.rtt(OP_size_defer, rt)
+        p.def(OP_size_defer, rt, self.p)
         return
     }
 
@@ -46,22 +46,40 @@ func (self *Compiler) measure(p *Program, sp int, vt *defs.Type, startpc int) {
 }
 
 func (self *Compiler) measureOne(p *Program, sp int, vt *defs.Type, startpc int) {
+    if self.p == defs.Compact {
+        self.measureCompact(p, sp, vt, startpc)
+        return
+    }
+
+    /* Binary Protocol */
     switch vt.T {
-        case defs.T_bool    : p.i64(OP_size_const, 1)
-        case defs.T_i8      : p.i64(OP_size_const, 1)
-        case defs.T_i16     : p.i64(OP_size_const, 2)
-        case defs.T_i32     : p.i64(OP_size_const, 4)
-        case defs.T_i64     : p.i64(OP_size_const, 8)
-        case defs.T_enum    : p.i64(OP_size_const, 4)
-        case defs.T_double  : p.i64(OP_size_const, 8)
-        case defs.T_string  : p.i64(OP_size_const, 4); p.dyn(OP_size_dyn, abi.PtrSize, 1)
-        case defs.T_binary  : p.i64(OP_size_const, 4); p.dyn(OP_size_dyn, abi.PtrSize, 1)
-        case defs.T_map     : self.measureMap(p, sp, vt, startpc)
-        case defs.T_set     : self.measureSeq(p, sp, vt, startpc)
-        case defs.T_list    : self.measureSeq(p, sp, vt, startpc)
-        case defs.T_struct  : self.measureStruct(p, sp, vt, startpc)
-        case defs.T_pointer : self.measurePtr(p, sp, vt, startpc)
-        default             : panic("measureOne: unreachable")
+        case defs.T_bool     : p.i64(OP_size_const, 1)
+        case defs.T_i8       : p.i64(OP_size_const, 1)
+        case defs.T_i16      : p.i64(OP_size_const, 2)
+        case defs.T_i32      : p.i64(OP_size_const, 4)
+        case defs.T_i64      : p.i64(OP_size_const, 8)
+        case defs.T_enum     : p.i64(OP_size_const, 4)
+        case defs.T_double   : p.i64(OP_size_const, 8)
+        case defs.T_string   : p.i64(OP_size_const, 4); p.dyn(OP_size_dyn, abi.PtrSize, 1)
+        case defs.T_binary   : p.i64(OP_size_const, 4); p.dyn(OP_size_dyn, abi.PtrSize, 1)
+        case defs.T_unsigned : p.i64(OP_size_const, uintSize(vt))
+        case defs.T_float    : p.i64(OP_size_const, 8)
+        case defs.T_array    : p.i64(OP_size_const, 4 + int64(vt.S.Len()))
+        case defs.T_codec    : self.measureCodec(p, vt)
+        case defs.T_map      : self.measureMap(p, sp, vt, startpc)
+        case defs.T_set      : self.measureSeq(p, sp, vt, startpc)
+        case defs.T_list     : self.measureSeq(p, sp, vt, startpc)
+        case defs.T_struct   : self.measureStruct(p, sp, vt, startpc)
+        case defs.T_pointer  : self.measurePtr(p, sp, vt, startpc)
+        default              : panic("measureOne: unreachable")
+    }
+}
+
+func (self *Compiler) measureCodec(p *Program, vt *defs.Type) {
+    if cc := defs.CodecOf(vt); cc.FixedSize() > 0 {
+        p.i64(OP_size_const, int64(cc.FixedSize()))
+    } else {
+        p.cdc(OP_size_codec, cc, defs.Binary)
     }
 }
 
@@ -165,6 +183,7 @@ func (self *Compiler) measureSeq(p *Program, sp int, vt *defs.Type, startpc int)
 
 func (self *Compiler) measureStruct(p *Program, sp int, vt *defs.Type, startpc int) {
     var err error
+    var uid int
     var fvs []defs.Field
 
     /* struct is trivially measuable */
@@ -178,12 +197,24 @@ func (self *Compiler) measureStruct(p *Program, sp int, vt *defs.Type, startpc i
         panic(err)
     }
 
+    /* resolve the unknown fields collector */
+    if uid, err = defs.ResolveUnknownFields(vt.S); err != nil {
+        panic(err)
+    }
+
     /* empty structs */
-    if len(fvs) == 0 {
+    if len(fvs) == 0 && uid < 0 {
         p.i64(OP_size_const, 4)
         return
     }
 
+    /* unions must have exactly one member set */
+    if un, err := defs.IsUnion(vt.S); err != nil {
+        panic(err)
+    } else if un {
+        p.tab(OP_union_check, unionOffsets(fvs))
+    }
+
     /* 1-byte stop field */
     p.tag(sp)
     p.i64(OP_size_const, 1)
@@ -194,6 +225,13 @@ func (self *Compiler) measureStruct(p *Program, sp int, vt *defs.Type, startpc i
         self.measureField(p, sp + 1, fv, startpc)
         p.i64(OP_seek, -int64(fv.F))
     }
+
+    /* the unknown fields are copied verbatim */
+    if uid >= 0 {
+        p.i64(OP_seek, int64(uid))
+        p.dyn(OP_size_dyn, abi.PtrSize, 1)
+        p.i64(OP_seek, -int64(uid))
+    }
 }
 
 func (self *Compiler) measureField(p *Program, sp int, fv defs.Field, startpc int) {
@@ -203,15 +241,17 @@ func (self *Compiler) measureField(p *Program, sp int, fv defs.Field, startpc in
         }
 
         /* non-pointer types */
-        case defs.T_bool   : fallthrough
-        case defs.T_i8     : fallthrough
-        case defs.T_double : fallthrough
-        case defs.T_i16    : fallthrough
-        case defs.T_i32    : fallthrough
-        case defs.T_i64    : fallthrough
-        case defs.T_string : fallthrough
-        case defs.T_enum   : fallthrough
-        case defs.T_binary : {
+        case defs.T_bool     : fallthrough
+        case defs.T_i8       : fallthrough
+        case defs.T_double   : fallthrough
+        case defs.T_i16      : fallthrough
+        case defs.T_i32      : fallthrough
+        case defs.T_i64      : fallthrough
+        case defs.T_string   : fallthrough
+        case defs.T_enum     : fallthrough
+        case defs.T_binary   : fallthrough
+        case defs.T_unsigned : fallthrough
+        case defs.T_float    : {
             if fv.Default.IsValid() && fv.Spec == defs.Optional {
                 self.measureStructDefault(p, sp, fv, startpc)
             } else {
@@ -219,8 +259,10 @@ func (self *Compiler) measureField(p *Program, sp int, fv defs.Field, startpc in
             }
         }
 
-        /* struct types, only available in hand-written structs */
-        case defs.T_struct: {
+        /* struct types, only available in hand-written structs, fixed-size byte arrays and custom codecs */
+        case defs.T_array  : fallthrough
+        case defs.T_codec  : fallthrough
+        case defs.T_struct : {
             self.measureStructRequired(p, sp, fv, startpc)
         }
 
@@ -254,16 +296,18 @@ func (self *Compiler) measureStructDefault(p *Program, sp int, fv defs.Field, st
 
     /* check for default values */
     switch t {
-        case defs.T_bool   : p.dyn(OP_if_eq_imm, 1, bool2i64(fv.Default.Bool()))
-        case defs.T_i8     : p.dyn(OP_if_eq_imm, 1, fv.Default.Int())
-        case defs.T_double : p.dyn(OP_if_eq_imm, 8, int64(math.Float64bits(fv.Default.Float())))
-        case defs.T_i16    : p.dyn(OP_if_eq_imm, 2, fv.Default.Int())
-        case defs.T_i32    : p.dyn(OP_if_eq_imm, 4, fv.Default.Int())
-        case defs.T_i64    : p.dyn(OP_if_eq_imm, 8, fv.Default.Int())
-        case defs.T_string : p.str(OP_if_eq_str, fv.Default.String())
-        case defs.T_enum   : p.dyn(OP_if_eq_imm, 4, fv.Default.Int())
-        case defs.T_binary : p.str(OP_if_eq_str, mem2str(fv.Default.Bytes()))
-        default            : panic("unreachable")
+        case defs.T_bool     : p.dyn(OP_if_eq_imm, 1, bool2i64(fv.Default.Bool()))
+        case defs.T_i8       : p.dyn(OP_if_eq_imm, 1, fv.Default.Int())
+        case defs.T_double   : p.dyn(OP_if_eq_imm, 8, int64(math.Float64bits(fv.Default.Float())))
+        case defs.T_i16      : p.dyn(OP_if_eq_imm, 2, fv.Default.Int())
+        case defs.T_i32      : p.dyn(OP_if_eq_imm, 4, fv.Default.Int())
+        case defs.T_i64      : p.dyn(OP_if_eq_imm, 8, fv.Default.Int())
+        case defs.T_string   : p.str(OP_if_eq_str, fv.Default.String())
+        case defs.T_enum     : p.dyn(OP_if_eq_imm, 4, fv.Default.Int())
+        case defs.T_binary   : p.str(OP_if_eq_str, mem2str(fv.Default.Bytes()))
+        case defs.T_unsigned : p.dyn(OP_if_eq_imm, int32(fv.Type.S.Size()), int64(fv.Default.Uint()))
+        case defs.T_float    : p.dyn(OP_if_eq_imm, 4, int64(math.Float32bits(float32(fv.Default.Float()))))
+        default              : panic("unreachable")
     }
 
     /* measure if it's not the default value */
@@ -0,0 +1,371 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package encoder
+
+import (
+    `github.com/cloudwego/frugal/internal/atm/abi`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+)
+
+func compactSize(vt *defs.Type) int64 {
+    switch vt.T {
+        case defs.T_bool     : return 1
+        case defs.T_i8       : return 1
+        case defs.T_double   : return 8
+        case defs.T_float    : return 8
+        case defs.T_array    : return int64(uvlen(uint64(vt.S.Len())) + vt.S.Len())
+        case defs.T_unsigned : return compactUintSize(vt)
+        case defs.T_codec    : return compactCodecSize(vt)
+        default              : return -1
+    }
+}
+
+func compactUintSize(vt *defs.Type) int64 {
+    if uintSize(vt) == 1 {
+        return 1
+    } else {
+        return -1
+    }
+}
+
+func compactCodecSize(vt *defs.Type) int64 {
+    switch vt.V.T {
+        case defs.T_i8     : return 1
+        case defs.T_double : return 8
+        default            : return -1
+    }
+}
+
+func (self *Compiler) measureCompactCodec(p *Program, vt *defs.Type) {
+    if nb := compactCodecSize(vt); nb > 0 {
+        p.i64(OP_size_const, nb)
+    } else {
+        p.cdc(OP_size_codec, defs.CodecOf(vt), defs.Compact)
+    }
+}
+
+func (self *Compiler) measureCompact(p *Program, sp int, vt *defs.Type, startpc int) {
+    switch vt.T {
+        case defs.T_bool     : p.i64(OP_size_const, 1)
+        case defs.T_i8       : p.i64(OP_size_const, 1)
+        case defs.T_i16      : p.i64(OP_size_varint, 2)
+        case defs.T_i32      : p.i64(OP_size_varint, 4)
+        case defs.T_i64      : p.i64(OP_size_varint, 8)
+        case defs.T_enum     : p.i64(OP_size_varint, 4)
+        case defs.T_double   : p.i64(OP_size_const, 8)
+        case defs.T_string   : p.i64(OP_size_length_uv, abi.PtrSize); p.dyn(OP_size_dyn, abi.PtrSize, 1)
+        case defs.T_binary   : p.i64(OP_size_length_uv, abi.PtrSize); p.dyn(OP_size_dyn, abi.PtrSize, 1)
+        case defs.T_unsigned : self.measureCompactUnsigned(p, vt)
+        case defs.T_float    : p.i64(OP_size_const, 8)
+        case defs.T_array    : p.i64(OP_size_const, compactSize(vt))
+        case defs.T_codec    : self.measureCompactCodec(p, vt)
+        case defs.T_map      : self.measureCompactMap(p, sp, vt, startpc)
+        case defs.T_set      : self.measureCompactSeq(p, sp, vt, startpc)
+        case defs.T_list     : self.measureCompactSeq(p, sp, vt, startpc)
+        case defs.T_struct   : self.measureCompactStruct(p, sp, vt, startpc)
+        case defs.T_pointer  : self.measurePtr(p, sp, vt, startpc)
+        default              : panic("measureCompact: unreachable")
+    }
+}
+
+func (self *Compiler) measureCompactUnsigned(p *Program, vt *defs.Type) {
+    if nb := uintSize(vt); nb != 1 {
+        p.dyn(OP_size_varint_u, int32(vt.S.Size()), nb)
+    } else {
+        p.i64(OP_size_const, 1)
+    }
+}
+
+func (self *Compiler) measureCompactMap(p *Program, sp int, vt *defs.Type, startpc int) {
+    nk := compactSize(vt.K)
+    nv := compactSize(vt.V)
+
+    /* check for nil maps */
+    p.tag(sp)
+    i := p.pc()
+    p.add(OP_if_nil)
+
+    /* variable-length map header */
+    p.add(OP_size_map_head)
+
+    /* key and value are both trivially measuable */
+    if nk > 0 && nv > 0 {
+        p.i64(OP_size_map, nk + nv)
+        j := p.pc()
+        p.add(OP_goto)
+        p.pin(i)
+        p.i64(OP_size_const, 1)
+        p.pin(j)
+        return
+    }
+
+    /* key or value is trivially measuable */
+    if nk > 0 { p.i64(OP_size_map, nk) }
+    if nv > 0 { p.i64(OP_size_map, nv) }
+
+    /* complex maps */
+    j := p.pc()
+    p.add(OP_map_if_empty)
+    p.add(OP_make_state)
+    p.rtt(OP_map_begin, vt.S)
+    k := p.pc()
+
+    /* complex keys */
+    if nk <= 0 {
+        p.add(OP_map_key)
+        self.measure(p, sp + 1, vt.K, startpc)
+    }
+
+    /* complex values */
+    if nv <= 0 {
+        p.add(OP_map_value)
+        self.measure(p, sp + 1, vt.V, startpc)
+    }
+
+    /* move to the next state */
+    p.add(OP_map_next)
+    p.jmp(OP_map_if_next, k)
+    p.add(OP_drop_state)
+
+    /* nil maps are encoded as empty maps */
+    r := p.pc()
+    p.add(OP_goto)
+    p.pin(i)
+    p.i64(OP_size_const, 1)
+    p.pin(j)
+    p.pin(r)
+}
+
+func (self *Compiler) measureCompactSeq(p *Program, sp int, vt *defs.Type, startpc int) {
+    et := vt.V
+    nb := compactSize(et)
+
+    /* variable-length list or set header */
+    p.tag(sp)
+    p.add(OP_size_list_head)
+
+    /* check for nil slice */
+    i := p.pc()
+    p.add(OP_if_nil)
+
+    /* element is trivially measuable */
+    if nb > 0 {
+        p.dyn(OP_size_dyn, abi.PtrSize, nb)
+        p.pin(i)
+        return
+    }
+
+    /* complex lists or sets */
+    j := p.pc()
+    p.add(OP_list_if_empty)
+    p.add(OP_make_state)
+    p.add(OP_list_begin)
+    k := p.pc()
+    p.add(OP_goto)
+    r := p.pc()
+    p.i64(OP_seek, int64(et.S.Size()))
+    p.pin(k)
+    self.measure(p, sp + 1, et, startpc)
+    p.add(OP_list_decr)
+    p.jmp(OP_list_if_next, r)
+    p.add(OP_drop_state)
+    p.pin(i)
+    p.pin(j)
+}
+
+func (self *Compiler) measureCompactStruct(p *Program, sp int, vt *defs.Type, startpc int) {
+    var id int
+    var uid int
+    var tr bool
+    var err error
+    var fvs []defs.Field
+
+    /* resolve the field */
+    if fvs, err = defs.ResolveFields(vt.S); err != nil {
+        panic(err)
+    }
+
+    /* resolve the unknown fields collector */
+    if uid, err = defs.ResolveUnknownFields(vt.S); err != nil {
+        panic(err)
+    }
+
+    /* unions must have exactly one member set */
+    if un, err := defs.IsUnion(vt.S); err != nil {
+        panic(err)
+    } else if un {
+        p.tab(OP_union_check, unionOffsets(fvs))
+    }
+
+    /* 1-byte stop field */
+    p.tag(sp)
+    p.i64(OP_size_const, 1)
+
+    /* the last written field ID is only known at runtime if any field follows an optional one */
+    if tr = compactTracked(fvs); tr {
+        id = -1
+        p.add(OP_make_state)
+        p.add(OP_field_reset)
+    }
+
+    /* measure every field, the header size depends on the last written field ID */
+    for _, fv := range fvs {
+        p.i64(OP_seek, int64(fv.F))
+        id = self.measureCompactField(p, sp + 1, fv, id, startpc)
+        p.i64(OP_seek, -int64(fv.F))
+
+        /* the header sizes are all measured at runtime */
+        if tr {
+            id = -1
+        }
+    }
+
+    /* drop the field ID state */
+    if tr {
+        p.add(OP_drop_state)
+    }
+
+    /* the unknown fields are copied verbatim */
+    if uid >= 0 {
+        p.i64(OP_seek, int64(uid))
+        p.dyn(OP_size_dyn, abi.PtrSize, 1)
+        p.i64(OP_seek, -int64(uid))
+    }
+}
+
+func (self *Compiler) measureCompactField(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    switch fv.Type.T {
+        default: {
+            panic("fatal: invalid field type: " + fv.Type.String())
+        }
+
+        /* non-pointer types */
+        case defs.T_bool     : fallthrough
+        case defs.T_i8       : fallthrough
+        case defs.T_double   : fallthrough
+        case defs.T_i16      : fallthrough
+        case defs.T_i32      : fallthrough
+        case defs.T_i64      : fallthrough
+        case defs.T_string   : fallthrough
+        case defs.T_enum     : fallthrough
+        case defs.T_binary   : fallthrough
+        case defs.T_unsigned : fallthrough
+        case defs.T_float    : {
+            if fv.Default.IsValid() && fv.Spec == defs.Optional {
+                return self.measureCompactStructDefault(p, sp, fv, id, startpc)
+            } else {
+                return self.measureCompactStructRequired(p, sp, fv, id, startpc)
+            }
+        }
+
+        /* struct types, only available in hand-written structs, fixed-size byte arrays and custom codecs */
+        case defs.T_array  : fallthrough
+        case defs.T_codec  : fallthrough
+        case defs.T_struct : {
+            return self.measureCompactStructRequired(p, sp, fv, id, startpc)
+        }
+
+        /* sequencial types */
+        case defs.T_map  : fallthrough
+        case defs.T_set  : fallthrough
+        case defs.T_list : {
+            if fv.Spec == defs.Optional {
+                return self.measureCompactStructIterable(p, sp, fv, id, startpc)
+            } else {
+                return self.measureCompactStructRequired(p, sp, fv, id, startpc)
+            }
+        }
+
+        /* pointers */
+        case defs.T_pointer: {
+            if fv.Spec == defs.Optional {
+                return self.measureCompactStructOptional(p, sp, fv, id, startpc)
+            } else if fv.Type.V.T == defs.T_struct {
+                return self.measureCompactStructPointer(p, sp, fv, id, startpc)
+            } else {
+                panic("fatal: non-optional non-struct pointers")
+            }
+        }
+    }
+}
+
+func (self *Compiler) measureCompactStructDefault(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    i := p.pc()
+    compactDefault(p, fv)
+    self.measureCompactStructFieldBegin(p, fv, id)
+    self.measureCompactStructValue(p, sp, fv.Type, startpc)
+    p.pin(i)
+    return -1
+}
+
+func (self *Compiler) measureCompactStructPointer(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    self.measureCompactStructFieldBegin(p, fv, id)
+    i := p.pc()
+    p.add(OP_if_nil)
+    p.add(OP_make_state)
+    p.add(OP_deref)
+    self.measure(p, sp + 1, fv.Type.V, startpc)
+    p.add(OP_drop_state)
+    j := p.pc()
+    p.add(OP_goto)
+    p.pin(i)
+    p.i64(OP_size_const, 1)
+    p.pin(j)
+    return int(fv.ID)
+}
+
+func (self *Compiler) measureCompactStructIterable(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    i := p.pc()
+    p.add(OP_if_nil)
+    self.measureCompactStructFieldBegin(p, fv, id)
+    self.measure(p, sp, fv.Type, startpc)
+    p.pin(i)
+    return -1
+}
+
+func (self *Compiler) measureCompactStructOptional(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    i := p.pc()
+    p.add(OP_if_nil)
+    self.measureCompactStructFieldBegin(p, fv, id)
+    p.add(OP_make_state)
+    p.add(OP_deref)
+    self.measureCompactStructValue(p, sp + 1, fv.Type.V, startpc)
+    p.add(OP_drop_state)
+    p.pin(i)
+    return -1
+}
+
+func (self *Compiler) measureCompactStructRequired(p *Program, sp int, fv defs.Field, id int, startpc int) int {
+    self.measureCompactStructFieldBegin(p, fv, id)
+    self.measureCompactStructValue(p, sp, fv.Type, startpc)
+    return int(fv.ID)
+}
+
+func (self *Compiler) measureCompactStructValue(p *Program, sp int, vt *defs.Type, startpc int) {
+    if vt.T != defs.T_bool {
+        self.measure(p, sp, vt, startpc)
+    }
+}
+
+func (self *Compiler) measureCompactStructFieldBegin(p *Program, fv defs.Field, id int) {
+    if id < 0 {
+        p.i64(OP_size_field, int64(fv.ID))
+    } else {
+        p.i64(OP_size_const, int64(len(compactHeader(fv, id))))
+    }
+}
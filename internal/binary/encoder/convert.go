@@ -0,0 +1,31 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoder
+
+import (
+    `math`
+
+    `github.com/cloudwego/frugal/internal/atm/hir`
+)
+
+func float_widen(v uint64) uint64 {
+    return math.Float64bits(float64(math.Float32frombits(uint32(v))))
+}
+
+var (
+    F_float_widen = hir.RegisterGCall(float_widen, emu_gcall_float_widen)
+)
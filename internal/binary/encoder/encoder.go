@@ -21,6 +21,7 @@ import (
     `sync/atomic`
     `unsafe`
 
+    `github.com/cloudwego/frugal/internal/binary/defs`
     `github.com/cloudwego/frugal/internal/opts`
     `github.com/cloudwego/frugal/internal/rt`
     `github.com/cloudwego/frugal/internal/utils`
@@ -36,6 +37,20 @@ type Encoder func (
     st  int,
 ) (int, error)
 
+type encodeFunc func (
+    vt  *rt.GoType,
+    buf unsafe.Pointer,
+    len int,
+    mem iov.BufferWriter,
+    p   unsafe.Pointer,
+    rs  *RuntimeState,
+    st  int,
+) (int, error)
+
+const (
+    _MinAppend = 256
+)
+
 var (
     HitCount  uint64 = 0
     MissCount uint64 = 0
@@ -44,6 +59,7 @@ var (
 
 var (
     programCache = utils.CreateProgramCache()
+    compactCache = utils.CreateProgramCache()
 )
 
 func encode(vt *rt.GoType, buf unsafe.Pointer, len int, mem iov.BufferWriter, p unsafe.Pointer, rs *RuntimeState, st int) (int, error) {
@@ -54,35 +70,98 @@ func encode(vt *rt.GoType, buf unsafe.Pointer, len int, mem iov.BufferWriter, p
     }
 }
 
+func encodeCompact(vt *rt.GoType, buf unsafe.Pointer, len int, mem iov.BufferWriter, p unsafe.Pointer, rs *RuntimeState, st int) (int, error) {
+    if enc, err := resolveCompact(vt); err != nil {
+        return -1, err
+    } else {
+        return enc(buf, len, mem, p, rs, st)
+    }
+}
+
+func (self Encoder) Encode(buf []byte, mem iov.BufferWriter, p unsafe.Pointer) (int, error) {
+    return self.EncodeWithOptions(buf, mem, p, opts.GetDefaultOptions())
+}
+
+func (self Encoder) EncodeWithOptions(buf []byte, mem iov.BufferWriter, p unsafe.Pointer, o opts.Options) (int, error) {
+    rst := newRuntimeState()
+    out := (*rt.GoSlice)(unsafe.Pointer(&buf))
+    rst.Cn = o.Canonical
+    ret, err := self(out.Ptr, out.Len, mem, p, rst, 0)
+
+    /* return the state into pool */
+    freeRuntimeState(rst)
+    return ret, err
+}
+
+func Resolve(vt *rt.GoType, pt defs.Protocol) (Encoder, error) {
+    if pt == defs.Compact {
+        return resolveCompact(vt)
+    } else {
+        return resolve(vt)
+    }
+}
+
 func resolve(vt *rt.GoType) (Encoder, error) {
+    return resolveWith(programCache, vt, compile)
+}
+
+func resolveCompact(vt *rt.GoType) (Encoder, error) {
+    return resolveWith(compactCache, vt, compileCompact)
+}
+
+func resolveWith(pc *utils.ProgramCache, vt *rt.GoType, fn func(*rt.GoType) (interface{}, error)) (Encoder, error) {
     var err error
     var val interface{}
 
     /* fast-path: type is cached */
-    if val = programCache.Get(vt); val != nil {
+    if val = pc.Get(vt); val != nil {
         atomic.AddUint64(&HitCount, 1)
         return val.(Encoder), nil
     }
 
     /* record the cache miss, and compile the type */
     atomic.AddUint64(&MissCount, 1)
-    val, err = programCache.Compute(vt, compile)
+    val, err = pc.Compute(vt, fn)
 
     /* check for errors */
     if err != nil {
         return nil, err
     }
 
-    /* record the successful compilation */
+    /* record the successful compilation, and keep the cache within the budget */
     atomic.AddUint64(&TypeCount, 1)
+    shrink(pc)
     return val.(Encoder), nil
 }
 
+func shrink(pc *utils.ProgramCache) {
+    if n := pc.Shrink(opts.MaxCachedPrograms); n != 0 {
+        atomic.AddUint64(&TypeCount, ^uint64(n - 1))
+    }
+}
+
+// Evict removes the programs of vt from the caches, they will be compiled again on the next use.
+func Evict(vt *rt.GoType) {
+    for _, pc := range []*utils.ProgramCache { programCache, compactCache } {
+        if pc.Delete(vt) {
+            atomic.AddUint64(&TypeCount, ^uint64(0))
+        }
+    }
+}
+
 func compile(vt *rt.GoType) (interface{}, error) {
     if pp, err := CreateCompiler().CompileAndFree(vt.Pack()); err != nil {
         return nil, err
     } else {
-        return Link(Translate(pp)), nil
+        return Link(pp), nil
+    }
+}
+
+func compileCompact(vt *rt.GoType) (interface{}, error) {
+    if pp, err := CreateCompiler().Protocol(defs.Compact).CompileAndFree(vt.Pack()); err != nil {
+        return nil, err
+    } else {
+        return Link(pp), nil
     }
 }
 
@@ -91,7 +170,7 @@ func mkcompile(opts opts.Options) func(*rt.GoType) (interface{}, error) {
         if pp, err := CreateCompiler().Apply(opts).CompileAndFree(vt.Pack()); err != nil {
             return nil, err
         } else {
-            return Link(Translate(pp)), nil
+            return Link(pp), nil
         }
     }
 }
@@ -103,6 +182,7 @@ func Pretouch(vt *rt.GoType, opts opts.Options) error {
         return err
     } else {
         atomic.AddUint64(&TypeCount, 1)
+        shrink(programCache)
         return nil
     }
 }
@@ -115,16 +195,81 @@ func EncodedSize(val interface{}) int {
     }
 }
 
+func EncodedSizeCompact(val interface{}) int {
+    if ret, err := EncodeCompact(nil, nil, val); err != nil {
+        panic(fmt.Errorf("frugal: cannot measure encoded size: %w", err))
+    } else {
+        return ret
+    }
+}
+
 func EncodeObject(buf []byte, mem iov.BufferWriter, val interface{}) (ret int, err error) {
+    return encodeObject(buf, mem, val, encode, false)
+}
+
+func EncodeCompact(buf []byte, mem iov.BufferWriter, val interface{}) (ret int, err error) {
+    return encodeObject(buf, mem, val, encodeCompact, false)
+}
+
+func EncodeObjectWithOptions(buf []byte, mem iov.BufferWriter, val interface{}, o opts.Options) (ret int, err error) {
+    return encodeObject(buf, mem, val, encode, o.Canonical)
+}
+
+func EncodeCompactWithOptions(buf []byte, mem iov.BufferWriter, val interface{}, o opts.Options) (ret int, err error) {
+    return encodeObject(buf, mem, val, encodeCompact, o.Canonical)
+}
+
+func AppendObject(buf []byte, val interface{}) ([]byte, error) {
+    nb := len(buf)
+    mm := utils.MaxInt(cap(buf) - nb, _MinAppend)
+
+    /* retry with a larger buffer until the object fits */
+    for {
+        if cap(buf) - nb < mm {
+            buf = growBuffer(buf, mm)
+        }
+
+        /* encode into the remaining capacity */
+        ret, err := EncodeObject(buf[nb:cap(buf)], nil, val)
+        if err == nil {
+            return buf[:nb + ret], nil
+        }
+
+        /* the required size is at least ret */
+        if err != _E_nomem {
+            return buf, err
+        } else {
+            mm = utils.MaxInt(ret, mm * 2)
+        }
+    }
+}
+
+func growBuffer(buf []byte, n int) []byte {
+    ret := make([]byte, len(buf), len(buf) + n)
+    copy(ret, buf)
+    return ret
+}
+
+func boxPointer(p unsafe.Pointer) unsafe.Pointer {
+    ret := new(unsafe.Pointer)
+    *ret = p
+    return unsafe.Pointer(ret)
+}
+
+func encodeObject(buf []byte, mem iov.BufferWriter, val interface{}, fn encodeFunc, cn bool) (ret int, err error) {
     rst := newRuntimeState()
     efv := rt.UnpackEface(val)
     out := (*rt.GoSlice)(unsafe.Pointer(&buf))
+    rst.Cn = cn
 
-    /* check for indirect types */
+    /* check for indirect types, direct values can only be passed from the stack to native code,
+     * the interpreter keeps the pointer in the heap, which is not updated when the stack moves */
     if efv.Type.IsIndirect() {
-        ret, err = encode(efv.Type, out.Ptr, out.Len, mem, efv.Value, rst, 0)
+        ret, err = fn(efv.Type, out.Ptr, out.Len, mem, efv.Value, rst, 0)
+    } else if !isNative() {
+        ret, err = fn(efv.Type, out.Ptr, out.Len, mem, boxPointer(efv.Value), rst, 0)
     } else {
-        ret, err = encode(efv.Type, out.Ptr, out.Len, mem, rt.NoEscape(unsafe.Pointer(&efv.Value)), rst, 0)
+        ret, err = fn(efv.Type, out.Ptr, out.Len, mem, rt.NoEscape(unsafe.Pointer(&efv.Value)), rst, 0)
     }
 
     /* return the state into pool */
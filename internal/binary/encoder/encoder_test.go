@@ -19,14 +19,24 @@ package encoder
 import (
     `bytes`
     `encoding/base64`
+    `encoding/binary`
+    `reflect`
+    `runtime`
+    `sync`
     `testing`
+    `unsafe`
 
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/opts`
+    `github.com/cloudwego/frugal/internal/rt`
+    `github.com/cloudwego/frugal/internal/snapshot`
+    `github.com/cloudwego/frugal/internal/utils`
     `github.com/davecgh/go-spew/spew`
     `github.com/stretchr/testify/require`
 )
 
-func TestEncoder_Encode(t *testing.T) {
-    v := TranslatorTestStruct {
+func newTranslatorTestStruct() TranslatorTestStruct {
+    return TranslatorTestStruct {
         A: true,
         B: 0x12,
         C: 12.34,
@@ -49,6 +59,10 @@ func TestEncoder_Encode(t *testing.T) {
         P: &(&struct{ x int32 }{0x123456}).x,
         Q: &(&struct{ x int64 }{0x12345678}).x,
     }
+}
+
+func TestEncoder_Encode(t *testing.T) {
+    v := newTranslatorTestStruct()
     nb := EncodedSize(v)
     println("Estimated Size:", nb)
     buf := make([]byte, nb)
@@ -96,3 +110,446 @@ func TestEncoder_ListI8Unique(t *testing.T) {
         t.Fatal(err)
     }
 }
+
+func TestEncoder_Compact(t *testing.T) {
+    v := newTranslatorTestStruct()
+    nb := EncodedSizeCompact(v)
+    buf := make([]byte, nb)
+    ret, err := EncodeCompact(buf, nil, v)
+    require.NoError(t, err)
+    require.Equal(t, nb, ret)
+    require.Equal(t, byte(0), buf[ret - 1])
+}
+
+type CompactDeltaTest struct {
+    A *int32 `frugal:"1,optional,i32"`
+    B *bool  `frugal:"2,optional,bool"`
+    C int8   `frugal:"3,default,i8"`
+    D *int8  `frugal:"20,optional,i8"`
+    E int8   `frugal:"21,default,i8"`
+}
+
+func TestEncoder_CompactDelta(t *testing.T) {
+    a, b, d := int32(1), true, int8(3)
+    f := false
+    for _, tc := range []struct {
+        v   CompactDeltaTest
+        exp []byte
+    } {
+        { CompactDeltaTest { A: &a, B: &b, C: 2, D: &d, E: 4 }, []byte { 0x15, 0x02, 0x11, 0x13, 0x02, 0x03, 0x28, 0x03, 0x13, 0x04, 0x00 } },
+        { CompactDeltaTest { B: &f, C: 2, E: 4 }                , []byte { 0x22, 0x13, 0x02, 0x03, 0x2a, 0x04, 0x00 } },
+        { CompactDeltaTest { C: 2, E: 4 }                       , []byte { 0x33, 0x02, 0x03, 0x2a, 0x04, 0x00 } },
+    } {
+        buf := make([]byte, EncodedSizeCompact(tc.v))
+        ret, err := EncodeCompact(buf, nil, tc.v)
+        require.NoError(t, err)
+        require.Equal(t, len(buf), ret)
+        require.Equal(t, tc.exp, buf)
+    }
+}
+
+type UnknownFieldsTest struct {
+    A int32  `frugal:"1,default,i32"`
+    U []byte `frugal:"_unknown"`
+}
+
+func TestEncoder_UnknownFields(t *testing.T) {
+    v := UnknownFieldsTest {
+        A: 0x2a,
+        U: []byte{0x0b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x02, 0x68, 0x69},
+    }
+    nb := EncodedSize(v)
+    buf := make([]byte, nb)
+    ret, err := EncodeObject(buf, nil, v)
+    require.NoError(t, err)
+    require.Equal(t, nb, ret)
+    require.Equal(t, []byte {
+        0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x2a, 0x0b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x02, 0x68, 0x69,
+        0x00,
+    }, buf)
+}
+
+type UnionTest struct {
+    _ struct{} `frugal:"union"`
+    A *int32   `frugal:"1,optional,i32"`
+    B *string  `frugal:"2,optional,string"`
+}
+
+func TestEncoder_Union(t *testing.T) {
+    a, b := int32(0x2a), "hi"
+    buf := make([]byte, 64)
+    ret, err := EncodeObject(buf, nil, UnionTest { A: &a })
+    require.NoError(t, err)
+    require.Equal(t, []byte { 0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x2a, 0x00 }, buf[:ret])
+    _, err = EncodeObject(buf, nil, UnionTest {})
+    require.Error(t, err)
+    _, err = EncodeObject(buf, nil, UnionTest { A: &a, B: &b })
+    require.Error(t, err)
+}
+
+func TestEncoder_UnionMeasure(t *testing.T) {
+    a, b := int32(0x2a), "hi"
+    buf := make([]byte, 64)
+    require.Equal(t, 8, EncodedSize(UnionTest { A: &a }))
+    ret, err := EncodeCompact(buf, nil, UnionTest { B: &b })
+    require.NoError(t, err)
+    require.Equal(t, ret, EncodedSizeCompact(UnionTest { B: &b }))
+
+    /* measuring must reject the invalid unions as well */
+    for _, v := range []UnionTest { {}, { A: &a, B: &b } } {
+        _, err := EncodeObject(nil, nil, v)
+        require.ErrorIs(t, err, utils.ErrInvalidData)
+        _, err = EncodeCompact(nil, nil, v)
+        require.ErrorIs(t, err, utils.ErrInvalidData)
+        require.Panics(t, func() { EncodedSize(v) })
+        require.Panics(t, func() { EncodedSizeCompact(v) })
+    }
+}
+
+type UnsignedTest struct {
+    A uint32  `frugal:"1,default,i32,unsigned"`
+    B float32 `frugal:"2,default,double"`
+    C [4]byte `frugal:"3,default,binary"`
+    D uint64  `frugal:"4,default,i16,unsigned"`
+}
+
+func TestEncoder_Unsigned(t *testing.T) {
+    buf := make([]byte, 64)
+    ret, err := EncodeObject(buf, nil, UnsignedTest { A: 0xffffffff, B: 1.5, C: [4]byte { 'a', 'b', 'c', 'd' }, D: 0xffff })
+    require.NoError(t, err)
+    require.Equal(t, []byte {
+        0x08, 0x00, 0x01, 0xff, 0xff, 0xff, 0xff, 0x04, 0x00, 0x02, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00,
+        0x00, 0x00, 0x0b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x04, 0x61, 0x62, 0x63, 0x64, 0x06, 0x00, 0x04,
+        0xff, 0xff, 0x00,
+    }, buf[:ret])
+    _, err = EncodeObject(buf, nil, UnsignedTest { D: 0x10000 })
+    require.Error(t, err)
+    _, err = EncodeCompact(buf, nil, UnsignedTest { D: 0x10000 })
+    require.Error(t, err)
+}
+
+type CodecTestPoint struct {
+    X int16
+    Y int16
+}
+
+type CodecTest struct {
+    P CodecTestPoint  `frugal:"1,default,i32"`
+    Q *CodecTestPoint `frugal:"2,optional,i32"`
+}
+
+func init() {
+    err := defs.RegisterCodec(
+        reflect.TypeOf(CodecTestPoint{}),
+        "i32",
+        func(buf []byte, v interface{}) (int, error) {
+            binary.BigEndian.PutUint16(buf, uint16(v.(*CodecTestPoint).X))
+            binary.BigEndian.PutUint16(buf[2:], uint16(v.(*CodecTestPoint).Y))
+            return 4, nil
+        },
+        func(buf []byte, v interface{}) error {
+            panic("not implemented")
+        },
+        nil,
+    )
+    if err != nil {
+        panic(err)
+    }
+}
+
+func TestEncoder_Codec(t *testing.T) {
+    v := CodecTest { P: CodecTestPoint { X: 1, Y: 2 } }
+    buf := make([]byte, 64)
+    ret, err := EncodeObject(buf, nil, v)
+    require.NoError(t, err)
+    require.Equal(t, EncodedSize(v), ret)
+    require.Equal(t, []byte { 0x08, 0x00, 0x01, 0x00, 0x01, 0x00, 0x02, 0x00 }, buf[:ret])
+    ret, err = EncodeCompact(buf, nil, v)
+    require.NoError(t, err)
+    require.Equal(t, EncodedSizeCompact(v), ret)
+    require.Equal(t, []byte { 0x15, 0x84, 0x80, 0x08, 0x00 }, buf[:ret])
+    _, err = EncodeCompact(buf[:3], nil, v)
+    require.Equal(t, _E_nomem, err)
+}
+
+type RawValueTest struct {
+    B []byte
+}
+
+func (self *RawValueTest) RawType() reflect.Type                              { return nil }
+func (self *RawValueTest) RawSize(pt defs.Protocol) (int, error)              { return len(self.B), nil }
+func (self *RawValueTest) RawEncode(buf []byte, pt defs.Protocol) (int, error) { return copy(buf, self.B), nil }
+func (self *RawValueTest) RawDecode(buf []byte, pt defs.Protocol) error        { panic("not implemented") }
+
+type RawCodecTest struct {
+    R RawValueTest  `frugal:"1,default,struct"`
+    P *RawValueTest `frugal:"2,optional,struct"`
+}
+
+func TestEncoder_RawCodec(t *testing.T) {
+    v := RawCodecTest { R: RawValueTest { B: []byte { 0x03, 0x00, 0x01, 0x05, 0x00 } } }
+    buf := make([]byte, 64)
+    ret, err := EncodeObject(buf, nil, v)
+    require.NoError(t, err)
+    require.Equal(t, EncodedSize(v), ret)
+    require.Equal(t, []byte { 0x0c, 0x00, 0x01, 0x03, 0x00, 0x01, 0x05, 0x00, 0x00 }, buf[:ret])
+    v.P = &RawValueTest { B: []byte { 0x00 } }
+    ret, err = EncodeCompact(buf, nil, v)
+    require.NoError(t, err)
+    require.Equal(t, EncodedSizeCompact(v), ret)
+    require.Equal(t, []byte { 0x1c, 0x03, 0x00, 0x01, 0x05, 0x00, 0x1c, 0x00, 0x00 }, buf[:ret])
+    _, err = EncodeObject(buf[:5], nil, v)
+    require.Equal(t, _E_nomem, err)
+}
+
+type RawWireCodecTest struct {
+    L RawValueTest `frugal:"1,default,list<i32>"`
+    I RawValueTest `frugal:"2,default,i32"`
+}
+
+func TestEncoder_RawWireCodec(t *testing.T) {
+    v := RawWireCodecTest {
+        L: RawValueTest { B: []byte { 0x08, 0x00, 0x00, 0x00, 0x00 } },
+        I: RawValueTest { B: []byte { 0x00, 0x00, 0x00, 0x07 } },
+    }
+    buf := make([]byte, 64)
+    ret, err := EncodeObject(buf, nil, v)
+    require.NoError(t, err)
+    require.Equal(t, EncodedSize(v), ret)
+    require.Equal(t, []byte {
+        0x0f, 0x00, 0x01, 0x08, 0x00, 0x00, 0x00, 0x00,
+        0x08, 0x00, 0x02, 0x00, 0x00, 0x00, 0x07, 0x00,
+    }, buf[:ret])
+}
+
+type CanonicalKey struct {
+    A int32 `frugal:"1,default,i32"`
+}
+
+type CanonicalTest struct {
+    M map[int32]string          `frugal:"1,default,map<i32:string>"`
+    S []string                  `frugal:"2,default,set<string>"`
+    B []int8                    `frugal:"3,default,set<i8>"`
+    K map[*CanonicalKey]float64 `frugal:"4,default,map<CanonicalKey:double>"`
+}
+
+func newCanonicalTest() CanonicalTest {
+    return CanonicalTest {
+        M: map[int32]string { 3: "c", -1: "a", 2: "b", 100: "d", -100: "e" },
+        S: []string { "b", "a", "c" },
+        B: []int8 { 5, -2, 1 },
+        K: map[*CanonicalKey]float64 { { 2 }: 1.5, { 1 }: 2.5 },
+    }
+}
+
+func TestEncoder_Canonical(t *testing.T) {
+    var exp bytes.Buffer
+    put := func(v ...interface{}) { for _, x := range v { _ = binary.Write(&exp, binary.BigEndian, x) } }
+    put(uint8(0x0d), int16(1), uint8(0x08), uint8(0x0b), int32(5))
+    put(int32(-100), int32(1), uint8('e'), int32(-1), int32(1), uint8('a'), int32(2), int32(1), uint8('b'))
+    put(int32(3), int32(1), uint8('c'), int32(100), int32(1), uint8('d'))
+    put(uint8(0x0e), int16(2), uint8(0x0b), int32(3), int32(1), uint8('a'), int32(1), uint8('b'), int32(1), uint8('c'))
+    put(uint8(0x0e), int16(3), uint8(0x03), int32(3), int8(-2), int8(1), int8(5))
+    put(uint8(0x0d), int16(4), uint8(0x0c), uint8(0x04), int32(2))
+    put(uint8(0x08), int16(1), int32(1), uint8(0), 2.5, uint8(0x08), int16(1), int32(2), uint8(0), 1.5, uint8(0))
+    for i := 0; i < 16; i++ {
+        v := newCanonicalTest()
+        buf := make([]byte, EncodedSize(v))
+        ret, err := EncodeObjectWithOptions(buf, nil, v, opts.Options { Canonical: true })
+        require.NoError(t, err)
+        require.Equal(t, exp.Bytes(), buf[:ret])
+        require.Equal(t, []string { "b", "a", "c" }, v.S)
+    }
+    v := newCanonicalTest()
+    buf := make([]byte, EncodedSizeCompact(v))
+    ret, err := EncodeCompactWithOptions(buf, nil, v, opts.Options { Canonical: true })
+    require.NoError(t, err)
+    for i := 0; i < 16; i++ {
+        out := make([]byte, ret)
+        nb, err := EncodeCompactWithOptions(out, nil, newCanonicalTest(), opts.Options { Canonical: true })
+        require.NoError(t, err)
+        require.Equal(t, buf[:ret], out[:nb])
+    }
+    v.S = append(v.S, "a")
+    _, err = EncodeObjectWithOptions(buf, nil, v, opts.Options { Canonical: true })
+    require.Error(t, err)
+}
+
+func TestEncoder_Resolve(t *testing.T) {
+    v := TranslatorTestStruct {
+        G: "hello, world",
+        I: []int32{1, 2, 3},
+    }
+    for _, pt := range []defs.Protocol { defs.Binary, defs.Compact } {
+        enc, err := Resolve(rt.UnpackEface(v).Type, pt)
+        require.NoError(t, err)
+        nb, err := enc.Encode(nil, nil, unsafe.Pointer(&v))
+        require.NoError(t, err)
+        buf := make([]byte, nb)
+        ret, err := enc.Encode(buf, nil, unsafe.Pointer(&v))
+        require.NoError(t, err)
+        require.Equal(t, nb, ret)
+        exp := make([]byte, nb)
+        if pt == defs.Compact {
+            _, err = EncodeCompact(exp, nil, v)
+        } else {
+            _, err = EncodeObject(exp, nil, v)
+        }
+        require.NoError(t, err)
+        require.Equal(t, exp, buf)
+        _, err = enc.Encode(buf[:nb - 1], nil, unsafe.Pointer(&v))
+        require.Equal(t, _E_nomem, err)
+    }
+    _, err := Resolve(rt.UnpackType(reflect.TypeOf(make(chan int))), defs.Binary)
+    require.Error(t, err)
+}
+
+func TestEncoder_AppendObject(t *testing.T) {
+    v := TranslatorTestStruct {
+        G: "hello, world",
+        H: bytes.Repeat([]byte("x"), 1000),
+        I: []int32{1, 2, 3},
+        J: map[string]string{"asdf": "qwer"},
+        K: map[string]*TranslatorTestStruct{"foo": {G: "bar"}},
+    }
+    exp := make([]byte, EncodedSize(v))
+    _, err := EncodeObject(exp, nil, v)
+    require.NoError(t, err)
+    for i := 0; i <= len(exp); i++ {
+        buf, err := AppendObject(make([]byte, 3, 3 + i), v)
+        require.NoError(t, err)
+        require.Equal(t, exp, buf[3:])
+    }
+}
+
+func TestEncoder_BufferTooSmall(t *testing.T) {
+    v := TranslatorTestStruct {
+        G: "hello, world",
+        H: []byte("testbytebuffer"),
+        I: []int32{1, 2, 3},
+    }
+    nb := EncodedSize(v)
+    for i := 0; i < nb; i++ {
+        buf := bytes.Repeat([]byte{0xcc}, nb)
+        ret, err := EncodeObject(buf[:i], nil, v)
+        require.Equal(t, _E_nomem, err)
+        require.Greater(t, ret, i)
+        require.Equal(t, bytes.Repeat([]byte{0xcc}, nb - i), buf[i:])
+    }
+}
+
+type ErrorTagTest struct {
+    A int32 `frugal:"1,default"`
+}
+
+func TestEncoder_ErrorKinds(t *testing.T) {
+    a, b := int32(0x2a), "hi"
+    buf := make([]byte, 64)
+    _, err := EncodeObject(buf[:4], nil, UnsignedTest { A: 1 })
+    require.ErrorIs(t, err, utils.ErrBufferTooSmall)
+    _, err = EncodeObject(buf, nil, UnsignedTest { D: 0x10000 })
+    require.ErrorIs(t, err, utils.ErrInvalidData)
+    _, err = EncodeObject(buf, nil, UnionTest { A: &a, B: &b })
+    require.ErrorIs(t, err, utils.ErrInvalidData)
+    require.EqualError(t, err, "frugal: exactly one field of a union must be set")
+    _, err = EncodeObject(buf, nil, ErrorTagTest{})
+    require.ErrorIs(t, err, utils.ErrUnsupportedType)
+    _, err = Resolve(rt.UnpackType(reflect.TypeOf(make(chan int))), defs.Binary)
+    require.ErrorIs(t, err, utils.ErrUnsupportedType)
+}
+
+type SnapshotTest struct {
+    A int32  `frugal:"1,default,i32"`
+    B string `frugal:"2,default,string"`
+}
+
+func TestEncoder_Snapshot(t *testing.T) {
+    if snapshotLinker() == nil {
+        t.Skip("snapshots are not supported by the current backend")
+    }
+    var sb bytes.Buffer
+    v := SnapshotTest { A: 12345, B: "hello" }
+    vt := rt.UnpackEface(v).Type
+    exp := make([]byte, EncodedSize(v))
+    _, err := EncodeObject(exp, nil, v)
+    require.NoError(t, err)
+    progs, err := Snapshot(nil)
+    require.NoError(t, err)
+    _, err = snapshot.Write(&sb, progs)
+    require.NoError(t, err)
+    progs, err = snapshot.Read(&sb)
+    require.NoError(t, err)
+    Evict(vt)
+    for i := range progs {
+        if progs[i].Type == vt && progs[i].Protocol == defs.Binary {
+            require.NoError(t, Restore(&progs[i]))
+        }
+    }
+    require.NotNil(t, programCache.Get(vt))
+    buf := make([]byte, len(exp))
+    _, err = EncodeObject(buf, nil, v)
+    require.NoError(t, err)
+    require.Equal(t, exp, buf)
+}
+
+type DeepNestingTest struct {
+    N *DeepNestingTest `frugal:"1,optional,DeepNestingTest"`
+    V int64            `frugal:"2,default,i64"`
+}
+
+type DeepNestingHead struct {
+    N *DeepNestingTest `frugal:"1,optional,DeepNestingTest"`
+}
+
+func TestEncoder_DeepNesting(t *testing.T) {
+    var p *DeepNestingTest
+    for i := 0; i < 900; i++ {
+        p = &DeepNestingTest { N: p, V: int64(i) }
+    }
+
+    /* DeepNestingHead is a direct type, so it is passed by a pointer to the encoder */
+    v := DeepNestingHead { N: p }
+    exp := make([]byte, EncodedSize(&v))
+    _, err := EncodeObject(exp, nil, &v)
+    require.NoError(t, err)
+
+    /* keep the GC running while the stacks of fresh goroutines grow during the nested calls */
+    var wg sync.WaitGroup
+    stop := make(chan struct{})
+    go func() {
+        for {
+            select {
+                case <-stop : return
+                default     : runtime.GC()
+            }
+        }
+    }()
+
+    /* every goroutine must give the same result */
+    for i := 0; i < 200; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            buf := make([]byte, len(exp))
+            _, err := EncodeObject(buf, nil, v)
+            require.NoError(t, err)
+            require.Equal(t, exp, buf)
+        }()
+    }
+
+    /* stop the GC loop */
+    wg.Wait()
+    close(stop)
+}
+
+func TestEncoder_PretouchMaxCachedPrograms(t *testing.T) {
+    old := opts.MaxCachedPrograms
+    opts.MaxCachedPrograms = 1
+    defer func() { opts.MaxCachedPrograms = old }()
+    for _, v := range []interface{} { TranslatorTestStruct{}, UnionTest{} } {
+        vt := rt.UnpackEface(v).Type
+        Evict(vt)
+        require.NoError(t, Pretouch(vt, opts.GetDefaultOptions()))
+    }
+    require.Equal(t, 1, programCache.Len())
+}
@@ -0,0 +1,1026 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoder
+
+import (
+    `encoding/binary`
+    `reflect`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/rt`
+    `github.com/cloudwego/frugal/iov`
+)
+
+/** Interpreter
+ *
+ *      Every instruction is compiled into a closure with all the operands resolved, which
+ *      runs the instruction and returns the index of the next one, or _PC_halt to stop.
+ */
+
+type (
+    _Op func(fr *_Frame) int
+)
+
+type _Frame struct {
+    buf unsafe.Pointer
+    rl  int
+    rc  int
+    mem iov.BufferWriter
+    wp  unsafe.Pointer
+    rs  *RuntimeState
+    st  int
+    err error
+}
+
+const (
+    _PC_halt = -1
+)
+
+func (self *_Frame) at(i int) unsafe.Pointer {
+    return unsafe.Pointer(uintptr(self.buf) + uintptr(i))
+}
+
+func (self *_Frame) field(d uintptr) unsafe.Pointer {
+    return unsafe.Pointer(uintptr(self.wp) + d)
+}
+
+func (self *_Frame) state() *StateItem {
+    return (*StateItem)(unsafe.Pointer(uintptr(unsafe.Pointer(self.rs)) + uintptr(self.st)))
+}
+
+func (self *_Frame) fail(err error) int {
+    self.err = err
+    return _PC_halt
+}
+
+func (self *_Frame) nomem(n int) int {
+    self.rl = n
+    return self.fail(_E_nomem)
+}
+
+func (self *_Frame) bytes(n int) []byte {
+    p := self.at(self.rl)
+    self.rl += n
+    return rt.BytesFrom(p, n, n)
+}
+
+func (self *_Frame) put(v uint64, n int64) {
+    switch n {
+        case 1  : *(*uint8)(self.at(self.rl)) = uint8(v)
+        case 2  : binary.BigEndian.PutUint16(self.bytes(2), uint16(v)); return
+        case 4  : binary.BigEndian.PutUint32(self.bytes(4), uint32(v)); return
+        case 8  : binary.BigEndian.PutUint64(self.bytes(8), v); return
+        default : panic("can only convert 1, 2, 4 or 8 bytes at a time")
+    }
+
+    /* single byte */
+    self.rl++
+}
+
+func (self *_Frame) advance(i int) bool {
+    if i > self.rc {
+        self.nomem(i)
+        return false
+    } else {
+        self.rl = i
+        return true
+    }
+}
+
+func load(p unsafe.Pointer, n int32) uint64 {
+    switch n {
+        case 1  : return uint64(*(*uint8)(p))
+        case 2  : return uint64(*(*uint16)(p))
+        case 4  : return uint64(*(*uint32)(p))
+        case 8  : return *(*uint64)(p)
+        default : panic("can only load 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func maplen(p unsafe.Pointer) int {
+    if mp := *(**rt.GoMap)(p); mp == nil {
+        return 0
+    } else {
+        return mp.Count
+    }
+}
+
+func slicelen(p unsafe.Pointer) int {
+    return (*rt.GoSlice)(p).Len
+}
+
+func interpret(s Program) []_Op {
+    ret := make([]_Op, 0, len(s) + 1)
+
+    /* compile every instruction */
+    for i, v := range s {
+        ret = append(ret, interpreters[v.Op](v, i + 1))
+    }
+
+    /* running off the end of the program halts */
+    return append(ret, interpret_OP_halt(Instr{}, len(s) + 1))
+}
+
+var (
+    interpreters [256]func(Instr, int) _Op
+)
+
+/* the table is filled at init time, since the deferred ops refer back to the linker */
+func init() {
+    interpreters = [256]func(Instr, int) _Op {
+        OP_size_check     : interpret_OP_size_check,
+        OP_size_const     : interpret_OP_size_const,
+        OP_size_dyn       : interpret_OP_size_dyn,
+        OP_size_map       : interpret_OP_size_map,
+        OP_size_defer     : interpret_OP_size_defer,
+        OP_size_codec     : interpret_OP_size_codec,
+        OP_size_varint    : interpret_OP_size_varint,
+        OP_size_varint_u  : interpret_OP_size_varint_u,
+        OP_size_length_uv : interpret_OP_size_length_uv,
+        OP_size_map_head  : interpret_OP_size_map_head,
+        OP_size_list_head : interpret_OP_size_list_head,
+        OP_size_field     : interpret_OP_size_field,
+        OP_byte           : interpret_OP_byte,
+        OP_word           : interpret_OP_word,
+        OP_long           : interpret_OP_long,
+        OP_quad           : interpret_OP_quad,
+        OP_sint           : interpret_OP_sint,
+        OP_length         : interpret_OP_length,
+        OP_memcpy_be      : interpret_OP_memcpy_be,
+        OP_memcpy         : interpret_OP_memcpy,
+        OP_uint           : interpret_OP_uint,
+        OP_float          : interpret_OP_float,
+        OP_bool           : interpret_OP_bool,
+        OP_varint         : interpret_OP_varint,
+        OP_varint_u       : interpret_OP_varint_u,
+        OP_sint_le        : interpret_OP_sint_le,
+        OP_float_le       : interpret_OP_float_le,
+        OP_length_uv      : interpret_OP_length_uv,
+        OP_memcpy_le      : interpret_OP_memcpy_le,
+        OP_map_head       : interpret_OP_map_head,
+        OP_list_head      : interpret_OP_list_head,
+        OP_field_head     : interpret_OP_field_head,
+        OP_field_reset    : interpret_OP_field_reset,
+        OP_seek           : interpret_OP_seek,
+        OP_deref          : interpret_OP_deref,
+        OP_defer          : interpret_OP_defer,
+        OP_codec          : interpret_OP_codec,
+        OP_map_len        : interpret_OP_map_len,
+        OP_map_key        : interpret_OP_map_key,
+        OP_map_next       : interpret_OP_map_next,
+        OP_map_value      : interpret_OP_map_value,
+        OP_map_begin      : interpret_OP_map_begin,
+        OP_map_if_next    : interpret_OP_map_if_next,
+        OP_map_if_empty   : interpret_OP_map_if_empty,
+        OP_map_sort_begin : interpret_OP_map_sort_begin,
+        OP_map_sort_next  : interpret_OP_map_sort_next,
+        OP_set_sort_begin : interpret_OP_set_sort_begin,
+        OP_list_decr      : interpret_OP_list_decr,
+        OP_list_begin     : interpret_OP_list_begin,
+        OP_list_if_next   : interpret_OP_list_if_next,
+        OP_list_if_empty  : interpret_OP_list_if_empty,
+        OP_unique         : interpret_OP_unique,
+        OP_union_check    : interpret_OP_union_check,
+        OP_goto           : interpret_OP_goto,
+        OP_if_nil         : interpret_OP_if_nil,
+        OP_if_hasbuf      : interpret_OP_if_hasbuf,
+        OP_if_eq_imm      : interpret_OP_if_eq_imm,
+        OP_if_eq_str      : interpret_OP_if_eq_str,
+        OP_if_canonical   : interpret_OP_if_canonical,
+        OP_make_state     : interpret_OP_make_state,
+        OP_drop_state     : interpret_OP_drop_state,
+        OP_halt           : interpret_OP_halt,
+    }
+}
+
+func interpret_OP_size_check(v Instr, next int) _Op {
+    n := int(v.Iv)
+    return func(fr *_Frame) int {
+        if fr.rl + n > fr.rc {
+            return fr.nomem(fr.rl + n)
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_size_const(v Instr, next int) _Op {
+    n := int(v.Iv)
+    return func(fr *_Frame) int {
+        fr.rl += n
+        return next
+    }
+}
+
+func interpret_OP_size_dyn(v Instr, next int) _Op {
+    d := uintptr(v.Uv)
+    n := int(v.Iv)
+
+    /* the size is proportional to the length */
+    return func(fr *_Frame) int {
+        fr.rl += *(*int)(fr.field(d)) * n
+        return next
+    }
+}
+
+func interpret_OP_size_map(v Instr, next int) _Op {
+    n := int(v.Iv)
+    return func(fr *_Frame) int {
+        fr.rl += maplen(fr.wp) * n
+        return next
+    }
+}
+
+func interpret_OP_size_defer(v Instr, next int) _Op {
+    vt := v.Vt()
+    enc := encodeFuncOf(v)
+
+    /* measure the value with its own program */
+    return func(fr *_Frame) int {
+        if n, err := enc(vt, nil, 0, nil, fr.wp, fr.rs, fr.st); err != nil {
+            return fr.fail(err)
+        } else {
+            fr.rl += n
+            return next
+        }
+    }
+}
+
+func interpret_OP_size_codec(v Instr, next int) _Op {
+    pt := int(v.Iv)
+    cc := v.Codec()
+
+    /* measure with the codec */
+    return func(fr *_Frame) int {
+        if n, err := codec_size(cc, fr.wp, pt); err != nil {
+            return fr.fail(err)
+        } else {
+            fr.rl += n
+            return next
+        }
+    }
+}
+
+func interpret_OP_size_varint(v Instr, next int) _Op {
+    w := int(v.Iv)
+    return func(fr *_Frame) int {
+        fr.rl += compact_varint_size(fr.wp, w)
+        return next
+    }
+}
+
+func interpret_OP_size_varint_u(v Instr, next int) _Op {
+    n := v.Uv
+    w := int(v.Iv)
+
+    /* the value is sign-extended from the wire type */
+    return func(fr *_Frame) int {
+        fr.rl += compact_uint_size(load(fr.wp, n), w)
+        return next
+    }
+}
+
+func interpret_OP_size_length_uv(v Instr, next int) _Op {
+    d := uintptr(v.Iv)
+    return func(fr *_Frame) int {
+        fr.rl += compact_uvarint_size(*(*uint64)(fr.field(d)))
+        return next
+    }
+}
+
+func interpret_OP_size_map_head(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.rl += compact_map_size(maplen(fr.wp))
+        return next
+    }
+}
+
+func interpret_OP_size_list_head(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.rl += compact_list_size(slicelen(fr.wp))
+        return next
+    }
+}
+
+func interpret_OP_size_field(v Instr, next int) _Op {
+    id := int(v.Iv)
+    return func(fr *_Frame) int {
+        fr.rl += compact_field_size(unsafe.Pointer(&fr.state().Id), id)
+        return next
+    }
+}
+
+func interpret_OP_byte(v Instr, next int) _Op {
+    x := uint64(v.Iv)
+    return func(fr *_Frame) int {
+        fr.put(x, 1)
+        return next
+    }
+}
+
+func interpret_OP_word(v Instr, next int) _Op {
+    x := uint64(v.Iv)
+    return func(fr *_Frame) int {
+        fr.put(x, 2)
+        return next
+    }
+}
+
+func interpret_OP_long(v Instr, next int) _Op {
+    x := uint64(v.Iv)
+    return func(fr *_Frame) int {
+        fr.put(x, 4)
+        return next
+    }
+}
+
+func interpret_OP_quad(v Instr, next int) _Op {
+    x := uint64(v.Iv)
+    return func(fr *_Frame) int {
+        fr.put(x, 8)
+        return next
+    }
+}
+
+func interpret_OP_sint(v Instr, next int) _Op {
+    n := v.Iv
+    return func(fr *_Frame) int {
+        fr.put(load(fr.wp, int32(n)), n)
+        return next
+    }
+}
+
+func interpret_OP_length(v Instr, next int) _Op {
+    d := uintptr(v.Iv)
+    return func(fr *_Frame) int {
+        fr.put(uint64(*(*int)(fr.field(d))), 4)
+        return next
+    }
+}
+
+func interpret_OP_memcpy_be(v Instr, next int) _Op {
+    d := uintptr(v.Uv)
+    w := v.Iv
+
+    /* special case: unit of a single byte */
+    if w == 1 {
+        return interpret_OP_memcpy_1(d, next)
+    }
+
+    /* swap every element */
+    return func(fr *_Frame) int {
+        n := *(*int)(fr.field(d))
+        p := *(*unsafe.Pointer)(fr.wp)
+
+        /* check for the buffer size */
+        if n == 0 {
+            return next
+        } else if fr.rl + n * int(w) > fr.rc {
+            return fr.nomem(fr.rl + n * int(w))
+        }
+
+        /* load-swap-store sequence */
+        for i := 0; i < n; i++ {
+            fr.put(load(unsafe.Pointer(uintptr(p) + uintptr(i) * uintptr(w)), int32(w)), w)
+        }
+
+        /* all done */
+        return next
+    }
+}
+
+func interpret_OP_memcpy_1(d uintptr, next int) _Op {
+    return func(fr *_Frame) int {
+        n := *(*int)(fr.field(d))
+        p := *(*unsafe.Pointer)(fr.wp)
+
+        /* nothing to copy */
+        if n == 0 {
+            return next
+        }
+
+        /* large blocks are written into the buffer writer directly, if any */
+        if n > int(_N_page) && fr.mem != nil {
+            if err := fr.mem.WriteDirect(rt.BytesFrom(p, n, n), fr.rc - fr.rl); err != nil {
+                return fr.fail(err)
+            } else {
+                return next
+            }
+        }
+
+        /* check for the buffer size */
+        if fr.rl + n > fr.rc {
+            return fr.nomem(fr.rl + n)
+        }
+
+        /* copy the bytes */
+        copy(fr.bytes(n), rt.BytesFrom(p, n, n))
+        return next
+    }
+}
+
+func interpret_OP_memcpy(v Instr, next int) _Op {
+    n := int(v.Iv)
+    return func(fr *_Frame) int {
+        copy(fr.bytes(n), rt.BytesFrom(fr.wp, n, n))
+        return next
+    }
+}
+
+func interpret_OP_uint(v Instr, next int) _Op {
+    n := v.Uv
+    w := v.Iv
+
+    /* check for overflows only if the memory type is wider */
+    return func(fr *_Frame) int {
+        if x := load(fr.wp, n); int64(n) > w && x >> (w * 8) != 0 {
+            return fr.fail(_E_range)
+        } else {
+            fr.put(x, w)
+            return next
+        }
+    }
+}
+
+func interpret_OP_float(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.put(float_widen(uint64(*(*uint32)(fr.wp))), 8)
+        return next
+    }
+}
+
+func interpret_OP_bool(v Instr, next int) _Op {
+    t := uint64(v.Iv)
+    f := uint64(v.Iv + 1)
+
+    /* the false value follows the true value */
+    return func(fr *_Frame) int {
+        if *(*bool)(fr.wp) {
+            fr.put(t, 1)
+        } else {
+            fr.put(f, 1)
+        }
+        return next
+    }
+}
+
+func interpret_OP_varint(v Instr, next int) _Op {
+    w := int(v.Iv)
+    return func(fr *_Frame) int {
+        if !fr.advance(compact_varint(fr.buf, fr.rl, fr.rc, fr.wp, w)) {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_varint_u(v Instr, next int) _Op {
+    n := v.Uv
+    w := int(v.Iv)
+
+    /* check for overflows only if the memory type is wider */
+    return func(fr *_Frame) int {
+        if x := load(fr.wp, n); int(n) > w && x >> (w * 8) != 0 {
+            return fr.fail(_E_range)
+        } else if !fr.advance(compact_uint(fr.buf, fr.rl, fr.rc, x, w)) {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_sint_le(v Instr, next int) _Op {
+    n := int(v.Iv)
+    return func(fr *_Frame) int {
+        switch x := load(fr.wp, int32(n)); n {
+            case 1  : fr.put(x, 1)
+            case 2  : binary.LittleEndian.PutUint16(fr.bytes(2), uint16(x))
+            case 4  : binary.LittleEndian.PutUint32(fr.bytes(4), uint32(x))
+            case 8  : binary.LittleEndian.PutUint64(fr.bytes(8), x)
+            default : panic("can only copy 1, 2, 4 or 8 bytes at a time")
+        }
+        return next
+    }
+}
+
+func interpret_OP_float_le(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        binary.LittleEndian.PutUint64(fr.bytes(8), float_widen(uint64(*(*uint32)(fr.wp))))
+        return next
+    }
+}
+
+func interpret_OP_length_uv(v Instr, next int) _Op {
+    d := uintptr(v.Iv)
+    return func(fr *_Frame) int {
+        if !fr.advance(compact_uvarint(fr.buf, fr.rl, fr.rc, *(*uint64)(fr.field(d)))) {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_memcpy_le(v Instr, next int) _Op {
+    d := uintptr(v.Uv)
+    w := int(v.Iv)
+
+    /* copy the elements as is */
+    return func(fr *_Frame) int {
+        n := *(*int)(fr.field(d)) * w
+        p := *(*unsafe.Pointer)(fr.wp)
+
+        /* check for the buffer size */
+        if n == 0 {
+            return next
+        } else if fr.rl + n > fr.rc {
+            return fr.nomem(fr.rl + n)
+        }
+
+        /* copy the bytes */
+        copy(fr.bytes(n), rt.BytesFrom(p, n, n))
+        return next
+    }
+}
+
+func interpret_OP_map_head(v Instr, next int) _Op {
+    kv := int(v.Iv)
+    return func(fr *_Frame) int {
+        if !fr.advance(compact_map_head(fr.buf, fr.rl, fr.rc, maplen(fr.wp), kv)) {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_list_head(v Instr, next int) _Op {
+    et := int(v.Iv)
+    return func(fr *_Frame) int {
+        if !fr.advance(compact_list_head(fr.buf, fr.rl, fr.rc, slicelen(fr.wp), et)) {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_field_head(v Instr, next int) _Op {
+    id := int(v.Iv)
+    tt := int(v.Uv)
+
+    /* the last written field ID is kept in the state of the struct */
+    return func(fr *_Frame) int {
+        if !fr.advance(compact_field_head(fr.buf, fr.rl, fr.rc, unsafe.Pointer(&fr.state().Id), id, tt)) {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_field_reset(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.state().Id = 0
+        return next
+    }
+}
+
+func interpret_OP_seek(v Instr, next int) _Op {
+    d := uintptr(v.Iv)
+    return func(fr *_Frame) int {
+        fr.wp = fr.field(d)
+        return next
+    }
+}
+
+func interpret_OP_deref(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.wp = *(*unsafe.Pointer)(fr.wp)
+        return next
+    }
+}
+
+func interpret_OP_defer(v Instr, next int) _Op {
+    vt := v.Vt()
+    enc := encodeFuncOf(v)
+
+    /* encode the value with its own program */
+    return func(fr *_Frame) int {
+        var n int
+        var err error
+
+        /* the buffer may be absent when measuring */
+        if fr.buf == nil {
+            n, err = enc(vt, nil, fr.rc - fr.rl, fr.mem, fr.wp, fr.rs, fr.st)
+        } else {
+            n, err = enc(vt, fr.at(fr.rl), fr.rc - fr.rl, fr.mem, fr.wp, fr.rs, fr.st)
+        }
+
+        /* advance the buffer */
+        fr.rl += n
+
+        /* check for errors */
+        if err != nil {
+            return fr.fail(err)
+        } else {
+            return next
+        }
+    }
+}
+
+func encodeFuncOf(v Instr) encodeFunc {
+    switch defs.Protocol(v.Iv) {
+        case defs.Binary  : return encode
+        case defs.Compact : return encodeCompact
+        default           : panic("invalid protocol: " + defs.Protocol(v.Iv).String())
+    }
+}
+
+func interpret_OP_codec(v Instr, next int) _Op {
+    pt := int(v.Iv)
+    cc := v.Codec()
+
+    /* encode with the codec */
+    return func(fr *_Frame) int {
+        if i, err := codec_encode(cc, fr.wp, fr.buf, fr.rl, fr.rc, pt); err != nil {
+            return fr.fail(err)
+        } else if !fr.advance(i) {
+            return _PC_halt
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_map_len(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.put(uint64(maplen(fr.wp)), 4)
+        return next
+    }
+}
+
+func interpret_OP_map_key(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.wp = fr.state().Mi.K
+        return next
+    }
+}
+
+func interpret_OP_map_next(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        mapiternext(&fr.state().Mi)
+        return next
+    }
+}
+
+func interpret_OP_map_value(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.wp = fr.state().Mi.V
+        return next
+    }
+}
+
+func interpret_OP_map_begin(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt())
+    return func(fr *_Frame) int {
+        mapiterstart(mt, *(**rt.GoMap)(fr.wp), &fr.state().Mi)
+        return next
+    }
+}
+
+func interpret_OP_map_if_next(v Instr, next int) _Op {
+    to := v.To
+    return func(fr *_Frame) int {
+        if fr.state().Mi.K != nil {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_map_if_empty(v Instr, next int) _Op {
+    to := v.To
+    return func(fr *_Frame) int {
+        if maplen(fr.wp) == 0 {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_map_sort_begin(v Instr, next int) _Op {
+    mt := rt.MapType(v.Vt())
+    return func(fr *_Frame) int {
+        if mp := *(**rt.GoMap)(fr.wp); fr.rs.Cn {
+            mapsortstart(mt, mp, fr.state())
+        } else {
+            mapiterstart(mt, mp, &fr.state().Mi)
+        }
+        return next
+    }
+}
+
+func interpret_OP_map_sort_next(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        if fr.rs.Cn {
+            mapsortnext(fr.state())
+        } else {
+            mapiternext(&fr.state().Mi)
+        }
+        return next
+    }
+}
+
+func interpret_OP_set_sort_begin(v Instr, next int) _Op {
+    vt := v.Vt()
+    return func(fr *_Frame) int {
+        sp := (*rt.GoSlice)(fr.wp)
+        setsortstart(vt, sp.Ptr, sp.Len, fr.state())
+        return next
+    }
+}
+
+func interpret_OP_list_decr(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.state().Ln--
+        return next
+    }
+}
+
+func interpret_OP_list_begin(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        sp := (*rt.GoSlice)(fr.wp)
+        fr.state().Ln = uintptr(sp.Len)
+        fr.wp = sp.Ptr
+        return next
+    }
+}
+
+func interpret_OP_list_if_next(v Instr, next int) _Op {
+    to := v.To
+    return func(fr *_Frame) int {
+        if fr.state().Ln != 0 {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_list_if_empty(v Instr, next int) _Op {
+    to := v.To
+    return func(fr *_Frame) int {
+        if slicelen(fr.wp) == 0 {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_unique(v Instr, next int) _Op {
+    fn := uniqueOf(v.Vt())
+
+    /* types that are not checked */
+    if fn == nil {
+        return func(_ *_Frame) int {
+            return next
+        }
+    }
+
+    /* sets with less than 2 elements are always unique */
+    return func(fr *_Frame) int {
+        if sp := (*rt.GoSlice)(fr.wp); sp.Len >= 2 && fn(sp.Ptr, sp.Len, fr.rs) {
+            return fr.fail(_E_duplicated)
+        } else {
+            return next
+        }
+    }
+}
+
+func uniqueOf(vt *rt.GoType) func(unsafe.Pointer, int, *RuntimeState) bool {
+    switch vt.Kind() {
+        case reflect.Bool    : return uniqueBool
+        case reflect.Int     : return uniqueInt()
+        case reflect.Int8    : return uniqueI8
+        case reflect.Int16   : return uniqueI16
+        case reflect.Int32   : return uniqueI32
+        case reflect.Int64   : return uniqueI64
+        case reflect.Uint    : return uniqueInt()
+        case reflect.Uint8   : return uniqueI8
+        case reflect.Uint16  : return uniqueI16
+        case reflect.Uint32  : return uniqueI32
+        case reflect.Uint64  : return uniqueI64
+        case reflect.Float32 : return uniqueI32
+        case reflect.Float64 : return uniqueI64
+        case reflect.Array   : return nil
+        case reflect.Map     : return nil
+        case reflect.Ptr     : return nil
+        case reflect.Slice   : return nil
+        case reflect.String  : return uniqueStr
+        case reflect.Struct  : return nil
+        default              : panic("unique: invalid type: " + vt.String())
+    }
+}
+
+func uniqueInt() func(unsafe.Pointer, int, *RuntimeState) bool {
+    switch defs.IntSize {
+        case 4  : return uniqueI32
+        case 8  : return uniqueI64
+        default : panic("invalid int size")
+    }
+}
+
+func uniqueBool(p unsafe.Pointer, n int, _ *RuntimeState) bool {
+    return n > 2 || *(*bool)(p) == *(*bool)(unsafe.Pointer(uintptr(p) + 1))
+}
+
+func uniqueI8(p unsafe.Pointer, n int, rs *RuntimeState) bool {
+    if n > RangeUint8 {
+        return true
+    } else {
+        return uniqueSmall(rs.Bm[:RangeUint8 / 64], n, func(i int) int { return int(*(*uint8)(unsafe.Pointer(uintptr(p) + uintptr(i)))) })
+    }
+}
+
+func uniqueI16(p unsafe.Pointer, n int, rs *RuntimeState) bool {
+    if n > RangeUint16 {
+        return true
+    } else {
+        return uniqueSmall(rs.Bm[:RangeUint16 / 64], n, func(i int) int { return int(*(*uint16)(unsafe.Pointer(uintptr(p) + uintptr(i) * 2))) })
+    }
+}
+
+func uniqueSmall(bm []uint64, n int, at func(int) int) bool {
+    for i := range bm {
+        bm[i] = 0
+    }
+
+    /* test-and-set every element */
+    for i := 0; i < n; i++ {
+        x := at(i)
+        m := uint64(1) << (x % 64)
+
+        /* check for duplications */
+        if bm[x / 64] & m != 0 {
+            return true
+        } else {
+            bm[x / 64] |= m
+        }
+    }
+
+    /* all elements are unique */
+    return false
+}
+
+func uniqueI32(p unsafe.Pointer, n int, _ *RuntimeState) bool {
+    return unique32(p, n)
+}
+
+func uniqueI64(p unsafe.Pointer, n int, _ *RuntimeState) bool {
+    return unique64(p, n)
+}
+
+func uniqueStr(p unsafe.Pointer, n int, _ *RuntimeState) bool {
+    return uniquestr(p, n)
+}
+
+func interpret_OP_union_check(v Instr, next int) _Op {
+    fv := append([]int(nil), v.IntSeq()...)
+    return func(fr *_Frame) int {
+        n := 0
+
+        /* count all the non-nil members */
+        for _, d := range fv {
+            if *(*unsafe.Pointer)(fr.field(uintptr(d))) != nil {
+                n++
+            }
+        }
+
+        /* exactly one member must be set */
+        if n != 1 {
+            return fr.fail(_E_union)
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_goto(v Instr, _ int) _Op {
+    to := v.To
+    return func(_ *_Frame) int {
+        return to
+    }
+}
+
+func interpret_OP_if_nil(v Instr, next int) _Op {
+    to := v.To
+    return func(fr *_Frame) int {
+        if *(*unsafe.Pointer)(fr.wp) == nil {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_if_hasbuf(v Instr, next int) _Op {
+    to := v.To
+    return func(fr *_Frame) int {
+        if fr.buf != nil {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_if_eq_imm(v Instr, next int) _Op {
+    n := v.Uv
+    to := v.To
+
+    /* truncate the immediate value */
+    x := uint64(v.Iv)
+    x &= ^uint64(0) >> (64 - n * 8)
+
+    /* compare the value */
+    return func(fr *_Frame) int {
+        if load(fr.wp, n) == x {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_if_eq_str(v Instr, next int) _Op {
+    to := v.To
+    sv := v.Str()
+
+    /* compare the value */
+    return func(fr *_Frame) int {
+        if *(*string)(fr.wp) == sv {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_if_canonical(v Instr, next int) _Op {
+    to := v.To
+    return func(fr *_Frame) int {
+        if fr.rs.Cn {
+            return to
+        } else {
+            return next
+        }
+    }
+}
+
+func interpret_OP_make_state(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        if fr.st >= int(StateMax) {
+            return fr.fail(_E_overflow)
+        } else {
+            fr.state().Wp = fr.wp
+            fr.st += int(StateSize)
+            return next
+        }
+    }
+}
+
+func interpret_OP_drop_state(_ Instr, next int) _Op {
+    return func(fr *_Frame) int {
+        fr.st -= int(StateSize)
+        st := fr.state()
+        fr.wp, st.Wp = st.Wp, nil
+        return next
+    }
+}
+
+func interpret_OP_halt(_ Instr, _ int) _Op {
+    return func(_ *_Frame) int {
+        return _PC_halt
+    }
+}
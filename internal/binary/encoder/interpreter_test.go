@@ -0,0 +1,116 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoder
+
+import (
+    `reflect`
+    `testing`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/rt`
+    `github.com/stretchr/testify/require`
+)
+
+func interpTestRun(enc Encoder, buf []byte, v unsafe.Pointer, cn bool) (int, error) {
+    rs := new(RuntimeState)
+    rs.Cn = cn
+    sl := (*rt.GoSlice)(unsafe.Pointer(&buf))
+    return enc(sl.Ptr, sl.Len, nil, v, rs, 0)
+}
+
+func interpTestCompare(t *testing.T, v interface{}, pt defs.Protocol, cn bool) {
+    vt := reflect.TypeOf(v)
+    vp := reflect.New(vt)
+    vp.Elem().Set(reflect.ValueOf(v))
+
+    /* compile the program */
+    p, err := CreateCompiler().Protocol(pt).Compile(vt)
+    require.NoError(t, err)
+
+    /* link with both backends */
+    emu := link_emu(Translate(p))
+    interp := link_interp(p)
+    addr := unsafe.Pointer(vp.Pointer())
+
+    /* measure the size */
+    nb, err := interpTestRun(emu, nil, addr, cn)
+    require.NoError(t, err)
+    ret, err := interpTestRun(interp, nil, addr, cn)
+    require.NoError(t, err)
+    require.Equal(t, nb, ret)
+
+    /* every buffer size must give the same result, including the required size when it is too small */
+    for i := 0; i <= nb; i++ {
+        b1 := make([]byte, i)
+        b2 := make([]byte, i)
+        r1, e1 := interpTestRun(emu, b1, addr, cn)
+        r2, e2 := interpTestRun(interp, b2, addr, cn)
+        require.Equal(t, e1, e2, "error mismatch with %d bytes", i)
+        require.Equal(t, r1, r2, "length mismatch with %d bytes", i)
+        require.Equal(t, b1, b2, "buffer mismatch with %d bytes", i)
+    }
+}
+
+func TestInterpreter_Binary(t *testing.T) {
+    interpTestCompare(t, TranslatorTestStruct {
+        A: true,
+        B: 0x12,
+        C: 12.34,
+        D: 0x3456,
+        E: 0x12345678,
+        F: 0x66778899aabbccdd,
+        G: "hello, world",
+        H: []byte("testbytebuffer"),
+        I: []int32{0x11223344, 0x55667788, 3, 4, 5},
+        J: map[string]string{"asdf": "qwer"},
+        K: map[string]*TranslatorTestStruct{"foo": {B: -1}},
+        M: &(&struct{ x int8 }{0x1}).x,
+        Q: &(&struct{ x int64 }{0x12345678}).x,
+    }, defs.Binary, false)
+}
+
+func TestInterpreter_Compact(t *testing.T) {
+    v := newTranslatorTestStruct()
+    v.J = map[string]string{"asdf": "qwer"}
+    interpTestCompare(t, v, defs.Compact, false)
+}
+
+func TestInterpreter_Canonical(t *testing.T) {
+    interpTestCompare(t, newCanonicalTest(), defs.Binary, true)
+    interpTestCompare(t, newCanonicalTest(), defs.Compact, true)
+}
+
+func TestInterpreter_Errors(t *testing.T) {
+    a, b := int32(0x2a), "hi"
+    p, err := CreateCompiler().Compile(reflect.TypeOf(UnionTest{}))
+    require.NoError(t, err)
+    interp := link_interp(p)
+    buf := make([]byte, 64)
+    ret, err := interpTestRun(interp, buf, unsafe.Pointer(&UnionTest { A: &a }), false)
+    require.NoError(t, err)
+    require.Equal(t, []byte { 0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x2a, 0x00 }, buf[:ret])
+    _, err = interpTestRun(interp, buf, unsafe.Pointer(&UnionTest { A: &a, B: &b }), false)
+    require.Equal(t, _E_union, err)
+    p, err = CreateCompiler().Compile(reflect.TypeOf(CanonicalTest{}))
+    require.NoError(t, err)
+    interp = link_interp(p)
+    _, err = interpTestRun(interp, nil, unsafe.Pointer(&CanonicalTest { B: []int8 { 1, 2, 1 } }), false)
+    require.NoError(t, err)
+    _, err = interpTestRun(interp, buf, unsafe.Pointer(&CanonicalTest { B: []int8 { 1, 2, 1 } }), false)
+    require.Equal(t, _E_duplicated, err)
+}
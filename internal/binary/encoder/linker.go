@@ -18,6 +18,7 @@ package encoder
 
 import (
     `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/snapshot`
     `github.com/cloudwego/frugal/internal/utils`
 )
 
@@ -25,20 +26,42 @@ type Linker interface {
     Link(p hir.Program) Encoder
 }
 
+type SnapshotLinker interface {
+    Linker
+    Load(sp *snapshot.Program) Encoder
+    Generate(p hir.Program, sp *snapshot.Program)
+}
+
 var (
-    linker   Linker
-    F_encode *hir.CallHandle
+    linker           Linker
+    F_encode         *hir.CallHandle
+    F_encode_compact *hir.CallHandle
 )
 
 func init() {
     F_encode = hir.RegisterGCall(encode, emu_gcall_encode)
+    F_encode_compact = hir.RegisterGCall(encodeCompact, emu_gcall_encode_compact)
+}
+
+func Link(p Program) Encoder {
+    if utils.ForceEmulator {
+        return link_emu(Translate(p))
+    } else if linker == nil || utils.ForceInterpreter {
+        return link_interp(p)
+    } else {
+        return linker.Link(Translate(p))
+    }
+}
+
+func isNative() bool {
+    return linker != nil && !utils.ForceEmulator && !utils.ForceInterpreter
 }
 
-func Link(p hir.Program) Encoder {
-    if linker == nil || utils.ForceEmulator {
-        return link_emu(p)
+func snapshotLinker() SnapshotLinker {
+    if sl, ok := linker.(SnapshotLinker); !ok || utils.ForceEmulator || utils.ForceInterpreter {
+        return nil
     } else {
-        return linker.Link(p)
+        return sl
     }
 }
 
@@ -1,3 +1,5 @@
+// +build !frugal_nojit
+
 /*
  * Copyright 2022 ByteDance Inc.
  *
@@ -17,11 +19,14 @@
 package encoder
 
 import (
+    `runtime`
     `unsafe`
 
     `github.com/cloudwego/frugal/internal/atm/hir`
     `github.com/cloudwego/frugal/internal/atm/pgen`
     `github.com/cloudwego/frugal/internal/loader`
+    `github.com/cloudwego/frugal/internal/snapshot`
+    `github.com/cloudwego/frugal/iov`
 )
 
 type (
@@ -32,8 +37,26 @@ func init() {
     SetLinker(new(LinkerAMD64))
 }
 
+func collectable(fp loader.Function) Encoder {
+    fn := *(*Encoder)(unsafe.Pointer(&fp))
+    /* running code does not keep itself reachable, so the reference is held until the call returns */
+    return func(buf unsafe.Pointer, len int, mem iov.BufferWriter, p unsafe.Pointer, rs *RuntimeState, st int) (int, error) {
+        ret, err := fn(buf, len, mem, p, rs, st)
+        runtime.KeepAlive(fp)
+        return ret, err
+    }
+}
+
 func (LinkerAMD64) Link(p hir.Program) Encoder {
     fn := pgen.CreateCodeGen((Encoder)(nil)).Generate(p, 0)
-    fp := loader.Loader(fn.Code).Load("encoder", fn.Frame)
-    return *(*Encoder)(unsafe.Pointer(&fp))
-}
\ No newline at end of file
+    return collectable(loader.Loader(fn.Code).LoadCollectable("encoder", fn.Frame))
+}
+
+func (LinkerAMD64) Load(sp *snapshot.Program) Encoder {
+    return collectable(loader.Loader(sp.Code).LoadCollectable("encoder", sp.Frame))
+}
+
+func (LinkerAMD64) Generate(p hir.Program, sp *snapshot.Program) {
+    fn := pgen.CreateCodeGen((Encoder)(nil)).Generate(p, 0)
+    sp.Code, sp.Frame, sp.Relocs = fn.Code, fn.Frame, fn.Relocs
+}
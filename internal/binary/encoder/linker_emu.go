@@ -61,8 +61,8 @@ func emu_setret(ctx hir.CallContext) func(int, error) {
     }
 }
 
-func emu_encode(ctx hir.CallContext) (int, error) {
-    return encode(
+func emu_encode(ctx hir.CallContext, fn encodeFunc) (int, error) {
+    return fn(
         (*rt.GoType)(ctx.Ap(0)),
         ctx.Ap(1),
         int(ctx.Au(2)),
@@ -77,6 +77,14 @@ func emu_gcall_encode(ctx hir.CallContext) {
     if !ctx.Verify("**i****i", "i**") {
         panic("invalid encode call")
     } else {
-        emu_setret(ctx)(emu_encode(ctx))
+        emu_setret(ctx)(emu_encode(ctx, encode))
+    }
+}
+
+func emu_gcall_encode_compact(ctx hir.CallContext) {
+    if !ctx.Verify("**i****i", "i**") {
+        panic("invalid encodeCompact call")
+    } else {
+        emu_setret(ctx)(emu_encode(ctx, encodeCompact))
     }
 }
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoder
+
+import (
+    `unsafe`
+
+    `github.com/cloudwego/frugal/iov`
+)
+
+func link_interp(prog Program) Encoder {
+    ops := interpret(prog)
+    return func(buf unsafe.Pointer, len int, mem iov.BufferWriter, p unsafe.Pointer, rs *RuntimeState, st int) (ret int, err error) {
+        fr := newFrame()
+        fr.buf = buf
+        fr.rc = len
+        fr.mem = mem
+        fr.wp = p
+        fr.rs = rs
+        fr.st = st
+
+        /* run until halt */
+        for pc := 0; pc != _PC_halt; {
+            pc = ops[pc](fr)
+        }
+
+        /* return the frame into pool */
+        ret, err = fr.rl, fr.err
+        freeFrame(fr)
+        return
+    }
+}
@@ -28,6 +28,13 @@ const (
     OP_size_dyn
     OP_size_map
     OP_size_defer
+    OP_size_codec
+    OP_size_varint
+    OP_size_varint_u
+    OP_size_length_uv
+    OP_size_map_head
+    OP_size_list_head
+    OP_size_field
     OP_byte
     OP_word
     OP_long
@@ -35,9 +42,24 @@ const (
     OP_sint
     OP_length
     OP_memcpy_be
+    OP_memcpy
+    OP_uint
+    OP_float
+    OP_bool
+    OP_varint
+    OP_varint_u
+    OP_sint_le
+    OP_float_le
+    OP_length_uv
+    OP_memcpy_le
+    OP_map_head
+    OP_list_head
+    OP_field_head
+    OP_field_reset
     OP_seek
     OP_deref
     OP_defer
+    OP_codec
     OP_map_len
     OP_map_key
     OP_map_next
@@ -45,57 +67,89 @@ const (
     OP_map_begin
     OP_map_if_next
     OP_map_if_empty
+    OP_map_sort_begin
+    OP_map_sort_next
+    OP_set_sort_begin
     OP_list_decr
     OP_list_begin
     OP_list_if_next
     OP_list_if_empty
     OP_unique
+    OP_union_check
     OP_goto
     OP_if_nil
     OP_if_hasbuf
     OP_if_eq_imm
     OP_if_eq_str
+    OP_if_canonical
     OP_make_state
     OP_drop_state
     OP_halt
 )
 
 var _OpNames = [256]string {
-    OP_size_check    : "size_check",
-    OP_size_const    : "size_const",
-    OP_size_dyn      : "size_dyn",
-    OP_size_map      : "size_map",
-    OP_size_defer    : "size_defer",
-    OP_byte          : "byte",
-    OP_word          : "word",
-    OP_long          : "long",
-    OP_quad          : "quad",
-    OP_sint          : "sint",
-    OP_length        : "length",
-    OP_memcpy_be     : "memcpy_be",
-    OP_seek          : "seek",
-    OP_deref         : "deref",
-    OP_defer         : "defer",
-    OP_map_len       : "map_len",
-    OP_map_key       : "map_key",
-    OP_map_next      : "map_next",
-    OP_map_value     : "map_value",
-    OP_map_begin     : "map_begin",
-    OP_map_if_next   : "map_if_next",
-    OP_map_if_empty  : "map_if_empty",
-    OP_list_decr     : "list_decr",
-    OP_list_begin    : "list_begin",
-    OP_list_if_next  : "list_if_next",
-    OP_list_if_empty : "list_if_empty",
-    OP_unique        : "unique",
-    OP_goto          : "goto",
-    OP_if_nil        : "if_nil",
-    OP_if_hasbuf     : "if_hasbuf",
-    OP_if_eq_imm     : "if_eq_imm",
-    OP_if_eq_str     : "if_eq_str",
-    OP_make_state    : "make_state",
-    OP_drop_state    : "drop_state",
-    OP_halt          : "halt",
+    OP_size_check     : "size_check",
+    OP_size_const     : "size_const",
+    OP_size_dyn       : "size_dyn",
+    OP_size_map       : "size_map",
+    OP_size_defer     : "size_defer",
+    OP_size_codec     : "size_codec",
+    OP_size_varint    : "size_varint",
+    OP_size_varint_u  : "size_varint_u",
+    OP_size_length_uv : "size_length_uv",
+    OP_size_map_head  : "size_map_head",
+    OP_size_list_head : "size_list_head",
+    OP_size_field     : "size_field",
+    OP_byte           : "byte",
+    OP_word           : "word",
+    OP_long           : "long",
+    OP_quad           : "quad",
+    OP_sint           : "sint",
+    OP_length         : "length",
+    OP_memcpy_be      : "memcpy_be",
+    OP_memcpy         : "memcpy",
+    OP_uint           : "uint",
+    OP_float          : "float",
+    OP_bool           : "bool",
+    OP_varint         : "varint",
+    OP_varint_u       : "varint_u",
+    OP_sint_le        : "sint_le",
+    OP_float_le       : "float_le",
+    OP_length_uv      : "length_uv",
+    OP_memcpy_le      : "memcpy_le",
+    OP_map_head       : "map_head",
+    OP_list_head      : "list_head",
+    OP_field_head     : "field_head",
+    OP_field_reset    : "field_reset",
+    OP_seek           : "seek",
+    OP_deref          : "deref",
+    OP_defer          : "defer",
+    OP_codec          : "codec",
+    OP_map_len        : "map_len",
+    OP_map_key        : "map_key",
+    OP_map_next       : "map_next",
+    OP_map_value      : "map_value",
+    OP_map_begin      : "map_begin",
+    OP_map_if_next    : "map_if_next",
+    OP_map_if_empty   : "map_if_empty",
+    OP_map_sort_begin : "map_sort_begin",
+    OP_map_sort_next  : "map_sort_next",
+    OP_set_sort_begin : "set_sort_begin",
+    OP_list_decr      : "list_decr",
+    OP_list_begin     : "list_begin",
+    OP_list_if_next   : "list_if_next",
+    OP_list_if_empty  : "list_if_empty",
+    OP_unique         : "unique",
+    OP_union_check    : "union_check",
+    OP_goto           : "goto",
+    OP_if_nil         : "if_nil",
+    OP_if_hasbuf      : "if_hasbuf",
+    OP_if_eq_imm      : "if_eq_imm",
+    OP_if_eq_str      : "if_eq_str",
+    OP_if_canonical   : "if_canonical",
+    OP_make_state     : "make_state",
+    OP_drop_state     : "drop_state",
+    OP_halt           : "halt",
 }
 
 var _OpBranches = [256]bool {
@@ -108,6 +162,7 @@ var _OpBranches = [256]bool {
     OP_if_hasbuf     : true,
     OP_if_eq_imm     : true,
     OP_if_eq_str     : true,
+    OP_if_canonical  : true,
 }
 
 func (self OpCode) String() string {
@@ -368,8 +368,14 @@ func _PASS_SizeCheckMerging(bb *BasicBlock) {
                     case OP_seek       : break
                     case OP_deref      : break
                     case OP_length     : break
-                    case OP_memcpy_be  : break
+                    case OP_bool       : break
+                    case OP_sint_le    : break
+                    case OP_memcpy     : break
+                    case OP_uint       : break
+                    case OP_float      : break
+                    case OP_float_le   : break
                     case OP_size_check : p.Iv += bb.P[j].Iv; bb.P[j].Op = _NOP
+                    case OP_memcpy_be  : r = false      // checks on its own, and advances the buffer by a dynamic length
                     default            : r = false
                 }
             }
@@ -20,6 +20,7 @@ import (
     `reflect`
     `sync`
 
+    `github.com/cloudwego/frugal/internal/binary/defs`
     `github.com/cloudwego/frugal/internal/opts`
     `github.com/cloudwego/frugal/internal/rt`
 )
@@ -31,6 +32,7 @@ var (
     graphBuilderPool   sync.Pool
     runtimeStatePool   sync.Pool
     optimizerStatePool sync.Pool
+    framePool          sync.Pool
 )
 
 func newProgram() Program {
@@ -49,7 +51,7 @@ func newCompiler() *Compiler {
     if v := compilerPool.Get(); v == nil {
         return allocCompiler()
     } else {
-        return resetCompiler(v.(*Compiler))
+        return resetCompiler(v.(*Compiler)).Protocol(defs.Binary)
     }
 }
 
@@ -119,6 +121,19 @@ func freeRuntimeState(p *RuntimeState) {
     runtimeStatePool.Put(p)
 }
 
+func newFrame() *_Frame {
+    if v := framePool.Get(); v != nil {
+        return v.(*_Frame)
+    } else {
+        return new(_Frame)
+    }
+}
+
+func freeFrame(p *_Frame) {
+    *p = _Frame{}
+    framePool.Put(p)
+}
+
 func newOptimizerState() *_OptimizerState {
     if v := optimizerStatePool.Get(); v == nil {
         return allocOptimizerState()
@@ -0,0 +1,246 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoder
+
+import (
+    `bytes`
+    `reflect`
+    `sort`
+    `sync`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+type _SortItem struct {
+    k unsafe.Pointer
+    v unsafe.Pointer
+    b []byte
+}
+
+type Sorter struct {
+    i  int
+    kv []_SortItem
+    kb []byte
+    lt func(a *_SortItem, b *_SortItem) bool
+}
+
+var (
+    sorterPool sync.Pool
+)
+
+func newSorter() *Sorter {
+    if v := sorterPool.Get(); v != nil {
+        return v.(*Sorter)
+    } else {
+        return new(Sorter)
+    }
+}
+
+func freeSorter(p *Sorter) {
+    for i := range p.kv {
+        p.kv[i] = _SortItem{}
+    }
+
+    /* clear the references before putting back into pool */
+    p.i = 0
+    p.lt = nil
+    p.kv = p.kv[:0]
+    p.kb = p.kb[:0]
+    sorterPool.Put(p)
+}
+
+func (self *Sorter) Len() int {
+    return len(self.kv)
+}
+
+func (self *Sorter) Less(i int, j int) bool {
+    return self.lt(&self.kv[i], &self.kv[j])
+}
+
+func (self *Sorter) Swap(i int, j int) {
+    self.kv[i], self.kv[j] = self.kv[j], self.kv[i]
+}
+
+func (self *Sorter) add(k unsafe.Pointer, v unsafe.Pointer) {
+    self.kv = append(self.kv, _SortItem { k: k, v: v })
+}
+
+func (self *Sorter) sort(vt *rt.GoType) {
+    switch vt.Kind() {
+        case reflect.Bool    : self.lt = lessBool
+        case reflect.Int     : self.lt = lessInt
+        case reflect.Int8    : self.lt = lessInt8
+        case reflect.Int16   : self.lt = lessInt16
+        case reflect.Int32   : self.lt = lessInt32
+        case reflect.Int64   : self.lt = lessInt64
+        case reflect.Float64 : self.lt = lessFloat64
+        case reflect.String  : self.lt = lessString
+        case reflect.Ptr     : self.lt = lessBytes; self.encodeKeys((*rt.GoPtrType)(unsafe.Pointer(vt)).Elem)
+        default              : panic("sort: invalid key type: " + vt.String())
+    }
+
+    /* sort all the items */
+    if len(self.kv) > 1 {
+        sort.Sort(self)
+    }
+}
+
+func (self *Sorter) encodeKeys(vt *rt.GoType) {
+    rs := newRuntimeState()
+    rs.Cn = true
+
+    /* struct keys are ordered by their canonical encoded form */
+    for i := range self.kv {
+        if p := *(*unsafe.Pointer)(self.kv[i].k); p != nil {
+            self.kv[i].b = self.encodeKey(vt, p, rs)
+        }
+    }
+
+    /* return the state into pool */
+    freeRuntimeState(rs)
+}
+
+func (self *Sorter) encodeKey(vt *rt.GoType, p unsafe.Pointer, rs *RuntimeState) []byte {
+    nb := len(self.kb)
+    ret, err := encode(vt, nil, 0, nil, p, rs, 0)
+
+    /* keys that cannot be encoded are reported by the encoder later */
+    if err != nil {
+        return nil
+    }
+
+    /* grow the key buffer, previous keys still refer to the old one, which is never modified again */
+    if cap(self.kb) - nb < ret {
+        self.kb = growBuffer(self.kb, ret)
+    }
+
+    /* encode the key into the buffer */
+    buf := self.kb[nb:nb + ret]
+    out := (*rt.GoSlice)(unsafe.Pointer(&buf))
+
+    /* the measured size may not be enough if the key is being modified concurrently */
+    if ret, err = encode(vt, out.Ptr, out.Len, nil, p, rs, 0); err != nil {
+        return nil
+    } else {
+        self.kb = self.kb[:nb + ret]
+        return self.kb[nb:nb + ret:nb + ret]
+    }
+}
+
+func (self *Sorter) next(it *rt.GoMapIterator) bool {
+    if self.i >= len(self.kv) {
+        it.K = nil
+        it.V = nil
+        return false
+    } else {
+        it.K = self.kv[self.i].k
+        it.V = self.kv[self.i].v
+        self.i++
+        return true
+    }
+}
+
+func lessBool(a *_SortItem, b *_SortItem) bool {
+    return !*(*bool)(a.k) && *(*bool)(b.k)
+}
+
+func lessInt(a *_SortItem, b *_SortItem) bool {
+    return *(*int)(a.k) < *(*int)(b.k)
+}
+
+func lessInt8(a *_SortItem, b *_SortItem) bool {
+    return *(*int8)(a.k) < *(*int8)(b.k)
+}
+
+func lessInt16(a *_SortItem, b *_SortItem) bool {
+    return *(*int16)(a.k) < *(*int16)(b.k)
+}
+
+func lessInt32(a *_SortItem, b *_SortItem) bool {
+    return *(*int32)(a.k) < *(*int32)(b.k)
+}
+
+func lessInt64(a *_SortItem, b *_SortItem) bool {
+    return *(*int64)(a.k) < *(*int64)(b.k)
+}
+
+func lessFloat64(a *_SortItem, b *_SortItem) bool {
+    x := *(*float64)(a.k)
+    y := *(*float64)(b.k)
+    return x < y || (x != x && y == y)
+}
+
+func lessString(a *_SortItem, b *_SortItem) bool {
+    return *(*string)(a.k) < *(*string)(b.k)
+}
+
+func lessBytes(a *_SortItem, b *_SortItem) bool {
+    if a.b == nil || b.b == nil {
+        return a.b == nil && b.b != nil
+    } else {
+        return bytes.Compare(a.b, b.b) < 0
+    }
+}
+
+func mapsortstart(t *rt.GoMapType, h *rt.GoMap, st *StateItem) {
+    ss := newSorter()
+    st.So = ss
+
+    /* collect all the key-value pairs */
+    for mapiterstart(t, h, &st.Mi); st.Mi.K != nil; mapiternext(&st.Mi) {
+        ss.add(st.Mi.K, st.Mi.V)
+    }
+
+    /* sort by keys, and move to the first pair */
+    ss.sort(t.Key)
+    mapsortnext(st)
+}
+
+func setsortstart(t *rt.GoType, p unsafe.Pointer, n int, st *StateItem) {
+    ss := newSorter()
+    st.So = ss
+
+    /* collect all the elements */
+    for i := 0; i < n; i++ {
+        ss.add(unsafe.Pointer(uintptr(p) + uintptr(i) * t.Size), nil)
+    }
+
+    /* sort the elements, and move to the first one */
+    ss.sort(t)
+    mapsortnext(st)
+}
+
+func mapsortnext(st *StateItem) {
+    if !st.So.next(&st.Mi) {
+        freeSorter(st.So)
+        st.So = nil
+    }
+}
+
+var (
+    F_mapsortnext  *hir.CallHandle
+    F_mapsortstart *hir.CallHandle
+    F_setsortstart *hir.CallHandle
+)
+
+func init() {
+    F_mapsortnext = hir.RegisterGCall(mapsortnext, emu_gcall_mapsortnext)
+    F_mapsortstart = hir.RegisterGCall(mapsortstart, emu_gcall_mapsortstart)
+    F_setsortstart = hir.RegisterGCall(setsortstart, emu_gcall_setsortstart)
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoder
+
+import (
+    `github.com/cloudwego/frugal/internal/atm/hir`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+func emu_gcall_mapsortnext(ctx hir.CallContext) {
+    if !ctx.Verify("*", "") {
+        panic("invalid mapsortnext call")
+    } else {
+        mapsortnext((*StateItem)(ctx.Ap(0)))
+    }
+}
+
+func emu_gcall_mapsortstart(ctx hir.CallContext) {
+    if !ctx.Verify("***", "") {
+        panic("invalid mapsortstart call")
+    } else {
+        mapsortstart((*rt.GoMapType)(ctx.Ap(0)), (*rt.GoMap)(ctx.Ap(1)), (*StateItem)(ctx.Ap(2)))
+    }
+}
+
+func emu_gcall_setsortstart(ctx hir.CallContext) {
+    if !ctx.Verify("**i*", "") {
+        panic("invalid setsortstart call")
+    } else {
+        setsortstart((*rt.GoType)(ctx.Ap(0)), ctx.Ap(1), int(ctx.Au(2)), (*StateItem)(ctx.Ap(3)))
+    }
+}
@@ -25,10 +25,12 @@ import (
 )
 
 const (
+    IdOffset = int64(unsafe.Offsetof(StateItem{}.Id))
     LnOffset = int64(unsafe.Offsetof(StateItem{}.Ln))
     MiOffset = int64(unsafe.Offsetof(StateItem{}.Mi))
     WpOffset = int64(unsafe.Offsetof(StateItem{}.Wp))
     BmOffset = int64(unsafe.Offsetof(RuntimeState{}.Bm))
+    CnOffset = int64(unsafe.Offsetof(RuntimeState{}.Cn))
 )
 
 const (
@@ -47,12 +49,15 @@ const (
 )
 
 type StateItem struct {
+    Id int          // The last written field ID, for delta encoding of Compact Protocol field headers.
     Ln uintptr
     Wp unsafe.Pointer
     Mi rt.GoMapIterator
+    So *Sorter
 }
 
 type RuntimeState struct {
     St [defs.StackSize]StateItem    // Must be the first field.
     Bm [1024]uint64                 // Bitmap, used for uniqueness check of set<i8> and set<i16>.
+    Cn bool                         // Canonical mode, map keys and set elements are sorted.
 }
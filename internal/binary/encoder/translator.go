@@ -92,13 +92,17 @@ const (
     LB_nomem      = "_nomem"
     LB_overflow   = "_overflow"
     LB_duplicated = "_duplicated"
+    LB_union      = "_union"
+    LB_range      = "_range"
 )
 
 var (
     _N_page       = int64(os.Getpagesize())
-    _E_nomem      = fmt.Errorf("frugal: buffer is too small")
-    _E_overflow   = fmt.Errorf("frugal: encoder stack overflow")
-    _E_duplicated = fmt.Errorf("frugal: duplicated element within sets")
+    _E_nomem      = utils.ErrBufferTooSmall
+    _E_overflow   = utils.EKindf(utils.ErrInvalidData, "frugal: encoder stack overflow")
+    _E_duplicated = utils.EKindf(utils.ErrInvalidData, "frugal: duplicated element within sets")
+    _E_union      = utils.EKindf(utils.ErrInvalidData, "frugal: exactly one field of a union must be set")
+    _E_range      = utils.EKindf(utils.ErrInvalidData, "frugal: value out of range of the wire type")
 )
 
 func Translate(s Program) hir.Program {
@@ -120,6 +124,12 @@ func errors(p *hir.Builder) {
     p.JMP   ("_basic_error")
     p.Label (LB_duplicated)
     p.IP    (&_E_duplicated, TP)
+    p.JMP   ("_basic_error")
+    p.Label (LB_union)
+    p.IP    (&_E_union, TP)
+    p.JMP   ("_basic_error")
+    p.Label (LB_range)
+    p.IP    (&_E_range, TP)
     p.Label ("_basic_error")
     p.LP    (TP, 0, ET)
     p.LP    (TP, 8, EP)
@@ -155,41 +165,68 @@ func epilogue(p *hir.Builder) {
 }
 
 var translators = [256]func(*hir.Builder, Instr) {
-    OP_size_check    : translate_OP_size_check,
-    OP_size_const    : translate_OP_size_const,
-    OP_size_dyn      : translate_OP_size_dyn,
-    OP_size_map      : translate_OP_size_map,
-    OP_size_defer    : translate_OP_size_defer,
-    OP_byte          : translate_OP_byte,
-    OP_word          : translate_OP_word,
-    OP_long          : translate_OP_long,
-    OP_quad          : translate_OP_quad,
-    OP_sint          : translate_OP_sint,
-    OP_length        : translate_OP_length,
-    OP_memcpy_be     : translate_OP_memcpy_be,
-    OP_seek          : translate_OP_seek,
-    OP_deref         : translate_OP_deref,
-    OP_defer         : translate_OP_defer,
-    OP_map_len       : translate_OP_map_len,
-    OP_map_key       : translate_OP_map_key,
-    OP_map_next      : translate_OP_map_next,
-    OP_map_value     : translate_OP_map_value,
-    OP_map_begin     : translate_OP_map_begin,
-    OP_map_if_next   : translate_OP_map_if_next,
-    OP_map_if_empty  : translate_OP_map_if_empty,
-    OP_list_decr     : translate_OP_list_decr,
-    OP_list_begin    : translate_OP_list_begin,
-    OP_list_if_next  : translate_OP_list_if_next,
-    OP_list_if_empty : translate_OP_list_if_empty,
-    OP_unique        : translate_OP_unique,
-    OP_goto          : translate_OP_goto,
-    OP_if_nil        : translate_OP_if_nil,
-    OP_if_hasbuf     : translate_OP_if_hasbuf,
-    OP_if_eq_imm     : translate_OP_if_eq_imm,
-    OP_if_eq_str     : translate_OP_if_eq_str,
-    OP_make_state    : translate_OP_make_state,
-    OP_drop_state    : translate_OP_drop_state,
-    OP_halt          : translate_OP_halt,
+    OP_size_check     : translate_OP_size_check,
+    OP_size_const     : translate_OP_size_const,
+    OP_size_dyn       : translate_OP_size_dyn,
+    OP_size_map       : translate_OP_size_map,
+    OP_size_defer     : translate_OP_size_defer,
+    OP_size_codec     : translate_OP_size_codec,
+    OP_size_varint    : translate_OP_size_varint,
+    OP_size_varint_u  : translate_OP_size_varint_u,
+    OP_size_length_uv : translate_OP_size_length_uv,
+    OP_size_map_head  : translate_OP_size_map_head,
+    OP_size_list_head : translate_OP_size_list_head,
+    OP_size_field     : translate_OP_size_field,
+    OP_byte           : translate_OP_byte,
+    OP_word           : translate_OP_word,
+    OP_long           : translate_OP_long,
+    OP_quad           : translate_OP_quad,
+    OP_sint           : translate_OP_sint,
+    OP_length         : translate_OP_length,
+    OP_memcpy_be      : translate_OP_memcpy_be,
+    OP_memcpy         : translate_OP_memcpy,
+    OP_uint           : translate_OP_uint,
+    OP_float          : translate_OP_float,
+    OP_bool           : translate_OP_bool,
+    OP_varint         : translate_OP_varint,
+    OP_varint_u       : translate_OP_varint_u,
+    OP_sint_le        : translate_OP_sint_le,
+    OP_float_le       : translate_OP_float_le,
+    OP_length_uv      : translate_OP_length_uv,
+    OP_memcpy_le      : translate_OP_memcpy_le,
+    OP_map_head       : translate_OP_map_head,
+    OP_list_head      : translate_OP_list_head,
+    OP_field_head     : translate_OP_field_head,
+    OP_field_reset    : translate_OP_field_reset,
+    OP_seek           : translate_OP_seek,
+    OP_deref          : translate_OP_deref,
+    OP_defer          : translate_OP_defer,
+    OP_codec          : translate_OP_codec,
+    OP_map_len        : translate_OP_map_len,
+    OP_map_key        : translate_OP_map_key,
+    OP_map_next       : translate_OP_map_next,
+    OP_map_value      : translate_OP_map_value,
+    OP_map_begin      : translate_OP_map_begin,
+    OP_map_if_next    : translate_OP_map_if_next,
+    OP_map_if_empty   : translate_OP_map_if_empty,
+    OP_map_sort_begin : translate_OP_map_sort_begin,
+    OP_map_sort_next  : translate_OP_map_sort_next,
+    OP_set_sort_begin : translate_OP_set_sort_begin,
+    OP_list_decr      : translate_OP_list_decr,
+    OP_list_begin     : translate_OP_list_begin,
+    OP_list_if_next   : translate_OP_list_if_next,
+    OP_list_if_empty  : translate_OP_list_if_empty,
+    OP_unique         : translate_OP_unique,
+    OP_union_check    : translate_OP_union_check,
+    OP_goto           : translate_OP_goto,
+    OP_if_nil         : translate_OP_if_nil,
+    OP_if_hasbuf      : translate_OP_if_hasbuf,
+    OP_if_eq_imm      : translate_OP_if_eq_imm,
+    OP_if_eq_str      : translate_OP_if_eq_str,
+    OP_if_canonical   : translate_OP_if_canonical,
+    OP_make_state     : translate_OP_make_state,
+    OP_drop_state     : translate_OP_drop_state,
+    OP_halt           : translate_OP_halt,
 }
 
 func translate_OP_size_check(p *hir.Builder, v Instr) {
@@ -216,7 +253,7 @@ func translate_OP_size_map(p *hir.Builder, v Instr) {
 
 func translate_OP_size_defer(p *hir.Builder, v Instr) {
     p.IP    (v.Vt(), TP)
-    p.GCALL (F_encode).
+    p.GCALL (encoderOf(v)).
       A0    (TP).
       A1    (hir.Pn).
       A2    (hir.Rz).
@@ -232,6 +269,75 @@ func translate_OP_size_defer(p *hir.Builder, v Instr) {
     p.ADD   (RL, TR, RL)
 }
 
+func translate_OP_size_codec(p *hir.Builder, v Instr) {
+    p.IP    (v.Codec(), TP)
+    p.IQ    (v.Iv, TR)
+    p.GCALL (F_codec_size).
+      A0    (TP).
+      A1    (WP).
+      A2    (TR).
+      R0    (TR).
+      R1    (ET).
+      R2    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+    p.ADD   (RL, TR, RL)
+}
+
+func translate_OP_size_varint(p *hir.Builder, v Instr) {
+    p.IQ    (v.Iv, TR)
+    p.GCALL (F_compact_varint_size).
+      A0    (WP).
+      A1    (TR).
+      R0    (TR)
+    p.ADD   (RL, TR, RL)
+}
+
+func translate_OP_size_varint_u(p *hir.Builder, v Instr) {
+    translate_OP_uint_load(p, v)
+    p.IQ    (v.Iv, UR)
+    p.GCALL (F_compact_uint_size).
+      A0    (TR).
+      A1    (UR).
+      R0    (TR)
+    p.ADD   (RL, TR, RL)
+}
+
+func translate_OP_size_length_uv(p *hir.Builder, v Instr) {
+    p.LQ    (WP, v.Iv, TR)
+    p.GCALL (F_compact_uvarint_size).
+      A0    (TR).
+      R0    (TR)
+    p.ADD   (RL, TR, RL)
+}
+
+func translate_OP_size_map_head(p *hir.Builder, _ Instr) {
+    p.LP    (WP, 0, TP)
+    p.LQ    (TP, 0, TR)
+    p.GCALL (F_compact_map_size).
+      A0    (TR).
+      R0    (TR)
+    p.ADD   (RL, TR, RL)
+}
+
+func translate_OP_size_list_head(p *hir.Builder, _ Instr) {
+    p.LQ    (WP, abi.PtrSize, TR)
+    p.GCALL (F_compact_list_size).
+      A0    (TR).
+      R0    (TR)
+    p.ADD   (RL, TR, RL)
+}
+
+func translate_OP_size_field(p *hir.Builder, v Instr) {
+    p.ADDP  (RS, ST, TP)
+    p.ADDPI (TP, IdOffset, TP)
+    p.IQ    (v.Iv, TR)
+    p.GCALL (F_compact_field_size).
+      A0    (TP).
+      A1    (TR).
+      R0    (TR)
+    p.ADD   (RL, TR, RL)
+}
+
 func translate_OP_byte(p *hir.Builder, v Instr) {
     p.ADDP  (RP, RL, TP)
     p.ADDI  (RL, 1, RL)
@@ -343,6 +449,196 @@ func translate_OP_memcpy_be(p *hir.Builder, v Instr) {
     p.Label ("_done_{n}")
 }
 
+func translate_OP_memcpy(p *hir.Builder, v Instr) {
+    p.IQ    (v.Iv, TR)
+    p.ADDP  (RP, RL, TP)
+    p.ADDI  (RL, v.Iv, RL)
+    p.BCOPY (WP, TR, TP)
+}
+
+func translate_OP_uint(p *hir.Builder, v Instr) {
+    translate_OP_uint_load(p, v)
+    translate_OP_uint_check(p, v)
+    p.ADDP  (RP, RL, TP)
+    p.ADDI  (RL, v.Iv, RL)
+
+    /* check for copy size */
+    switch v.Iv {
+        case 1  : p.SB(TR, TP, 0)
+        case 2  : p.SWAPW(TR, TR); p.SW(TR, TP, 0)
+        case 4  : p.SWAPL(TR, TR); p.SL(TR, TP, 0)
+        case 8  : p.SWAPQ(TR, TR); p.SQ(TR, TP, 0)
+        default : panic("can only convert 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func translate_OP_uint_load(p *hir.Builder, v Instr) {
+    switch v.Uv {
+        case 1  : p.LB(WP, 0, TR)
+        case 2  : p.LW(WP, 0, TR)
+        case 4  : p.LL(WP, 0, TR)
+        case 8  : p.LQ(WP, 0, TR)
+        default : panic("can only load 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func translate_OP_uint_check(p *hir.Builder, v Instr) {
+    if int64(v.Uv) > v.Iv {
+        p.SHRI  (TR, v.Iv * 8, UR)
+        p.BNE   (UR, hir.Rz, LB_range)
+    }
+}
+
+func translate_OP_float(p *hir.Builder, _ Instr) {
+    p.LL    (WP, 0, TR)
+    p.GCALL (F_float_widen).
+      A0    (TR).
+      R0    (TR)
+    p.SWAPQ (TR, TR)
+    p.ADDP  (RP, RL, TP)
+    p.ADDI  (RL, 8, RL)
+    p.SQ    (TR, TP, 0)
+}
+
+func translate_OP_bool(p *hir.Builder, v Instr) {
+    p.LB    (WP, 0, TR)
+    p.IB    (int8(v.Iv), UR)
+    p.BNE   (TR, hir.Rz, "_true_{n}")
+    p.ADDI  (UR, 1, UR)
+    p.Label ("_true_{n}")
+    p.ADDP  (RP, RL, TP)
+    p.ADDI  (RL, 1, RL)
+    p.SB    (UR, TP, 0)
+}
+
+func translate_OP_varint(p *hir.Builder, v Instr) {
+    p.IQ    (v.Iv, TR)
+    p.GCALL (F_compact_varint).
+      A0    (RP).
+      A1    (RL).
+      A2    (RC).
+      A3    (WP).
+      A4    (TR).
+      R0    (UR)
+    p.BLTU  (RC, UR, LB_nomem)
+    p.MOV   (UR, RL)
+}
+
+func translate_OP_varint_u(p *hir.Builder, v Instr) {
+    translate_OP_uint_load(p, v)
+    translate_OP_uint_check(p, v)
+    p.IQ    (v.Iv, UR)
+    p.GCALL (F_compact_uint).
+      A0    (RP).
+      A1    (RL).
+      A2    (RC).
+      A3    (TR).
+      A4    (UR).
+      R0    (UR)
+    p.BLTU  (RC, UR, LB_nomem)
+    p.MOV   (UR, RL)
+}
+
+func translate_OP_sint_le(p *hir.Builder, v Instr) {
+    p.ADDP  (RP, RL, TP)
+    p.ADDI  (RL, v.Iv, RL)
+
+    /* check for copy size */
+    switch v.Iv {
+        case 1  : p.LB(WP, 0, TR); p.SB(TR, TP, 0)
+        case 2  : p.LW(WP, 0, TR); p.SW(TR, TP, 0)
+        case 4  : p.LL(WP, 0, TR); p.SL(TR, TP, 0)
+        case 8  : p.LQ(WP, 0, TR); p.SQ(TR, TP, 0)
+        default : panic("can only copy 1, 2, 4 or 8 bytes at a time")
+    }
+}
+
+func translate_OP_float_le(p *hir.Builder, _ Instr) {
+    p.LL    (WP, 0, TR)
+    p.GCALL (F_float_widen).
+      A0    (TR).
+      R0    (TR)
+    p.ADDP  (RP, RL, TP)
+    p.ADDI  (RL, 8, RL)
+    p.SQ    (TR, TP, 0)
+}
+
+func translate_OP_length_uv(p *hir.Builder, v Instr) {
+    p.LQ    (WP, v.Iv, TR)
+    p.GCALL (F_compact_uvarint).
+      A0    (RP).
+      A1    (RL).
+      A2    (RC).
+      A3    (TR).
+      R0    (UR)
+    p.BLTU  (RC, UR, LB_nomem)
+    p.MOV   (UR, RL)
+}
+
+func translate_OP_memcpy_le(p *hir.Builder, v Instr) {
+    p.LQ    (WP, int64(v.Uv), TR)
+    p.BEQ   (TR, hir.Rz, "_done_{n}")
+    p.LP    (WP, 0, TP)
+    p.MULI  (TR, v.Iv, TR)
+    p.ADD   (RL, TR, UR)
+    p.BLTU  (RC, UR, LB_nomem)
+    p.ADDP  (RP, RL, EP)
+    p.MOV   (UR, RL)
+    p.BCOPY (TP, TR, EP)
+    p.Label ("_done_{n}")
+}
+
+func translate_OP_map_head(p *hir.Builder, v Instr) {
+    p.LP    (WP, 0, TP)
+    p.LQ    (TP, 0, TR)
+    p.IQ    (v.Iv, UR)
+    p.GCALL (F_compact_map_head).
+      A0    (RP).
+      A1    (RL).
+      A2    (RC).
+      A3    (TR).
+      A4    (UR).
+      R0    (UR)
+    p.BLTU  (RC, UR, LB_nomem)
+    p.MOV   (UR, RL)
+}
+
+func translate_OP_list_head(p *hir.Builder, v Instr) {
+    p.LQ    (WP, abi.PtrSize, TR)
+    p.IQ    (v.Iv, UR)
+    p.GCALL (F_compact_list_head).
+      A0    (RP).
+      A1    (RL).
+      A2    (RC).
+      A3    (TR).
+      A4    (UR).
+      R0    (UR)
+    p.BLTU  (RC, UR, LB_nomem)
+    p.MOV   (UR, RL)
+}
+
+func translate_OP_field_head(p *hir.Builder, v Instr) {
+    p.ADDP  (RS, ST, TP)
+    p.ADDPI (TP, IdOffset, TP)
+    p.IQ    (v.Iv, TR)
+    p.IQ    (int64(v.Uv), UR)
+    p.GCALL (F_compact_field_head).
+      A0    (RP).
+      A1    (RL).
+      A2    (RC).
+      A3    (TP).
+      A4    (TR).
+      A5    (UR).
+      R0    (UR)
+    p.BLTU  (RC, UR, LB_nomem)
+    p.MOV   (UR, RL)
+}
+
+func translate_OP_field_reset(p *hir.Builder, _ Instr) {
+    p.ADDP  (RS, ST, TP)
+    p.SQ    (hir.Rz, TP, IdOffset)
+}
+
 func translate_OP_seek(p *hir.Builder, v Instr) {
     p.ADDPI (WP, v.Iv, WP)
 }
@@ -357,7 +653,7 @@ func translate_OP_defer(p *hir.Builder, v Instr) {
     p.LDAP  (ARG_mem_data, EP)
     p.SUB   (RC, RL, TR)
     p.ADDP  (RP, RL, RP)
-    p.GCALL (F_encode).
+    p.GCALL (encoderOf(v)).
       A0    (TP).
       A1    (RP).
       A2    (TR).
@@ -370,8 +666,34 @@ func translate_OP_defer(p *hir.Builder, v Instr) {
       R1    (ET).
       R2    (EP)
     p.SUBP  (RP, RL, RP)
-    p.BNEP  (ET, hir.Pn, LB_error)
     p.ADD   (RL, TR, RL)
+    p.BNEP  (ET, hir.Pn, LB_error)
+}
+
+func translate_OP_codec(p *hir.Builder, v Instr) {
+    p.IP    (v.Codec(), TP)
+    p.IQ    (v.Iv, TR)
+    p.GCALL (F_codec_encode).
+      A0    (TP).
+      A1    (WP).
+      A2    (RP).
+      A3    (RL).
+      A4    (RC).
+      A5    (TR).
+      R0    (UR).
+      R1    (ET).
+      R2    (EP)
+    p.BNEP  (ET, hir.Pn, LB_error)
+    p.BLTU  (RC, UR, LB_nomem)
+    p.MOV   (UR, RL)
+}
+
+func encoderOf(v Instr) *hir.CallHandle {
+    switch defs.Protocol(v.Iv) {
+        case defs.Binary  : return F_encode
+        case defs.Compact : return F_encode_compact
+        default           : panic("invalid protocol: " + defs.Protocol(v.Iv).String())
+    }
 }
 
 func translate_OP_map_len(p *hir.Builder, _ Instr) {
@@ -422,6 +744,45 @@ func translate_OP_map_if_empty(p *hir.Builder, v Instr) {
     p.BEQ   (TR, hir.Rz, p.At(v.To))
 }
 
+func translate_OP_map_sort_begin(p *hir.Builder, v Instr) {
+    p.LB    (RS, CnOffset, TR)
+    p.BEQ   (TR, hir.Rz, "_unsorted_{n}")
+    p.IP    (v.Vt(), ET)
+    p.LP    (WP, 0, EP)
+    p.ADDP  (RS, ST, TP)
+    p.GCALL (F_mapsortstart).
+      A0    (ET).
+      A1    (EP).
+      A2    (TP)
+    p.JMP   ("_sorted_{n}")
+    p.Label ("_unsorted_{n}")
+    translate_OP_map_begin(p, v)
+    p.Label ("_sorted_{n}")
+}
+
+func translate_OP_map_sort_next(p *hir.Builder, v Instr) {
+    p.LB    (RS, CnOffset, TR)
+    p.BEQ   (TR, hir.Rz, "_unsorted_{n}")
+    p.ADDP  (RS, ST, TP)
+    p.GCALL (F_mapsortnext).A0(TP)
+    p.JMP   ("_sorted_{n}")
+    p.Label ("_unsorted_{n}")
+    translate_OP_map_next(p, v)
+    p.Label ("_sorted_{n}")
+}
+
+func translate_OP_set_sort_begin(p *hir.Builder, v Instr) {
+    p.IP    (v.Vt(), ET)
+    p.LP    (WP, 0, EP)
+    p.LQ    (WP, abi.PtrSize, TR)
+    p.ADDP  (RS, ST, TP)
+    p.GCALL (F_setsortstart).
+      A0    (ET).
+      A1    (EP).
+      A2    (TR).
+      A3    (TP)
+}
+
 func translate_OP_list_decr(p *hir.Builder, _ Instr) {
     p.ADDP  (RS, ST, TP)
     p.LQ    (TP, LnOffset, TR)
@@ -463,7 +824,14 @@ func translate_OP_unique_type(p *hir.Builder, vt *rt.GoType) {
         case reflect.Int16   : translate_OP_unique_i16(p)
         case reflect.Int32   : translate_OP_unique_i32(p)
         case reflect.Int64   : translate_OP_unique_i64(p)
+        case reflect.Uint    : translate_OP_unique_int(p)
+        case reflect.Uint8   : translate_OP_unique_i8(p)
+        case reflect.Uint16  : translate_OP_unique_i16(p)
+        case reflect.Uint32  : translate_OP_unique_i32(p)
+        case reflect.Uint64  : translate_OP_unique_i64(p)
+        case reflect.Float32 : translate_OP_unique_i32(p)
         case reflect.Float64 : translate_OP_unique_i64(p)
+        case reflect.Array   : break
         case reflect.Map     : break
         case reflect.Ptr     : break
         case reflect.Slice   : break
@@ -550,6 +918,22 @@ func translate_OP_unique_str(p *hir.Builder) {
     p.BNE   (TR, hir.Rz, LB_duplicated)
 }
 
+func translate_OP_union_check(p *hir.Builder, v Instr) {
+    p.MOV   (hir.Rz, TR)
+
+    /* count all the non-nil members */
+    for i, fp := range v.IntSeq() {
+        p.LP    (WP, int64(fp), TP)
+        p.BEQP  (TP, hir.Pn, fmt.Sprintf("_unset_%d_{n}", i))
+        p.ADDI  (TR, 1, TR)
+        p.Label (fmt.Sprintf("_unset_%d_{n}", i))
+    }
+
+    /* exactly one member must be set */
+    p.IQ    (1, UR)
+    p.BNE   (TR, UR, LB_union)
+}
+
 func translate_OP_goto(p *hir.Builder, v Instr) {
     p.JMP   (p.At(v.To))
 }
@@ -565,10 +949,10 @@ func translate_OP_if_hasbuf(p *hir.Builder, v Instr) {
 
 func translate_OP_if_eq_imm(p *hir.Builder, v Instr) {
     switch v.Uv {
-        case 1  : p.LB(WP, 0, TR); p.IB( int8(v.Iv), UR); p.BEQ(TR, UR, p.At(v.To))
-        case 2  : p.LW(WP, 0, TR); p.IW(int16(v.Iv), UR); p.BEQ(TR, UR, p.At(v.To))
-        case 4  : p.LL(WP, 0, TR); p.IL(int32(v.Iv), UR); p.BEQ(TR, UR, p.At(v.To))
-        case 8  : p.LQ(WP, 0, TR); p.IQ(      v.Iv , UR); p.BEQ(TR, UR, p.At(v.To))
+        case 1  : p.LB(WP, 0, TR); p.IQ(int64( uint8(v.Iv)), UR); p.BEQ(TR, UR, p.At(v.To))
+        case 2  : p.LW(WP, 0, TR); p.IQ(int64(uint16(v.Iv)), UR); p.BEQ(TR, UR, p.At(v.To))
+        case 4  : p.LL(WP, 0, TR); p.IQ(int64(uint32(v.Iv)), UR); p.BEQ(TR, UR, p.At(v.To))
+        case 8  : p.LQ(WP, 0, TR); p.IQ(             v.Iv , UR); p.BEQ(TR, UR, p.At(v.To))
         default : panic("invalid imm size")
     }
 }
@@ -621,6 +1005,11 @@ func translate_OP_if_eq_str(p *hir.Builder, v Instr) {
     p.Label ("_neq_{n}")
 }
 
+func translate_OP_if_canonical(p *hir.Builder, v Instr) {
+    p.LB    (RS, CnOffset, TR)
+    p.BNE   (TR, hir.Rz, p.At(v.To))
+}
+
 func translate_OP_make_state(p *hir.Builder, _ Instr) {
     p.IQ    (StateMax, TR)
     p.BGEU  (ST, TR, LB_overflow)
@@ -19,6 +19,8 @@ package encoder
 import (
     `math/bits`
     `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
 )
 
 func bswap16(v int64) int16 {
@@ -44,3 +46,13 @@ func bool2i64(v bool) int64 {
         return 0
     }
 }
+
+func uintSize(vt *defs.Type) int64 {
+    return int64(defs.GetIntSize(vt.V.T))
+}
+
+func unionOffsets(fvs []defs.Field) []int {
+    ret := make([]int, 0, len(fvs))
+    for _, fv := range fvs { ret = append(ret, fv.F) }
+    return ret
+}
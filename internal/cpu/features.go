@@ -23,3 +23,12 @@ import (
 var (
     HasMOVBE = cpuid.CPU.Has(cpuid.MOVBE)
 )
+
+// Features returns the CPU features that affect the generated code.
+func Features() string {
+    if HasMOVBE {
+        return "movbe"
+    } else {
+        return ""
+    }
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deep
+
+import (
+    `bytes`
+    `sync`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/binary/encoder`
+    `github.com/cloudwego/frugal/internal/opts`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+const (
+    _FNVOffset = 14695981039346656037
+    _FNVPrime  = 1099511628211
+)
+
+var (
+    bufferPool sync.Pool
+)
+
+var (
+    canonical = opts.Options { Canonical: true }
+)
+
+func newBuffer() *[]byte {
+    if v := bufferPool.Get(); v != nil {
+        return v.(*[]byte)
+    } else {
+        return new([]byte)
+    }
+}
+
+func freeBuffer(p *[]byte) {
+    *p = (*p)[:0]
+    bufferPool.Put(p)
+}
+
+// Encode appends the canonical Thrift Binary Protocol encoding of the value of type vt at p to buf.
+func Encode(buf []byte, vt *rt.GoType, p unsafe.Pointer) ([]byte, error) {
+    var nb int
+    var err error
+    var enc encoder.Encoder
+
+    /* resolve the encoder */
+    if enc, err = encoder.Resolve(vt, defs.Binary); err != nil {
+        return buf, err
+    }
+
+    /* measure the value, the size does not depend on the order */
+    if nb, err = enc.EncodeWithOptions(nil, nil, p, canonical); err != nil {
+        return buf, err
+    }
+
+    /* grow the buffer if needed */
+    if cap(buf) - len(buf) < nb {
+        mem := make([]byte, len(buf), len(buf) + nb)
+        buf = mem[:copy(mem, buf)]
+    }
+
+    /* encode into the remaining capacity */
+    ret := buf[len(buf):len(buf) + nb]
+    nb, err = enc.EncodeWithOptions(ret, nil, p, canonical)
+    return buf[:len(buf) + nb], err
+}
+
+// Equal reports whether the values of type vt at a and b are equal, which means their
+// canonical encodings are identical.
+func Equal(vt *rt.GoType, a unsafe.Pointer, b unsafe.Pointer) (bool, error) {
+    var err error
+    var x, y *[]byte
+
+    /* the same value is always equal to itself */
+    if a == b {
+        return true, nil
+    }
+
+    /* allocate buffers for both sides */
+    x = newBuffer()
+    y = newBuffer()
+    defer freeBuffer(x)
+    defer freeBuffer(y)
+
+    /* encode both values */
+    if *x, err = Encode(*x, vt, a); err != nil {
+        return false, err
+    } else if *y, err = Encode(*y, vt, b); err != nil {
+        return false, err
+    } else {
+        return bytes.Equal(*x, *y), nil
+    }
+}
+
+// Hash computes the 64-bit FNV-1a hash of the canonical encoding of the value of type vt at p.
+func Hash(vt *rt.GoType, p unsafe.Pointer) (uint64, error) {
+    var err error
+    var buf = newBuffer()
+    var ret = uint64(_FNVOffset)
+
+    /* encode the value */
+    defer freeBuffer(buf)
+    if *buf, err = Encode(*buf, vt, p); err != nil {
+        return 0, err
+    }
+
+    /* hash the encoded bytes */
+    for _, v := range *buf {
+        ret ^= uint64(v)
+        ret *= _FNVPrime
+    }
+
+    /* all done */
+    return ret, nil
+}
+
+// Clone copies the value of type vt at src into dst by encoding and decoding it, so only
+// the Thrift fields are copied. dst must point to a zero value.
+func Clone(vt *rt.GoType, dst unsafe.Pointer, src unsafe.Pointer) error {
+    var err error
+    var buf []byte
+    var dec decoder.Decoder
+
+    /* resolve the decoder */
+    if dec, err = decoder.Resolve(vt, defs.Binary); err != nil {
+        return err
+    }
+
+    /* the clone may refer to the buffer, so it cannot be reused */
+    if buf, err = Encode(nil, vt, src); err != nil {
+        return err
+    }
+
+    /* decode without any limits, the value is already in memory */
+    _, err = dec.Decode(vt, defs.Binary, buf, dst, canonical)
+    return err
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deep
+
+import (
+    `reflect`
+    `testing`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/rt`
+    `github.com/stretchr/testify/require`
+)
+
+type DeepTestItem struct {
+    A int32 `frugal:"1,default,i32"`
+}
+
+type DeepTestStruct struct {
+    M map[string]int64 `frugal:"1,default,map<string:i64>"`
+    S []*DeepTestItem  `frugal:"2,default,set<DeepTestItem>"`
+    L []int32          `frugal:"3,default,list<i32>"`
+    O []int32          `frugal:"4,optional,list<i32>"`
+    P *DeepTestItem    `frugal:"5,optional,DeepTestItem"`
+}
+
+var (
+    deepTestType = rt.UnpackType(reflect.TypeOf(DeepTestStruct{}))
+)
+
+func newDeepTestStruct() *DeepTestStruct {
+    return &DeepTestStruct {
+        M: map[string]int64 { "a": 1, "b": 2, "c": 3, "d": 4 },
+        S: []*DeepTestItem { { 3 }, { 1 }, { 2 } },
+        L: []int32 { 1, 2, 3 },
+        P: &DeepTestItem { 5 },
+    }
+}
+
+func deepEqual(t *testing.T, a *DeepTestStruct, b *DeepTestStruct) bool {
+    ok, err := Equal(deepTestType, unsafe.Pointer(a), unsafe.Pointer(b))
+    require.NoError(t, err)
+    return ok
+}
+
+func deepHash(t *testing.T, v *DeepTestStruct) uint64 {
+    ret, err := Hash(deepTestType, unsafe.Pointer(v))
+    require.NoError(t, err)
+    return ret
+}
+
+func TestDeep_Equal(t *testing.T) {
+    a := newDeepTestStruct()
+    b := newDeepTestStruct()
+    b.M = map[string]int64 { "d": 4, "c": 3, "b": 2, "a": 1 }
+    b.S = []*DeepTestItem { { 2 }, { 3 }, { 1 } }
+    require.True(t, deepEqual(t, a, a))
+    require.True(t, deepEqual(t, a, b))
+    require.Equal(t, deepHash(t, a), deepHash(t, b))
+    b.L = []int32 { 3, 2, 1 }
+    require.False(t, deepEqual(t, a, b))
+    require.NotEqual(t, deepHash(t, a), deepHash(t, b))
+    a.L, b.L = nil, []int32{}
+    require.True(t, deepEqual(t, a, b))
+    a.O, b.O = nil, []int32{}
+    require.False(t, deepEqual(t, a, b))
+    a.O = []int32{}
+    b.P.A = 6
+    require.False(t, deepEqual(t, a, b))
+}
+
+func TestDeep_Clone(t *testing.T) {
+    var v DeepTestStruct
+    src := newDeepTestStruct()
+    require.NoError(t, Clone(deepTestType, unsafe.Pointer(&v), unsafe.Pointer(src)))
+    require.True(t, deepEqual(t, src, &v))
+    require.Equal(t, src.M, v.M)
+    require.Equal(t, *src.P, *v.P)
+    require.NotSame(t, src.P, v.P)
+    v.M["a"] = 100
+    v.S[0].A = 100
+    require.Equal(t, int64(1), src.M["a"])
+    require.Equal(t, int32(3), src.S[0].A)
+}
@@ -0,0 +1,309 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package descriptor
+
+import (
+    `fmt`
+    `reflect`
+    `strconv`
+    `strings`
+    `unicode`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+)
+
+// Enum is the Go type of enum fields in the built structs.
+type Enum int64
+
+// Type describes a Thrift type.
+type Type struct {
+    Type   defs.Tag     // Wire type.
+    Binary bool         // Binary instead of string, only for string types.
+    Enum   bool         // Enum instead of i32, only for i32 types.
+    Key    *Type        // Key type of maps.
+    Elem   *Type        // Value type of maps, or element type of sets and lists.
+    Struct *Struct      // Struct type of structs.
+}
+
+// Field describes a field of a Thrift struct.
+type Field struct {
+    ID   uint16
+    Name string
+    Type *Type
+    Spec defs.Requiredness
+}
+
+// Struct describes a Thrift struct, union or exception.
+type Struct struct {
+    Name   string
+    Fields []Field
+    Union  bool
+}
+
+var (
+    boolType   = reflect.TypeOf(false)
+    i8Type     = reflect.TypeOf(int8(0))
+    i16Type    = reflect.TypeOf(int16(0))
+    i32Type    = reflect.TypeOf(int32(0))
+    i64Type    = reflect.TypeOf(int64(0))
+    enumType   = reflect.TypeOf(Enum(0))
+    doubleType = reflect.TypeOf(float64(0))
+    stringType = reflect.TypeOf("")
+    binaryType = reflect.TypeOf([]byte(nil))
+    unionType  = reflect.TypeOf(struct{}{})
+)
+
+type builder struct {
+    done map[*Struct]reflect.Type
+    busy map[*Struct]bool
+}
+
+// Build creates the Go struct type described by sd, which can be used with all the codecs.
+// Nested structs are represented as pointers, the same way as the code generated by Thriftgo.
+func Build(sd *Struct) (reflect.Type, error) {
+    return (&builder {
+        done: make(map[*Struct]reflect.Type),
+        busy: make(map[*Struct]bool),
+    }).structure(sd)
+}
+
+func (self *builder) structure(sd *Struct) (reflect.Type, error) {
+    var ok bool
+    var vt reflect.Type
+
+    /* check for previously built structs */
+    if vt, ok = self.done[sd]; ok {
+        return vt, nil
+    }
+
+    /* Go types created at runtime cannot refer to themselves */
+    if self.busy[sd] {
+        return nil, fmt.Errorf("frugal: recursive struct %s is not supported", sd.Name)
+    }
+
+    /* mark as building */
+    self.busy[sd] = true
+    defer delete(self.busy, sd)
+
+    /* build every field */
+    ns := make(map[string]bool, len(sd.Fields))
+    fv := make([]reflect.StructField, 0, len(sd.Fields) + 1)
+
+    /* unions are marked by a blank field */
+    if sd.Union {
+        fv = append(fv, reflect.StructField {
+            Name    : "_",
+            Type    : unionType,
+            Tag     : `frugal:"union"`,
+            PkgPath : enumType.PkgPath(),
+        })
+    }
+
+    /* add all the fields */
+    for i := range sd.Fields {
+        if sf, err := self.field(sd, &sd.Fields[i], ns); err != nil {
+            return nil, err
+        } else {
+            fv = append(fv, sf)
+        }
+    }
+
+    /* create the type */
+    vt = reflect.StructOf(fv)
+    self.done[sd] = vt
+
+    /* validate with the resolver */
+    if err := resolve(vt); err != nil {
+        return nil, fmt.Errorf("frugal: invalid struct %s: %w", sd.Name, err)
+    } else {
+        return vt, nil
+    }
+}
+
+func (self *builder) field(sd *Struct, fd *Field, ns map[string]bool) (reflect.StructField, error) {
+    rx := fd.Spec
+    vt, tv, err := self.typeOf(fd.Type)
+
+    /* check for errors */
+    if err != nil {
+        return reflect.StructField{}, fmt.Errorf("%w: field %s.%s", err, sd.Name, fd.Name)
+    }
+
+    /* union members are always optional */
+    if sd.Union {
+        rx = defs.Optional
+    }
+
+    /* optional scalar fields are pointers, so they can be checked against nil */
+    if rx == defs.Optional {
+        switch vt.Kind() {
+            case reflect.Slice : break
+            case reflect.Map   : break
+            case reflect.Ptr   : break
+            default            : vt = reflect.PtrTo(vt)
+        }
+    }
+
+    /* field tags */
+    tag := fmt.Sprintf(`frugal:"%d,%s,%s"`, fd.ID, rx, tv)
+    name := fieldName(fd, ns)
+
+    /* keep the Thrift name if any */
+    if fd.Name != "" {
+        tag += ` thrift:` + strconv.Quote(fmt.Sprintf("%s,%d", fd.Name, fd.ID))
+    }
+
+    /* construct the field */
+    return reflect.StructField {
+        Name : name,
+        Type : vt,
+        Tag  : reflect.StructTag(tag),
+    }, nil
+}
+
+func (self *builder) typeOf(td *Type) (reflect.Type, string, error) {
+    if td == nil {
+        return nil, "", fmt.Errorf("frugal: missing type")
+    }
+
+    /* check for the wire type */
+    switch td.Type {
+        case defs.T_bool   : return boolType, "bool", nil
+        case defs.T_i8     : return i8Type, "i8", nil
+        case defs.T_i16    : return i16Type, "i16", nil
+        case defs.T_i32    : return self.integer(td)
+        case defs.T_i64    : return i64Type, "i64", nil
+        case defs.T_double : return doubleType, "double", nil
+        case defs.T_string : return self.str(td)
+        case defs.T_struct : return self.pointer(td)
+        case defs.T_map    : return self.mapping(td)
+        case defs.T_set    : return self.list(td, "set")
+        case defs.T_list   : return self.list(td, "list")
+        default            : return nil, "", fmt.Errorf("frugal: invalid wire type: %d", td.Type)
+    }
+}
+
+func (self *builder) integer(td *Type) (reflect.Type, string, error) {
+    if td.Enum {
+        return enumType, enumType.Name(), nil
+    } else {
+        return i32Type, "i32", nil
+    }
+}
+
+func (self *builder) str(td *Type) (reflect.Type, string, error) {
+    if td.Binary {
+        return binaryType, "binary", nil
+    } else {
+        return stringType, "string", nil
+    }
+}
+
+func (self *builder) pointer(td *Type) (reflect.Type, string, error) {
+    if td.Struct == nil {
+        return nil, "", fmt.Errorf("frugal: missing struct descriptor")
+    } else if vt, err := self.structure(td.Struct); err != nil {
+        return nil, "", err
+    } else {
+        return reflect.PtrTo(vt), "struct", nil
+    }
+}
+
+func (self *builder) mapping(td *Type) (reflect.Type, string, error) {
+    kt, ks, err := self.typeOf(td.Key)
+    if err != nil {
+        return nil, "", err
+    }
+
+    /* binary keys are represented as strings, because slices are not comparable */
+    if kt == binaryType {
+        kt, ks = stringType, "string"
+    }
+
+    /* Go maps can only have comparable keys */
+    if !kt.Comparable() {
+        return nil, "", fmt.Errorf("frugal: %s cannot be used as map keys", ks)
+    }
+
+    /* build the value type */
+    vt, vs, err := self.typeOf(td.Elem)
+    if err != nil {
+        return nil, "", err
+    }
+
+    /* construct the map type */
+    return reflect.MapOf(kt, vt), fmt.Sprintf("map<%s:%s>", ks, vs), nil
+}
+
+func (self *builder) list(td *Type, kind string) (reflect.Type, string, error) {
+    if et, es, err := self.typeOf(td.Elem); err != nil {
+        return nil, "", err
+    } else {
+        return reflect.SliceOf(et), fmt.Sprintf("%s<%s>", kind, es), nil
+    }
+}
+
+func fieldName(fd *Field, ns map[string]bool) string {
+    var sb strings.Builder
+    var up = true
+
+    /* convert the snake-case name into an exported Go name */
+    for _, ch := range fd.Name {
+        switch {
+            case ch == '_'         : up = true
+            case up                : sb.WriteRune(unicode.ToUpper(ch)); up = false
+            default                : sb.WriteRune(ch)
+        }
+    }
+
+    /* fallback to the field ID if it's not a valid identifier, or is duplicated */
+    if ret := sb.String(); !isExported(ret) || ns[ret] {
+        ret = fmt.Sprintf("Field%d", fd.ID)
+        ns[ret] = true
+        return ret
+    } else {
+        ns[ret] = true
+        return ret
+    }
+}
+
+func isExported(name string) bool {
+    for i, ch := range name {
+        if i == 0 && !unicode.IsUpper(ch) {
+            return false
+        } else if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) {
+            return false
+        }
+    }
+    return name != ""
+}
+
+func resolve(vt reflect.Type) (err error) {
+    defer func() {
+        if v := recover(); v != nil {
+            if e, ok := v.(error); ok {
+                err = e
+            } else {
+                panic(v)
+            }
+        }
+    }()
+
+    /* resolve the fields, which also checks for unions */
+    _, err = defs.ResolveFields(vt)
+    return
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package descriptor
+
+import (
+    `reflect`
+    `testing`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/stretchr/testify/require`
+)
+
+var (
+    descriptorTestInner = &Struct {
+        Name   : "Inner",
+        Fields : []Field {
+            { ID: 1, Name: "value", Type: &Type { Type: defs.T_i64 } },
+        },
+    }
+    descriptorTestUnion = &Struct {
+        Name   : "Choice",
+        Union  : true,
+        Fields : []Field {
+            { ID: 1, Name: "num", Type: &Type { Type: defs.T_i32 } },
+            { ID: 2, Name: "str", Type: &Type { Type: defs.T_string } },
+        },
+    }
+)
+
+func TestDescriptor_Build(t *testing.T) {
+    vt, err := Build(&Struct {
+        Name   : "Outer",
+        Fields : []Field {
+            { ID: 1, Name: "flag"       , Type: &Type { Type: defs.T_bool }, Spec: defs.Required },
+            { ID: 2, Name: "user_id"    , Type: &Type { Type: defs.T_i64 }, Spec: defs.Optional },
+            { ID: 3, Name: "kind"       , Type: &Type { Type: defs.T_i32, Enum: true } },
+            { ID: 4, Name: "data"       , Type: &Type { Type: defs.T_string, Binary: true }, Spec: defs.Optional },
+            { ID: 5, Name: "inner"      , Type: &Type { Type: defs.T_struct, Struct: descriptorTestInner } },
+            { ID: 6, Name: "items"      , Type: &Type { Type: defs.T_list, Elem: &Type { Type: defs.T_struct, Struct: descriptorTestInner } } },
+            { ID: 7, Name: "blobs"      , Type: &Type { Type: defs.T_map, Key: &Type { Type: defs.T_string, Binary: true }, Elem: &Type { Type: defs.T_double } } },
+            { ID: 8, Name: "choice"     , Type: &Type { Type: defs.T_struct, Struct: descriptorTestUnion } },
+            { ID: 9, Name: "not-a-name" , Type: &Type { Type: defs.T_set, Elem: &Type { Type: defs.T_i8 } } },
+        },
+    })
+    require.NoError(t, err)
+    require.Equal(t, 9, vt.NumField())
+    iv := reflect.PtrTo(vt.Field(4).Type.Elem())
+    ut := reflect.PtrTo(vt.Field(7).Type.Elem())
+    for i, v := range []struct { name string; typ reflect.Type; tag reflect.StructTag } {
+        { "Flag"   , reflect.TypeOf(false)                   , `frugal:"1,required,bool" thrift:"flag,1"` },
+        { "UserId" , reflect.TypeOf(new(int64))              , `frugal:"2,optional,i64" thrift:"user_id,2"` },
+        { "Kind"   , reflect.TypeOf(Enum(0))                 , `frugal:"3,default,Enum" thrift:"kind,3"` },
+        { "Data"   , reflect.TypeOf([]byte(nil))             , `frugal:"4,optional,binary" thrift:"data,4"` },
+        { "Inner"  , iv                                      , `frugal:"5,default,struct" thrift:"inner,5"` },
+        { "Items"  , reflect.SliceOf(iv)                     , `frugal:"6,default,list<struct>" thrift:"items,6"` },
+        { "Blobs"  , reflect.TypeOf(map[string]float64(nil)) , `frugal:"7,default,map<string:double>" thrift:"blobs,7"` },
+        { "Choice" , ut                                      , `frugal:"8,default,struct" thrift:"choice,8"` },
+        { "Field9" , reflect.TypeOf([]int8(nil))             , `frugal:"9,default,set<i8>" thrift:"not-a-name,9"` },
+    } {
+        sf := vt.Field(i)
+        require.Equal(t, v.name, sf.Name)
+        require.Equal(t, v.typ, sf.Type)
+        require.Equal(t, v.tag, sf.Tag)
+    }
+    ok, err := defs.IsUnion(ut.Elem())
+    require.NoError(t, err)
+    require.True(t, ok)
+    require.Equal(t, reflect.TypeOf(new(int32)), ut.Elem().Field(1).Type)
+}
+
+func TestDescriptor_BuildErrors(t *testing.T) {
+    rec := &Struct { Name: "Node" }
+    rec.Fields = []Field {{ ID: 1, Name: "next", Type: &Type { Type: defs.T_struct, Struct: rec } }}
+    for _, sd := range []*Struct {
+        rec,
+        { Fields: []Field {{ ID: 1 }} },
+        { Fields: []Field {{ ID: 1, Type: &Type { Type: defs.T_struct } }} },
+        { Fields: []Field {{ ID: 1, Type: &Type { Type: defs.T_list } }} },
+        { Fields: []Field {{ ID: 1, Type: &Type { Type: defs.Tag(100) } }} },
+        { Fields: []Field {{ ID: 1, Type: &Type { Type: defs.T_map, Key: &Type { Type: defs.T_list, Elem: &Type { Type: defs.T_i8 } }, Elem: &Type { Type: defs.T_i8 } } }} },
+        { Fields: []Field {{ ID: 1, Name: "a", Type: &Type { Type: defs.T_i8 } }, { ID: 1, Name: "b", Type: &Type { Type: defs.T_i8 } }} },
+    } {
+        _, err := Build(sd)
+        require.Error(t, err, "%+v", sd)
+    }
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamic
+
+import (
+    `encoding/binary`
+    `math`
+
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/opts`
+)
+
+// Decode decodes the Binary Protocol encoded struct at the beginning of buf into a
+// value tree, and returns the number of bytes consumed.
+func Decode(buf []byte, o opts.Options) (Value, int, error) {
+    nb, err := decoder.Skip(buf, defs.T_struct, o)
+    if err != nil {
+        return Value{}, 0, err
+    }
+
+    /* the value is validated by the skipper, so the walk can never go out of bounds */
+    rd := reader { buf: buf[:nb] }
+    return rd.value(defs.T_struct), nb, nil
+}
+
+type reader struct {
+    buf []byte
+    pos int
+}
+
+func (self *reader) read(n int) []byte {
+    self.pos += n
+    return self.buf[self.pos - n:self.pos]
+}
+
+func (self *reader) u8() uint8 {
+    return self.read(1)[0]
+}
+
+func (self *reader) u16() uint16 {
+    return binary.BigEndian.Uint16(self.read(2))
+}
+
+func (self *reader) u32() uint32 {
+    return binary.BigEndian.Uint32(self.read(4))
+}
+
+func (self *reader) u64() uint64 {
+    return binary.BigEndian.Uint64(self.read(8))
+}
+
+func (self *reader) value(t defs.Tag) Value {
+    switch ret := (Value { Type: t }); t {
+        case defs.T_bool   : ret.Bool = self.u8() != 0; return ret
+        case defs.T_i8     : ret.Int = int64(int8(self.u8())); return ret
+        case defs.T_i16    : ret.Int = int64(int16(self.u16())); return ret
+        case defs.T_i32    : ret.Int = int64(int32(self.u32())); return ret
+        case defs.T_i64    : ret.Int = int64(self.u64()); return ret
+        case defs.T_double : ret.Double = math.Float64frombits(self.u64()); return ret
+        case defs.T_string : ret.Binary = append([]byte{}, self.read(int(self.u32()))...); return ret
+        case defs.T_struct : return self.structure(ret)
+        case defs.T_map    : return self.mapping(ret)
+        case defs.T_set    : return self.list(ret)
+        case defs.T_list   : return self.list(ret)
+        default            : panic("unreachable")
+    }
+}
+
+func (self *reader) structure(ret Value) Value {
+    for {
+        tt := defs.Tag(self.u8())
+        if tt == 0 {
+            return ret
+        }
+
+        /* field ID and value */
+        id := self.u16()
+        ret.Fields = append(ret.Fields, FieldValue { ID: id, Value: self.value(tt) })
+    }
+}
+
+func (self *reader) mapping(ret Value) Value {
+    ret.Key = defs.Tag(self.u8())
+    ret.Elem = defs.Tag(self.u8())
+    ret.Pairs = make([]PairValue, self.u32())
+
+    /* decode every key-value pair */
+    for i := range ret.Pairs {
+        ret.Pairs[i].Key = self.value(ret.Key)
+        ret.Pairs[i].Value = self.value(ret.Elem)
+    }
+
+    /* all done */
+    return ret
+}
+
+func (self *reader) list(ret Value) Value {
+    ret.Elem = defs.Tag(self.u8())
+    ret.Elems = make([]Value, self.u32())
+
+    /* decode every element */
+    for i := range ret.Elems {
+        ret.Elems[i] = self.value(ret.Elem)
+    }
+
+    /* all done */
+    return ret
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamic
+
+import (
+    `errors`
+    `testing`
+
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/opts`
+    `github.com/stretchr/testify/require`
+)
+
+var dynamicTestBinary = []byte {
+    0x02, 0x00, 0x01, 0x01,
+    0x03, 0x00, 0x02, 0xff,
+    0x04, 0x00, 0x03, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+    0x06, 0x00, 0x04, 0xff, 0xfe,
+    0x0b, 0x00, 0x05, 0x00, 0x00, 0x00, 0x02, 'h', 'i',
+    0x0a, 0x00, 0x06, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+    0x0f, 0x00, 0x07, 0x08, 0x00, 0x00, 0x00, 0x02,
+        0x00, 0x00, 0x00, 0x01,
+        0x00, 0x00, 0x00, 0x02,
+    0x0e, 0x00, 0x08, 0x0b, 0x00, 0x00, 0x00, 0x00,
+    0x0d, 0x00, 0x09, 0x08, 0x0c, 0x00, 0x00, 0x00, 0x01,
+        0x00, 0x00, 0x00, 0x05, 0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x00,
+    0x0c, 0x00, 0x0a, 0x00,
+    0x00,
+}
+
+var dynamicTestValue = Value {
+    Type   : defs.T_struct,
+    Fields : []FieldValue {
+        { ID:  1, Value: Value { Type: defs.T_bool, Bool: true } },
+        { ID:  2, Value: Value { Type: defs.T_i8, Int: -1 } },
+        { ID:  3, Value: Value { Type: defs.T_double, Double: 1.5 } },
+        { ID:  4, Value: Value { Type: defs.T_i16, Int: -2 } },
+        { ID:  5, Value: Value { Type: defs.T_string, Binary: []byte("hi") } },
+        { ID:  6, Value: Value { Type: defs.T_i64, Int: -1 << 63 } },
+        { ID:  7, Value: Value {
+            Type  : defs.T_list,
+            Elem  : defs.T_i32,
+            Elems : []Value {
+                { Type: defs.T_i32, Int: 1 },
+                { Type: defs.T_i32, Int: 2 },
+            },
+        }},
+        { ID:  8, Value: Value { Type: defs.T_set, Elem: defs.T_string, Elems: []Value {} } },
+        { ID:  9, Value: Value {
+            Type  : defs.T_map,
+            Key   : defs.T_i32,
+            Elem  : defs.T_struct,
+            Pairs : []PairValue {{
+                Key   : Value { Type: defs.T_i32, Int: 5 },
+                Value : Value { Type: defs.T_struct, Fields: []FieldValue {{ ID: 1, Value: Value { Type: defs.T_i32, Int: 6 } }} },
+            }},
+        }},
+        { ID: 10, Value: Value { Type: defs.T_struct } },
+    },
+}
+
+func TestDynamic_Decode(t *testing.T) {
+    buf := append(append([]byte{}, dynamicTestBinary...), 0xff)
+    ret, nb, err := Decode(buf, opts.GetDefaultOptions())
+    require.NoError(t, err)
+    require.Equal(t, len(dynamicTestBinary), nb)
+    require.Equal(t, dynamicTestValue, ret)
+    require.Equal(t, &ret.Fields[4].Value, ret.Field(5))
+    require.Nil(t, ret.Field(11))
+}
+
+func TestDynamic_DecodeErrors(t *testing.T) {
+    _, _, err := Decode(dynamicTestBinary[:20], opts.GetDefaultOptions())
+    require.Equal(t, decoder.EOFError(0), err)
+    _, _, err = Decode([]byte { 0x09, 0x00, 0x01, 0x00 }, opts.GetDefaultOptions())
+    require.Error(t, err)
+    _, _, err = Decode(dynamicTestBinary, opts.Options { MaxDepth: 1 })
+    require.True(t, errors.As(err, new(decoder.LimitError)))
+}
+
+func TestDynamic_Append(t *testing.T) {
+    buf, err := Append([]byte { 0xff }, &dynamicTestValue)
+    require.NoError(t, err)
+    require.Equal(t, append([]byte { 0xff }, dynamicTestBinary...), buf)
+}
+
+func TestDynamic_AppendErrors(t *testing.T) {
+    for _, v := range []Value {
+        {},
+        { Type: defs.T_i8, Int: 128 },
+        { Type: defs.T_i32, Int: -1 << 31 - 1 },
+        { Type: defs.T_list },
+        { Type: defs.T_map, Key: defs.T_i32 },
+        { Type: defs.T_list, Elem: defs.T_i32, Elems: []Value {{ Type: defs.T_i64 }} },
+        { Type: defs.T_map, Key: defs.T_i32, Elem: defs.T_i32, Pairs: []PairValue {{ Key: Value { Type: defs.T_i32 } }} },
+        { Type: defs.T_struct, Fields: []FieldValue {{ ID: 1 }} },
+    } {
+        _, err := Append(nil, &v)
+        require.Error(t, err, "%+v", v)
+    }
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamic
+
+import (
+    `fmt`
+    `math`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+)
+
+var _IntRanges = [256]struct { min, max int64 } {
+    defs.T_i8  : { math.MinInt8  , math.MaxInt8  },
+    defs.T_i16 : { math.MinInt16 , math.MaxInt16 },
+    defs.T_i32 : { math.MinInt32 , math.MaxInt32 },
+    defs.T_i64 : { math.MinInt64 , math.MaxInt64 },
+}
+
+// Append encodes the value tree v with Binary Protocol, and appends the result to buf.
+func Append(buf []byte, v *Value) ([]byte, error) {
+    return appendValue(buf, v, v.Type, defs.StackSize)
+}
+
+func appendValue(buf []byte, v *Value, t defs.Tag, depth int) ([]byte, error) {
+    if v.Type != t {
+        return nil, fmt.Errorf("frugal: type mismatch: %d expected, got %d", t, v.Type)
+    }
+
+    /* check for nesting depth */
+    if depth <= 0 {
+        return nil, fmt.Errorf("frugal: value nesting too deep")
+    }
+
+    /* encode the value */
+    switch t {
+        case defs.T_bool   : return appendBool(buf, v.Bool), nil
+        case defs.T_i8     : return appendInt(buf, t, v.Int)
+        case defs.T_i16    : return appendInt(buf, t, v.Int)
+        case defs.T_i32    : return appendInt(buf, t, v.Int)
+        case defs.T_i64    : return appendInt(buf, t, v.Int)
+        case defs.T_double : return appendU64(buf, math.Float64bits(v.Double)), nil
+        case defs.T_string : return appendBinary(buf, v.Binary)
+        case defs.T_struct : return appendStruct(buf, v, depth)
+        case defs.T_map    : return appendMap(buf, v, depth)
+        case defs.T_set    : return appendList(buf, v, depth)
+        case defs.T_list   : return appendList(buf, v, depth)
+        default            : return nil, fmt.Errorf("frugal: invalid wire type: %d", t)
+    }
+}
+
+func appendBool(buf []byte, v bool) []byte {
+    if v {
+        return append(buf, 1)
+    } else {
+        return append(buf, 0)
+    }
+}
+
+func appendInt(buf []byte, t defs.Tag, v int64) ([]byte, error) {
+    if v < _IntRanges[t].min || v > _IntRanges[t].max {
+        return nil, fmt.Errorf("frugal: value %d overflows type %d", v, t)
+    }
+
+    /* encode with the exact width */
+    switch t {
+        case defs.T_i8  : return append(buf, uint8(v)), nil
+        case defs.T_i16 : return appendU16(buf, uint16(v)), nil
+        case defs.T_i32 : return appendU32(buf, uint32(v)), nil
+        default         : return appendU64(buf, uint64(v)), nil
+    }
+}
+
+func appendU16(buf []byte, v uint16) []byte {
+    return append(buf, byte(v >> 8), byte(v))
+}
+
+func appendU32(buf []byte, v uint32) []byte {
+    return append(buf, byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v))
+}
+
+func appendU64(buf []byte, v uint64) []byte {
+    return appendU32(appendU32(buf, uint32(v >> 32)), uint32(v))
+}
+
+func appendSize(buf []byte, n int) ([]byte, error) {
+    if n > math.MaxInt32 {
+        return nil, fmt.Errorf("frugal: size too large: %d", n)
+    } else {
+        return appendU32(buf, uint32(n)), nil
+    }
+}
+
+func appendBinary(buf []byte, v []byte) ([]byte, error) {
+    buf, err := appendSize(buf, len(v))
+    if err != nil {
+        return nil, err
+    } else {
+        return append(buf, v...), nil
+    }
+}
+
+func appendStruct(buf []byte, v *Value, depth int) ([]byte, error) {
+    var err error
+    var fv *FieldValue
+
+    /* encode every field */
+    for i := range v.Fields {
+        fv = &v.Fields[i]
+        buf = appendU16(append(buf, uint8(fv.Value.Type)), fv.ID)
+
+        /* encode the field value */
+        if buf, err = appendValue(buf, &fv.Value, fv.Value.Type, depth - 1); err != nil {
+            return nil, err
+        }
+    }
+
+    /* add the STOP mark */
+    return append(buf, 0), nil
+}
+
+func appendMap(buf []byte, v *Value, depth int) ([]byte, error) {
+    var err error
+    var kv *PairValue
+
+    /* check for key and value types, they are needed even if the map is empty */
+    if !v.Key.IsWireTag() || !v.Elem.IsWireTag() {
+        return nil, fmt.Errorf("frugal: invalid map type: %d -> %d", v.Key, v.Elem)
+    }
+
+    /* map header */
+    if buf, err = appendSize(append(buf, uint8(v.Key), uint8(v.Elem)), len(v.Pairs)); err != nil {
+        return nil, err
+    }
+
+    /* encode every key-value pair */
+    for i := range v.Pairs {
+        kv = &v.Pairs[i]
+
+        /* encode the key */
+        if buf, err = appendValue(buf, &kv.Key, v.Key, depth - 1); err != nil {
+            return nil, err
+        }
+
+        /* encode the value */
+        if buf, err = appendValue(buf, &kv.Value, v.Elem, depth - 1); err != nil {
+            return nil, err
+        }
+    }
+
+    /* all done */
+    return buf, nil
+}
+
+func appendList(buf []byte, v *Value, depth int) ([]byte, error) {
+    var err error
+
+    /* check for element type, it is needed even if the sequence is empty */
+    if !v.Elem.IsWireTag() {
+        return nil, fmt.Errorf("frugal: invalid element type: %d", v.Elem)
+    }
+
+    /* set or list header */
+    if buf, err = appendSize(append(buf, uint8(v.Elem)), len(v.Elems)); err != nil {
+        return nil, err
+    }
+
+    /* encode every element */
+    for i := range v.Elems {
+        if buf, err = appendValue(buf, &v.Elems[i], v.Elem, depth - 1); err != nil {
+            return nil, err
+        }
+    }
+
+    /* all done */
+    return buf, nil
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamic
+
+import (
+    `github.com/cloudwego/frugal/internal/binary/defs`
+)
+
+// Value is a node of a Thrift value tree, only the members that match Type are meaningful.
+type Value struct {
+    Type   defs.Tag     // Wire type of the value.
+    Bool   bool         // Value of bool.
+    Int    int64        // Value of i8, i16, i32 and i64.
+    Double float64      // Value of double.
+    Binary []byte       // Value of string and binary.
+    Fields []FieldValue // Fields of struct, in the order they appear.
+    Key    defs.Tag     // Key type of map.
+    Elem   defs.Tag     // Element type of set and list, or value type of map.
+    Elems  []Value      // Elements of set and list.
+    Pairs  []PairValue  // Key-value pairs of map, in the order they appear.
+}
+
+// FieldValue is a field of a struct Value.
+type FieldValue struct {
+    ID    uint16
+    Value Value
+}
+
+// PairValue is a key-value pair of a map Value.
+type PairValue struct {
+    Key   Value
+    Value Value
+}
+
+// Field returns the field with the given ID of a struct Value, or nil if it does not exist.
+func (self *Value) Field(id uint16) *Value {
+    for i := range self.Fields {
+        if self.Fields[i].ID == id {
+            return &self.Fields[i].Value
+        }
+    }
+    return nil
+}
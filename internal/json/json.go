@@ -0,0 +1,182 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package json
+
+import (
+    `errors`
+    `fmt`
+    `reflect`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+)
+
+type Mode uint8
+
+const (
+    TJSON Mode = iota
+    SimpleJSON
+)
+
+func (self Mode) String() string {
+    switch self {
+        case TJSON      : return "TJSON"
+        case SimpleJSON : return "SimpleJSON"
+        default         : return fmt.Sprintf("Mode(%d)", uint8(self))
+    }
+}
+
+const (
+    _MaxNesting = 1024
+)
+
+var (
+    errEOF     = errors.New("frugal: unexpected end of Binary Protocol data")
+    errNesting = errors.New("frugal: JSON value is nested too deeply")
+)
+
+var typeNames = [256]string {
+    defs.T_bool   : "tf",
+    defs.T_i8     : "i8",
+    defs.T_double : "dbl",
+    defs.T_i16    : "i16",
+    defs.T_i32    : "i32",
+    defs.T_i64    : "i64",
+    defs.T_string : "str",
+    defs.T_struct : "rec",
+    defs.T_map    : "map",
+    defs.T_set    : "set",
+    defs.T_list   : "lst",
+}
+
+var typeTags = map[string]defs.Tag {
+    "tf"  : defs.T_bool,
+    "i8"  : defs.T_i8,
+    "dbl" : defs.T_double,
+    "i16" : defs.T_i16,
+    "i32" : defs.T_i32,
+    "i64" : defs.T_i64,
+    "str" : defs.T_string,
+    "rec" : defs.T_struct,
+    "map" : defs.T_map,
+    "set" : defs.T_set,
+    "lst" : defs.T_list,
+}
+
+// FromBinary converts the Thrift Binary Protocol encoded value of type vt in src
+// into JSON, and appends the result to buf.
+func FromBinary(buf []byte, src []byte, vt reflect.Type, mode Mode) (ret []byte, err error) {
+    defer rescue(&err)
+    tt := defs.ParseType(vt, "")
+
+    /* convert the value, and free the type when done */
+    wr := writer { buf: buf, src: src, mode: mode }
+    wr.value(tt.Tag(), tt)
+    tt.Free()
+    return wr.buf, nil
+}
+
+// ToBinary converts the JSON encoded value of type vt in src into Thrift Binary
+// Protocol, and appends the result to buf.
+func ToBinary(buf []byte, src []byte, vt reflect.Type, mode Mode) (ret []byte, err error) {
+    defer rescue(&err)
+    tt := defs.ParseType(vt, "")
+
+    /* convert the value, and free the type when done */
+    rd := reader { buf: buf, src: src, mode: mode }
+    rd.value(tt.Tag(), tt)
+    tt.Free()
+
+    /* nothing but spaces is allowed after the value */
+    if rd.space(); rd.pos != len(rd.src) {
+        rd.fail("invalid character after top-level value")
+    }
+
+    /* all done */
+    return rd.buf, nil
+}
+
+func rescue(ep *error) {
+    if val := recover(); val != nil {
+        if err, ok := val.(error); ok {
+            *ep = err
+        } else {
+            panic(val)
+        }
+    }
+}
+
+func fieldsOf(vt *defs.Type) []defs.Field {
+    if vt == nil || vt.T != defs.T_struct {
+        return nil
+    } else if fv, err := defs.ResolveFields(vt.S); err != nil {
+        panic(err)
+    } else {
+        return fv
+    }
+}
+
+func fieldByID(fv []defs.Field, id uint16) *defs.Field {
+    for i := range fv {
+        if fv[i].ID == id {
+            return &fv[i]
+        }
+    }
+    return nil
+}
+
+func fieldByName(fv []defs.Field, name string) *defs.Field {
+    for i := range fv {
+        if fv[i].Name == name {
+            return &fv[i]
+        }
+    }
+    return nil
+}
+
+func elementOf(vt *defs.Type) *defs.Type {
+    for vt != nil && (vt.T == defs.T_pointer || vt.T == defs.T_codec) {
+        vt = valueOf(vt)
+    }
+    return vt
+}
+
+func valueOf(vt *defs.Type) *defs.Type {
+    if vt.T != defs.T_codec {
+        return vt.V
+    }
+
+    /* raw values are described by their own types, if any */
+    if cc := defs.LookupCodec(vt.S); !cc.Raw {
+        return vt.V
+    } else if cc.Elem == nil {
+        return nil
+    } else {
+        return defs.ParseType(cc.Elem, "")
+    }
+}
+
+func isBinary(vt *defs.Type) bool {
+    return vt != nil && (vt.T == defs.T_binary || vt.T == defs.T_array)
+}
+
+func isUnsigned(vt *defs.Type) bool {
+    return vt != nil && vt.T == defs.T_unsigned
+}
+
+func isFloat(vt *defs.Type) bool {
+    return vt != nil && vt.T == defs.T_float
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package json
+
+import (
+    `encoding/json`
+    `reflect`
+    `testing`
+
+    `github.com/stretchr/testify/require`
+)
+
+type JSONTestEnum int64
+
+type JSONTestItem struct {
+    X int32 `frugal:"1,default,i32"`
+}
+
+type JSONTestStruct struct {
+    A bool                    `frugal:"1,default,bool"`
+    B int8                    `frugal:"2,default,i8"`
+    C float64                 `frugal:"3,default,double" thrift:"c_double,3"`
+    D uint16                  `frugal:"4,default,i16,unsigned"`
+    E string                  `frugal:"5,default,string"`
+    F []byte                  `frugal:"6,default,binary"`
+    G JSONTestEnum            `frugal:"7,default,JSONTestEnum"`
+    H []int64                 `frugal:"8,default,list<i64>"`
+    I map[string]float32      `frugal:"9,default,map<string:double>"`
+    J map[int32]*JSONTestItem `frugal:"10,default,map<i32:JSONTestItem>"`
+    K *JSONTestItem           `frugal:"11,optional,JSONTestItem"`
+}
+
+var (
+    jsonTestBinary = []byte {
+        0x02, 0x00, 0x01, 0x01,
+        0x03, 0x00, 0x02, 0xff,
+        0x04, 0x00, 0x03, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+        0x06, 0x00, 0x04, 0xff, 0xfe,
+        0x0b, 0x00, 0x05, 0x00, 0x00, 0x00, 0x04, 'a', '"', '\n', 'b',
+        0x0b, 0x00, 0x06, 0x00, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03,
+        0x08, 0x00, 0x07, 0x00, 0x00, 0x00, 0x02,
+        0x0f, 0x00, 0x08, 0x0a, 0x00, 0x00, 0x00, 0x02,
+            0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+            0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+        0x0d, 0x00, 0x09, 0x0b, 0x04, 0x00, 0x00, 0x00, 0x01,
+            0x00, 0x00, 0x00, 0x01, 'k', 0x3f, 0xb9, 0x99, 0x99, 0xa0, 0x00, 0x00, 0x00,
+        0x0d, 0x00, 0x0a, 0x08, 0x0c, 0x00, 0x00, 0x00, 0x01,
+            0x00, 0x00, 0x00, 0x05, 0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x00,
+        0x0c, 0x00, 0x0b, 0x00,
+        0x08, 0x00, 0x63, 0x00, 0x00, 0x00, 0x01,
+        0x00,
+    }
+    jsonTestTJSON = `{` +
+        `"1":{"tf":1},` +
+        `"2":{"i8":-1},` +
+        `"3":{"dbl":1.5},` +
+        `"4":{"i16":65534},` +
+        `"5":{"str":"a\"\nb"},` +
+        `"6":{"str":"AQID"},` +
+        `"7":{"i32":2},` +
+        `"8":{"lst":["i64",2,1,-1]},` +
+        `"9":{"map":["str","dbl",1,{"k":0.1}]},` +
+        `"10":{"map":["i32","rec",1,{"5":{"1":{"i32":6}}}]},` +
+        `"11":{"rec":{}}` +
+    `}`
+    jsonTestSimpleJSON = `{` +
+        `"A":true,` +
+        `"B":-1,` +
+        `"c_double":1.5,` +
+        `"D":65534,` +
+        `"E":"a\"\nb",` +
+        `"F":"AQID",` +
+        `"G":2,` +
+        `"H":[1,-1],` +
+        `"I":{"k":0.1},` +
+        `"J":{"5":{"X":6}},` +
+        `"K":{}` +
+    `}`
+)
+
+/* the unknown field #99 is dropped, so the converted results have no trailing field */
+var jsonTestCanonical = append(append([]byte(nil), jsonTestBinary[:len(jsonTestBinary) - 8]...), 0x00)
+
+func TestJSON_FromBinary(t *testing.T) {
+    vt := reflect.TypeOf(JSONTestStruct{})
+    ret, err := FromBinary(nil, jsonTestBinary, vt, TJSON)
+    require.NoError(t, err)
+    require.Equal(t, jsonTestTJSON, string(ret))
+    require.True(t, json.Valid(ret))
+    ret, err = FromBinary(nil, jsonTestBinary, vt, SimpleJSON)
+    require.NoError(t, err)
+    require.Equal(t, jsonTestSimpleJSON, string(ret))
+    require.True(t, json.Valid(ret))
+    _, err = FromBinary(nil, jsonTestBinary[:20], vt, TJSON)
+    require.Error(t, err)
+}
+
+func TestJSON_ToBinary(t *testing.T) {
+    vt := reflect.TypeOf(JSONTestStruct{})
+    ret, err := ToBinary(nil, []byte(jsonTestTJSON), vt, TJSON)
+    require.NoError(t, err)
+    require.Equal(t, jsonTestCanonical, ret)
+    ret, err = ToBinary(nil, []byte(jsonTestSimpleJSON), vt, SimpleJSON)
+    require.NoError(t, err)
+    require.Equal(t, jsonTestCanonical, ret)
+}
+
+func TestJSON_ToBinaryLenient(t *testing.T) {
+    vt := reflect.TypeOf(JSONTestItem{})
+    ret, err := ToBinary(nil, []byte(` { "Y" : [1, {"z": null}], "X" : 6 , "K": null } `), vt, SimpleJSON)
+    require.NoError(t, err)
+    require.Equal(t, []byte { 0x08, 0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x00 }, ret)
+    ret, err = ToBinary(nil, []byte(`{"2":{"str":"x"},"1":null}`), vt, TJSON)
+    require.NoError(t, err)
+    require.Equal(t, []byte { 0x00 }, ret)
+    ret, err = ToBinary(nil, []byte(`{"E":"é😀\/","F":"AQI="}`), reflect.TypeOf(JSONTestStruct{}), SimpleJSON)
+    require.NoError(t, err)
+    require.Equal(t, []byte {
+        0x0b, 0x00, 0x05, 0x00, 0x00, 0x00, 0x07, 0xc3, 0xa9, 0xf0, 0x9f, 0x98, 0x80, '/',
+        0x0b, 0x00, 0x06, 0x00, 0x00, 0x00, 0x02, 0x01, 0x02,
+        0x00,
+    }, ret)
+}
+
+func TestJSON_ToBinaryErrors(t *testing.T) {
+    vt := reflect.TypeOf(JSONTestStruct{})
+    for _, src := range []string {
+        ``,
+        `{`,
+        `{"A":2}`,
+        `{"B":128}`,
+        `{"D":-1}`,
+        `{"F":"!!"}`,
+        `{"H":[1,]}`,
+        `{"H":[1 2]}`,
+        `{"I":{"k":1,}}`,
+        `{"E":"\x"}`,
+        `{} {}`,
+    } {
+        _, err := ToBinary(nil, []byte(src), vt, SimpleJSON)
+        require.Error(t, err, src)
+    }
+    for _, src := range []string {
+        `{"A":{"tf":1}}`,
+        `{"1":{"xx":1}}`,
+        `{"8":{"lst":["i64",3,1,2]}}`,
+        `{"9":{"map":["str","dbl",0,{"k":1}]}}`,
+        `{"2":{"i8":-129}}`,
+    } {
+        _, err := ToBinary(nil, []byte(src), vt, TJSON)
+        require.Error(t, err, src)
+    }
+}
+
+func TestJSON_SpecialValues(t *testing.T) {
+    vt := reflect.TypeOf(map[float64]bool{})
+    for _, mode := range []Mode { TJSON, SimpleJSON } {
+        src := []byte { 0x04, 0x02, 0x00, 0x00, 0x00, 0x02, 0x7f, 0xf0, 0, 0, 0, 0, 0, 0, 0x01, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0, 0x00 }
+        ret, err := FromBinary(nil, src, vt, mode)
+        require.NoError(t, err)
+        require.True(t, json.Valid(ret), string(ret))
+        bin, err := ToBinary(nil, ret, vt, mode)
+        require.NoError(t, err)
+        require.Equal(t, src, bin)
+    }
+}
+
+func TestJSON_Nesting(t *testing.T) {
+    src := make([]byte, 0, _MaxNesting * 2 + 16)
+    src = append(src, `{"Y":`...)
+    for i := 0; i < _MaxNesting; i++ {
+        src = append(src, '[')
+    }
+    _, err := ToBinary(nil, src, reflect.TypeOf(JSONTestItem{}), SimpleJSON)
+    require.Equal(t, errNesting, err)
+}
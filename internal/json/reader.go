@@ -0,0 +1,573 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package json
+
+import (
+    `bytes`
+    `encoding/base64`
+    `encoding/binary`
+    `fmt`
+    `math`
+    `strconv`
+    `strings`
+    `unicode/utf16`
+    `unicode/utf8`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/utils`
+)
+
+type reader struct {
+    buf  []byte
+    src  []byte
+    pos  int
+    mode Mode
+    nest int
+}
+
+func (self *reader) fail(reason string) {
+    panic(utils.ESyntax(self.pos, "", reason))
+}
+
+func (self *reader) space() {
+    for self.pos < len(self.src) {
+        switch self.src[self.pos] {
+            case ' ', '\t', '\n', '\r' : self.pos++
+            default                    : return
+        }
+    }
+}
+
+func (self *reader) peek() byte {
+    if self.space(); self.pos < len(self.src) {
+        return self.src[self.pos]
+    } else {
+        self.fail("unexpected end of JSON input")
+        return 0
+    }
+}
+
+func (self *reader) expect(ch byte) {
+    if self.peek() != ch {
+        self.fail(fmt.Sprintf("%q expected", ch))
+    } else {
+        self.pos++
+    }
+}
+
+func (self *reader) next(ch byte) bool {
+    if self.peek() != ch {
+        return false
+    } else {
+        self.pos++
+        return true
+    }
+}
+
+func (self *reader) literal(lit string) bool {
+    if self.space(); !bytes.HasPrefix(self.src[self.pos:], []byte(lit)) {
+        return false
+    } else {
+        self.pos += len(lit)
+        return true
+    }
+}
+
+func (self *reader) enter() {
+    if self.nest++; self.nest > _MaxNesting {
+        panic(errNesting)
+    }
+}
+
+func (self *reader) leave() {
+    self.nest--
+}
+
+func (self *reader) number() string {
+    self.space()
+    p := self.pos
+
+    /* scan the number characters, the actual parsing is done by strconv */
+    for ; self.pos < len(self.src); self.pos++ {
+        if ch := self.src[self.pos]; (ch < '0' || ch > '9') && ch != '-' && ch != '+' && ch != '.' && ch != 'e' && ch != 'E' {
+            break
+        }
+    }
+
+    /* must have at least one character */
+    if p == self.pos {
+        self.fail("number expected")
+    }
+
+    /* extract the number */
+    return string(self.src[p:self.pos])
+}
+
+func (self *reader) string() string {
+    self.expect('"')
+    p := self.pos
+    r := []byte(nil)
+
+    /* scan until the closing quote */
+    for {
+        if self.pos >= len(self.src) {
+            self.fail("unterminated string")
+        }
+
+        /* check for special characters */
+        switch ch := self.src[self.pos]; {
+            case ch == '"'  : break
+            case ch == '\\' : r = append(r, self.src[p:self.pos]...); r = self.escape(r); p = self.pos; continue
+            case ch < 0x20  : self.fail("invalid control character in string")
+            default         : self.pos++; continue
+        }
+
+        /* end of string */
+        if r == nil {
+            self.pos++
+            return string(self.src[p:self.pos - 1])
+        } else {
+            self.pos++
+            return string(append(r, self.src[p:self.pos - 1]...))
+        }
+    }
+}
+
+func (self *reader) escape(buf []byte) []byte {
+    if self.pos += 2; self.pos > len(self.src) {
+        self.fail("unterminated string")
+    }
+
+    /* single character escapes */
+    switch self.src[self.pos - 1] {
+        case '"'  : return append(buf, '"')
+        case '\\' : return append(buf, '\\')
+        case '/'  : return append(buf, '/')
+        case 'b'  : return append(buf, '\b')
+        case 'f'  : return append(buf, '\f')
+        case 'n'  : return append(buf, '\n')
+        case 'r'  : return append(buf, '\r')
+        case 't'  : return append(buf, '\t')
+        case 'u'  : break
+        default   : self.fail("invalid escape sequence")
+    }
+
+    /* unicode escapes, with optional surrogate pairs */
+    ch := self.unicode()
+    if utf16.IsSurrogate(ch) && bytes.HasPrefix(self.src[self.pos:], []byte(`\u`)) {
+        self.pos += 2
+        ch = utf16.DecodeRune(ch, self.unicode())
+    }
+
+    /* encode as UTF-8 */
+    return utf8.AppendRune(buf, ch)
+}
+
+func (self *reader) unicode() rune {
+    if self.pos + 4 > len(self.src) {
+        self.fail("invalid unicode escape")
+    }
+
+    /* parse the hex digits */
+    cc, err := strconv.ParseUint(string(self.src[self.pos:self.pos + 4]), 16, 16)
+    if err != nil {
+        self.fail("invalid unicode escape")
+    }
+
+    /* skip the digits */
+    self.pos += 4
+    return rune(cc)
+}
+
+func (self *reader) skip() {
+    switch self.peek() {
+        case '"' : self.string()
+        case '{' : self.skipObject()
+        case '[' : self.skipArray()
+        case 't' : self.keyword("true")
+        case 'f' : self.keyword("false")
+        case 'n' : self.keyword("null")
+        default  : self.number()
+    }
+}
+
+func (self *reader) keyword(lit string) {
+    if !self.literal(lit) {
+        self.fail("invalid literal")
+    }
+}
+
+func (self *reader) skipObject() {
+    self.enter()
+    self.expect('{')
+
+    /* skip every key-value pair */
+    for ok := !self.next('}'); ok; ok = self.delim('}') {
+        self.string()
+        self.expect(':')
+        self.skip()
+    }
+
+    /* end of object */
+    self.leave()
+}
+
+func (self *reader) skipArray() {
+    self.enter()
+    self.expect('[')
+
+    /* skip every element */
+    for ok := !self.next(']'); ok; ok = self.delim(']') {
+        self.skip()
+    }
+
+    /* end of array */
+    self.leave()
+}
+
+func (self *reader) delim(end byte) bool {
+    switch self.peek() {
+        case ',' : self.pos++; return true
+        case end : self.pos++; return false
+        default  : self.fail(fmt.Sprintf("%q or %q expected", ',', end)); return false
+    }
+}
+
+func (self *reader) tag() defs.Tag {
+    if tv, ok := typeTags[self.string()]; !ok {
+        self.fail("invalid type name")
+        return 0
+    } else {
+        return tv
+    }
+}
+
+func (self *reader) u8(v uint8) {
+    self.buf = append(self.buf, v)
+}
+
+func (self *reader) u16(v uint16) {
+    self.buf = append(self.buf, byte(v >> 8), byte(v))
+}
+
+func (self *reader) u32(v uint32) {
+    self.buf = append(self.buf, byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v))
+}
+
+func (self *reader) u64(v uint64) {
+    self.u32(uint32(v >> 32))
+    self.u32(uint32(v))
+}
+
+func (self *reader) value(t defs.Tag, vt *defs.Type) {
+    switch vt = elementOf(vt); t {
+        case defs.T_bool   : self.boolean(self.keyOrValue())
+        case defs.T_i8     : self.integer(t, vt, self.number())
+        case defs.T_i16    : self.integer(t, vt, self.number())
+        case defs.T_i32    : self.integer(t, vt, self.number())
+        case defs.T_i64    : self.integer(t, vt, self.number())
+        case defs.T_double : self.double(self.keyOrValue(), vt)
+        case defs.T_string : self.str(self.string(), vt)
+        case defs.T_struct : self.structure(vt)
+        case defs.T_map    : self.mapping(vt)
+        case defs.T_set    : self.list(t, vt)
+        case defs.T_list   : self.list(t, vt)
+        default            : self.fail(fmt.Sprintf("invalid wire type: %d", t))
+    }
+}
+
+func (self *reader) key(t defs.Tag, vt *defs.Type, key string) {
+    switch vt = elementOf(vt); t {
+        case defs.T_bool   : self.boolean(key)
+        case defs.T_i8     : self.integer(t, vt, key)
+        case defs.T_i16    : self.integer(t, vt, key)
+        case defs.T_i32    : self.integer(t, vt, key)
+        case defs.T_i64    : self.integer(t, vt, key)
+        case defs.T_double : self.double(key, vt)
+        case defs.T_string : self.str(key, vt)
+        default            : self.fail(fmt.Sprintf("cannot use %s as JSON object keys", typeNames[t]))
+    }
+}
+
+func (self *reader) keyOrValue() string {
+    switch self.peek() {
+        case '"' : return self.string()
+        case 't' : self.keyword("true"); return "true"
+        case 'f' : self.keyword("false"); return "false"
+        default  : return self.number()
+    }
+}
+
+func (self *reader) boolean(v string) {
+    switch v {
+        case "0", "false" : self.u8(0)
+        case "1", "true"  : self.u8(1)
+        default           : self.fail("invalid boolean value")
+    }
+}
+
+func (self *reader) integer(t defs.Tag, vt *defs.Type, v string) {
+    var nb int
+    var iv int64
+    var uv uint64
+    var err error
+
+    /* bit size of the wire type */
+    switch t {
+        case defs.T_i8  : nb = 8
+        case defs.T_i16 : nb = 16
+        case defs.T_i32 : nb = 32
+        case defs.T_i64 : nb = 64
+    }
+
+    /* parse the integer */
+    if isUnsigned(vt) {
+        uv, err = strconv.ParseUint(v, 10, nb)
+    } else {
+        iv, err = strconv.ParseInt(v, 10, nb)
+        uv = uint64(iv)
+    }
+
+    /* check for errors */
+    if err != nil {
+        self.fail(fmt.Sprintf("invalid %s value: %s", typeNames[t], v))
+    }
+
+    /* write the value */
+    switch t {
+        case defs.T_i8  : self.u8(uint8(uv))
+        case defs.T_i16 : self.u16(uint16(uv))
+        case defs.T_i32 : self.u32(uint32(uv))
+        case defs.T_i64 : self.u64(uv)
+    }
+}
+
+func (self *reader) double(v string, vt *defs.Type) {
+    nb := 64
+    fv := float64(0)
+    err := error(nil)
+
+    /* float32 values are parsed with float32 precision */
+    if isFloat(vt) {
+        nb = 32
+    }
+
+    /* check for special values */
+    switch v {
+        case "NaN"       : fv = math.NaN()
+        case "Infinity"  : fv = math.Inf(1)
+        case "-Infinity" : fv = math.Inf(-1)
+        default          : fv, err = strconv.ParseFloat(v, nb)
+    }
+
+    /* check for errors */
+    if err != nil {
+        self.fail("invalid dbl value: " + v)
+    }
+
+    /* write the value */
+    self.u64(math.Float64bits(fv))
+}
+
+func (self *reader) str(v string, vt *defs.Type) {
+    var err error
+    var bv []byte
+
+    /* binaries are encoded with base64, padding is optional */
+    if !isBinary(vt) {
+        bv = []byte(v)
+    } else if bv, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(v, "=")); err != nil {
+        self.fail("invalid base64 value: " + err.Error())
+    }
+
+    /* write the value */
+    self.u32(uint32(len(bv)))
+    self.buf = append(self.buf, bv...)
+}
+
+func (self *reader) structure(vt *defs.Type) {
+    fv := fieldsOf(vt)
+    self.enter()
+    self.expect('{')
+
+    /* convert every field */
+    for ok := !self.next('}'); ok; ok = self.delim('}') {
+        var fp *defs.Field
+        var id uint64
+        var err error
+
+        /* field key */
+        nm := self.string()
+        self.expect(':')
+
+        /* TJSON fields are keyed by ID, SimpleJSON fields are keyed by name */
+        if self.mode == SimpleJSON {
+            fp = fieldByName(fv, nm)
+        } else if id, err = strconv.ParseUint(nm, 10, 16); err != nil {
+            self.fail("invalid field ID: " + nm)
+        } else {
+            fp = fieldByID(fv, uint16(id))
+        }
+
+        /* unknown fields are skipped, and nulls are treated as absent */
+        switch {
+            case fp == nil               : self.skip()
+            case self.literal("null")    : break
+            case self.mode == SimpleJSON : self.field(fp.Type.Tag(), fp)
+            default                      : self.expect('{'); self.field(self.tag(), fp); self.expect('}')
+        }
+    }
+
+    /* add the STOP mark */
+    self.u8(0)
+    self.leave()
+}
+
+func (self *reader) field(t defs.Tag, fp *defs.Field) {
+    if self.mode != SimpleJSON {
+        self.expect(':')
+    }
+
+    /* field header and value */
+    self.u8(uint8(t))
+    self.u16(fp.ID)
+    self.value(t, fp.Type)
+}
+
+func (self *reader) mapping(vt *defs.Type) {
+    var kv *defs.Type
+    var ev *defs.Type
+    var kt defs.Tag
+    var et defs.Tag
+    var nb int
+
+    /* extract the key and value types */
+    if vt != nil && vt.T == defs.T_map {
+        kv, ev = vt.K, vt.V
+    }
+
+    /* TJSON maps are wrapped with the header */
+    if self.enter(); self.mode == SimpleJSON {
+        kt, et, nb = self.hint(kv), self.hint(ev), -1
+    } else {
+        self.expect('[')
+        kt = self.tag()
+        self.expect(',')
+        et = self.tag()
+        self.expect(',')
+        nb = self.count()
+        self.expect(',')
+    }
+
+    /* map header, the size is filled later */
+    self.u8(uint8(kt))
+    self.u8(uint8(et))
+    p := self.reserve()
+    n := 0
+
+    /* convert every key-value pair */
+    self.expect('{')
+    for ok := !self.next('}'); ok; ok = self.delim('}') {
+        self.key(kt, kv, self.string())
+        self.expect(':')
+        self.value(et, ev)
+        n++
+    }
+
+    /* TJSON maps must match the declared size */
+    if self.mode != SimpleJSON {
+        self.expect(']')
+        self.check(nb, n)
+    }
+
+    /* fill the map size */
+    self.leave()
+    binary.BigEndian.PutUint32(self.buf[p:], uint32(n))
+}
+
+func (self *reader) list(t defs.Tag, vt *defs.Type) {
+    var nb int
+    var ok bool
+    var et defs.Tag
+    var ev *defs.Type
+
+    /* extract the element type */
+    if vt != nil && vt.T == t {
+        ev = vt.V
+    }
+
+    /* TJSON lists are prefixed with the header */
+    self.enter()
+    self.expect('[')
+
+    /* element type and declared size, TJSON elements follow the header */
+    if self.mode == SimpleJSON {
+        et, nb, ok = self.hint(ev), -1, !self.next(']')
+    } else {
+        et = self.tag()
+        self.expect(',')
+        nb, ok = self.count(), self.delim(']')
+    }
+
+    /* list header, the size is filled later */
+    self.u8(uint8(et))
+    p := self.reserve()
+    n := 0
+
+    /* convert every element */
+    for ; ok; ok = self.delim(']') {
+        self.value(et, ev)
+        n++
+    }
+
+    /* TJSON lists must match the declared size */
+    if self.mode != SimpleJSON {
+        self.check(nb, n)
+    }
+
+    /* fill the list size */
+    self.leave()
+    binary.BigEndian.PutUint32(self.buf[p:], uint32(n))
+}
+
+func (self *reader) hint(vt *defs.Type) defs.Tag {
+    if vt == nil {
+        self.fail("missing type information")
+    }
+    return vt.Tag()
+}
+
+func (self *reader) count() int {
+    if nb, err := strconv.ParseUint(self.number(), 10, 31); err != nil {
+        self.fail("invalid container size")
+        return 0
+    } else {
+        return int(nb)
+    }
+}
+
+func (self *reader) check(exp int, act int) {
+    if exp != act {
+        self.fail(fmt.Sprintf("container size mismatch: %d declared, %d found", exp, act))
+    }
+}
+
+func (self *reader) reserve() int {
+    p := len(self.buf)
+    self.buf = append(self.buf, 0, 0, 0, 0)
+    return p
+}
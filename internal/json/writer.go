@@ -0,0 +1,401 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package json
+
+import (
+    `encoding/base64`
+    `encoding/binary`
+    `fmt`
+    `math`
+    `strconv`
+    `unicode/utf8`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+)
+
+const (
+    _HexDigits = "0123456789abcdef"
+)
+
+type writer struct {
+    buf  []byte
+    src  []byte
+    pos  int
+    mode Mode
+}
+
+func (self *writer) read(n int) []byte {
+    if n < 0 || len(self.src) - self.pos < n {
+        panic(errEOF)
+    } else {
+        self.pos += n
+        return self.src[self.pos - n:self.pos]
+    }
+}
+
+func (self *writer) u8() uint8 {
+    return self.read(1)[0]
+}
+
+func (self *writer) u16() uint16 {
+    return binary.BigEndian.Uint16(self.read(2))
+}
+
+func (self *writer) u32() uint32 {
+    return binary.BigEndian.Uint32(self.read(4))
+}
+
+func (self *writer) u64() uint64 {
+    return binary.BigEndian.Uint64(self.read(8))
+}
+
+func (self *writer) size() int {
+    if nb := int32(self.u32()); nb < 0 {
+        panic(fmt.Errorf("frugal: negative size: %d", nb))
+    } else {
+        return int(nb)
+    }
+}
+
+func (self *writer) tag() defs.Tag {
+    if tv := defs.Tag(self.u8()); !tv.IsWireTag() {
+        panic(fmt.Errorf("frugal: invalid wire type: %d", tv))
+    } else {
+        return tv
+    }
+}
+
+func (self *writer) value(t defs.Tag, vt *defs.Type) {
+    switch vt = elementOf(vt); t {
+        case defs.T_double : self.double(vt, false)
+        case defs.T_string : self.str(self.read(self.size()), vt)
+        case defs.T_struct : self.structure(vt)
+        case defs.T_map    : self.mapping(vt)
+        case defs.T_set    : self.list(t, vt)
+        case defs.T_list   : self.list(t, vt)
+        default            : self.number(t, vt)
+    }
+}
+
+func (self *writer) key(t defs.Tag, vt *defs.Type) {
+    switch vt = elementOf(vt); t {
+        case defs.T_double : self.double(vt, true)
+        case defs.T_string : self.str(self.read(self.size()), vt)
+        case defs.T_struct : panic(fmt.Errorf("frugal: cannot use %s as JSON object keys", typeNames[t]))
+        case defs.T_map    : panic(fmt.Errorf("frugal: cannot use %s as JSON object keys", typeNames[t]))
+        case defs.T_set    : panic(fmt.Errorf("frugal: cannot use %s as JSON object keys", typeNames[t]))
+        case defs.T_list   : panic(fmt.Errorf("frugal: cannot use %s as JSON object keys", typeNames[t]))
+        default            : self.quoted(t, vt)
+    }
+}
+
+func (self *writer) quoted(t defs.Tag, vt *defs.Type) {
+    self.buf = append(self.buf, '"')
+    self.number(t, vt)
+    self.buf = append(self.buf, '"')
+}
+
+func (self *writer) number(t defs.Tag, vt *defs.Type) {
+    var iv int64
+    var uv uint64
+
+    /* read the value with sign extension */
+    switch t {
+        case defs.T_bool : self.boolean(self.u8() != 0); return
+        case defs.T_i8   : uv = uint64(self.u8()); iv = int64(int8(uv))
+        case defs.T_i16  : uv = uint64(self.u16()); iv = int64(int16(uv))
+        case defs.T_i32  : uv = uint64(self.u32()); iv = int64(int32(uv))
+        case defs.T_i64  : uv = self.u64(); iv = int64(uv)
+        default          : panic(fmt.Errorf("frugal: invalid wire type: %d", t))
+    }
+
+    /* unsigned integers are printed without sign extension */
+    if isUnsigned(vt) {
+        self.buf = strconv.AppendUint(self.buf, uv, 10)
+    } else {
+        self.buf = strconv.AppendInt(self.buf, iv, 10)
+    }
+}
+
+func (self *writer) boolean(v bool) {
+    if self.mode == SimpleJSON {
+        self.buf = strconv.AppendBool(self.buf, v)
+    } else if v {
+        self.buf = append(self.buf, '1')
+    } else {
+        self.buf = append(self.buf, '0')
+    }
+}
+
+func (self *writer) double(vt *defs.Type, key bool) {
+    nb := 64
+    fv := math.Float64frombits(self.u64())
+
+    /* float32 values are printed with the shortest float32 representation */
+    if isFloat(vt) {
+        nb = 32
+    }
+
+    /* special values are always quoted */
+    switch {
+        case math.IsNaN(fv)     : self.buf = append(self.buf, `"NaN"`...)
+        case math.IsInf(fv, 1)  : self.buf = append(self.buf, `"Infinity"`...)
+        case math.IsInf(fv, -1) : self.buf = append(self.buf, `"-Infinity"`...)
+        case !key               : self.buf = strconv.AppendFloat(self.buf, fv, 'g', -1, nb)
+        default                 : self.buf = append(strconv.AppendFloat(append(self.buf, '"'), fv, 'g', -1, nb), '"')
+    }
+}
+
+func (self *writer) str(v []byte, vt *defs.Type) {
+    if isBinary(vt) {
+        self.base64(v)
+    } else {
+        self.quote(v)
+    }
+}
+
+func (self *writer) base64(v []byte) {
+    nb := len(self.buf)
+    nx := base64.StdEncoding.EncodedLen(len(v))
+
+    /* grow the buffer, then encode in place */
+    self.buf = append(self.buf, make([]byte, nx + 2)...)
+    self.buf[nb] = '"'
+    self.buf[nb + nx + 1] = '"'
+    base64.StdEncoding.Encode(self.buf[nb + 1:], v)
+}
+
+func (self *writer) quote(v []byte) {
+    i := 0
+    p := 0
+    self.buf = append(self.buf, '"')
+
+    /* escape the special characters */
+    for i < len(v) {
+        var ch rune
+        var nb int
+
+        /* decode the next character */
+        if v[i] < utf8.RuneSelf {
+            ch, nb = rune(v[i]), 1
+        } else {
+            ch, nb = utf8.DecodeRune(v[i:])
+        }
+
+        /* characters that can be copied as is */
+        if ch >= 0x20 && ch != '"' && ch != '\\' && ch != utf8.RuneError && ch != '\u2028' && ch != '\u2029' {
+            i += nb
+            continue
+        }
+
+        /* flush the pending characters */
+        self.buf = append(self.buf, v[p:i]...)
+        i += nb
+        p = i
+
+        /* escape the character */
+        switch ch {
+            case '"'            : self.buf = append(self.buf, `\"`...)
+            case '\\'           : self.buf = append(self.buf, `\\`...)
+            case '\b'           : self.buf = append(self.buf, `\b`...)
+            case '\f'           : self.buf = append(self.buf, `\f`...)
+            case '\n'           : self.buf = append(self.buf, `\n`...)
+            case '\r'           : self.buf = append(self.buf, `\r`...)
+            case '\t'           : self.buf = append(self.buf, `\t`...)
+            case utf8.RuneError : self.buf = append(self.buf, `\ufffd`...)
+            default             : self.buf = append(self.buf, '\\', 'u', _HexDigits[ch >> 12 & 0xf], _HexDigits[ch >> 8 & 0xf], _HexDigits[ch >> 4 & 0xf], _HexDigits[ch & 0xf])
+        }
+    }
+
+    /* flush the remaining characters */
+    self.buf = append(self.buf, v[p:]...)
+    self.buf = append(self.buf, '"')
+}
+
+func (self *writer) structure(vt *defs.Type) {
+    nf := 0
+    fv := fieldsOf(vt)
+    self.buf = append(self.buf, '{')
+
+    /* convert every field until the STOP mark */
+    for {
+        tt := defs.Tag(self.u8())
+        if tt == 0 {
+            break
+        }
+
+        /* find the field, unknown fields are not representable without type information */
+        id := self.u16()
+        fp := fieldByID(fv, id)
+
+        /* skip the unknown fields */
+        if fp == nil {
+            self.skip(tt)
+            continue
+        }
+
+        /* add the delimiter */
+        if nf++; nf != 1 {
+            self.buf = append(self.buf, ',')
+        }
+
+        /* TJSON fields are keyed by the field ID, and carry the type names */
+        if self.mode == SimpleJSON {
+            self.quote([]byte(fp.Name))
+            self.buf = append(self.buf, ':')
+            self.value(tt, fp.Type)
+        } else {
+            self.buf = append(strconv.AppendUint(append(self.buf, '"'), uint64(id), 10), `":{`...)
+            self.typename(tt)
+            self.buf = append(self.buf, ':')
+            self.value(tt, fp.Type)
+            self.buf = append(self.buf, '}')
+        }
+    }
+
+    /* end of the structure */
+    self.buf = append(self.buf, '}')
+}
+
+func (self *writer) mapping(vt *defs.Type) {
+    var kv *defs.Type
+    var ev *defs.Type
+
+    /* read the map header */
+    kt := self.tag()
+    et := self.tag()
+    nb := self.size()
+
+    /* extract the key and value types */
+    if vt != nil && vt.T == defs.T_map {
+        kv, ev = vt.K, vt.V
+    }
+
+    /* TJSON maps are wrapped with the header */
+    if self.mode == SimpleJSON {
+        self.buf = append(self.buf, '{')
+    } else {
+        self.buf = append(self.buf, '[')
+        self.typename(kt)
+        self.buf = append(self.buf, ',')
+        self.typename(et)
+        self.buf = append(strconv.AppendInt(append(self.buf, ','), int64(nb), 10), ",{"...)
+    }
+
+    /* convert every key-value pair */
+    for i := 0; i < nb; i++ {
+        if i != 0 {
+            self.buf = append(self.buf, ',')
+        }
+
+        /* key and value */
+        self.key(kt, kv)
+        self.buf = append(self.buf, ':')
+        self.value(et, ev)
+    }
+
+    /* end of the map */
+    if self.mode == SimpleJSON {
+        self.buf = append(self.buf, '}')
+    } else {
+        self.buf = append(self.buf, "}]"...)
+    }
+}
+
+func (self *writer) list(t defs.Tag, vt *defs.Type) {
+    var ev *defs.Type
+    et := self.tag()
+    nb := self.size()
+
+    /* extract the element type */
+    if vt != nil && vt.T == t {
+        ev = vt.V
+    }
+
+    /* TJSON lists are prefixed with the header */
+    if self.buf = append(self.buf, '['); self.mode != SimpleJSON {
+        self.typename(et)
+        self.buf = strconv.AppendInt(append(self.buf, ','), int64(nb), 10)
+    }
+
+    /* convert every element */
+    for i := 0; i < nb; i++ {
+        if i != 0 || self.mode != SimpleJSON {
+            self.buf = append(self.buf, ',')
+        }
+
+        /* convert the element */
+        self.value(et, ev)
+    }
+
+    /* end of the list */
+    self.buf = append(self.buf, ']')
+}
+
+func (self *writer) typename(t defs.Tag) {
+    self.buf = append(self.buf, '"')
+    self.buf = append(self.buf, typeNames[t]...)
+    self.buf = append(self.buf, '"')
+}
+
+func (self *writer) skip(t defs.Tag) {
+    switch t {
+        case defs.T_bool   : self.read(1)
+        case defs.T_i8     : self.read(1)
+        case defs.T_double : self.read(8)
+        case defs.T_i16    : self.read(2)
+        case defs.T_i32    : self.read(4)
+        case defs.T_i64    : self.read(8)
+        case defs.T_string : self.read(self.size())
+        case defs.T_struct : self.skipStruct()
+        case defs.T_map    : self.skipMap()
+        case defs.T_set    : self.skipList()
+        case defs.T_list   : self.skipList()
+        default            : panic(fmt.Errorf("frugal: invalid wire type: %d", t))
+    }
+}
+
+func (self *writer) skipStruct() {
+    for {
+        if tt := defs.Tag(self.u8()); tt == 0 {
+            return
+        } else {
+            self.u16()
+            self.skip(tt)
+        }
+    }
+}
+
+func (self *writer) skipMap() {
+    kt := self.tag()
+    et := self.tag()
+
+    /* skip every key-value pair */
+    for nb := self.size(); nb > 0; nb-- {
+        self.skip(kt)
+        self.skip(et)
+    }
+}
+
+func (self *writer) skipList() {
+    et := self.tag()
+
+    /* skip every element */
+    for nb := self.size(); nb > 0; nb-- {
+        self.skip(et)
+    }
+}
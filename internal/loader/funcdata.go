@@ -17,8 +17,11 @@
 package loader
 
 import (
+    `reflect`
     `sync`
     _ `unsafe`
+
+    `github.com/cloudwego/frugal/internal/rt`
 )
 
 const (
@@ -27,6 +30,15 @@ const (
     _PCDATA_UnsafePointUnsafe = -2
 )
 
+type funcInfo struct {
+    *_Func
+    datap *_ModuleData
+}
+
+//go:linkname findfunc runtime.findfunc
+//goland:noinspection GoUnusedParameter
+func findfunc(pc uintptr) funcInfo
+
 //go:linkname lastmoduledatap runtime.lastmoduledatap
 //goland:noinspection GoUnusedGlobalVariable
 var lastmoduledatap *_ModuleData
@@ -34,9 +46,16 @@ var lastmoduledatap *_ModuleData
 //go:linkname moduledataverify1 runtime.moduledataverify1
 func moduledataverify1(_ *_ModuleData)
 
+type _ModulePins struct {
+    ftab  *_FindFuncBucket
+    frame rt.Frame
+}
+
 var (
     modLock sync.Mutex
     modList []*_ModuleData
+    modPins = make(map[*_ModuleData]_ModulePins)
+    modSelf = findfunc(reflect.ValueOf(registerModule).Pointer()).datap
 )
 
 func toZigzag(v int) int {
@@ -72,10 +91,59 @@ func encodeVariant(v int) []byte {
     return r
 }
 
-func registerModule(mod *_ModuleData) {
+func registerModule(mod *_ModuleData, ftab *_FindFuncBucket, frame rt.Frame) {
     modLock.Lock()
     modList = append(modList, mod)
+    modPins[mod] = _ModulePins { ftab, frame }
     lastmoduledatap.next = mod
     lastmoduledatap = mod
     modLock.Unlock()
 }
+
+// Image returns the address range of the executable image, which contains the code, types and
+// static data of the program. Addresses in this range are only moved as a whole between runs.
+func Image() (uintptr, uintptr) {
+    if modSelf.types < modSelf.text {
+        return modSelf.types, modSelf.end
+    } else {
+        return modSelf.text, modSelf.end
+    }
+}
+
+func unregisterModule(mod *_ModuleData) {
+    modLock.Lock()
+    defer modLock.Unlock()
+
+    /* find the previous module, the runtime walks through the list without locking, so
+     * the next pointer of the removed module is kept intact for the concurrent readers,
+     * JIT modules are always linked after the module that contains the loader itself */
+    for p := modSelf; p.next != nil; p = p.next {
+        if p.next == mod {
+            p.next = mod.next
+            break
+        }
+    }
+
+    /* remove from the module list */
+    for i, m := range modList {
+        if m == mod {
+            modList = append(modList[:i], modList[i + 1:]...)
+            break
+        }
+    }
+
+    /* release the find function bucket and the pointer maps */
+    if pin, ok := modPins[mod]; ok {
+        delete(modPins, mod)
+        pin.frame.ArgPtrs.Unpin()
+        pin.frame.LocalPtrs.Unpin()
+    }
+
+    /* the removed module might be the last one */
+    if lastmoduledatap == mod {
+        lastmoduledatap = modSelf
+        for lastmoduledatap.next != nil {
+            lastmoduledatap = lastmoduledatap.next
+        }
+    }
+}
@@ -88,11 +88,10 @@ const minfunc = 16                 // minimum function size
 const pcbucketsize = 256 * minfunc // size of bucket in the pc->func lookup table
 
 var (
-    emptyByte  byte
-    bucketList []*_FindFuncBucket
+    emptyByte byte
 )
 
-func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) {
+func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) *_ModuleData {
     minpc := pc
     maxpc := pc + size
     ffunc := make([]_FindFuncBucket, size / pcbucketsize + 1)
@@ -104,7 +103,6 @@ func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) {
 
     /* pin the find function bucket */
     pfunc := &ffunc[0]
-    bucketList = append(bucketList, pfunc)
 
     /* build the PC & line table */
     pclnt := []byte {
@@ -221,5 +219,6 @@ func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) {
 
     /* verify and register the new module */
     moduledataverify1(mod)
-    registerModule(mod)
+    registerModule(mod, pfunc, frame)
+    return mod
 }
@@ -114,11 +114,10 @@ var modHeader = &_PCHeader {
 }
 
 var (
-    emptyByte  byte
-    bucketList []*_FindFuncBucket
+    emptyByte byte
 )
 
-func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) {
+func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) *_ModuleData {
     var pbase uintptr
     var sbase uintptr
 
@@ -163,7 +162,6 @@ func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) {
     /* pin the find function bucket */
     ftab := &ffunc[0]
     pctab = append(pctab, 0)
-    bucketList = append(bucketList, ftab)
 
     /* function entry */
     fn := _Func {
@@ -223,5 +221,6 @@ func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) {
 
     /* verify and register the new module */
     moduledataverify1(mod)
-    registerModule(mod)
+    registerModule(mod, ftab, frame)
+    return mod
 }
\ No newline at end of file
@@ -111,11 +111,10 @@ const minfunc = 16
 const pcbucketsize = 256 * minfunc
 
 var (
-    emptyByte  byte
-    bucketList []*_FindFuncBucket
+    emptyByte byte
 )
 
-func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) {
+func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) *_ModuleData {
     var pbase uintptr
     var sbase uintptr
 
@@ -155,7 +154,6 @@ func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) {
     /* pin the find function bucket */
     ftab := &ffunc[0]
     pctab = append(pctab, 0)
-    bucketList = append(bucketList, ftab)
 
     /* pin the pointer maps */
     argptrs := frame.ArgPtrs.Pin()
@@ -237,5 +235,6 @@ func registerFunction(name string, pc uintptr, size uintptr, frame rt.Frame) {
 
     /* verify and register the new module */
     moduledataverify1(mod)
-    registerModule(mod)
+    registerModule(mod, ftab, frame)
+    return mod
 }
\ No newline at end of file
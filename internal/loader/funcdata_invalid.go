@@ -1,4 +1,4 @@
-// +build !go1.15 go1.20
+// +build !go1.15 go1.28
 
 /*
  * Copyright 2022 ByteDance Inc.
@@ -24,9 +24,9 @@ import (
 
 // triggers a compilation error
 const (
-    _ = panic("Unsupported Go version. Supported versions are 1.15 ~ 1.19")
+    _ = panic("Unsupported Go version. Supported versions are 1.15 ~ 1.27")
 )
 
-func registerFunction(_ string, _ uintptr, _ uintptr, _ rt.Frame) {
-    panic("Unsupported Go version. Supported versions are 1.15 ~ 1.19")
+func registerFunction(_ string, _ uintptr, _ uintptr, _ rt.Frame) *_ModuleData {
+    panic("Unsupported Go version. Supported versions are 1.15 ~ 1.27")
 }
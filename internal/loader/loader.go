@@ -19,6 +19,8 @@ package loader
 import (
     `fmt`
     `os`
+    `runtime`
+    `sync`
     `sync/atomic`
     `syscall`
     `unsafe`
@@ -31,9 +33,14 @@ const (
 )
 
 const (
-    _AP = syscall.MAP_ANON  | syscall.MAP_PRIVATE
-    _RX = syscall.PROT_READ | syscall.PROT_EXEC
-    _RW = syscall.PROT_READ | syscall.PROT_WRITE
+    _AP  = syscall.MAP_ANON  | syscall.MAP_PRIVATE
+    _RX  = syscall.PROT_READ | syscall.PROT_EXEC
+    _RWX = syscall.PROT_READ | syscall.PROT_WRITE | syscall.PROT_EXEC
+)
+
+const (
+    ArenaSize = 64 * 1024   // functions are packed into arenas of this size
+    FuncAlign = 32          // alignment of every function inside arenas
 )
 
 type (
@@ -41,12 +48,31 @@ type (
     Function unsafe.Pointer
 )
 
+type _Arena struct {
+    mem  uintptr
+    size uintptr
+    used uintptr
+    live int
+}
+
+type _Function struct {
+    pc   uintptr    // must be the first field, since function values point to it
+    size uintptr
+    mod  *_ModuleData
+    mem  *_Arena
+}
+
 var (
     FnCount  uint32
     LoadSize uintptr
     LoadBase uintptr = MAP_BASE
 )
 
+var (
+    arenaLock sync.Mutex
+    arenaHead *_Arena
+)
+
 func mkptr(m uintptr) unsafe.Pointer {
     return *(*unsafe.Pointer)(unsafe.Pointer(&m))
 }
@@ -55,31 +81,110 @@ func alignUp(n uintptr, a int) uintptr {
     return (n + uintptr(a) - 1) &^ (uintptr(a) - 1)
 }
 
-func (self Loader) Load(fn string, frame rt.Frame) (f Function) {
+func newArena(nb uintptr) *_Arena {
     var mm uintptr
     var er syscall.Errno
 
-    /* align the size to pages */
-    nf := uintptr(len(self))
-    nb := alignUp(nf, os.Getpagesize())
+    /* large functions have their own arenas */
+    nb = alignUp(nb, ArenaSize)
     fp := atomic.AddUintptr(&LoadBase, nb) - nb
 
     /* allocate a block of memory */
-    if mm, _, er = syscall.Syscall6(syscall.SYS_MMAP, fp, nb, _RW, _AP, 0, 0); er != 0 {
+    if mm, _, er = syscall.Syscall6(syscall.SYS_MMAP, fp, nb, _RX, _AP, 0, 0); er != 0 {
         panic(er)
     }
 
-    /* copy code into the memory, and register the function */
-    copy(rt.BytesFrom(mkptr(mm), len(self), int(nb)), self)
-    registerFunction(fmt.Sprintf("(frugal).%s_%x", fn, mm), mm, nf, frame)
+    /* record statistics */
+    atomic.AddUintptr(&LoadSize, nb)
+    return &_Arena { mem: mm, size: nb }
+}
 
-    /* make it executable */
-    if _, _, err := syscall.Syscall(syscall.SYS_MPROTECT, mm, nb, _RX); err != 0 {
+func (self *_Arena) free() {
+    if _, _, err := syscall.Syscall(syscall.SYS_MUNMAP, self.mem, self.size, 0); err != 0 {
         panic(err)
+    } else {
+        atomic.AddUintptr(&LoadSize, -self.size)
     }
+}
+
+func (self *_Arena) alloc(code []byte) uintptr {
+    pc := self.mem + self.used
+    nb := uintptr(len(code))
+
+    /* only the pages being written are made writable, other functions on these pages
+     * may be running, so they must remain executable all the time */
+    pp := pc &^ uintptr(os.Getpagesize() - 1)
+    np := alignUp(pc + nb, os.Getpagesize()) - pp
+
+    /* make the pages writable */
+    if _, _, err := syscall.Syscall(syscall.SYS_MPROTECT, pp, np, _RWX); err != 0 {
+        panic(err)
+    }
+
+    /* copy code into the memory */
+    copy(rt.BytesFrom(mkptr(pc), len(code), len(code)), code)
+    self.used = alignUp(self.used + nb, FuncAlign)
+
+    /* make it executable only */
+    if _, _, err := syscall.Syscall(syscall.SYS_MPROTECT, pp, np, _RX); err != 0 {
+        panic(err)
+    }
+
+    /* add to the live function count */
+    self.live++
+    return pc
+}
+
+func (self Loader) load(fn string, frame rt.Frame) *_Function {
+    nf := uintptr(len(self))
+    arenaLock.Lock()
+
+    /* allocate a new arena if the current one does not have enough space */
+    if arenaHead == nil || arenaHead.size - arenaHead.used < nf {
+        if arenaHead != nil && arenaHead.live == 0 {
+            arenaHead.free()
+        }
+        arenaHead = newArena(nf)
+    }
+
+    /* copy the code into the arena */
+    mm := arenaHead
+    pc := mm.alloc(self)
+    arenaLock.Unlock()
+
+    /* register the function */
+    name := fmt.Sprintf("(frugal).%s_%x", fn, pc)
+    mod := registerFunction(name, pc, nf, frame)
 
     /* record statistics */
     atomic.AddUint32(&FnCount, 1)
-    atomic.AddUintptr(&LoadSize, nb)
-    return Function(&mm)
+    return &_Function { pc: pc, size: nf, mod: mod, mem: mm }
+}
+
+// Load loads the code as a function, which is never released.
+func (self Loader) Load(fn string, frame rt.Frame) (f Function) {
+    return Function(self.load(fn, frame))
+}
+
+// LoadCollectable is like Load, but the function is unregistered and released after
+// the returned Function becomes unreachable. Running code does not keep the function
+// reachable by itself, so the caller must hold a reference to it around every call.
+func (self Loader) LoadCollectable(fn string, frame rt.Frame) (f Function) {
+    fp := self.load(fn, frame)
+    runtime.SetFinalizer(fp, (*_Function).release)
+    return Function(fp)
+}
+
+func (self *_Function) release() {
+    unregisterModule(self.mod)
+    arenaLock.Lock()
+
+    /* free the arena after all it's functions are released, the current arena is kept for new functions */
+    if self.mem.live--; self.mem.live == 0 && self.mem != arenaHead {
+        self.mem.free()
+    }
+
+    /* record statistics */
+    arenaLock.Unlock()
+    atomic.AddUint32(&FnCount, ^uint32(0))
 }
@@ -20,7 +20,9 @@ import (
     `fmt`
     `reflect`
     `runtime`
+    `sync/atomic`
     `testing`
+    `time`
     `unsafe`
 
     `github.com/chenzhuoyu/iasm/x86_64`
@@ -30,11 +32,6 @@ import (
     `golang.org/x/arch/x86/x86asm`
 )
 
-type funcInfo struct {
-    *_Func
-    datap *_ModuleData
-}
-
 func (self funcInfo) entry() uintptr {
     if runtime.Version() <= "go1.17" {
         return *(*uintptr)(unsafe.Pointer(self._Func))
@@ -44,10 +41,6 @@ func (self funcInfo) entry() uintptr {
     return off
 }
 
-//go:linkname findfunc runtime.findfunc
-//goland:noinspection GoUnusedParameter
-func findfunc(pc uintptr) funcInfo
-
 //go:linkname pcdatavalue2 runtime.pcdatavalue2
 //goland:noinspection GoUnusedParameter
 func pcdatavalue2(f funcInfo, table uint32, targetpc uintptr) (int32, uintptr)
@@ -80,6 +73,41 @@ func TestLoader_Load(t *testing.T) {
     assert.Equal(t, pc, startpc2)
 }
 
+func TestLoader_LoadCollectable(t *testing.T) {
+    var src string
+    var asm x86_64.Assembler
+    if runtime.Version() < "go1.17" { src += `
+        movq 8(%rsp), %rax`
+    }
+    src += `
+        movq $1234, (%rax)
+        ret`
+    require.NoError(t, asm.Assemble(src))
+    v0 := 0
+    np := modPinCount()
+    fp := Loader(asm.Code()).LoadCollectable("test_collectable", rt.Frame{})
+    (*(*func(*int))(unsafe.Pointer(&fp)))(&v0)
+    pc := *(*uintptr)(fp)
+    nf := atomic.LoadUint32(&FnCount)
+    assert.Equal(t, 1234, v0)
+    assert.NotNil(t, runtime.FuncForPC(pc))
+    assert.Equal(t, np + 1, modPinCount())
+    fp = nil
+    for i := 0; i < 100 && atomic.LoadUint32(&FnCount) == nf; i++ {
+        runtime.GC()
+        time.Sleep(10 * time.Millisecond)
+    }
+    assert.Equal(t, nf - 1, atomic.LoadUint32(&FnCount))
+    assert.Nil(t, runtime.FuncForPC(pc))
+    assert.Equal(t, np, modPinCount())
+}
+
+func modPinCount() int {
+    modLock.Lock()
+    defer modLock.Unlock()
+    return len(modPins)
+}
+
 func mkpointer() *int {
     ret := new(int)
     *ret = 1234
@@ -0,0 +1,62 @@
+// +build go1.27,!go1.28
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package loader
+
+import (
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+type _ModuleData struct {
+    pcHeader              *_PCHeader
+    funcnametab           []byte
+    cutab                 []uint32
+    filetab               []byte
+    pctab                 []byte
+    pclntable             []byte
+    ftab                  []_FuncTab
+    findfunctab           uintptr
+    minpc, maxpc          uintptr
+    text, etext           uintptr
+    noptrdata, enoptrdata uintptr
+    data, edata           uintptr
+    bss, ebss             uintptr
+    noptrbss, enoptrbss   uintptr
+    covctrs, ecovctrs     uintptr
+    end, gcdata, gcbss    uintptr
+    types, typedesclen    uintptr
+    etypes                uintptr
+    itaboffset, itabsize  uintptr
+    rodata                uintptr
+    gofunc                uintptr
+    epclntab              uintptr
+    textsectmap           [][3]uintptr
+    ptab                  [][2]int32
+    pluginpath            string
+    pkghashes             []struct{}
+    inittasks             []unsafe.Pointer
+    modulename            string
+    modulehashes          []struct{}
+    hasmain               uint8
+    bad                   bool
+    gcdatamask, gcbssmask _BitVector
+    typemap               map[*rt.GoType]*rt.GoType
+    next                  *_ModuleData
+}
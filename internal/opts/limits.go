@@ -31,6 +31,17 @@ var (
     MaxInlineILSize = parseOrDefault("FRUGAL_MAX_INLINE_IL_SIZE", _DefaultMaxInlineILSize, 256)
 )
 
+var (
+    MaxContainerLen = parseOrDefault("FRUGAL_MAX_CONTAINER_LEN", 0, 0)
+    MaxStringLen    = parseOrDefault("FRUGAL_MAX_STRING_LEN", 0, 0)
+    MaxDepth        = parseOrDefault("FRUGAL_MAX_DEPTH", 0, 0)
+    MaxTotalAlloc   = parseOrDefault("FRUGAL_MAX_TOTAL_ALLOC", 0, 0)
+)
+
+var (
+    MaxCachedPrograms = parseOrDefault("FRUGAL_MAX_CACHED_PROGRAMS", 0, 0)
+)
+
 func parseOrDefault(key string, def int, min int) int {
     if env := os.Getenv(key); env == "" {
         return def
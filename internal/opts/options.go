@@ -20,6 +20,12 @@ type Options struct {
     MaxInlineDepth   int
     MaxInlineILSize  int
     MaxPretouchDepth int
+    MaxContainerLen  int
+    MaxStringLen     int
+    MaxDepth         int
+    MaxTotalAlloc    int
+    Fields           []string
+    Canonical        bool
 }
 
 func (self *Options) CanInline(sp int, pc int) bool {
@@ -35,5 +41,9 @@ func GetDefaultOptions() Options {
         MaxInlineDepth   : MaxInlineDepth,
         MaxInlineILSize  : MaxInlineILSize,
         MaxPretouchDepth : 0,
+        MaxContainerLen  : MaxContainerLen,
+        MaxStringLen     : MaxStringLen,
+        MaxDepth         : MaxDepth,
+        MaxTotalAlloc    : MaxTotalAlloc,
     }
 }
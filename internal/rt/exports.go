@@ -21,27 +21,12 @@ import (
 )
 
 //go:noescape
-//go:linkname mapclear runtime.mapclear
-//goland:noinspection GoUnusedParameter
-func mapclear(t *GoType, h unsafe.Pointer)
-
-//go:noescape
-//go:linkname growslice runtime.growslice
-//goland:noinspection GoUnusedParameter
-func growslice(et *GoType, old GoSlice, cap int) GoSlice
-
-//go:noescape
-//go:linkname mapiternext runtime.mapiternext
-//goland:noinspection GoUnusedParameter
-func mapiternext(it *GoMapIterator)
-
-//go:noescape
-//go:linkname resolveNameOff runtime.resolveNameOff
+//go:linkname resolveNameOff reflect.resolveNameOff
 //goland:noinspection GoUnusedParameter
 func resolveNameOff(p unsafe.Pointer, off GoNameOffset) GoName
 
 //go:noescape
-//go:linkname resolveTypeOff runtime.resolveTypeOff
+//go:linkname resolveTypeOff reflect.resolveTypeOff
 //goland:noinspection GoUnusedParameter
 func resolveTypeOff(p unsafe.Pointer, off GoTypeOffset) *GoType
 
@@ -50,12 +35,6 @@ func resolveTypeOff(p unsafe.Pointer, off GoTypeOffset) *GoType
 //goland:noinspection GoUnusedParameter
 func resolveTextOff(p unsafe.Pointer, off GoTextOffset) unsafe.Pointer
 
-//go:nosplit
-func MapClear(m interface{}) {
-    v := UnpackEface(m)
-    mapclear(v.Type, v.Value)
-}
-
 //go:nosplit
 func GrowSlice(s interface{}, cap int) {
     v := UnpackEface(s)
@@ -27,3 +27,8 @@ type Frame struct {
     ArgPtrs   *StackMap
     LocalPtrs *StackMap
 }
+
+type Reloc struct {
+    Off  uintptr    // offset of the absolute address in the code
+    Size uintptr    // size of the absolute address, either 4 or 8
+}
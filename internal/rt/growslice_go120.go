@@ -0,0 +1,34 @@
+// +build go1.20
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rt
+
+import (
+    `unsafe`
+)
+
+//go:linkname runtime_growslice runtime.growslice
+//goland:noinspection GoUnusedParameter
+func runtime_growslice(oldPtr unsafe.Pointer, newLen int, oldCap int, num int, et *GoType) GoSlice
+
+// Go 1.20 changed growslice to take the new length, and return a slice of that length.
+func growslice(et *GoType, old GoSlice, cap int) GoSlice {
+    ret := runtime_growslice(old.Ptr, cap, old.Cap, cap - old.Len, et)
+    ret.Len = old.Len
+    return ret
+}
@@ -0,0 +1,102 @@
+// +build !go1.24 !goexperiment.swissmap
+// +build !go1.26
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rt
+
+import (
+    `reflect`
+    `unsafe`
+)
+
+const (
+    MaxFastMap = 128
+)
+
+type GoMapType struct {
+    GoType
+    Key        *GoType
+    Elem       *GoType
+    Bucket     *GoType
+    Hasher     func(unsafe.Pointer, uintptr) uintptr
+    KeySize    uint8
+    ElemSize   uint8
+    BucketSize uint16
+    Flags      uint32
+}
+
+// IsFastMap checks if the mapassign_fast* functions can be used, which hash the keys
+// as plain memory, so floating point keys must go through the hasher of the map.
+func (self *GoMapType) IsFastMap() bool {
+    return self.Elem.Size <= MaxFastMap && !isFloat(self.Key)
+}
+
+type GoMap struct {
+    Count      int
+    Flags      uint8
+    B          uint8
+    Overflow   uint16
+    Hash0      uint32
+    Buckets    unsafe.Pointer
+    OldBuckets unsafe.Pointer
+    Evacuate   uintptr
+    Extra      unsafe.Pointer
+}
+
+type GoMapIterator struct {
+    K           unsafe.Pointer
+    V           unsafe.Pointer
+    T           *GoMapType
+    H           *GoMap
+    Buckets     unsafe.Pointer
+    Bptr        *unsafe.Pointer
+    Overflow    *[]unsafe.Pointer
+    OldOverflow *[]unsafe.Pointer
+    StartBucket uintptr
+    Offset      uint8
+    Wrapped     bool
+    B           uint8
+    I           uint8
+    Bucket      uintptr
+    CheckBucket uintptr
+}
+
+func (self *GoMapIterator) Next() bool {
+    mapiternext(self)
+    return self.K != nil
+}
+
+//go:noescape
+//go:linkname mapclear runtime.mapclear
+//goland:noinspection GoUnusedParameter
+func mapclear(t *GoType, h unsafe.Pointer)
+
+//go:noescape
+//go:linkname mapiternext runtime.mapiternext
+//goland:noinspection GoUnusedParameter
+func mapiternext(it *GoMapIterator)
+
+//go:nosplit
+func MapClear(m interface{}) {
+    v := UnpackEface(m)
+    mapclear(v.Type, v.Value)
+}
+
+func isFloat(t *GoType) bool {
+    return t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+}
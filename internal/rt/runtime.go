@@ -21,12 +21,9 @@ import (
     `unsafe`
 )
 
-const (
-    MaxFastMap = 128
-)
-
 const (
     T_uncommon = 1 << 0
+    T_direct   = 1 << 5
 )
 
 const (
@@ -148,31 +145,11 @@ func (self *GoType) IsUncommon() bool {
     return (self.Flags & T_uncommon) != 0
 }
 
-func (self *GoType) IsIndirect() bool {
-    return (self.KindFlags & F_direct) == 0
-}
-
 type GoPtrType struct {
     GoType
     Elem *GoType
 }
 
-type GoMapType struct {
-    GoType
-    Key        *GoType
-    Elem       *GoType
-    Bucket     *GoType
-    Hasher     func(unsafe.Pointer, uintptr) uintptr
-    KeySize    uint8
-    ElemSize   uint8
-    BucketSize uint16
-    Flags      uint32
-}
-
-func (self *GoMapType) IsFastMap() bool {
-    return self.Elem.Size <= MaxFastMap
-}
-
 type GoSliceType struct {
     GoType
     Elem *GoType
@@ -254,41 +231,6 @@ type GoString struct {
     Len int
 }
 
-type GoMap struct {
-    Count      int
-    Flags      uint8
-    B          uint8
-    Overflow   uint16
-    Hash0      uint32
-    Buckets    unsafe.Pointer
-    OldBuckets unsafe.Pointer
-    Evacuate   uintptr
-    Extra      unsafe.Pointer
-}
-
-type GoMapIterator struct {
-    K           unsafe.Pointer
-    V           unsafe.Pointer
-    T           *GoMapType
-    H           *GoMap
-    Buckets     unsafe.Pointer
-    Bptr        *unsafe.Pointer
-    Overflow    *[]unsafe.Pointer
-    OldOverflow *[]unsafe.Pointer
-    StartBucket uintptr
-    Offset      uint8
-    Wrapped     bool
-    B           uint8
-    I           uint8
-    Bucket      uintptr
-    CheckBucket uintptr
-}
-
-func (self *GoMapIterator) Next() bool {
-    mapiternext(self)
-    return self.K != nil
-}
-
 func IsPtr(t *GoType) bool {
     return t.Kind() == reflect.Ptr || t.Kind() == reflect.UnsafePointer
 }
@@ -64,7 +64,7 @@ func (self *Bitmap) AppendMany(n int, bv int) {
 
 var (
     _stackMapLock  = sync.Mutex{}
-    _stackMapCache = make(map[*StackMap]struct{})
+    _stackMapCache = make(map[*StackMap]int)
 )
 
 type BitVec struct {
@@ -100,15 +100,29 @@ type StackMap struct {
 
 func (self *StackMap) add() {
     _stackMapLock.Lock()
-    _stackMapCache[self] = struct{}{}
+    _stackMapCache[self]++
     _stackMapLock.Unlock()
 }
 
+func (self *StackMap) remove() {
+    _stackMapLock.Lock()
+    defer _stackMapLock.Unlock()
+
+    /* the same stack map may be pinned by more than one function */
+    if _stackMapCache[self]--; _stackMapCache[self] <= 0 {
+        delete(_stackMapCache, self)
+    }
+}
+
 func (self *StackMap) Pin() uintptr {
     self.add()
     return uintptr(unsafe.Pointer(self))
 }
 
+func (self *StackMap) Unpin() {
+    self.remove()
+}
+
 func (self *StackMap) Get(i int32) BitVec {
     return BitVec {
         N: uintptr(self.L),
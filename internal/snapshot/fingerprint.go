@@ -0,0 +1,63 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snapshot
+
+import (
+    `fmt`
+    `hash/fnv`
+    `io`
+    `reflect`
+
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+// Fingerprint hashes the layout of vt, including the names, offsets and tags of all the fields
+// of the reachable structs, to make sure the snapshotted program is compiled from the same type.
+func Fingerprint(vt *rt.GoType) uint64 {
+    h := fnv.New64a()
+    fingerprint(h, vt.Pack(), make(map[reflect.Type]bool))
+    return h.Sum64()
+}
+
+func fingerprint(w io.Writer, vt reflect.Type, vis map[reflect.Type]bool) {
+    _, _ = fmt.Fprintf(w, "%s.%s:%d:%d;", vt.PkgPath(), vt.String(), vt.Kind(), vt.Size())
+
+    /* recursive types */
+    if vis[vt] {
+        return
+    }
+
+    /* mark as visited */
+    vis[vt] = true
+
+    /* check for element types */
+    switch vt.Kind() {
+        case reflect.Ptr    : fingerprint(w, vt.Elem(), vis)
+        case reflect.Array  : fingerprint(w, vt.Elem(), vis)
+        case reflect.Slice  : fingerprint(w, vt.Elem(), vis)
+        case reflect.Map    : fingerprint(w, vt.Key(), vis); fingerprint(w, vt.Elem(), vis)
+        case reflect.Struct : fingerprintFields(w, vt, vis)
+    }
+}
+
+func fingerprintFields(w io.Writer, vt reflect.Type, vis map[reflect.Type]bool) {
+    for i := 0; i < vt.NumField(); i++ {
+        fv := vt.Field(i)
+        _, _ = fmt.Fprintf(w, "%s@%d`%s`", fv.Name, fv.Offset, fv.Tag)
+        fingerprint(w, fv.Type, vis)
+    }
+}
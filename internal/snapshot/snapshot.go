@@ -0,0 +1,461 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snapshot
+
+import (
+    `bytes`
+    `crypto/sha256`
+    `debug/elf`
+    `encoding/binary`
+    `encoding/hex`
+    `errors`
+    `hash/crc32`
+    `io`
+    `math`
+    `os`
+    `runtime`
+    `sync`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/cpu`
+    `github.com/cloudwego/frugal/internal/loader`
+    `github.com/cloudwego/frugal/internal/rt`
+)
+
+const (
+    _Magic   = "FRUGALSS"
+    _Version = 1
+)
+
+// Kind is the kind of a compiled program.
+type Kind uint8
+
+const (
+    Encoder Kind = iota
+    Decoder
+)
+
+// Program is the machine code of a compiled type, with everything needed to load it again.
+type Program struct {
+    Kind     Kind
+    Protocol defs.Protocol
+    Type     *rt.GoType
+    Code     []byte
+    Relocs   []rt.Reloc
+    Frame    rt.Frame
+}
+
+var (
+    ErrMismatch    = errors.New("frugal: snapshot was created by a different executable, Go version or CPU")
+    ErrCorrupted   = errors.New("frugal: corrupted snapshot")
+    ErrUnsupported = errors.New("frugal: snapshots are not supported by the current backend")
+)
+
+var (
+    errNoExecutable = errors.New("frugal: cannot identify the executable")
+)
+
+var (
+    execOnce sync.Once
+    execHash string
+)
+
+func executable() string {
+    execOnce.Do(func() {
+        var err error
+        var buf []byte
+        var exe string
+
+        /* locate the executable */
+        if exe, err = os.Executable(); err != nil {
+            return
+        }
+
+        /* prefer the Go build ID, it's much cheaper than hashing the entire executable */
+        if fp, err := elf.Open(exe); err == nil {
+            if sec := fp.Section(".note.go.buildid"); sec != nil {
+                buf, _ = sec.Data()
+            }
+            _ = fp.Close()
+        }
+
+        /* fallback to the executable itself */
+        if buf == nil {
+            if buf, err = os.ReadFile(exe); err != nil {
+                return
+            }
+        }
+
+        /* hash the identity */
+        sum := sha256.Sum256(buf)
+        execHash = hex.EncodeToString(sum[:])
+    })
+    return execHash
+}
+
+func header() []string {
+    return []string {
+        runtime.Version(),
+        runtime.GOOS + "/" + runtime.GOARCH,
+        cpu.Features(),
+        executable(),
+    }
+}
+
+func inImage(p uintptr) bool {
+    lo, hi := loader.Image()
+    return p >= lo && p < hi
+}
+
+func mkptr(m uintptr) unsafe.Pointer {
+    return *(*unsafe.Pointer)(unsafe.Pointer(&m))
+}
+
+func readAddr(code []byte, rel rt.Reloc) uintptr {
+    if rel.Size == 8 {
+        return uintptr(binary.LittleEndian.Uint64(code[rel.Off:]))
+    } else {
+        return uintptr(binary.LittleEndian.Uint32(code[rel.Off:]))
+    }
+}
+
+func writeAddr(code []byte, rel rt.Reloc, addr uintptr) bool {
+    if rel.Size == 8 {
+        binary.LittleEndian.PutUint64(code[rel.Off:], uint64(addr))
+        return true
+    } else if addr <= math.MaxInt32 {
+        binary.LittleEndian.PutUint32(code[rel.Off:], uint32(addr))
+        return true
+    } else {
+        return false
+    }
+}
+
+// Relocatable checks if p only refers to the executable image, programs that refer to
+// the heap, like types created with reflect, cannot be saved.
+func Relocatable(p *Program) bool {
+    if !inImage(uintptr(unsafe.Pointer(p.Type))) {
+        return false
+    }
+
+    /* check every absolute address */
+    for _, rel := range p.Relocs {
+        if !inImage(readAddr(p.Code, rel)) {
+            return false
+        }
+    }
+
+    /* all addresses are in the image */
+    return true
+}
+
+// Write serializes the relocatable programs into w, and returns the number of programs written.
+func Write(w io.Writer, progs []Program) (int, error) {
+    n := 0
+    b := new(_Buffer)
+    lo, _ := loader.Image()
+
+    /* the executable must be identified */
+    if executable() == "" {
+        return 0, errNoExecutable
+    }
+
+    /* add the header */
+    b.buf.WriteString(_Magic)
+    b.u32(_Version)
+
+    /* add the environment */
+    for _, v := range header() {
+        b.str(v)
+    }
+
+    /* count the relocatable programs */
+    for i := range progs {
+        if Relocatable(&progs[i]) {
+            n++
+        }
+    }
+
+    /* add all the relocatable programs */
+    b.u32(uint32(n))
+    for i := range progs {
+        if Relocatable(&progs[i]) {
+            b.program(&progs[i], lo)
+        }
+    }
+
+    /* checksum of the entire snapshot */
+    b.u32(crc32.ChecksumIEEE(b.buf.Bytes()))
+    _, err := w.Write(b.buf.Bytes())
+    return n, err
+}
+
+// Read deserializes the programs from r and relocates them into the current process. It returns
+// ErrMismatch if the snapshot was not created by the same executable on the same kind of CPU.
+func Read(r io.Reader) ([]Program, error) {
+    var err error
+    var buf []byte
+
+    /* read the entire snapshot */
+    if buf, err = io.ReadAll(r); err != nil {
+        return nil, err
+    }
+
+    /* verify the checksum */
+    if n := len(buf) - 4; n < len(_Magic) || crc32.ChecksumIEEE(buf[:n]) != binary.LittleEndian.Uint32(buf[n:]) {
+        return nil, ErrCorrupted
+    } else if string(buf[:len(_Magic)]) != _Magic {
+        return nil, ErrCorrupted
+    }
+
+    /* the executable must be identified */
+    if executable() == "" {
+        return nil, ErrMismatch
+    }
+
+    /* skip the magic and checksum */
+    lo, hi := loader.Image()
+    rd := &_Reader { buf: buf[len(_Magic):len(buf) - 4] }
+
+    /* check the format version */
+    if rd.u32() != _Version {
+        return nil, ErrMismatch
+    }
+
+    /* check the environment */
+    for _, v := range header() {
+        if rd.str() != v {
+            return nil, ErrMismatch
+        }
+    }
+
+    /* read all the programs */
+    nb := int(rd.u32())
+    ret := make([]Program, 0, nb)
+
+    /* relocate every program */
+    for i := 0; i < nb && rd.err == nil; i++ {
+        if p, fp := rd.program(lo); rd.err != nil {
+            break
+        } else if vt := uintptr(unsafe.Pointer(p.Type)); vt < lo || vt >= hi || Fingerprint(p.Type) != fp {
+            return nil, ErrMismatch
+        } else if p.relocate(lo) {
+            ret = append(ret, p)
+        }
+    }
+
+    /* check for errors */
+    if rd.err != nil {
+        return nil, rd.err
+    } else {
+        return ret, nil
+    }
+}
+
+func (self *Program) relocate(base uintptr) bool {
+    for _, rel := range self.Relocs {
+        if !writeAddr(self.Code, rel, base + readAddr(self.Code, rel)) {
+            return false
+        }
+    }
+    return true
+}
+
+type _Buffer struct {
+    buf bytes.Buffer
+}
+
+func (self *_Buffer) u8(v uint8) {
+    self.buf.WriteByte(v)
+}
+
+func (self *_Buffer) u32(v uint32) {
+    var b [4]byte
+    binary.LittleEndian.PutUint32(b[:], v)
+    self.buf.Write(b[:])
+}
+
+func (self *_Buffer) u64(v uint64) {
+    var b [8]byte
+    binary.LittleEndian.PutUint64(b[:], v)
+    self.buf.Write(b[:])
+}
+
+func (self *_Buffer) raw(v []byte) {
+    self.u32(uint32(len(v)))
+    self.buf.Write(v)
+}
+
+func (self *_Buffer) str(v string) {
+    self.u32(uint32(len(v)))
+    self.buf.WriteString(v)
+}
+
+func (self *_Buffer) stackmap(v *rt.StackMap) {
+    if v == nil {
+        self.u32(math.MaxUint32)
+        return
+    }
+
+    /* only the first bitmap is used by the generated code */
+    bv := v.Get(0)
+    self.u32(uint32(bv.N))
+
+    /* add every bit */
+    for i := uintptr(0); i < bv.N; i++ {
+        self.u8(bv.Bit(i))
+    }
+}
+
+func (self *_Buffer) program(p *Program, base uintptr) {
+    code := append([]byte(nil), p.Code...)
+    self.u8(uint8(p.Kind))
+    self.u8(uint8(p.Protocol))
+    self.u64(uint64(uintptr(unsafe.Pointer(p.Type)) - base))
+    self.u64(Fingerprint(p.Type))
+
+    /* addresses are saved as offsets into the image */
+    for _, rel := range p.Relocs {
+        writeAddr(code, rel, readAddr(code, rel) - base)
+    }
+
+    /* add the code and relocations */
+    self.raw(code)
+    self.u32(uint32(len(p.Relocs)))
+
+    /* add every relocation */
+    for _, rel := range p.Relocs {
+        self.u32(uint32(rel.Off))
+        self.u8(uint8(rel.Size))
+    }
+
+    /* add the PC-SP table */
+    self.u32(uint32(len(p.Frame.SpTab)))
+    for _, sp := range p.Frame.SpTab {
+        self.u64(uint64(sp.Sp))
+        self.u64(uint64(sp.Nb))
+    }
+
+    /* add the stack maps */
+    self.u64(uint64(p.Frame.ArgSize))
+    self.stackmap(p.Frame.ArgPtrs)
+    self.stackmap(p.Frame.LocalPtrs)
+}
+
+type _Reader struct {
+    err error
+    buf []byte
+}
+
+func (self *_Reader) take(n int) []byte {
+    if self.err != nil || n > len(self.buf) {
+        self.err = ErrCorrupted
+        return make([]byte, n)
+    } else {
+        ret := self.buf[:n]
+        self.buf = self.buf[n:]
+        return ret
+    }
+}
+
+func (self *_Reader) u8() uint8 {
+    return self.take(1)[0]
+}
+
+func (self *_Reader) u32() uint32 {
+    return binary.LittleEndian.Uint32(self.take(4))
+}
+
+func (self *_Reader) u64() uint64 {
+    return binary.LittleEndian.Uint64(self.take(8))
+}
+
+func (self *_Reader) size() int {
+    if n := int(self.u32()); n > len(self.buf) {
+        self.err = ErrCorrupted
+        return 0
+    } else {
+        return n
+    }
+}
+
+func (self *_Reader) raw() []byte {
+    return append([]byte(nil), self.take(self.size())...)
+}
+
+func (self *_Reader) str() string {
+    return string(self.take(self.size()))
+}
+
+func (self *_Reader) stackmap() *rt.StackMap {
+    var n uint32
+    var mb rt.StackMapBuilder
+
+    /* check for nil stack maps */
+    if n = self.u32(); n == math.MaxUint32 {
+        return nil
+    } else if int(n) > len(self.buf) {
+        self.err = ErrCorrupted
+        return nil
+    }
+
+    /* add every bit */
+    for i := uint32(0); i < n; i++ {
+        mb.AddField(self.u8() != 0)
+    }
+
+    /* build the stack map */
+    return mb.Build()
+}
+
+func (self *_Reader) program(base uintptr) (p Program, fp uint64) {
+    p.Kind = Kind(self.u8())
+    p.Protocol = defs.Protocol(self.u8())
+    p.Type = (*rt.GoType)(mkptr(base + uintptr(self.u64())))
+    fp = self.u64()
+    p.Code = self.raw()
+
+    /* read all the relocations */
+    nr := self.size()
+    p.Relocs = make([]rt.Reloc, nr)
+
+    /* read every relocation, and make sure it's within the code */
+    for i := range p.Relocs {
+        p.Relocs[i].Off = uintptr(self.u32())
+        p.Relocs[i].Size = uintptr(self.u8())
+
+        /* check the relocation */
+        if rel := p.Relocs[i]; (rel.Size != 4 && rel.Size != 8) || rel.Off + rel.Size > uintptr(len(p.Code)) {
+            self.err = ErrCorrupted
+            return
+        }
+    }
+
+    /* read the PC-SP table */
+    p.Frame.SpTab = make([]rt.Stack, self.size())
+    for i := range p.Frame.SpTab {
+        p.Frame.SpTab[i].Sp = uintptr(self.u64())
+        p.Frame.SpTab[i].Nb = uintptr(self.u64())
+    }
+
+    /* read the stack maps */
+    p.Frame.ArgSize = uintptr(self.u64())
+    p.Frame.ArgPtrs = self.stackmap()
+    p.Frame.LocalPtrs = self.stackmap()
+    return
+}
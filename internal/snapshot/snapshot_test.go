@@ -0,0 +1,112 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package snapshot
+
+import (
+    `bytes`
+    `encoding/binary`
+    `hash/crc32`
+    `reflect`
+    `testing`
+    `unsafe`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/rt`
+    `github.com/stretchr/testify/require`
+)
+
+type SnapshotTestStruct struct {
+    A int64  `frugal:"1,default,i64"`
+    B string `frugal:"2,default,string"`
+}
+
+var snapshotTestVar int
+
+func mkstackmap(bv ...bool) *rt.StackMap {
+    var mb rt.StackMapBuilder
+    for _, v := range bv {
+        mb.AddField(v)
+    }
+    return mb.Build()
+}
+
+func mkprogram(vt reflect.Type, addr uintptr) Program {
+    code := make([]byte, 16)
+    binary.LittleEndian.PutUint64(code[4:], uint64(addr))
+    return Program {
+        Kind     : Decoder,
+        Protocol : defs.Compact,
+        Type     : rt.UnpackType(vt),
+        Code     : code,
+        Relocs   : []rt.Reloc {{ Off: 4, Size: 8 }},
+        Frame    : rt.Frame {
+            SpTab     : []rt.Stack {{ Sp: 0, Nb: 4 }, { Sp: 24, Nb: 12 }, { Sp: 0, Nb: 0 }},
+            ArgSize   : 16,
+            ArgPtrs   : mkstackmap(true, false, true),
+            LocalPtrs : mkstackmap(false, true),
+        },
+    }
+}
+
+func TestSnapshot_WriteRead(t *testing.T) {
+    var buf bytes.Buffer
+    vt := reflect.TypeOf(SnapshotTestStruct{})
+    sp := mkprogram(vt, uintptr(unsafe.Pointer(&snapshotTestVar)))
+    ht := reflect.StructOf([]reflect.StructField {{ Name: "X", Type: vt }})
+    n, err := Write(&buf, []Program { sp, mkprogram(ht, uintptr(unsafe.Pointer(&snapshotTestVar))) })
+    require.NoError(t, err)
+    require.Equal(t, 1, n)
+    rp, err := Read(bytes.NewReader(buf.Bytes()))
+    require.NoError(t, err)
+    require.Len(t, rp, 1)
+    require.Equal(t, sp.Kind, rp[0].Kind)
+    require.Equal(t, sp.Protocol, rp[0].Protocol)
+    require.Equal(t, sp.Type, rp[0].Type)
+    require.Equal(t, sp.Code, rp[0].Code)
+    require.Equal(t, sp.Relocs, rp[0].Relocs)
+    require.Equal(t, sp.Frame.SpTab, rp[0].Frame.SpTab)
+    require.Equal(t, sp.Frame.ArgSize, rp[0].Frame.ArgSize)
+    require.Equal(t, sp.Frame.ArgPtrs.String(), rp[0].Frame.ArgPtrs.String())
+    require.Equal(t, sp.Frame.LocalPtrs.String(), rp[0].Frame.LocalPtrs.String())
+}
+
+func TestSnapshot_Invalid(t *testing.T) {
+    var buf bytes.Buffer
+    sp := mkprogram(reflect.TypeOf(SnapshotTestStruct{}), uintptr(unsafe.Pointer(&snapshotTestVar)))
+    _, err := Write(&buf, []Program { sp })
+    require.NoError(t, err)
+    data := buf.Bytes()
+    _, err = Read(bytes.NewReader(data[:len(data) - 1]))
+    require.Equal(t, ErrCorrupted, err)
+    data[len(data) - 8] ^= 0xff
+    _, err = Read(bytes.NewReader(data))
+    require.Equal(t, ErrCorrupted, err)
+    data[len(data) - 8] ^= 0xff
+    data[len(_Magic) + 8] ^= 0xff
+    binary.LittleEndian.PutUint32(data[len(data) - 4:], crc32.ChecksumIEEE(data[:len(data) - 4]))
+    _, err = Read(bytes.NewReader(data))
+    require.Equal(t, ErrMismatch, err)
+}
+
+func TestSnapshot_Fingerprint(t *testing.T) {
+    type T1 struct { A int64 `frugal:"1,default,i64"` }
+    type T2 struct { A int64 `frugal:"2,default,i64"` }
+    v1 := Fingerprint(rt.UnpackType(reflect.TypeOf(T1{})))
+    v2 := Fingerprint(rt.UnpackType(reflect.TypeOf(T2{})))
+    require.NotEqual(t, v1, v2)
+    require.Equal(t, v1, Fingerprint(rt.UnpackType(reflect.TypeOf(T1{}))))
+}
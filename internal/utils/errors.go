@@ -17,10 +17,38 @@
 package utils
 
 import (
+    `errors`
     `fmt`
     `reflect`
 )
 
+var (
+    ErrTruncated       = errors.New("frugal: unexpected EOF")
+    ErrTypeMismatch    = errors.New("frugal: type mismatch")
+    ErrMissingRequired = errors.New("frugal: missing required field")
+    ErrInvalidData     = errors.New("frugal: invalid data")
+    ErrLimitExceeded   = errors.New("frugal: limit exceeded")
+    ErrUnsupportedType = errors.New("frugal: unsupported type")
+    ErrBufferTooSmall  = errors.New("frugal: buffer is too small")
+)
+
+type KindError struct {
+    Kind error
+    Err  error
+}
+
+func (self KindError) Error() string {
+    return self.Err.Error()
+}
+
+func (self KindError) Unwrap() error {
+    return self.Err
+}
+
+func (self KindError) Is(err error) bool {
+    return err == self.Kind
+}
+
 type TypeError struct {
     Note string
     Type reflect.Type
@@ -34,6 +62,10 @@ func (self TypeError) Error() string {
     }
 }
 
+func (self TypeError) Is(err error) bool {
+    return err == ErrUnsupportedType
+}
+
 type SyntaxError struct {
     Pos    int
     Src    string
@@ -44,6 +76,10 @@ func (self SyntaxError) Error() string {
     return fmt.Sprintf("Syntax error at position %d: %s", self.Pos, self.Reason)
 }
 
+func (self SyntaxError) Is(err error) bool {
+    return err == ErrUnsupportedType
+}
+
 func EType(vt reflect.Type) TypeError {
     return TypeError {
         Type: vt,
@@ -68,3 +104,14 @@ func ENotSupp(vt reflect.Type, alt string) TypeError {
         Note: fmt.Sprintf("Thrift does not support %s, use %s instead", vt, alt),
     }
 }
+
+func EKind(kind error, err error) error {
+    return KindError {
+        Kind : kind,
+        Err  : err,
+    }
+}
+
+func EKindf(kind error, format string, args ...interface{}) error {
+    return EKind(kind, fmt.Errorf(format, args...))
+}
@@ -20,4 +20,7 @@ import (
     `os`
 )
 
-var ForceEmulator = os.Getenv("FRUGAL_BACKEND") == "emu"
+var (
+    ForceEmulator    = os.Getenv("FRUGAL_BACKEND") == "emu"
+    ForceInterpreter = os.Getenv("FRUGAL_BACKEND") == "interp"
+)
@@ -40,6 +40,7 @@ type ProgramMap struct {
 type ProgramEntry struct {
     vt *rt.GoType
     fn interface{}
+    rf uint32
 }
 
 func newProgramMap() *ProgramMap {
@@ -56,7 +57,8 @@ func (self *ProgramMap) get(vt *rt.GoType) interface{} {
 
     /* linear probing */
     for ; i > 0; i-- {
-        if b := self.b[p]; b.vt == vt {
+        if b := &self.b[p]; b.vt == vt {
+            b.touch()
             return b.fn
         } else if b.vt == nil {
             break
@@ -85,6 +87,20 @@ func (self *ProgramMap) add(vt *rt.GoType, fn interface{}) *ProgramMap {
     return p
 }
 
+func (self *ProgramMap) remove(ev map[*rt.GoType]bool) *ProgramMap {
+    r := &ProgramMap{m: self.m, b: make([]ProgramEntry, len(self.b))}
+
+    /* linear probing does not allow holes, so rebuild with the remaining entries */
+    for i := uint32(0); i <= self.m; i++ {
+        if b := self.b[i]; b.vt != nil && !ev[b.vt] {
+            r.insert(b.vt, b.fn)
+        }
+    }
+
+    /* rebuild successful */
+    return r
+}
+
 func (self *ProgramMap) copy() *ProgramMap {
     p := new(ProgramMap)
     p.n = self.n
@@ -130,9 +146,16 @@ func (self *ProgramMap) insert(vt *rt.GoType, fn interface{}) {
     panic("no available slots")
 }
 
+func (self *ProgramEntry) touch() {
+    if atomic.LoadUint32(&self.rf) == 0 {
+        atomic.StoreUint32(&self.rf, 1)
+    }
+}
+
 /** RCU Program Cache **/
 
 type ProgramCache struct {
+    h uint32
     m sync.Mutex
     p unsafe.Pointer
 }
@@ -170,3 +193,67 @@ func (self *ProgramCache) Compute(vt *rt.GoType, compute func(*rt.GoType) (inter
     atomic.StorePointer(&self.p, unsafe.Pointer((*ProgramMap)(atomic.LoadPointer(&self.p)).add(vt, val)))
     return val, nil
 }
+
+func (self *ProgramCache) Len() int {
+    return int(atomic.LoadUint64(&(*ProgramMap)(atomic.LoadPointer(&self.p)).n))
+}
+
+func (self *ProgramCache) Keys() []*rt.GoType {
+    p := (*ProgramMap)(atomic.LoadPointer(&self.p))
+    r := make([]*rt.GoType, 0, p.n)
+
+    /* add every type */
+    for i := uint32(0); i <= p.m; i++ {
+        if b := p.b[i]; b.vt != nil {
+            r = append(r, b.vt)
+        }
+    }
+
+    /* all done */
+    return r
+}
+
+func (self *ProgramCache) Delete(vt *rt.GoType) bool {
+    self.m.Lock()
+    defer self.m.Unlock()
+
+    /* check if the type exists */
+    if p := (*ProgramMap)(atomic.LoadPointer(&self.p)); p.get(vt) == nil {
+        return false
+    } else {
+        atomic.StorePointer(&self.p, unsafe.Pointer(p.remove(map[*rt.GoType]bool { vt: true })))
+        return true
+    }
+}
+
+// Shrink evicts the least recently used programs until at most n programs are left,
+// with the CLOCK algorithm. It returns the number of evicted programs.
+func (self *ProgramCache) Shrink(n int) int {
+    self.m.Lock()
+    defer self.m.Unlock()
+
+    /* check for the program count */
+    p := (*ProgramMap)(atomic.LoadPointer(&self.p))
+    nb := int(atomic.LoadUint64(&p.n)) - n
+
+    /* no limits, or still within the limit */
+    if n <= 0 || nb <= 0 {
+        return 0
+    }
+
+    /* sweep the entries, giving a second chance to the referenced ones */
+    ev := make(map[*rt.GoType]bool, nb)
+    for len(ev) < nb {
+        b := &p.b[self.h & p.m]
+        self.h++
+
+        /* evict the entry if not referenced since the last sweep */
+        if b.vt != nil && atomic.SwapUint32(&b.rf, 0) == 0 {
+            ev[b.vt] = true
+        }
+    }
+
+    /* update the RCU cache */
+    atomic.StorePointer(&self.p, unsafe.Pointer(p.remove(ev)))
+    return nb
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+    `reflect`
+    `testing`
+
+    `github.com/cloudwego/frugal/internal/rt`
+    `github.com/stretchr/testify/require`
+)
+
+var testTypes = []reflect.Type {
+    reflect.TypeOf(int8(0)),
+    reflect.TypeOf(int16(0)),
+    reflect.TypeOf(int32(0)),
+    reflect.TypeOf(int64(0)),
+    reflect.TypeOf(""),
+}
+
+func fillProgramCache(t *testing.T, pc *ProgramCache) {
+    for _, vt := range testTypes {
+        _, err := pc.Compute(rt.UnpackType(vt), func(vt *rt.GoType) (interface{}, error) { return vt.String(), nil })
+        require.NoError(t, err)
+    }
+}
+
+func TestProgramCache_Delete(t *testing.T) {
+    pc := CreateProgramCache()
+    fillProgramCache(t, pc)
+    require.Equal(t, len(testTypes), pc.Len())
+    require.True(t, pc.Delete(rt.UnpackType(testTypes[1])))
+    require.False(t, pc.Delete(rt.UnpackType(testTypes[1])))
+    require.Equal(t, len(testTypes) - 1, pc.Len())
+    require.Nil(t, pc.Get(rt.UnpackType(testTypes[1])))
+    for i, vt := range testTypes {
+        if i != 1 {
+            require.Equal(t, vt.String(), pc.Get(rt.UnpackType(vt)))
+        }
+    }
+}
+
+func TestProgramCache_Shrink(t *testing.T) {
+    pc := CreateProgramCache()
+    fillProgramCache(t, pc)
+    require.Equal(t, 0, pc.Shrink(0))
+    require.Equal(t, 0, pc.Shrink(len(testTypes)))
+    pc.Get(rt.UnpackType(testTypes[0]))
+    pc.Get(rt.UnpackType(testTypes[4]))
+    require.Equal(t, 3, pc.Shrink(2))
+    require.Equal(t, 2, pc.Len())
+    require.Equal(t, testTypes[0].String(), pc.Get(rt.UnpackType(testTypes[0])))
+    require.Equal(t, testTypes[4].String(), pc.Get(rt.UnpackType(testTypes[4])))
+}
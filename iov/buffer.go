@@ -22,3 +22,13 @@ type BufferWriter interface {
     // without copy. It splits the original buffer at remainingCap.
     WriteDirect(buf []byte, remainingCap int) error
 }
+
+// BufferReader implement zero-copy buffer reading.
+type BufferReader interface {
+    // Peek returns the next n bytes without advancing the reader,
+    // it blocks until n bytes are available.
+    Peek(n int) ([]byte, error)
+
+    // Next returns the next n bytes and advances the reader.
+    Next(n int) ([]byte, error)
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `reflect`
+
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+    `github.com/cloudwego/frugal/internal/binary/encoder`
+    `github.com/cloudwego/frugal/internal/json`
+)
+
+// JSONMode selects the JSON flavor used by EncodeJSON and DecodeJSON.
+type JSONMode = json.Mode
+
+const (
+    // TJSONProtocol is the JSON protocol of Apache Thrift (TJSONProtocol). Fields are keyed
+    // by their IDs and carry the Thrift type names, so the output is self-describing.
+    TJSONProtocol = json.TJSON
+
+    // SimpleJSONProtocol is the human-readable JSON flavor. Fields are keyed by their names,
+    // which are taken from the "thrift" tag if present, otherwise the Go field names.
+    SimpleJSONProtocol = json.SimpleJSON
+)
+
+// EncodeJSON serializes val into JSON with the given mode. It uses the same "frugal" tags as
+// the binary protocols: binaries are encoded with base64, enums as integers, and NaN and infinite
+// doubles as the strings "NaN", "Infinity" and "-Infinity". Unknown fields are not included.
+func EncodeJSON(val interface{}, mode JSONMode) ([]byte, error) {
+    buf, err := encoder.AppendObject(nil, val)
+    if err != nil {
+        return nil, err
+    }
+
+    /* convert the binary encoding into JSON */
+    return json.FromBinary(nil, buf, reflect.TypeOf(val), mode)
+}
+
+// DecodeJSON deserializes JSON produced by EncodeJSON (or any compatible encoder) in buf into
+// val, which must be a non-nil pointer. Fields that do not exist in val, and fields with null
+// values are ignored. Requiredness, unions and default values are handled the same way as
+// DecodeObject.
+func DecodeJSON(buf []byte, val interface{}, mode JSONMode) error {
+    var err error
+    var bin []byte
+
+    /* convert into the binary encoding, the decoder reports the error if val is not a pointer */
+    if vt := reflect.TypeOf(val); vt != nil && vt.Kind() == reflect.Ptr {
+        if bin, err = json.ToBinary(nil, buf, vt.Elem(), mode); err != nil {
+            return err
+        }
+    }
+
+    /* decode the converted object */
+    _, err = decoder.DecodeObject(bin, val)
+    return err
+}
@@ -0,0 +1,139 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `reflect`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+)
+
+// Lazy is a struct field of type T, which is kept in the encoded form when decoded, and is only
+// decoded on the first call to Get. If it's never decoded or replaced, it's encoded by copying the
+// encoded form back verbatim, so sub-structs that are only forwarded are never decoded at all.
+//
+// T must be a struct type, and Lazy fields must be tagged with the "struct" type if the type is
+// specified, like `frugal:"1,default,struct"`. A Lazy is not safe for concurrent use.
+type Lazy[T any] struct {
+    buf []byte
+    val *T
+    pt  defs.Protocol
+}
+
+// LazyOf returns a Lazy holding the decoded value v.
+func LazyOf[T any](v *T) Lazy[T] {
+    return Lazy[T] { val: v }
+}
+
+// Get decodes the value on the first call, and returns it. Changes to the returned value are
+// reflected when the Lazy is encoded. The zero Lazy holds the zero value of T.
+func (self *Lazy[T]) Get() (*T, error) {
+    if self.val != nil {
+        return self.val, nil
+    } else if val, err := self.decode(); err != nil {
+        return nil, err
+    } else {
+        self.val, self.buf = val, nil
+        return val, nil
+    }
+}
+
+// Set replaces the value with v, discarding the encoded form.
+func (self *Lazy[T]) Set(v *T) {
+    self.val, self.buf = v, nil
+}
+
+// Decoded reports whether the value has been decoded, or set.
+func (self *Lazy[T]) Decoded() bool {
+    return self.val != nil
+}
+
+func (self *Lazy[T]) decode() (*T, error) {
+    val := new(T)
+
+    /* the zero Lazy has no encoded form */
+    if self.buf == nil {
+        return val, nil
+    }
+
+    /* decode with the protocol it was decoded from */
+    if cc, err := newCodec[T](self.pt, nil); err != nil {
+        return nil, err
+    } else if _, err = cc.Decode(self.buf, val); err != nil {
+        return nil, err
+    } else {
+        return val, nil
+    }
+}
+
+func (self *Lazy[T]) encoded(pt defs.Protocol) bool {
+    return self.val == nil && self.buf != nil && self.pt == pt
+}
+
+/* lazyValue implements the internal raw value interface for Lazy[T], so Lazy[T] does not export it */
+type lazyValue[T any] Lazy[T]
+
+func (self *Lazy[T]) rawValue() defs.RawValue {
+    return (*lazyValue[T])(self)
+}
+
+func (self *lazyValue[T]) RawType() reflect.Type {
+    return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (self *lazyValue[T]) RawSize(pt defs.Protocol) (int, error) {
+    return self.RawEncode(nil, pt)
+}
+
+func (self *lazyValue[T]) RawEncode(buf []byte, pt defs.Protocol) (int, error) {
+    var err error
+    var val *T
+    var cc *Codec[T]
+
+    /* copy the encoded form if possible */
+    if (*Lazy[T])(self).encoded(pt) {
+        if buf == nil {
+            return len(self.buf), nil
+        } else {
+            return copy(buf, self.buf), nil
+        }
+    }
+
+    /* the encoded form of another protocol has to be decoded first, but not kept */
+    if val = self.val; val == nil {
+        if val, err = (*Lazy[T])(self).decode(); err != nil {
+            return 0, err
+        }
+    }
+
+    /* encode the value, or measure it without a buffer */
+    if cc, err = newCodec[T](pt, nil); err != nil {
+        return 0, err
+    } else {
+        return cc.Encode(buf, val)
+    }
+}
+
+func (self *lazyValue[T]) RawDecode(buf []byte, pt defs.Protocol) error {
+    self.buf = append(make([]byte, 0, len(buf)), buf...)
+    self.val = nil
+    self.pt = pt
+    return nil
+}
@@ -0,0 +1,63 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `reflect`
+    `testing`
+
+    `github.com/stretchr/testify/require`
+)
+
+type TestLazyOuter struct {
+    A int32                 `frugal:"1,default,i32"`
+    B Lazy[TestMessageArgs] `frugal:"2,default,struct"`
+}
+
+func TestLazy_RoundTrip(t *testing.T) {
+    v := TestLazyOuter { A: 1, B: LazyOf(&TestMessageArgs { A: 2, B: "hello" }) }
+    buf := make([]byte, EncodedSize(&v))
+    ret, err := EncodeObject(buf, nil, &v)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), ret)
+
+    /* the lazy field is kept encoded, and copied back verbatim */
+    var w TestLazyOuter
+    pos, err := DecodeObject(buf, &w)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), pos)
+    require.False(t, w.B.Decoded())
+    out := make([]byte, EncodedSize(&w))
+    _, err = EncodeObject(out, nil, &w)
+    require.NoError(t, err)
+    require.Equal(t, buf, out)
+
+    /* decoded on the first access */
+    b, err := w.B.Get()
+    require.NoError(t, err)
+    require.Equal(t, TestMessageArgs { A: 2, B: "hello" }, *b)
+}
+
+func TestLazy_NoRawMethods(t *testing.T) {
+    vt := reflect.TypeOf(&Lazy[TestMessageArgs]{})
+    for i := 0; i < vt.NumMethod(); i++ {
+        require.NotContains(t, []string { "RawType", "RawSize", "RawEncode", "RawDecode" }, vt.Method(i).Name)
+    }
+}
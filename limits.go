@@ -0,0 +1,42 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+)
+
+// LimitError is returned when decoding an object exceeds one of the decoding
+// limits, use errors.As to check for it.
+type LimitError = decoder.LimitError
+
+// Limit is the kind of decoding limit reported by a LimitError.
+type Limit = decoder.Limit
+
+const (
+    // LimitContainerLen is the limit set by WithMaxContainerLen.
+    LimitContainerLen = decoder.LimitContainerLen
+
+    // LimitStringLen is the limit set by WithMaxStringLen.
+    LimitStringLen = decoder.LimitStringLen
+
+    // LimitDepth is the limit set by WithMaxDepth.
+    LimitDepth = decoder.LimitDepth
+
+    // LimitTotalAlloc is the limit set by WithMaxTotalAlloc.
+    LimitTotalAlloc = decoder.LimitTotalAlloc
+)
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `encoding/binary`
+    `fmt`
+
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+    `github.com/cloudwego/frugal/internal/utils`
+)
+
+const (
+    _VersionMask = 0xffff0000
+    _Version1    = 0x80010000
+)
+
+// MessageType is the type of a Thrift message.
+type MessageType int32
+
+const (
+    Call      MessageType = 1
+    Reply     MessageType = 2
+    Exception MessageType = 3
+    Oneway    MessageType = 4
+)
+
+func (self MessageType) String() string {
+    switch self {
+        case Call      : return "CALL"
+        case Reply     : return "REPLY"
+        case Exception : return "EXCEPTION"
+        case Oneway    : return "ONEWAY"
+        default        : return fmt.Sprintf("MessageType(%d)", int32(self))
+    }
+}
+
+// ApplicationException types, as defined by Thrift.
+const (
+    UnknownApplicationException int32 = iota
+    UnknownMethod
+    InvalidMessageTypeException
+    WrongMethodName
+    BadSequenceID
+    MissingResult
+    InternalError
+    ProtocolError
+)
+
+// ApplicationException is the TApplicationException carried by an EXCEPTION
+// message, it is returned as an error by DecodeMessage.
+type ApplicationException struct {
+    Message string `frugal:"1,default,string"`
+    Type    int32  `frugal:"2,default,i32"`
+}
+
+func (self *ApplicationException) Error() string {
+    if self.Message != "" {
+        return self.Message
+    } else {
+        return fmt.Sprintf("frugal: application exception of type %d", self.Type)
+    }
+}
+
+// EncodedMessageSize measures the encoded size of a message with a strict
+// header named name, and args as the body.
+func EncodedMessageSize(name string, args interface{}) int {
+    return 12 + len(name) + EncodedSize(args)
+}
+
+// EncodeMessage serializes a message with Thrift Binary Protocol into buf, with
+// a strict (versioned) header and args as the body. buf must be large enough to
+// contain the entire message, see EncodedMessageSize.
+func EncodeMessage(buf []byte, name string, msgType MessageType, seqID int32, args interface{}) (int, error) {
+    nb := 12 + len(name)
+
+    /* check for buffer size */
+    if len(buf) < nb {
+        return 0, ErrBufferTooSmall
+    }
+
+    /* version, name and sequence ID */
+    binary.BigEndian.PutUint32(buf, _Version1 | uint32(msgType))
+    binary.BigEndian.PutUint32(buf[4:], uint32(len(name)))
+    binary.BigEndian.PutUint32(buf[8 + copy(buf[8:], name):], uint32(seqID))
+
+    /* encode the body */
+    ret, err := EncodeObject(buf[nb:], nil, args)
+    return nb + ret, err
+}
+
+// EncodeMessageNonStrict is like EncodeMessage, but writes the old-style
+// non-strict header, which is 3 bytes shorter than the strict one.
+func EncodeMessageNonStrict(buf []byte, name string, msgType MessageType, seqID int32, args interface{}) (int, error) {
+    nb := 9 + len(name)
+
+    /* check for buffer size */
+    if len(buf) < nb {
+        return 0, ErrBufferTooSmall
+    }
+
+    /* name, type and sequence ID */
+    binary.BigEndian.PutUint32(buf, uint32(len(name)))
+    buf[4 + copy(buf[4:], name)] = byte(msgType)
+    binary.BigEndian.PutUint32(buf[5 + len(name):], uint32(seqID))
+
+    /* encode the body */
+    ret, err := EncodeObject(buf[nb:], nil, args)
+    return nb + ret, err
+}
+
+// DecodeMessage deserializes the header of a Thrift Binary Protocol message from
+// buf, both strict and non-strict headers are accepted. n is the number of bytes
+// consumed, the message body starts at buf[n:] and can be decoded with DecodeObject.
+//
+// If the message is an EXCEPTION, the body is also consumed, and the
+// *ApplicationException it carries is returned as err.
+func DecodeMessage(buf []byte) (name string, msgType MessageType, seqID int32, n int, err error) {
+    var nb int
+    var nt int
+    var vv uint32
+
+    /* read the first word */
+    if len(buf) < 4 {
+        return "", 0, 0, 0, decoder.EOFError(4 - len(buf))
+    }
+
+    /* strict headers start with a negative version, followed by the name length */
+    if vv = binary.BigEndian.Uint32(buf); int32(vv) < 0 {
+        if vv & _VersionMask != _Version1 {
+            return "", 0, 0, 0, utils.EKindf(ErrInvalidData, "frugal: bad version in message header: %#x", vv)
+        } else if len(buf) < 8 {
+            return "", 0, 0, 0, decoder.EOFError(8 - len(buf))
+        } else if nb = int(int32(binary.BigEndian.Uint32(buf[4:]))); nb < 0 {
+            return "", 0, 0, 0, utils.EKindf(ErrInvalidData, "frugal: negative name length in message header: %d", nb)
+        } else {
+            msgType, n = MessageType(vv & 0xff), 8
+        }
+    } else {
+        nb, nt, n = int(vv), 1, 4
+    }
+
+    /* check for the rest of the header, non-strict headers have an extra type byte */
+    if len(buf) < n + nb + nt + 4 {
+        return "", 0, 0, 0, decoder.EOFError(n + nb + nt + 4 - len(buf))
+    }
+
+    /* extract the name, and the type of non-strict headers */
+    if name, n = string(buf[n:n + nb]), n + nb; nt != 0 {
+        msgType, n = MessageType(buf[n]), n + 1
+    }
+
+    /* extract the sequence ID */
+    seqID, n = int32(binary.BigEndian.Uint32(buf[n:])), n + 4
+
+    /* not an exception */
+    if msgType != Exception {
+        return name, msgType, seqID, n, nil
+    }
+
+    /* decode the exception */
+    ex := new(ApplicationException)
+    nb, err = DecodeObject(buf[n:], ex)
+
+    /* check for errors */
+    if err != nil {
+        return name, msgType, seqID, n, err
+    } else {
+        return name, msgType, seqID, n + nb, ex
+    }
+}
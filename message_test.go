@@ -0,0 +1,154 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `errors`
+    `testing`
+
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+    `github.com/stretchr/testify/require`
+)
+
+type TestMessageArgs struct {
+    A int32  `frugal:"1,default,i32"`
+    B string `frugal:"2,default,string"`
+}
+
+func TestMessage_RoundTrip(t *testing.T) {
+    v := TestMessageArgs{A: 123, B: "hello"}
+    buf := make([]byte, EncodedMessageSize("echo", v))
+    ret, err := EncodeMessage(buf, "echo", Call, 42, v)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), ret)
+    require.Equal(t, []byte{0x80, 0x01, 0x00, 0x01, 0, 0, 0, 4, 'e', 'c', 'h', 'o', 0, 0, 0, 42}, buf[:16])
+
+    /* decode the header */
+    name, mt, seq, n, err := DecodeMessage(buf)
+    require.NoError(t, err)
+    require.Equal(t, "echo", name)
+    require.Equal(t, Call, mt)
+    require.Equal(t, int32(42), seq)
+    require.Equal(t, 16, n)
+
+    /* decode the body */
+    var w TestMessageArgs
+    nb, err := DecodeObject(buf[n:], &w)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), n + nb)
+    require.Equal(t, v, w)
+}
+
+func TestMessage_NonStrict(t *testing.T) {
+    v := TestMessageArgs{A: -1, B: "x"}
+    buf := make([]byte, EncodedMessageSize("ping", v) - 3)
+    ret, err := EncodeMessageNonStrict(buf, "ping", Oneway, -7, v)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), ret)
+    require.Equal(t, []byte{0, 0, 0, 4, 'p', 'i', 'n', 'g', 4, 0xff, 0xff, 0xff, 0xf9}, buf[:13])
+
+    /* decode the header */
+    name, mt, seq, n, err := DecodeMessage(buf)
+    require.NoError(t, err)
+    require.Equal(t, "ping", name)
+    require.Equal(t, Oneway, mt)
+    require.Equal(t, int32(-7), seq)
+    require.Equal(t, 13, n)
+
+    /* decode the body */
+    var w TestMessageArgs
+    nb, err := DecodeObject(buf[n:], &w)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), n + nb)
+    require.Equal(t, v, w)
+}
+
+func TestMessage_BufferTooSmall(t *testing.T) {
+    buf := make([]byte, 15)
+    _, err := EncodeMessage(buf, "echo", Call, 1, TestMessageArgs{})
+    require.True(t, errors.Is(err, ErrBufferTooSmall), err)
+    _, err = EncodeMessageNonStrict(buf[:12], "echo", Call, 1, TestMessageArgs{})
+    require.True(t, errors.Is(err, ErrBufferTooSmall), err)
+}
+
+func TestMessage_Exception(t *testing.T) {
+    v := &ApplicationException{Message: "no such method", Type: UnknownMethod}
+    buf := make([]byte, EncodedMessageSize("echo", v))
+    ret, err := EncodeMessage(buf, "echo", Exception, 3, v)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), ret)
+
+    /* the exception is returned as an error, together with the header */
+    var ex *ApplicationException
+    name, mt, seq, n, err := DecodeMessage(buf)
+    require.True(t, errors.As(err, &ex), err)
+    require.Equal(t, v, ex)
+    require.Equal(t, "no such method", ex.Error())
+    require.Equal(t, "echo", name)
+    require.Equal(t, Exception, mt)
+    require.Equal(t, int32(3), seq)
+    require.Equal(t, len(buf), n)
+
+    /* exceptions without messages are named by their types */
+    require.Equal(t, "frugal: application exception of type 1", (&ApplicationException{Type: UnknownMethod}).Error())
+}
+
+func TestMessage_BadVersion(t *testing.T) {
+    _, _, _, _, err := DecodeMessage([]byte{0x80, 0x02, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 0})
+    require.True(t, errors.Is(err, ErrInvalidData), err)
+    require.Contains(t, err.Error(), "bad version")
+}
+
+func TestMessage_NegativeNameLength(t *testing.T) {
+    _, _, _, _, err := DecodeMessage([]byte{0x80, 0x01, 0x00, 0x01, 0xff, 0xff, 0xff, 0xfe, 0, 0, 0, 0})
+    require.True(t, errors.Is(err, ErrInvalidData), err)
+}
+
+func TestMessage_Truncated(t *testing.T) {
+    for _, strict := range []bool{true, false} {
+        var nh  int
+        var err error
+        buf := make([]byte, EncodedMessageSize("echo", TestMessageArgs{}))
+
+        /* encode the message, and remember the header size */
+        if strict {
+            nh = 16
+            _, err = EncodeMessage(buf, "echo", Reply, 5, TestMessageArgs{})
+        } else {
+            nh = 13
+            _, err = EncodeMessageNonStrict(buf, "echo", Reply, 5, TestMessageArgs{})
+        }
+
+        /* every truncated header must report a shortfall that does not overshoot the header */
+        require.NoError(t, err)
+        for i := 0; i < nh; i++ {
+            var ex decoder.EOFError
+            _, _, _, _, err = DecodeMessage(buf[:i])
+            require.True(t, errors.Is(err, ErrTruncated), "strict=%v, len=%d: %v", strict, i, err)
+            require.True(t, errors.As(err, &ex))
+            require.Greater(t, int(ex), 0)
+            require.LessOrEqual(t, i + int(ex), nh, "strict=%v, len=%d", strict, i)
+        }
+
+        /* the complete header is accepted */
+        _, mt, seq, n, err := DecodeMessage(buf[:nh])
+        require.NoError(t, err)
+        require.Equal(t, Reply, mt)
+        require.Equal(t, int32(5), seq)
+        require.Equal(t, nh, n)
+    }
+}
@@ -3,6 +3,9 @@
 #define ETAG        -1
 #define EEOF        -2
 #define ESTACK      -3
+#define ECOUNT      -5
+#define ESIZE       -6
+#define EDEPTH      -7
 #define MAX_STACK   1024
 
 #define T_bool      2
@@ -17,6 +20,7 @@
 #define T_set       14
 #define T_list      15
 #define T_map_pair  0xff
+#define T_list_elem 0xfe
 
 typedef struct {
     uint8_t  t;
@@ -25,6 +29,15 @@ typedef struct {
     uint32_t n;
 } skipbuf_t;
 
+/* skipped values are not allocated, so "na" is never checked by the skipper,
+ * the decoder charges the bytes it keeps (raw values and unknown fields) itself */
+typedef struct {
+    uint64_t nc;
+    uint64_t ns;
+    uint64_t nd;
+    uint64_t na;
+} limits_t;
+
 static const char WireTags[256] = {
     [T_bool  ] = 1,
     [T_i8    ] = 1,
@@ -78,7 +91,7 @@ static inline void mvbuf(const char **s, int64_t *n, int64_t *r, int64_t nb) {
     *s += nb;
 }
 
-int64_t do_skip(skipbuf_t *st, const char *s, int64_t n, uint8_t t) {
+int64_t do_skip(skipbuf_t *st, const char *s, int64_t n, uint8_t t, const limits_t *lm) {
     int64_t nb;
     int64_t rv = 0;
     int64_t sp = 0;
@@ -114,6 +127,8 @@ int64_t do_skip(skipbuf_t *st, const char *s, int64_t n, uint8_t t) {
             case T_string: {
                 if (n < 4) {
                     return EEOF;
+                } else if ((uint64_t)u32be(s) > lm->ns) {
+                    return ESIZE;
                 } else if ((nb = u32be(s) + 4) > n) {
                     return EEOF;
                 } else {
@@ -128,6 +143,11 @@ int64_t do_skip(skipbuf_t *st, const char *s, int64_t n, uint8_t t) {
                 int64_t nf;
                 uint8_t vt;
 
+                /* check for the nesting depth */
+                if ((uint64_t)sp >= lm->nd) {
+                    return EDEPTH;
+                }
+
                 /* must have at least 1 byte */
                 if (n < 1) {
                     return EEOF;
@@ -172,6 +192,11 @@ int64_t do_skip(skipbuf_t *st, const char *s, int64_t n, uint8_t t) {
                 uint8_t kt;
                 uint8_t vt;
 
+                /* check for the nesting depth */
+                if ((uint64_t)sp >= lm->nd) {
+                    return EDEPTH;
+                }
+
                 /* must have at least 6 bytes */
                 if (n < 6) {
                     return EEOF;
@@ -187,6 +212,11 @@ int64_t do_skip(skipbuf_t *st, const char *s, int64_t n, uint8_t t) {
                     return ETAG;
                 }
 
+                /* check for the container length */
+                if ((uint64_t)np > lm->nc) {
+                    return ECOUNT;
+                }
+
                 /* empty map */
                 if (np == 0) {
                     stpop(st, &sp);
@@ -213,19 +243,25 @@ int64_t do_skip(skipbuf_t *st, const char *s, int64_t n, uint8_t t) {
                 st[sp].k = kt;
                 st[sp].v = vt;
                 st[sp].t = T_map_pair;
-                st[sp].n = np * 2 - 1;
+                st[sp].n = np * 2;
                 mvbuf(&s, &n, &rv, 6);
                 break;
             }
 
-            /* map pairs */
+            /* map pairs, the elements are pushed onto stack, so they are one level deeper than the map */
             case T_map_pair: {
-                uint8_t kt = st[sp].k;
-                uint8_t vt = st[sp].v;
+                uint8_t vt;
 
-                /* there are keys pending */
-                if (!stpop(st, &sp) && (st[sp].n & 1) == 0) {
-                    vt = kt;
+                /* all the pairs are skipped */
+                if (stpop(st, &sp)) {
+                    break;
+                }
+
+                /* keys and values come alternately */
+                if (st[sp].n & 1) {
+                    vt = st[sp].k;
+                } else {
+                    vt = st[sp].v;
                 }
 
                 /* push the element onto stack */
@@ -243,6 +279,11 @@ int64_t do_skip(skipbuf_t *st, const char *s, int64_t n, uint8_t t) {
                 int64_t nt;
                 uint8_t et;
 
+                /* check for the nesting depth */
+                if ((uint64_t)sp >= lm->nd) {
+                    return EDEPTH;
+                }
+
                 /* must have at least 5 bytes */
                 if (n < 5) {
                     return EEOF;
@@ -257,6 +298,11 @@ int64_t do_skip(skipbuf_t *st, const char *s, int64_t n, uint8_t t) {
                     return ETAG;
                 }
 
+                /* check for the container length */
+                if ((uint64_t)nv > lm->nc) {
+                    return ECOUNT;
+                }
+
                 /* empty sequence */
                 if (nv == 0) {
                     stpop(st, &sp);
@@ -276,11 +322,21 @@ int64_t do_skip(skipbuf_t *st, const char *s, int64_t n, uint8_t t) {
                 }
 
                 /* set to parse the elements */
-                st[sp].t = et;
-                st[sp].n = nv - 1;
+                st[sp].v = et;
+                st[sp].t = T_list_elem;
+                st[sp].n = nv;
                 mvbuf(&s, &n, &rv, 5);
                 break;
             }
+
+            /* list elements, also one level deeper than the list */
+            case T_list_elem: {
+                if (stpop(st, &sp) || stadd(st, &sp, st[sp].v)) {
+                    break;
+                } else {
+                    return ESTACK;
+                }
+            }
         }
     }
 
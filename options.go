@@ -19,6 +19,7 @@ package frugal
 import (
     `fmt`
 
+    `github.com/cloudwego/frugal/internal/binary/decoder`
     `github.com/cloudwego/frugal/internal/opts`
 )
 
@@ -85,6 +86,107 @@ func WithMaxPretouchDepth(depth int) Option {
     }
 }
 
+// WithMaxContainerLen sets the maximum number of elements in a list, set or map
+// when decoding. Larger containers fail with a LimitError before allocating
+// anything for them, including those in skipped fields.
+//
+// Set this option to "0" disables this limit.
+//
+// The default value of this option is "0".
+func WithMaxContainerLen(n int) Option {
+    if n < 0 {
+        panic(fmt.Sprintf("frugal: invalid max container length: %d", n))
+    } else {
+        return func(o *opts.Options) { o.MaxContainerLen = n }
+    }
+}
+
+// WithMaxStringLen sets the maximum length of a string or binary in bytes when
+// decoding. Longer values fail with a LimitError, including those in skipped
+// fields.
+//
+// Set this option to "0" disables this limit.
+//
+// The default value of this option is "0".
+func WithMaxStringLen(n int) Option {
+    if n < 0 {
+        panic(fmt.Sprintf("frugal: invalid max string length: %d", n))
+    } else {
+        return func(o *opts.Options) { o.MaxStringLen = n }
+    }
+}
+
+// WithMaxDepth sets the maximum nesting depth when decoding. Every struct,
+// list, set and map counts as one level, starting from the top-level struct.
+// Deeper values fail with a LimitError, including those in skipped fields.
+//
+// Set this option to "0" disables this limit, the depth is still bounded by
+// the size of the internal decoder stack.
+//
+// The default value of this option is "0".
+func WithMaxDepth(depth int) Option {
+    if depth < 0 {
+        panic(fmt.Sprintf("frugal: invalid max depth: %d", depth))
+    } else {
+        return func(o *opts.Options) { o.MaxDepth = depth }
+    }
+}
+
+// WithMaxTotalAlloc sets the maximum number of bytes allocated for a single
+// decoded object, which includes the strings, binaries, containers and structs
+// referenced by pointers. Decoding fails with a LimitError once the total size
+// would exceed this limit. Skipped fields are not allocated and do not count,
+// but unknown fields kept in the "_unknown" field do.
+//
+// Set this option to "0" disables this limit.
+//
+// The default value of this option is "0".
+func WithMaxTotalAlloc(size int) Option {
+    if size < 0 {
+        panic(fmt.Sprintf("frugal: invalid max total allocation: %d", size))
+    } else {
+        return func(o *opts.Options) { o.MaxTotalAlloc = size }
+    }
+}
+
+// WithFields makes the decoder only decode the fields on the given paths, all
+// the other fields are skipped over without being decoded or allocated, and
+// are left untouched in the decoded object.
+//
+// A path is a dot-separated list of Thrift field names (or Go field names for
+// fields without the `thrift` tag), where "[*]" selects the elements of a list
+// or set, or the values of a map, like "user.id" or "items[*].price". Selecting
+// a struct or a container selects everything inside it.
+//
+// Paths are checked against the decoded type when it's decoded for the first
+// time with them. Required fields are only checked if selected.
+//
+// This option only applies to DecodeObjectWithOptions, DecodeCompactWithOptions,
+// Codec and Decoder.
+func WithFields(paths ...string) Option {
+    if _, err := decoder.ParseSelection(paths); err != nil {
+        panic(err)
+    } else {
+        fv := decoder.CanonicalSelection(paths)
+        return func(o *opts.Options) { o.Fields = fv }
+    }
+}
+
+// WithCanonical makes the encoder produce the same bytes for equal objects, by
+// writing map entries in the order of their keys, and set elements in their own
+// order, instead of the random iteration order of Go maps and the order in the
+// slices. Integers, doubles and strings are ordered by their values, and struct
+// keys or elements are ordered by their encoded bytes with Thrift Binary Protocol.
+// Sets are only sorted if the elements can be map keys.
+//
+// Raw fields and undecoded Lazy fields are written as they are.
+//
+// This option only applies to EncodeObjectWithOptions, EncodeCompactWithOptions
+// and Codec.
+func WithCanonical() Option {
+    return func(o *opts.Options) { o.Canonical = true }
+}
+
 // SetMaxInlineDepth sets the default maximum inlining depth for all types from
 // now on.
 //
@@ -112,3 +214,75 @@ func SetMaxInlineILSize(size int) int {
     size, opts.MaxInlineILSize = opts.MaxInlineILSize, size
     return size
 }
+
+// SetMaxContainerLen sets the default maximum number of elements in a list, set
+// or map for all decoding from now on.
+//
+// This value can also be configured with the `FRUGAL_MAX_CONTAINER_LEN`
+// environment variable.
+//
+// The default value of this option is "0", which means unlimited.
+//
+// Returns the old opts.MaxContainerLen value.
+func SetMaxContainerLen(n int) int {
+    n, opts.MaxContainerLen = opts.MaxContainerLen, n
+    return n
+}
+
+// SetMaxStringLen sets the default maximum length of a string or binary for all
+// decoding from now on.
+//
+// This value can also be configured with the `FRUGAL_MAX_STRING_LEN`
+// environment variable.
+//
+// The default value of this option is "0", which means unlimited.
+//
+// Returns the old opts.MaxStringLen value.
+func SetMaxStringLen(n int) int {
+    n, opts.MaxStringLen = opts.MaxStringLen, n
+    return n
+}
+
+// SetMaxDepth sets the default maximum nesting depth for all decoding from now
+// on.
+//
+// This value can also be configured with the `FRUGAL_MAX_DEPTH` environment
+// variable.
+//
+// The default value of this option is "0", which means unlimited.
+//
+// Returns the old opts.MaxDepth value.
+func SetMaxDepth(depth int) int {
+    depth, opts.MaxDepth = opts.MaxDepth, depth
+    return depth
+}
+
+// SetMaxTotalAlloc sets the default maximum number of bytes allocated for a
+// single decoded object for all decoding from now on.
+//
+// This value can also be configured with the `FRUGAL_MAX_TOTAL_ALLOC`
+// environment variable.
+//
+// The default value of this option is "0", which means unlimited.
+//
+// Returns the old opts.MaxTotalAlloc value.
+func SetMaxTotalAlloc(size int) int {
+    size, opts.MaxTotalAlloc = opts.MaxTotalAlloc, size
+    return size
+}
+
+// SetMaxCachedPrograms sets the maximum number of compiled programs kept in each
+// of the encoder and decoder caches from now on. When the limit is exceeded, the
+// least recently used programs are evicted as if Evict was called, and their
+// generated code is released.
+//
+// This value can also be configured with the `FRUGAL_MAX_CACHED_PROGRAMS`
+// environment variable.
+//
+// The default value of this option is "0", which means unlimited.
+//
+// Returns the old opts.MaxCachedPrograms value.
+func SetMaxCachedPrograms(n int) int {
+    n, opts.MaxCachedPrograms = opts.MaxCachedPrograms, n
+    return n
+}
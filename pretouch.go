@@ -100,3 +100,19 @@ func Pretouch(vt reflect.Type, options ...Option) error {
     /* completed with no errors */
     return nil
 }
+
+// Evict removes the compiled encoders and decoders of vt from the caches, including
+// the ones created for WithFields. The generated code is released after it's no longer
+// running, and vt is compiled again the next time it's used. Programs of other types
+// that have vt inlined are not affected.
+func Evict(vt reflect.Type) {
+    t := rt.UnpackType(vt)
+    encoder.Evict(t)
+    decoder.Evict(t)
+
+    /* programs may also be cached with the dereferenced type */
+    if d := rt.Dereference(t); d != t {
+        encoder.Evict(d)
+        decoder.Evict(d)
+    }
+}
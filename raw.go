@@ -0,0 +1,94 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `reflect`
+
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/utils`
+)
+
+// Raw is a field that holds the exact encoded bytes of its value, without interpreting them.
+// The decoder captures the bytes after validating them with the same rules as skipping unknown
+// fields, and the encoder splices them back unchanged.
+//
+// The wire type of the value is taken from the field tag, like `frugal:"12,default,struct"`, and
+// can be any Thrift type except bool, the element types of containers are optional and ignored.
+// It's "struct" if the type is not specified.
+//
+// A Raw can only be encoded with the protocol of its bytes, and the zero Raw cannot be encoded.
+// JSON encoding cannot interpret the bytes either, so structs in Raw fields are empty in JSON.
+type Raw struct {
+    buf []byte
+    pt  defs.Protocol
+}
+
+// RawBinary returns a Raw holding buf, which must be a value encoded with Thrift Binary Protocol.
+func RawBinary(buf []byte) Raw {
+    return Raw { buf: buf, pt: defs.Binary }
+}
+
+// RawCompact returns a Raw holding buf, which must be a value encoded with Thrift Compact Protocol.
+func RawCompact(buf []byte) Raw {
+    return Raw { buf: buf, pt: defs.Compact }
+}
+
+// Bytes returns the encoded bytes.
+func (self Raw) Bytes() []byte {
+    return self.buf
+}
+
+// IsCompact reports whether the bytes are encoded with Thrift Compact Protocol.
+func (self Raw) IsCompact() bool {
+    return self.pt == defs.Compact
+}
+
+/* rawBytes implements the internal raw value interface for Raw, so Raw does not export it */
+type rawBytes Raw
+
+func (self *Raw) rawValue() defs.RawValue {
+    return (*rawBytes)(self)
+}
+
+func (self *rawBytes) RawType() reflect.Type {
+    return nil
+}
+
+func (self *rawBytes) RawSize(pt defs.Protocol) (int, error) {
+    if self.buf == nil {
+        return 0, utils.EKindf(ErrInvalidData, "frugal: cannot encode empty raw values")
+    } else if self.pt != pt {
+        return 0, utils.EKindf(ErrInvalidData, "frugal: raw value encoded with %s protocol cannot be written with %s protocol", self.pt, pt)
+    } else {
+        return len(self.buf), nil
+    }
+}
+
+func (self *rawBytes) RawEncode(buf []byte, pt defs.Protocol) (int, error) {
+    if _, err := self.RawSize(pt); err != nil {
+        return 0, err
+    } else {
+        return copy(buf, self.buf), nil
+    }
+}
+
+func (self *rawBytes) RawDecode(buf []byte, pt defs.Protocol) error {
+    self.buf = append(make([]byte, 0, len(buf)), buf...)
+    self.pt = pt
+    return nil
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `reflect`
+    `testing`
+
+    `github.com/stretchr/testify/require`
+)
+
+type TestRawOuter struct {
+    A int32 `frugal:"1,default,i32"`
+    B Raw   `frugal:"2,default,struct"`
+}
+
+func TestRaw_RoundTrip(t *testing.T) {
+    in := TestMessageArgs { A: 2, B: "hello" }
+    raw := make([]byte, EncodedSize(&in))
+    _, err := EncodeObject(raw, nil, &in)
+    require.NoError(t, err)
+
+    /* the bytes are spliced in unchanged */
+    v := TestRawOuter { A: 1, B: RawBinary(raw) }
+    buf := make([]byte, EncodedSize(&v))
+    ret, err := EncodeObject(buf, nil, &v)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), ret)
+
+    /* and captured back when decoding */
+    var w TestRawOuter
+    pos, err := DecodeObject(buf, &w)
+    require.NoError(t, err)
+    require.Equal(t, len(buf), pos)
+    require.Equal(t, raw, w.B.Bytes())
+    require.False(t, w.B.IsCompact())
+
+    /* the zero Raw cannot be encoded */
+    _, err = EncodeObject(buf, nil, &TestRawOuter{})
+    require.ErrorIs(t, err, ErrInvalidData)
+}
+
+func TestRaw_NoRawMethods(t *testing.T) {
+    vt := reflect.TypeOf(&Raw{})
+    for i := 0; i < vt.NumMethod(); i++ {
+        require.NotContains(t, []string { "RawType", "RawSize", "RawEncode", "RawDecode" }, vt.Method(i).Name)
+    }
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `io`
+
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+    `github.com/cloudwego/frugal/internal/binary/encoder`
+    `github.com/cloudwego/frugal/internal/snapshot`
+)
+
+var (
+    // ErrSnapshotMismatch is returned by LoadSnapshot when the snapshot was created by a
+    // different executable, Go version or kind of CPU.
+    ErrSnapshotMismatch = snapshot.ErrMismatch
+
+    // ErrSnapshotCorrupted is returned by LoadSnapshot when the snapshot is truncated or damaged.
+    ErrSnapshotCorrupted = snapshot.ErrCorrupted
+
+    // ErrSnapshotUnsupported is returned by SaveSnapshot and LoadSnapshot when the types are not
+    // compiled into machine code, like on platforms other than amd64.
+    ErrSnapshotUnsupported = snapshot.ErrUnsupported
+)
+
+// SaveSnapshot writes the machine code of all the types compiled so far into w, together with
+// the relocations and stack maps needed to load it again. Types that refer to memory which only
+// lives in the current process, like types created with reflect or NewStructType, are skipped.
+//
+// The snapshot can only be loaded by the same executable running on the same kind of CPU.
+func SaveSnapshot(w io.Writer) error {
+    progs, err := encoder.Snapshot(nil)
+    if err != nil {
+        return err
+    }
+
+    /* add the decoders */
+    if progs, err = decoder.Snapshot(progs); err != nil {
+        return err
+    }
+
+    /* serialize the programs */
+    _, err = snapshot.Write(w, progs)
+    return err
+}
+
+// LoadSnapshot loads the machine code saved by SaveSnapshot, so the types in it are not compiled
+// again. It's usually called at startup, before Pretouch. The snapshot is validated before loading
+// anything, an ErrSnapshotMismatch or ErrSnapshotCorrupted means it should be created again.
+func LoadSnapshot(r io.Reader) error {
+    progs, err := snapshot.Read(r)
+    if err != nil {
+        return err
+    }
+
+    /* load every program */
+    for i := range progs {
+        if progs[i].Kind == snapshot.Encoder {
+            err = encoder.Restore(&progs[i])
+        } else {
+            err = decoder.Restore(&progs[i])
+        }
+
+        /* check for errors */
+        if err != nil {
+            return err
+        }
+    }
+
+    /* all done */
+    return nil
+}
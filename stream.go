@@ -0,0 +1,257 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `bytes`
+    `errors`
+    `io`
+    `sync`
+
+    `github.com/cloudwego/frugal/internal/binary/decoder`
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/binary/encoder`
+    `github.com/cloudwego/frugal/internal/opts`
+    `github.com/cloudwego/frugal/internal/utils`
+    `github.com/cloudwego/frugal/iov`
+)
+
+const (
+    _MinReadSize   = 4096
+    _MaxPooledSize = 64 * 1024
+)
+
+var (
+    bufferPool sync.Pool
+)
+
+// Marshal serializes val with Thrift Binary Protocol into a newly allocated
+// buffer. Unlike EncodeObject, the encoded size does not need to be measured first.
+func Marshal(val interface{}) ([]byte, error) {
+    var ok bool
+    var mem *[]byte
+
+    /* the scratch buffer grows to fit the largest objects seen so far, up to _MaxPooledSize */
+    if mem, ok = bufferPool.Get().(*[]byte); !ok {
+        mem = new([]byte)
+    }
+
+    /* encode into the scratch buffer */
+    buf, err := encoder.AppendObject((*mem)[:0], val)
+
+    /* large buffers are handed over to the caller instead of being kept in the pool */
+    if cap(buf) > _MaxPooledSize {
+        if bufferPool.Put(mem); err != nil {
+            return nil, err
+        } else {
+            return buf, nil
+        }
+    }
+
+    /* the scratch buffer may have grown */
+    *mem = buf
+
+    /* copy the result out if succeeded */
+    if err == nil {
+        buf = append([]byte(nil), buf...)
+    } else {
+        buf = nil
+    }
+
+    /* return the scratch buffer into pool */
+    bufferPool.Put(mem)
+    return buf, err
+}
+
+// Encoder encodes and writes Thrift Binary Protocol objects to an output stream.
+type Encoder struct {
+    w   io.Writer
+    buf []byte
+}
+
+// NewEncoder returns a new Encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+    return &Encoder { w: w }
+}
+
+// Encode serializes val and writes it to the output stream with a single Write call.
+// The internal buffer grows as needed and is reused across calls.
+func (self *Encoder) Encode(val interface{}) error {
+    buf, err := encoder.AppendObject(self.buf[:0], val)
+    self.buf = buf
+
+    /* check for errors */
+    if err != nil {
+        return err
+    }
+
+    /* write the encoded object */
+    _, err = self.w.Write(buf)
+    return err
+}
+
+// Decoder reads and decodes Thrift Binary Protocol objects from an input stream.
+type Decoder struct {
+    r   io.Reader
+    o   opts.Options
+    buf []byte
+}
+
+// NewDecoder returns a new Decoder that reads from r. The Decoder may read
+// beyond the end of an object, the extra bytes are kept for the next Decode call.
+//
+// The decoding limits in options apply to every object read from the stream,
+// which protects the reader from untrusted length prefixes.
+func NewDecoder(r io.Reader, options ...Option) *Decoder {
+    ret := &Decoder { r: r, o: opts.GetDefaultOptions() }
+
+    /* apply all the options */
+    for _, fn := range options {
+        fn(&ret.o)
+    }
+
+    /* all done */
+    return ret
+}
+
+// Buffered returns a reader of the data remaining in the Decoder's buffer.
+func (self *Decoder) Buffered() io.Reader {
+    return bytes.NewReader(self.buf)
+}
+
+// Decode reads the next object from the input stream and stores it in val.
+// It returns io.EOF if the stream ends before any byte of the object is read.
+//
+// The object is decoded only after all of its bytes are read, so val is left
+// untouched if reading fails.
+func (self *Decoder) Decode(val interface{}) error {
+    var nb  int
+    var err error
+    var ex  decoder.EOFError
+
+    /* find the end of the object first, skipping is much cheaper than decoding */
+    for {
+        if nb, err = decoder.Skip(self.buf, defs.T_struct, self.o); err == nil {
+            break
+        }
+
+        /* not because of insufficient data */
+        if !errors.As(err, &ex) {
+            return err
+        }
+
+        /* read some more bytes, and try again */
+        if err = self.fill(int(ex)); err != nil {
+            return err
+        }
+    }
+
+    /* decode the object, and drop the consumed bytes */
+    if nb, err = decoder.DecodeObjectWithOptions(self.buf[:nb], val, self.o); err != nil {
+        return err
+    } else {
+        self.buf = self.buf[nb:]
+        return nil
+    }
+}
+
+func (self *Decoder) fill(n int) error {
+    nb := len(self.buf)
+    rem := nb + utils.MaxInt(n, 1)
+
+    /* grow the buffer geometrically, the consumed bytes might still be referenced
+     * by the previously decoded objects (with "nocopy" strings), so never overwrite them */
+    if rem > cap(self.buf) {
+        buf := make([]byte, nb, nb + utils.MaxInt(utils.MaxInt(n, nb), _MinReadSize))
+        self.buf = buf[:copy(buf, self.buf)]
+    }
+
+    /* read at least n bytes */
+    for len(self.buf) < rem {
+        nr, err := self.r.Read(self.buf[len(self.buf):cap(self.buf)])
+        self.buf = self.buf[:len(self.buf) + nr]
+
+        /* check for errors */
+        if err == nil || len(self.buf) >= rem {
+            continue
+        }
+
+        /* the stream ends before the object */
+        if err != io.EOF {
+            return err
+        } else if len(self.buf) == 0 {
+            return io.EOF
+        } else {
+            return io.ErrUnexpectedEOF
+        }
+    }
+
+    /* all done */
+    return nil
+}
+
+// DecodeReader decodes the next Thrift Binary Protocol object from r into val
+// without copying the input, and returns the number of bytes consumed.
+//
+// The reader is peeked only for the bytes that are required, or the bytes that
+// are already buffered if r also has a `Len() int` method, so this never blocks
+// waiting for the bytes of the next object. Strings decoded with the "nocopy"
+// option may reference the buffer of r. The decoding limits in options apply
+// to both finding the end of the object and decoding it.
+func DecodeReader(r iov.BufferReader, val interface{}, options ...Option) (int, error) {
+    var nb  int
+    var ret int
+    var err error
+    var buf []byte
+    var ex  decoder.EOFError
+
+    /* the same options are used for skipping and decoding */
+    o := applyOptions(options)
+
+    /* peek for more bytes until the end of the object is found */
+    for {
+        if buf, err = r.Peek(nb); err != nil {
+            return 0, err
+        }
+
+        /* skip over the object, without decoding it */
+        if ret, err = decoder.Skip(buf, defs.T_struct, o); err == nil {
+            break
+        }
+
+        /* not because of insufficient data */
+        if !errors.As(err, &ex) {
+            return 0, err
+        }
+
+        /* the number of missing bytes may not be known */
+        nb += utils.MaxInt(int(ex), 1)
+
+        /* all the buffered bytes can be peeked without blocking */
+        if rb, ok := r.(interface{ Len() int }); ok {
+            nb = utils.MaxInt(nb, rb.Len())
+        }
+    }
+
+    /* decode the object, and consume the bytes */
+    if ret, err = decoder.DecodeObjectWithOptions(buf[:ret], val, o); err != nil {
+        return 0, err
+    } else {
+        _, err = r.Next(ret)
+        return ret, err
+    }
+}
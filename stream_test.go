@@ -0,0 +1,161 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `bytes`
+    `io`
+    `testing`
+    `testing/iotest`
+
+    `github.com/stretchr/testify/require`
+)
+
+type TestStreamStruct struct {
+    A int32  `frugal:"1,default,i32"`
+    B []byte `frugal:"2,default,binary"`
+}
+
+func TestStream_Marshal(t *testing.T) {
+    var ret [][]byte
+    var exp [][]byte
+
+    /* both the pooled and the large buffers */
+    for _, n := range []int{0, 10, _MaxPooledSize, 4 * _MaxPooledSize, 10} {
+        v := TestStreamStruct{A: int32(n), B: bytes.Repeat([]byte{byte(n)}, n)}
+        buf := make([]byte, EncodedSize(v))
+        _, err := EncodeObject(buf, nil, v)
+        require.NoError(t, err)
+        out, err := Marshal(v)
+        require.NoError(t, err)
+        require.Equal(t, buf, out)
+        ret, exp = append(ret, out), append(exp, buf)
+    }
+
+    /* the returned buffers must not be reused by later calls */
+    require.Equal(t, exp, ret)
+}
+
+type TestStreamObject struct {
+    A int32            `frugal:"1,default,i32"`
+    B string           `frugal:"2,default,string"`
+    C []int32          `frugal:"3,default,list<i32>"`
+    D map[string]int32 `frugal:"4,default,map<string:i32>"`
+    E *int64           `frugal:"5,optional,i64"`
+}
+
+type testBufferReader struct {
+    buf []byte
+}
+
+func (self *testBufferReader) Peek(n int) ([]byte, error) {
+    if n > len(self.buf) {
+        return nil, io.ErrUnexpectedEOF
+    } else {
+        return self.buf[:n], nil
+    }
+}
+
+func (self *testBufferReader) Next(n int) ([]byte, error) {
+    if buf, err := self.Peek(n); err != nil {
+        return nil, err
+    } else {
+        self.buf = self.buf[n:]
+        return buf, nil
+    }
+}
+
+func newTestStreamObject() *TestStreamObject {
+    e := int64(7)
+    return &TestStreamObject{A: -1, B: "old", C: []int32{9, 9, 9, 9}, D: map[string]int32{"old": 1}, E: &e}
+}
+
+func encodeTestStreamObjects(t *testing.T) ([]byte, []*TestStreamObject) {
+    var buf []byte
+    var ret []*TestStreamObject
+
+    /* two objects, with the optional field missing from the input */
+    for _, v := range []TestStreamObject {
+        { A: 1, B: "hello", C: []int32{1, 2}, D: map[string]int32{"a": 1, "b": 2} },
+        { A: 2, B: "world", C: []int32{3}, D: map[string]int32{"c": 3} },
+    } {
+        mem := make([]byte, EncodedSize(v))
+        _, err := EncodeObject(mem, nil, v)
+        require.NoError(t, err)
+        buf = append(buf, mem...)
+
+        /* decoding the object at once into a pre-populated value */
+        exp := newTestStreamObject()
+        _, err = DecodeObject(mem, exp)
+        require.NoError(t, err)
+        ret = append(ret, exp)
+    }
+
+    /* the encoded stream, and the expected values */
+    return buf, ret
+}
+
+func TestStream_DecodeOneByte(t *testing.T) {
+    buf, exp := encodeTestStreamObjects(t)
+    dec := NewDecoder(iotest.OneByteReader(bytes.NewReader(buf)))
+
+    /* reading byte by byte must give the same result as decoding at once */
+    for _, v := range exp {
+        val := newTestStreamObject()
+        require.NoError(t, dec.Decode(val))
+        require.Equal(t, v, val)
+    }
+
+    /* end of the stream */
+    require.Equal(t, io.EOF, dec.Decode(newTestStreamObject()))
+}
+
+func TestStream_DecodeTruncated(t *testing.T) {
+    buf, _ := encodeTestStreamObjects(t)
+    val := newTestStreamObject()
+    dec := NewDecoder(iotest.OneByteReader(bytes.NewReader(buf[:10])))
+    require.Equal(t, io.ErrUnexpectedEOF, dec.Decode(val))
+    require.Equal(t, newTestStreamObject(), val)
+}
+
+func TestStream_DecodeReader(t *testing.T) {
+    buf, exp := encodeTestStreamObjects(t)
+    rd := &testBufferReader{buf: buf}
+
+    /* decode the objects one by one */
+    for _, v := range exp {
+        val := newTestStreamObject()
+        _, err := DecodeReader(rd, val)
+        require.NoError(t, err)
+        require.Equal(t, v, val)
+    }
+
+    /* a truncated object leaves the value untouched */
+    val := newTestStreamObject()
+    _, err := DecodeReader(&testBufferReader{buf: buf[:10]}, val)
+    require.Equal(t, io.ErrUnexpectedEOF, err)
+    require.Equal(t, newTestStreamObject(), val)
+    require.Empty(t, rd.buf)
+
+    /* the limits apply to skipping, and to decoding, which is the only one that allocates */
+    for _, opt := range []Option { WithMaxStringLen(4), WithMaxTotalAlloc(4) } {
+        rd = &testBufferReader{buf: buf}
+        _, err = DecodeReader(rd, newTestStreamObject(), opt)
+        require.ErrorIs(t, err, ErrLimitExceeded)
+        require.Equal(t, buf, rd.buf)
+    }
+}
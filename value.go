@@ -0,0 +1,76 @@
+/*
+ * Copyright 2022 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+    `github.com/cloudwego/frugal/internal/binary/defs`
+    `github.com/cloudwego/frugal/internal/dynamic`
+    `github.com/cloudwego/frugal/internal/opts`
+)
+
+// WireType is the type of a Thrift value on the wire.
+type WireType = defs.Tag
+
+const (
+    WireBool   = defs.T_bool
+    WireI8     = defs.T_i8
+    WireDouble = defs.T_double
+    WireI16    = defs.T_i16
+    WireI32    = defs.T_i32
+    WireI64    = defs.T_i64
+    WireString = defs.T_string
+    WireStruct = defs.T_struct
+    WireMap    = defs.T_map
+    WireSet    = defs.T_set
+    WireList   = defs.T_list
+)
+
+// Value is a node of a schema-less Thrift value tree, as returned by DecodeGeneric.
+// Only the members that match its Type are meaningful: Bool for bool, Int for all the
+// integer types, Double for double, Binary for string and binary, Fields for struct,
+// Key, Elem and Pairs for map, and Elem and Elems for set and list.
+type Value = dynamic.Value
+
+// FieldValue is a field of a struct Value, keyed by the field ID.
+type FieldValue = dynamic.FieldValue
+
+// PairValue is a key-value pair of a map Value.
+type PairValue = dynamic.PairValue
+
+// DecodeGeneric deserializes the Thrift Binary Protocol encoded struct at the beginning of buf
+// into a Value tree, without knowing its Go type. The payload is validated with the same rules
+// as skipping unknown fields in DecodeObject before the tree is built, and the decoding limits in
+// options are applied as well.
+func DecodeGeneric(buf []byte, options ...Option) (Value, error) {
+    o := opts.GetDefaultOptions()
+
+    /* apply all the options */
+    for _, fn := range options {
+        fn(&o)
+    }
+
+    /* decode the value tree */
+    ret, _, err := dynamic.Decode(buf, o)
+    return ret, err
+}
+
+// EncodeGeneric serializes the Value tree v with Thrift Binary Protocol. It is the inverse of
+// DecodeGeneric. The type of every element must match the element types of the containers,
+// and integers must fit in their wire types.
+func EncodeGeneric(v Value) ([]byte, error) {
+    return dynamic.Append(nil, &v)
+}